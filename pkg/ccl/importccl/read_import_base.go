@@ -448,6 +448,30 @@ type importRowConsumer interface {
 	FillDatums(row interface{}, rowNum int64, conv *row.DatumRowConverter) error
 }
 
+// importBatchConsumer is an optional interface that can be implemented by an
+// importRowConsumer which is able to convert a whole batch of records at once.
+// Implementations of this interface do not need to be thread safe.
+type importBatchConsumer interface {
+	importRowConsumer
+
+	// CanFillDatumsBatch returns whether FillDatumsBatch can be used with the
+	// given datum converter.
+	CanFillDatumsBatch(conv *row.DatumRowConverter) bool
+
+	// FillDatumsBatch converts the records into the provided datum converter
+	// one row at a time, in order. emit is called with the index of each
+	// record after its datums have been filled, and onRowErr is called with
+	// the index of each record that couldn't be converted and the error.
+	FillDatumsBatch(
+		ctx context.Context,
+		records []interface{},
+		startPos int64,
+		conv *row.DatumRowConverter,
+		emit func(batchIdx int) error,
+		onRowErr func(batchIdx int, err error) error,
+	) error
+}
+
 // batch represents batch of data to convert.
 type batch struct {
 	data     []interface{}
@@ -612,8 +636,33 @@ func (p *parallelImporter) importWorker(
 		return m
 	}
 
+	batchConsumer, ok := consumer.(importBatchConsumer)
+	if ok && !batchConsumer.CanFillDatumsBatch(conv) {
+		batchConsumer = nil
+	}
+
 	for batch := range p.recordCh {
 		conv.KvBatch.Progress = batch.progress
+		if batchConsumer != nil {
+			if err := batchConsumer.FillDatumsBatch(
+				ctx, batch.data, batch.startPos, conv,
+				func(batchIdx int) error {
+					rowNum = batch.startPos + int64(batchIdx)
+					rowIndex := int64(timestamp) + rowNum
+					if err := conv.Row(ctx, conv.KvBatch.Source, rowIndex); err != nil {
+						return newImportRowError(err, fmt.Sprintf("%v", batch.data[batchIdx]), rowNum)
+					}
+					return nil
+				},
+				func(batchIdx int, err error) error {
+					rowNum = batch.startPos + int64(batchIdx)
+					return handleCorruptRow(ctx, fileCtx, err)
+				},
+			); err != nil {
+				return err
+			}
+			continue
+		}
 		for batchIdx, record := range batch.data {
 			rowNum = batch.startPos + int64(batchIdx)
 			if err := consumer.FillDatums(record, rowNum, conv); err != nil {
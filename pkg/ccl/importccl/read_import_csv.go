@@ -13,7 +13,13 @@ import (
 	"io"
 	"strings"
 
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
 	"github.com/cockroachdb/cockroach/pkg/sql/row"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
@@ -23,6 +29,15 @@ import (
 	"github.com/cockroachdb/errors"
 )
 
+// csvVectorizedParsing controls whether the fields of CSV records are parsed
+// in batches using the vectorized parse operator rather than one datum at a
+// time. Other formats always parse one datum at a time.
+var csvVectorizedParsing = settings.RegisterBoolSetting(
+	"sql.import.csv.vectorized_parsing.enabled",
+	"if set, the fields of CSV files are parsed in batches by the vectorized engine",
+	false,
+)
+
 type csvInputReader struct {
 	importCtx *parallelImportContext
 	opts      roachpb.CSVOptions
@@ -195,7 +210,147 @@ func (c *csvRowConsumer) FillDatums(
 	return nil
 }
 
-func newCSVPipeline(c *csvInputReader, input *fileReader) (*csvRowProducer, *csvRowConsumer) {
+// csvBatchConsumer is an importBatchConsumer that parses the fields of the
+// CSV records using the vectorized parse operator.
+type csvBatchConsumer struct {
+	csvRowConsumer
+}
+
+var _ importBatchConsumer = &csvBatchConsumer{}
+
+// CanFillDatumsBatch implements the importBatchConsumer interface.
+func (c *csvBatchConsumer) CanFillDatumsBatch(conv *row.DatumRowConverter) bool {
+	if conv.EvalCtx.Mon == nil {
+		return false
+	}
+	for i := range conv.VisibleColTypes {
+		if _, ok := conv.IsTargetCol[i]; !ok {
+			continue
+		}
+		if typeconv.FromColumnType(conv.VisibleColTypes[i]) == coltypes.Unhandled {
+			return false
+		}
+	}
+	return true
+}
+
+// FillDatumsBatch implements the importBatchConsumer interface. The fields of
+// the records are copied into a batch with a Bytes column per target column,
+// and a parse operator per target column appends a column with the parsed
+// values. The records that couldn't be parsed are reported the same way
+// FillDatums reports them.
+func (c *csvBatchConsumer) FillDatumsBatch(
+	ctx context.Context,
+	records []interface{},
+	startPos int64,
+	conv *row.DatumRowConverter,
+	emit func(batchIdx int) error,
+	onRowErr func(batchIdx int, err error) error,
+) error {
+	// targetCols contains the indices of the target columns among the visible
+	// columns in the order of the datums.
+	var targetCols []int
+	for i := range conv.VisibleColTypes {
+		if _, ok := conv.IsTargetCol[i]; ok {
+			targetCols = append(targetCols, i)
+		}
+	}
+	nTargets := len(targetCols)
+
+	// The batches are accounted for against the memory monitor of the import
+	// processor.
+	acc := conv.EvalCtx.Mon.MakeBoundAccount()
+	defer acc.Close(ctx)
+	allocator := colexec.NewAllocator(ctx, &acc)
+
+	typs := make([]coltypes.T, nTargets)
+	for i := range typs {
+		typs[i] = coltypes.Bytes
+	}
+	// rowErrs contains the error parsing each tuple of the current batch, if
+	// any.
+	rowErrs := make([]error, coldata.BatchSize())
+	input := colexec.NewBatchBuffer()
+	var op colexec.Operator = input
+	for datumIdx, colIdx := range targetCols {
+		col := &conv.VisibleCols[colIdx]
+		var err error
+		op, err = colexec.NewParseOp(
+			allocator, op, datumIdx, nTargets+datumIdx, conv.VisibleColTypes[colIdx], conv.EvalCtx,
+			func(rowIdx int, err error) {
+				rowErrs[rowIdx] = errors.Wrapf(err, "parse %q as %s", col.Name, col.Type.SQLString())
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	op.Init()
+
+	var (
+		b          coldata.Batch
+		datumAlloc sqlbase.DatumAlloc
+	)
+	for chunkStart := 0; chunkStart < len(records); chunkStart += coldata.BatchSize() {
+		chunk := records[chunkStart:]
+		if len(chunk) > coldata.BatchSize() {
+			chunk = chunk[:coldata.BatchSize()]
+		}
+		if b == nil {
+			b = allocator.NewMemBatch(typs)
+		} else {
+			b.ResetInternalBatch()
+		}
+		for rowIdx, r := range chunk {
+			record := r.([]string)
+			datumIdx := 0
+			for i, field := range record {
+				if _, ok := conv.IsTargetCol[i]; !ok {
+					continue
+				}
+				vec := b.ColVec(datumIdx)
+				if c.opts.NullEncoding != nil && field == *c.opts.NullEncoding {
+					vec.Nulls().SetNull(rowIdx)
+				} else {
+					vec.Bytes().Set(rowIdx, []byte(field))
+				}
+				datumIdx++
+			}
+			rowErrs[rowIdx] = nil
+		}
+		b.SetLength(len(chunk))
+		input.Add(b)
+		if err := execerror.CatchVectorizedRuntimeError(func() {
+			b = op.Next(ctx)
+		}); err != nil {
+			return err
+		}
+
+		for rowIdx := range chunk {
+			batchIdx := chunkStart + rowIdx
+			if err := rowErrs[rowIdx]; err != nil {
+				rowNum := startPos + int64(batchIdx)
+				if err := onRowErr(batchIdx, newImportRowError(
+					err, strRecord(chunk[rowIdx].([]string), c.opts.Comma), rowNum,
+				)); err != nil {
+					return err
+				}
+				continue
+			}
+			for datumIdx, colIdx := range targetCols {
+				conv.Datums[datumIdx] = colexec.PhysicalTypeColElemToDatum(
+					b.ColVec(nTargets+datumIdx), rowIdx, datumAlloc, conv.VisibleColTypes[colIdx],
+				)
+			}
+			if err := emit(batchIdx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func newCSVPipeline(c *csvInputReader, input *fileReader) (*csvRowProducer, importRowConsumer) {
 	cr := csv.NewReader(input)
 	if c.opts.Comma != 0 {
 		cr.Comma = c.opts.Comma
@@ -215,6 +370,9 @@ func newCSVPipeline(c *csvInputReader, input *fileReader) (*csvRowProducer, *csv
 		importCtx: c.importCtx,
 		opts:      &c.opts,
 	}
+	if st := c.importCtx.evalCtx.Settings; st != nil && csvVectorizedParsing.Get(&st.SV) {
+		return producer, &csvBatchConsumer{csvRowConsumer: *consumer}
+	}
 
 	return producer, consumer
 }
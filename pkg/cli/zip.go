@@ -91,6 +91,7 @@ var debugZipTablesPerNode = []string{
 	"crdb_internal.node_sessions",
 	"crdb_internal.node_statement_statistics",
 	"crdb_internal.node_txn_stats",
+	"crdb_internal.node_vectorized_flows",
 }
 
 // Override for the default SELECT * when dumping the table.
@@ -162,6 +162,7 @@ retrieving SQL data for crdb_internal.node_runtime_info... writing: debug/nodes/
 retrieving SQL data for crdb_internal.node_sessions... writing: debug/nodes/1/crdb_internal.node_sessions.txt
 retrieving SQL data for crdb_internal.node_statement_statistics... writing: debug/nodes/1/crdb_internal.node_statement_statistics.txt
 retrieving SQL data for crdb_internal.node_txn_stats... writing: debug/nodes/1/crdb_internal.node_txn_stats.txt
+retrieving SQL data for crdb_internal.node_vectorized_flows... writing: debug/nodes/1/crdb_internal.node_vectorized_flows.txt
 requesting data for debug/nodes/1/details... writing: debug/nodes/1/details.json
 requesting data for debug/nodes/1/gossip... writing: debug/nodes/1/gossip.json
 requesting data for debug/nodes/1/enginestats... writing: debug/nodes/1/enginestats.json
@@ -464,6 +465,7 @@ retrieving SQL data for crdb_internal.node_runtime_info... writing: debug/nodes/
 retrieving SQL data for crdb_internal.node_sessions... writing: debug/nodes/1/crdb_internal.node_sessions.txt
 retrieving SQL data for crdb_internal.node_statement_statistics... writing: debug/nodes/1/crdb_internal.node_statement_statistics.txt
 retrieving SQL data for crdb_internal.node_txn_stats... writing: debug/nodes/1/crdb_internal.node_txn_stats.txt
+retrieving SQL data for crdb_internal.node_vectorized_flows... writing: debug/nodes/1/crdb_internal.node_vectorized_flows.txt
 requesting data for debug/nodes/1/details... writing: debug/nodes/1/details.json
 requesting data for debug/nodes/1/gossip... writing: debug/nodes/1/gossip.json
 requesting data for debug/nodes/1/enginestats... writing: debug/nodes/1/enginestats.json
@@ -570,6 +572,7 @@ retrieving SQL data for crdb_internal.node_runtime_info... writing: debug/nodes/
 retrieving SQL data for crdb_internal.node_sessions... writing: debug/nodes/1/crdb_internal.node_sessions.txt
 retrieving SQL data for crdb_internal.node_statement_statistics... writing: debug/nodes/1/crdb_internal.node_statement_statistics.txt
 retrieving SQL data for crdb_internal.node_txn_stats... writing: debug/nodes/1/crdb_internal.node_txn_stats.txt
+retrieving SQL data for crdb_internal.node_vectorized_flows... writing: debug/nodes/1/crdb_internal.node_vectorized_flows.txt
 requesting data for debug/nodes/1/details... writing: debug/nodes/1/details.json
 requesting data for debug/nodes/1/gossip... writing: debug/nodes/1/gossip.json
 requesting data for debug/nodes/1/enginestats... writing: debug/nodes/1/enginestats.json
@@ -639,6 +642,7 @@ retrieving SQL data for crdb_internal.node_sessions... writing: debug/nodes/2/cr
 retrieving SQL data for crdb_internal.node_statement_statistics... writing: debug/nodes/2/crdb_internal.node_statement_statistics.txt
   ^- resulted in ...
 retrieving SQL data for crdb_internal.node_txn_stats... writing: debug/nodes/2/crdb_internal.node_txn_stats.txt
+retrieving SQL data for crdb_internal.node_vectorized_flows... writing: debug/nodes/2/crdb_internal.node_vectorized_flows.txt
   ^- resulted in ...
 requesting data for debug/nodes/2/details... writing: debug/nodes/2/details.json
   ^- resulted in ...
@@ -675,6 +679,7 @@ retrieving SQL data for crdb_internal.node_runtime_info... writing: debug/nodes/
 retrieving SQL data for crdb_internal.node_sessions... writing: debug/nodes/3/crdb_internal.node_sessions.txt
 retrieving SQL data for crdb_internal.node_statement_statistics... writing: debug/nodes/3/crdb_internal.node_statement_statistics.txt
 retrieving SQL data for crdb_internal.node_txn_stats... writing: debug/nodes/3/crdb_internal.node_txn_stats.txt
+retrieving SQL data for crdb_internal.node_vectorized_flows... writing: debug/nodes/3/crdb_internal.node_vectorized_flows.txt
 requesting data for debug/nodes/3/details... writing: debug/nodes/3/details.json
 requesting data for debug/nodes/3/gossip... writing: debug/nodes/3/gossip.json
 requesting data for debug/nodes/3/enginestats... writing: debug/nodes/3/enginestats.json
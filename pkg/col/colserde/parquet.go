@@ -0,0 +1,1051 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colserde
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+)
+
+// This file contains a minimal implementation of the Apache Parquet file
+// format (https://github.com/apache/parquet-format) that supports all of the
+// types that are supported by the ArrowBatchConverter. Every batch is written
+// as a row group with a single data page per column chunk. The byte arrays are
+// dictionary encoded if the row group has few distinct values and the other
+// values are PLAIN encoded, the nulls are encoded as RLE definition levels, and
+// every column chunk is compressed with snappy if that reduces the size of all
+// of its pages enough. The columns are mapped to the Parquet types as follows:
+// - Bools, ints, floats, and bytes use the corresponding physical types.
+// - Timestamps are stored as INT64 with the TIMESTAMP_MICROS converted type,
+//   which is lossless for the microsecond precision of the SQL timestamps.
+// - Intervals are stored as a group of the INT64 months, days, and nanos,
+//   since the INTERVAL converted type only supports non-negative intervals
+//   with the millisecond precision.
+// - Decimals are stored as UTF8 strings, since the DECIMAL converted type
+//   requires a fixed scale per column (which the coltypes don't carry) and
+//   cannot represent NaN and Infinity.
+
+const parquetMagic = `PAR1`
+
+// parquetCompressionSizeReductionThreshold is the same threshold as the one
+// used by the DiskQueue: the compressed pages are only used if they result in
+// at least 12.5% size reduction.
+const parquetCompressionSizeReductionThreshold = 8
+
+// parquetIntervalFields are the names of the leaf columns of an interval.
+var parquetIntervalFields = []string{"months", "days", "nanos"}
+
+// parquetLeafColumn is a primitive column of the Parquet schema. Every column
+// is stored in a single leaf column except for the intervals, which are
+// stored in a leaf column per field.
+type parquetLeafColumn struct {
+	physicalType int32
+	path         []string
+}
+
+// parquetNumLeaves returns the number of leaf columns used to store a column
+// of type t.
+func parquetNumLeaves(t coltypes.T) int {
+	if t == coltypes.Interval {
+		return len(parquetIntervalFields)
+	}
+	return 1
+}
+
+// parquetTypes returns the Parquet physical and converted types that are used
+// to store a column of type t. The intervals are stored as groups of INT64
+// leaves.
+func parquetTypes(t coltypes.T) (physicalType int32, convertedType int32, err error) {
+	switch t {
+	case coltypes.Bool:
+		return parquetTypeBoolean, parquetConvertedTypeNone, nil
+	case coltypes.Bytes:
+		return parquetTypeByteArray, parquetConvertedTypeNone, nil
+	case coltypes.Decimal:
+		return parquetTypeByteArray, parquetConvertedTypeUTF8, nil
+	case coltypes.Timestamp:
+		return parquetTypeInt64, parquetConvertedTypeTimestampMicros, nil
+	case coltypes.Int16:
+		return parquetTypeInt32, parquetConvertedTypeInt16, nil
+	case coltypes.Int32:
+		return parquetTypeInt32, parquetConvertedTypeNone, nil
+	case coltypes.Int64, coltypes.Interval:
+		return parquetTypeInt64, parquetConvertedTypeNone, nil
+	case coltypes.Float64:
+		return parquetTypeDouble, parquetConvertedTypeNone, nil
+	default:
+		return 0, 0, errors.Errorf("parquet serializer unsupported type %v", t.String())
+	}
+}
+
+// parquetColumnName returns the name of the column at the given index.
+func parquetColumnName(idx int) string {
+	return fmt.Sprintf("col%d", idx)
+}
+
+// parquetColumnChunk is the metadata of a column chunk that needs to be
+// written in the footer of the file.
+type parquetColumnChunk struct {
+	// offset is the offset of the first page of the chunk, which is the
+	// dictionary page for the dictionary encoded chunks.
+	offset           int64
+	dataPageOffset   int64
+	encoding         int32
+	codec            int32
+	numValues        int64
+	uncompressedSize int64
+	compressedSize   int64
+}
+
+// parquetRowGroup is the metadata of a row group that needs to be written in
+// the footer of the file.
+type parquetRowGroup struct {
+	columns []parquetColumnChunk
+	numRows int64
+}
+
+// ParquetSerializer converts our in-mem columnar batch representation into
+// the Parquet file format. All batches serialized to a file must have the same
+// schema. The header and the footer of a file are written to the writer that
+// is passed in to StartFile and FinishFile, respectively, while the batches
+// are written to the writer that is passed in to the constructor. This allows
+// for the row groups to be buffered separately; however, all row groups must
+// have been written out before the footer is written since the footer refers
+// to their offsets within the file.
+type ParquetSerializer struct {
+	w    io.Writer
+	typs []coltypes.T
+
+	schema []parquetSchemaElement
+	leaves []parquetLeafColumn
+
+	// offset is the number of bytes of the current file that have been written
+	// so far.
+	offset    int64
+	rowGroups []parquetRowGroup
+
+	// buf is the buffer that protocol encodes the metadata into.
+	buf      *thrift.TMemoryBuffer
+	protocol thrift.TProtocol
+	scratch  struct {
+		body       []byte
+		dictionary []byte
+		compressed [2][]byte
+		present    []bool
+		values     [][]byte
+		indices    []int32
+		dictIdx    map[string]int32
+	}
+}
+
+// NewParquetSerializer creates a ParquetSerializer for the given coltypes
+// that writes the row groups to w. The caller is responsible for closing the
+// given writer.
+func NewParquetSerializer(w io.Writer, typs []coltypes.T) (*ParquetSerializer, error) {
+	s := &ParquetSerializer{
+		w:    w,
+		typs: typs,
+		buf:  thrift.NewTMemoryBuffer(),
+	}
+	s.protocol = thrift.NewTCompactProtocol(s.buf)
+	s.scratch.dictIdx = make(map[string]int32)
+	// The schema is a tree flattened in depth-first order, so the root comes
+	// first and the columns are its children.
+	s.schema = append(s.schema, parquetSchemaElement{
+		name:          "schema",
+		convertedType: parquetConvertedTypeNone,
+		numChildren:   int32(len(typs)),
+	})
+	for i, t := range typs {
+		physicalType, convertedType, err := parquetTypes(t)
+		if err != nil {
+			return nil, err
+		}
+		name := parquetColumnName(i)
+		if t != coltypes.Interval {
+			s.schema = append(s.schema, parquetSchemaElement{
+				name:           name,
+				physicalType:   physicalType,
+				convertedType:  convertedType,
+				repetitionType: parquetRepetitionOptional,
+			})
+			s.leaves = append(s.leaves, parquetLeafColumn{
+				physicalType: physicalType,
+				path:         []string{name},
+			})
+			continue
+		}
+		// The fields of an interval are required, so the group has the same
+		// definition levels as the other columns.
+		s.schema = append(s.schema, parquetSchemaElement{
+			name:           name,
+			convertedType:  parquetConvertedTypeNone,
+			repetitionType: parquetRepetitionOptional,
+			numChildren:    int32(len(parquetIntervalFields)),
+		})
+		for _, field := range parquetIntervalFields {
+			s.schema = append(s.schema, parquetSchemaElement{
+				name:           field,
+				physicalType:   physicalType,
+				convertedType:  convertedType,
+				repetitionType: parquetRepetitionRequired,
+			})
+			s.leaves = append(s.leaves, parquetLeafColumn{
+				physicalType: physicalType,
+				path:         []string{name, field},
+			})
+		}
+	}
+	return s, nil
+}
+
+// StartFile writes the header of a new file to w and returns the number of
+// bytes written. All subsequently appended batches belong to the new file.
+func (s *ParquetSerializer) StartFile(w io.Writer) (int, error) {
+	s.offset = 0
+	s.rowGroups = s.rowGroups[:0]
+	n, err := io.WriteString(w, parquetMagic)
+	s.offset += int64(n)
+	return n, err
+}
+
+// AppendBatch adds one batch of columnar data to the file as a row group. It
+// is assumed that the batch doesn't have a selection vector.
+func (s *ParquetSerializer) AppendBatch(batch coldata.Batch) error {
+	if batch.Width() != len(s.typs) {
+		return errors.Errorf("mismatched batch width and schema length: %d != %d", batch.Width(), len(s.typs))
+	}
+	n := batch.Length()
+	rowGroup := parquetRowGroup{
+		columns: make([]parquetColumnChunk, 0, len(s.leaves)),
+		numRows: int64(n),
+	}
+	for i, typ := range s.typs {
+		if err := s.encodeColumn(&rowGroup, typ, batch.ColVec(i), n); err != nil {
+			return err
+		}
+	}
+	s.rowGroups = append(s.rowGroups, rowGroup)
+	return nil
+}
+
+// startDataPage returns the beginning of the body of a data page of the first
+// n values of vec, that is the definition levels prefixed with their length,
+// and sets s.scratch.present to whether each of the values is non-null.
+func (s *ParquetSerializer) startDataPage(vec coldata.Vec, n int) []byte {
+	if cap(s.scratch.present) < n {
+		s.scratch.present = make([]bool, n)
+	}
+	present := s.scratch.present[:n]
+	nulls := vec.Nulls()
+	hasNulls := vec.MaybeHasNulls()
+	for i := range present {
+		present[i] = !hasNulls || !nulls.NullAt(i)
+	}
+	buf := append(s.scratch.body[:0], 0, 0, 0, 0)
+	buf = appendParquetDefinitionLevels(buf, present, hasNulls)
+	binary.LittleEndian.PutUint32(buf, uint32(len(buf)-4))
+	return buf
+}
+
+// encodeColumn writes the column chunks (one per leaf column) of the first n
+// values of vec and appends their metadata to rowGroup.
+func (s *ParquetSerializer) encodeColumn(
+	rowGroup *parquetRowGroup, typ coltypes.T, vec coldata.Vec, n int,
+) error {
+	buf := s.startDataPage(vec, n)
+	present := s.scratch.present[:n]
+	switch typ {
+	case coltypes.Bool:
+		col := vec.Bool()
+		var cur byte
+		var bit uint
+		for i := 0; i < n; i++ {
+			if !present[i] {
+				continue
+			}
+			if col[i] {
+				cur |= 1 << bit
+			}
+			bit++
+			if bit == 8 {
+				buf = append(buf, cur)
+				cur, bit = 0, 0
+			}
+		}
+		if bit > 0 {
+			buf = append(buf, cur)
+		}
+	case coltypes.Bytes:
+		col := vec.Bytes()
+		values := s.scratch.values[:0]
+		for i := 0; i < n; i++ {
+			if present[i] {
+				values = append(values, col.Get(i))
+			}
+		}
+		s.scratch.values = values
+		return s.writeByteArrayColumnChunk(rowGroup, buf, values, n)
+	case coltypes.Decimal:
+		col := vec.Decimal()
+		values := s.scratch.values[:0]
+		for i := 0; i < n; i++ {
+			if present[i] {
+				marshaled, err := col[i].MarshalText()
+				if err != nil {
+					return err
+				}
+				values = append(values, marshaled)
+			}
+		}
+		s.scratch.values = values
+		return s.writeByteArrayColumnChunk(rowGroup, buf, values, n)
+	case coltypes.Timestamp:
+		col := vec.Timestamp()
+		for i := 0; i < n; i++ {
+			if present[i] {
+				buf = appendUint64(buf, uint64(timeutil.ToUnixMicros(col[i])))
+			}
+		}
+	case coltypes.Interval:
+		col := vec.Interval()
+		// The definition levels are the same for all fields.
+		levels := append([]byte(nil), buf...)
+		for field := range parquetIntervalFields {
+			buf = append(s.scratch.body[:0], levels...)
+			for i := 0; i < n; i++ {
+				if !present[i] {
+					continue
+				}
+				var v int64
+				switch field {
+				case 0:
+					v = col[i].Months
+				case 1:
+					v = col[i].Days
+				default:
+					v = col[i].Nanos()
+				}
+				buf = appendUint64(buf, uint64(v))
+			}
+			if err := s.writeColumnChunk(rowGroup, nil /* dictionary */, 0, buf, n, parquetEncodingPlain); err != nil {
+				return err
+			}
+		}
+		return nil
+	case coltypes.Int16:
+		col := vec.Int16()
+		for i := 0; i < n; i++ {
+			if present[i] {
+				buf = appendUint32(buf, uint32(int32(col[i])))
+			}
+		}
+	case coltypes.Int32:
+		col := vec.Int32()
+		for i := 0; i < n; i++ {
+			if present[i] {
+				buf = appendUint32(buf, uint32(col[i]))
+			}
+		}
+	case coltypes.Int64:
+		col := vec.Int64()
+		for i := 0; i < n; i++ {
+			if present[i] {
+				buf = appendUint64(buf, uint64(col[i]))
+			}
+		}
+	case coltypes.Float64:
+		col := vec.Float64()
+		for i := 0; i < n; i++ {
+			if present[i] {
+				buf = appendUint64(buf, math.Float64bits(col[i]))
+			}
+		}
+	default:
+		return errors.Errorf("unsupported type for conversion to parquet %s", typ)
+	}
+	return s.writeColumnChunk(rowGroup, nil /* dictionary */, 0, buf, n, parquetEncodingPlain)
+}
+
+// writeByteArrayColumnChunk writes the column chunk of the given non-null byte
+// array values, where buf is the beginning of the data page returned by
+// startDataPage. The values are dictionary encoded if at most half of them are
+// distinct.
+func (s *ParquetSerializer) writeByteArrayColumnChunk(
+	rowGroup *parquetRowGroup, buf []byte, values [][]byte, numValues int,
+) error {
+	for k := range s.scratch.dictIdx {
+		delete(s.scratch.dictIdx, k)
+	}
+	dictionary := s.scratch.dictionary[:0]
+	indices := s.scratch.indices[:0]
+	useDictionary := len(values) > 0
+	for _, v := range values {
+		idx, ok := s.scratch.dictIdx[string(v)]
+		if !ok {
+			if 2*(len(s.scratch.dictIdx)+1) > len(values) {
+				useDictionary = false
+				break
+			}
+			idx = int32(len(s.scratch.dictIdx))
+			s.scratch.dictIdx[string(v)] = idx
+			dictionary = appendParquetByteArray(dictionary, v)
+		}
+		indices = append(indices, idx)
+	}
+	s.scratch.dictionary, s.scratch.indices = dictionary, indices
+	if !useDictionary {
+		for _, v := range values {
+			buf = appendParquetByteArray(buf, v)
+		}
+		return s.writeColumnChunk(rowGroup, nil /* dictionary */, 0, buf, numValues, parquetEncodingPlain)
+	}
+	// The indices are prefixed with their bit width.
+	bitWidth := bits.Len32(uint32(len(s.scratch.dictIdx) - 1))
+	if bitWidth == 0 {
+		bitWidth = 1
+	}
+	buf = append(buf, byte(bitWidth))
+	buf = appendParquetBitPacked(buf, indices, bitWidth)
+	return s.writeColumnChunk(
+		rowGroup, dictionary, len(s.scratch.dictIdx), buf, numValues, parquetEncodingPlainDictionary,
+	)
+}
+
+// writeColumnChunk writes a column chunk that consists of the given data page
+// body, preceded by the dictionary page if encoding is PLAIN_DICTIONARY, and
+// appends its metadata to rowGroup.
+func (s *ParquetSerializer) writeColumnChunk(
+	rowGroup *parquetRowGroup,
+	dictionary []byte,
+	numDictionaryValues int,
+	body []byte,
+	numValues int,
+	encoding int32,
+) error {
+	s.scratch.body = body
+	chunk := parquetColumnChunk{
+		offset:    s.offset,
+		encoding:  encoding,
+		codec:     parquetCodecSnappy,
+		numValues: int64(numValues),
+	}
+	headers := [2]parquetPageHeader{{
+		pageType:  parquetPageTypeDictionary,
+		numValues: int32(numDictionaryValues),
+		encoding:  parquetEncodingPlainDictionary,
+	}, {
+		pageType:  parquetPageTypeData,
+		numValues: int32(numValues),
+		encoding:  encoding,
+	}}
+	pages := [2][]byte{dictionary, body}
+	firstPage := 1
+	if encoding == parquetEncodingPlainDictionary {
+		firstPage = 0
+	}
+	// Compress the pages if it is worth it for all of them. Note that the
+	// decoder relies on a compressed page being strictly smaller than the
+	// uncompressed one.
+	var compressed [2][]byte
+	for i := firstPage; i < len(pages); i++ {
+		c := snappy.Encode(s.scratch.compressed[i], pages[i])
+		s.scratch.compressed[i] = c[:cap(c)]
+		compressed[i] = c
+		if len(c) >= len(pages[i])-len(pages[i])/parquetCompressionSizeReductionThreshold {
+			chunk.codec = parquetCodecUncompressed
+		}
+	}
+	for i := firstPage; i < len(pages); i++ {
+		page := pages[i]
+		if chunk.codec == parquetCodecSnappy {
+			page = compressed[i]
+		}
+		if i == 1 {
+			chunk.dataPageOffset = s.offset
+		}
+		headers[i].uncompressedSize = int32(len(pages[i]))
+		headers[i].compressedSize = int32(len(page))
+		headerLen, err := s.writePage(headers[i], page)
+		if err != nil {
+			return err
+		}
+		chunk.uncompressedSize += int64(headerLen + len(pages[i]))
+		chunk.compressedSize += int64(headerLen + len(page))
+	}
+	rowGroup.columns = append(rowGroup.columns, chunk)
+	return nil
+}
+
+// writePage writes the given page preceded by its header and returns the
+// length of the header.
+func (s *ParquetSerializer) writePage(header parquetPageHeader, page []byte) (int, error) {
+	s.buf.Reset()
+	w := parquetMetadataWriter{p: s.protocol}
+	if err := w.writePageHeader(header); err != nil {
+		return 0, err
+	}
+	headerLen := s.buf.Len()
+	if _, err := s.w.Write(s.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	if _, err := s.w.Write(page); err != nil {
+		return 0, err
+	}
+	s.offset += int64(headerLen + len(page))
+	return headerLen, nil
+}
+
+// FinishFile writes the footer of the current file to w and returns the
+// number of bytes written. All appended batches must have been written out to
+// the file before FinishFile is called. Nothing can be called after
+// FinishFile except StartFile.
+func (s *ParquetSerializer) FinishFile(w io.Writer) (int, error) {
+	s.buf.Reset()
+	mw := parquetMetadataWriter{p: s.protocol}
+	if err := mw.writeFileMetaData(s); err != nil {
+		return 0, err
+	}
+	footer := s.buf.Bytes()
+	footer = appendUint32(footer, uint32(len(footer)))
+	footer = append(footer, parquetMagic...)
+	n, err := w.Write(footer)
+	s.offset += int64(n)
+	return n, err
+}
+
+// parquetPage is a data or a dictionary page of a region that has been
+// serialized by the ParquetSerializer.
+type parquetPage struct {
+	parquetPageHeader
+	// body is the (possibly compressed) body of the page.
+	body []byte
+}
+
+// parquetPageChunk is the pages of a column chunk. The dictionary page is only
+// present if the data page is dictionary encoded.
+type parquetPageChunk struct {
+	dictionary parquetPage
+	data       parquetPage
+}
+
+// ParquetDeserializer decodes columnar data batches from a sequence of row
+// groups that have been written by the ParquetSerializer, i.e. from the bytes
+// between the header and the footer of a file (or from a part of them that
+// starts and ends at the row group boundaries). The footer is not needed since
+// every page is preceded by its header.
+type ParquetDeserializer struct {
+	typs      []coltypes.T
+	numLeaves int
+	chunks    []parquetPageChunk
+
+	scratch struct {
+		decompressed [2][]byte
+		levels       []int32
+		present      []bool
+		dictionary   [][]byte
+		indices      []int32
+	}
+}
+
+// NewParquetDeserializerFromBytes constructs a ParquetDeserializer for an
+// in-memory buffer of row groups of the given types. The buffer must stay
+// valid for the lifetime of the deserializer.
+func NewParquetDeserializerFromBytes(buf []byte, typs []coltypes.T) (*ParquetDeserializer, error) {
+	d := &ParquetDeserializer{typs: typs}
+	for _, t := range typs {
+		d.numLeaves += parquetNumLeaves(t)
+	}
+	r := &thrift.TMemoryBuffer{Buffer: bytes.NewBuffer(buf)}
+	p := thrift.NewTCompactProtocol(r)
+	var chunk parquetPageChunk
+	hasDictionary := false
+	for r.Len() > 0 {
+		header, err := readPageHeader(p)
+		if err != nil {
+			return nil, err
+		}
+		pos := len(buf) - r.Len()
+		size := int(header.compressedSize)
+		if size < 0 || pos+size > len(buf) {
+			return nil, errors.Errorf("parquet page of %d bytes at offset %d is out of bounds", size, pos)
+		}
+		page := parquetPage{parquetPageHeader: header, body: buf[pos : pos+size]}
+		r.Next(size)
+		if header.pageType == parquetPageTypeDictionary {
+			if hasDictionary {
+				return nil, errors.Errorf("unexpected parquet dictionary page at offset %d", pos)
+			}
+			chunk.dictionary, hasDictionary = page, true
+			continue
+		}
+		if hasDictionary != (header.encoding == parquetEncodingPlainDictionary) {
+			return nil, errors.Errorf("mismatched parquet dictionary page and data page encoding %d", header.encoding)
+		}
+		chunk.data = page
+		d.chunks = append(d.chunks, chunk)
+		chunk, hasDictionary = parquetPageChunk{}, false
+	}
+	if hasDictionary || d.numLeaves == 0 || len(d.chunks)%d.numLeaves != 0 {
+		return nil, errors.Errorf("%d parquet column chunks cannot be split into row groups of %d columns", len(d.chunks), d.numLeaves)
+	}
+	return d, nil
+}
+
+// Close is a noop. It exists so that the ParquetDeserializer can be used
+// interchangeably with the FileDeserializer.
+func (d *ParquetDeserializer) Close() error {
+	return nil
+}
+
+// Typs returns the in-memory columnar types for the data stored in this file.
+func (d *ParquetDeserializer) Typs() []coltypes.T {
+	return d.typs
+}
+
+// NumBatches returns the number of record batches stored in this file.
+func (d *ParquetDeserializer) NumBatches() int {
+	return len(d.chunks) / d.numLeaves
+}
+
+// GetBatch fills in the given in-mem batch with the requested on-disk data.
+func (d *ParquetDeserializer) GetBatch(batchIdx int, b coldata.Batch) error {
+	chunks := d.chunks[batchIdx*d.numLeaves : (batchIdx+1)*d.numLeaves]
+	n := int(chunks[0].data.numValues)
+	b.Reset(d.typs, n)
+	b.SetLength(n)
+	// Reset the batch, this resets the selection vector as well.
+	b.ResetInternalBatch()
+	for i, typ := range d.typs {
+		numLeaves := parquetNumLeaves(typ)
+		if err := d.decodeColumn(chunks[:numLeaves], typ, b.ColVec(i), n); err != nil {
+			return err
+		}
+		chunks = chunks[numLeaves:]
+	}
+	return nil
+}
+
+// decompress returns the uncompressed body of the given page using the
+// scratch space at the given index.
+func (d *ParquetDeserializer) decompress(page parquetPage, scratchIdx int) ([]byte, error) {
+	if len(page.body) == int(page.uncompressedSize) {
+		return page.body, nil
+	}
+	// Only the compressed pages are smaller than their uncompressed size.
+	decompressed, err := snappy.Decode(d.scratch.decompressed[scratchIdx], page.body)
+	if err != nil {
+		return nil, err
+	}
+	d.scratch.decompressed[scratchIdx] = decompressed[:cap(decompressed)]
+	if len(decompressed) != int(page.uncompressedSize) {
+		return nil, errors.Errorf("unexpected size of a decompressed parquet page: %d != %d",
+			len(decompressed), page.uncompressedSize)
+	}
+	return decompressed, nil
+}
+
+// decodeDataPage decodes the definition levels of the data page of chunk with
+// n values into d.scratch.present and returns the encoded non-null values.
+func (d *ParquetDeserializer) decodeDataPage(chunk parquetPageChunk, n int) ([]byte, error) {
+	if int(chunk.data.numValues) != n {
+		return nil, errors.Errorf("mismatched number of values in a row group: %d != %d", chunk.data.numValues, n)
+	}
+	body, err := d.decompress(chunk.data, 0 /* scratchIdx */)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) < 4 {
+		return nil, errors.Errorf("parquet page body is too short: %d bytes", len(body))
+	}
+	levelsLen := int(binary.LittleEndian.Uint32(body))
+	if 4+levelsLen > len(body) {
+		return nil, errors.Errorf("parquet definition levels of %d bytes are out of bounds", levelsLen)
+	}
+	if cap(d.scratch.levels) < n {
+		d.scratch.levels = make([]int32, n)
+		d.scratch.present = make([]bool, n)
+	}
+	levels := d.scratch.levels[:n]
+	if err := decodeParquetRLEHybrid(levels, body[4:4+levelsLen], 1 /* bitWidth */); err != nil {
+		return nil, err
+	}
+	present := d.scratch.present[:n]
+	for i := range present {
+		present[i] = levels[i] != 0
+	}
+	return body[4+levelsLen:], nil
+}
+
+// decodeByteArrays decodes the non-null byte array values of chunk whose data
+// page has been decoded into values by decodeDataPage. fn is called with the
+// index of each non-null value and the value itself, which is only valid for
+// the duration of the call.
+func (d *ParquetDeserializer) decodeByteArrays(
+	chunk parquetPageChunk, values []byte, fn func(i int, v []byte) error,
+) error {
+	present := d.scratch.present[:chunk.data.numValues]
+	var err error
+	if chunk.data.encoding == parquetEncodingPlain {
+		for i := range present {
+			if present[i] {
+				var v []byte
+				if v, values, err = readParquetByteArray(values); err != nil {
+					return err
+				}
+				if err := fn(i, v); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	// Decode the dictionary.
+	body, err := d.decompress(chunk.dictionary, 1 /* scratchIdx */)
+	if err != nil {
+		return err
+	}
+	dictionary := d.scratch.dictionary[:0]
+	for j := int32(0); j < chunk.dictionary.numValues; j++ {
+		var v []byte
+		if v, body, err = readParquetByteArray(body); err != nil {
+			return err
+		}
+		dictionary = append(dictionary, v)
+	}
+	d.scratch.dictionary = dictionary
+
+	// Decode the indices, which are prefixed with their bit width.
+	numPresent := 0
+	for i := range present {
+		if present[i] {
+			numPresent++
+		}
+	}
+	if len(values) < 1 {
+		return errParquetValuesOutOfBounds
+	}
+	if cap(d.scratch.indices) < numPresent {
+		d.scratch.indices = make([]int32, numPresent)
+	}
+	indices := d.scratch.indices[:numPresent]
+	if err := decodeParquetRLEHybrid(indices, values[1:], int(values[0])); err != nil {
+		return err
+	}
+	for i := range present {
+		if present[i] {
+			idx := indices[0]
+			indices = indices[1:]
+			if idx < 0 || int(idx) >= len(dictionary) {
+				return errors.Errorf("parquet dictionary index %d is out of bounds", idx)
+			}
+			if err := fn(i, dictionary[idx]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decodeColumn decodes the column chunks (one per leaf column) of a column
+// with n values into vec.
+func (d *ParquetDeserializer) decodeColumn(
+	chunks []parquetPageChunk, typ coltypes.T, vec coldata.Vec, n int,
+) error {
+	values, err := d.decodeDataPage(chunks[0], n)
+	if err != nil {
+		return err
+	}
+	present := d.scratch.present[:n]
+	nulls := vec.Nulls()
+	nulls.UnsetNulls()
+	for i := range present {
+		if !present[i] {
+			nulls.SetNull(i)
+		}
+	}
+
+	switch typ {
+	case coltypes.Bool:
+		col := vec.Bool()
+		bit := 0
+		for i := 0; i < n; i++ {
+			if !present[i] {
+				continue
+			}
+			if bit/8 >= len(values) {
+				return errParquetValuesOutOfBounds
+			}
+			col[i] = values[bit/8]&(1<<uint(bit%8)) != 0
+			bit++
+		}
+	case coltypes.Bytes:
+		col := vec.Bytes()
+		col.Reset()
+		// The values have to be set in order, so the nulls are set explicitly.
+		prev := -1
+		if err := d.decodeByteArrays(chunks[0], values, func(i int, v []byte) error {
+			for prev++; prev < i; prev++ {
+				col.Set(prev, nil)
+			}
+			col.Set(i, v)
+			return nil
+		}); err != nil {
+			return err
+		}
+		for prev++; prev < n; prev++ {
+			col.Set(prev, nil)
+		}
+	case coltypes.Decimal:
+		col := vec.Decimal()
+		return d.decodeByteArrays(chunks[0], values, func(i int, v []byte) error {
+			return col[i].UnmarshalText(v)
+		})
+	case coltypes.Timestamp:
+		col := vec.Timestamp()
+		for i := 0; i < n; i++ {
+			if present[i] {
+				if len(values) < sizeOfInt64 {
+					return errParquetValuesOutOfBounds
+				}
+				col[i] = timeutil.FromUnixMicros(int64(binary.LittleEndian.Uint64(values)))
+				values = values[sizeOfInt64:]
+			}
+		}
+	case coltypes.Interval:
+		col := vec.Interval()
+		for field := range parquetIntervalFields {
+			if field > 0 {
+				// The definition levels of all fields are the same as the ones
+				// of the first field, which have been decoded above.
+				if values, err = d.decodeDataPage(chunks[field], n); err != nil {
+					return err
+				}
+			}
+			for i := 0; i < n; i++ {
+				if !present[i] {
+					continue
+				}
+				if len(values) < sizeOfInt64 {
+					return errParquetValuesOutOfBounds
+				}
+				v := int64(binary.LittleEndian.Uint64(values))
+				values = values[sizeOfInt64:]
+				switch field {
+				case 0:
+					col[i].Months = v
+				case 1:
+					col[i].Days = v
+				default:
+					col[i] = duration.DecodeDuration(col[i].Months, col[i].Days, v)
+				}
+			}
+		}
+	case coltypes.Int16:
+		col := vec.Int16()
+		for i := 0; i < n; i++ {
+			if present[i] {
+				if len(values) < sizeOfInt32 {
+					return errParquetValuesOutOfBounds
+				}
+				col[i] = int16(int32(binary.LittleEndian.Uint32(values)))
+				values = values[sizeOfInt32:]
+			}
+		}
+	case coltypes.Int32:
+		col := vec.Int32()
+		for i := 0; i < n; i++ {
+			if present[i] {
+				if len(values) < sizeOfInt32 {
+					return errParquetValuesOutOfBounds
+				}
+				col[i] = int32(binary.LittleEndian.Uint32(values))
+				values = values[sizeOfInt32:]
+			}
+		}
+	case coltypes.Int64:
+		col := vec.Int64()
+		for i := 0; i < n; i++ {
+			if present[i] {
+				if len(values) < sizeOfInt64 {
+					return errParquetValuesOutOfBounds
+				}
+				col[i] = int64(binary.LittleEndian.Uint64(values))
+				values = values[sizeOfInt64:]
+			}
+		}
+	case coltypes.Float64:
+		col := vec.Float64()
+		for i := 0; i < n; i++ {
+			if present[i] {
+				if len(values) < sizeOfFloat64 {
+					return errParquetValuesOutOfBounds
+				}
+				col[i] = math.Float64frombits(binary.LittleEndian.Uint64(values))
+				values = values[sizeOfFloat64:]
+			}
+		}
+	default:
+		return errors.Errorf("unsupported type for conversion from parquet %s", typ)
+	}
+	return nil
+}
+
+var errParquetValuesOutOfBounds = errors.New("parquet values are out of bounds")
+
+// appendParquetDefinitionLevels appends the definition levels (which are 1
+// for non-null values and 0 for nulls) encoded with the RLE/bit-packing
+// hybrid encoding with the bit width of 1. If there are no nulls, a single RLE
+// run is used, otherwise the levels are bit-packed.
+func appendParquetDefinitionLevels(buf []byte, present []bool, hasNulls bool) []byte {
+	if !hasNulls {
+		buf = appendUvarint(buf, uint64(len(present))<<1)
+		return append(buf, 1)
+	}
+	numGroups := (len(present) + 7) / 8
+	buf = appendUvarint(buf, uint64(numGroups)<<1|1)
+	for g := 0; g < numGroups; g++ {
+		var cur byte
+		for j := 0; j < 8 && g*8+j < len(present); j++ {
+			if present[g*8+j] {
+				cur |= 1 << uint(j)
+			}
+		}
+		buf = append(buf, cur)
+	}
+	return buf
+}
+
+// appendParquetBitPacked appends the given values encoded with the
+// RLE/bit-packing hybrid encoding as a single bit-packed run with the given bit
+// width. The run is padded with zeros to a multiple of 8 values.
+func appendParquetBitPacked(buf []byte, values []int32, bitWidth int) []byte {
+	numGroups := (len(values) + 7) / 8
+	buf = appendUvarint(buf, uint64(numGroups)<<1|1)
+	var cur uint64
+	var numBits uint
+	for i := 0; i < numGroups*8; i++ {
+		if i < len(values) {
+			cur |= uint64(uint32(values[i])) << numBits
+		}
+		numBits += uint(bitWidth)
+		for numBits >= 8 {
+			buf = append(buf, byte(cur))
+			cur >>= 8
+			numBits -= 8
+		}
+	}
+	return buf
+}
+
+// decodeParquetRLEHybrid decodes len(dst) values encoded with the
+// RLE/bit-packing hybrid encoding with the given bit width into dst.
+func decodeParquetRLEHybrid(dst []int32, data []byte, bitWidth int) error {
+	if bitWidth > 32 {
+		return errors.Errorf("invalid parquet bit width %d", bitWidth)
+	}
+	byteWidth := (bitWidth + 7) / 8
+	mask := uint64(1)<<uint(bitWidth) - 1
+	pos := 0
+	for r := 0; r < len(dst); {
+		header, k := binary.Uvarint(data[pos:])
+		if k <= 0 {
+			return errors.New("invalid parquet run header")
+		}
+		pos += k
+		if header&1 == 0 {
+			// RLE run.
+			if pos+byteWidth > len(data) {
+				return errParquetValuesOutOfBounds
+			}
+			var v uint32
+			for j := 0; j < byteWidth; j++ {
+				v |= uint32(data[pos+j]) << uint(8*j)
+			}
+			pos += byteWidth
+			for j := uint64(0); j < header>>1 && r < len(dst); j++ {
+				dst[r] = int32(v)
+				r++
+			}
+			continue
+		}
+		// Bit-packed run of groups of 8 values.
+		numValues := int(header>>1) * 8
+		numBytes := numValues * bitWidth / 8
+		if pos+numBytes > len(data) {
+			return errParquetValuesOutOfBounds
+		}
+		run := data[pos : pos+numBytes]
+		var cur uint64
+		var numBits uint
+		for j := 0; j < numValues && r < len(dst); j++ {
+			for numBits < uint(bitWidth) {
+				cur |= uint64(run[0]) << numBits
+				run = run[1:]
+				numBits += 8
+			}
+			dst[r] = int32(cur & mask)
+			cur >>= uint(bitWidth)
+			numBits -= uint(bitWidth)
+			r++
+		}
+		pos += numBytes
+	}
+	return nil
+}
+
+func appendParquetByteArray(buf []byte, v []byte) []byte {
+	buf = appendUint32(buf, uint32(len(v)))
+	return append(buf, v...)
+}
+
+func readParquetByteArray(values []byte) (v []byte, rest []byte, err error) {
+	if len(values) < sizeOfInt32 {
+		return nil, nil, errParquetValuesOutOfBounds
+	}
+	l := int(binary.LittleEndian.Uint32(values))
+	if sizeOfInt32+l > len(values) {
+		return nil, nil, errParquetValuesOutOfBounds
+	}
+	return values[sizeOfInt32 : sizeOfInt32+l], values[sizeOfInt32+l:], nil
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	return append(buf, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func appendUint64(buf []byte, v uint64) []byte {
+	return append(buf,
+		byte(v), byte(v>>8), byte(v>>16), byte(v>>24),
+		byte(v>>32), byte(v>>40), byte(v>>48), byte(v>>56),
+	)
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	for v >= 0x80 {
+		buf = append(buf, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(buf, byte(v))
+}
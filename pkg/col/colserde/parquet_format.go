@@ -0,0 +1,360 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colserde
+
+import (
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/pkg/errors"
+)
+
+// The metadata of the Parquet files (the page headers and the footer) is
+// encoded with the Thrift compact protocol. This file contains the subset of
+// the structures defined in
+// https://github.com/apache/parquet-format/blob/master/src/main/thrift/parquet.thrift
+// that is needed to write the files and to read the page headers back.
+
+// Parquet physical types.
+const (
+	parquetTypeBoolean   int32 = 0
+	parquetTypeInt32     int32 = 1
+	parquetTypeInt64     int32 = 2
+	parquetTypeDouble    int32 = 5
+	parquetTypeByteArray int32 = 6
+)
+
+// Parquet converted types.
+const (
+	parquetConvertedTypeNone            int32 = -1
+	parquetConvertedTypeUTF8            int32 = 0
+	parquetConvertedTypeTimestampMicros int32 = 10
+	parquetConvertedTypeInt16           int32 = 16
+)
+
+// Parquet encodings. The dictionary pages and the dictionary encoded data
+// pages use PLAIN_DICTIONARY, as is required by the version 1 of the format.
+const (
+	parquetEncodingPlain           int32 = 0
+	parquetEncodingPlainDictionary int32 = 2
+	parquetEncodingRLE             int32 = 3
+)
+
+// Parquet compression codecs.
+const (
+	parquetCodecUncompressed int32 = 0
+	parquetCodecSnappy       int32 = 1
+)
+
+// Parquet page types.
+const (
+	parquetPageTypeData       int32 = 0
+	parquetPageTypeDictionary int32 = 2
+)
+
+// Parquet field repetition types.
+const (
+	parquetRepetitionRequired int32 = 0
+	parquetRepetitionOptional int32 = 1
+)
+
+const (
+	parquetFormatVersion int32 = 1
+	parquetCreatedBy           = "cockroachdb"
+)
+
+// parquetPageHeader is the PageHeader of a data or a dictionary page.
+type parquetPageHeader struct {
+	pageType         int32
+	uncompressedSize int32
+	compressedSize   int32
+	numValues        int32
+	encoding         int32
+}
+
+// parquetSchemaElement is a node of the schema tree of a file.
+type parquetSchemaElement struct {
+	name           string
+	physicalType   int32
+	convertedType  int32
+	repetitionType int32
+	// numChildren is only set for the groups, which have no physical type.
+	numChildren int32
+}
+
+// parquetMetadataWriter encodes the Parquet metadata with the given Thrift
+// protocol. The first error is remembered and all subsequent writes are
+// skipped, so it only needs to be checked once the whole structure has been
+// written.
+type parquetMetadataWriter struct {
+	p   thrift.TProtocol
+	err error
+}
+
+func (w *parquetMetadataWriter) structBegin(name string) {
+	if w.err == nil {
+		w.err = w.p.WriteStructBegin(name)
+	}
+}
+
+func (w *parquetMetadataWriter) structEnd() {
+	if w.err == nil {
+		w.err = w.p.WriteFieldStop()
+	}
+	if w.err == nil {
+		w.err = w.p.WriteStructEnd()
+	}
+}
+
+func (w *parquetMetadataWriter) fieldBegin(name string, typ thrift.TType, id int16) {
+	if w.err == nil {
+		w.err = w.p.WriteFieldBegin(name, typ, id)
+	}
+}
+
+func (w *parquetMetadataWriter) fieldEnd() {
+	if w.err == nil {
+		w.err = w.p.WriteFieldEnd()
+	}
+}
+
+func (w *parquetMetadataWriter) i32Field(name string, id int16, v int32) {
+	w.fieldBegin(name, thrift.I32, id)
+	w.i32(v)
+	w.fieldEnd()
+}
+
+func (w *parquetMetadataWriter) i64Field(name string, id int16, v int64) {
+	w.fieldBegin(name, thrift.I64, id)
+	if w.err == nil {
+		w.err = w.p.WriteI64(v)
+	}
+	w.fieldEnd()
+}
+
+func (w *parquetMetadataWriter) stringField(name string, id int16, v string) {
+	w.fieldBegin(name, thrift.STRING, id)
+	w.string(v)
+	w.fieldEnd()
+}
+
+// structField writes a struct field whose fields are written by fn.
+func (w *parquetMetadataWriter) structField(name string, id int16, fn func()) {
+	w.fieldBegin(name, thrift.STRUCT, id)
+	w.structBegin(name)
+	fn()
+	w.structEnd()
+	w.fieldEnd()
+}
+
+// listField writes a list field of size elements of elemType. The elements
+// are written by fn.
+func (w *parquetMetadataWriter) listField(
+	name string, id int16, elemType thrift.TType, size int, fn func(),
+) {
+	w.fieldBegin(name, thrift.LIST, id)
+	if w.err == nil {
+		w.err = w.p.WriteListBegin(elemType, size)
+	}
+	fn()
+	if w.err == nil {
+		w.err = w.p.WriteListEnd()
+	}
+	w.fieldEnd()
+}
+
+func (w *parquetMetadataWriter) i32(v int32) {
+	if w.err == nil {
+		w.err = w.p.WriteI32(v)
+	}
+}
+
+func (w *parquetMetadataWriter) string(v string) {
+	if w.err == nil {
+		w.err = w.p.WriteString(v)
+	}
+}
+
+// writePageHeader writes the PageHeader of a data or a dictionary page.
+func (w *parquetMetadataWriter) writePageHeader(h parquetPageHeader) error {
+	w.structBegin("PageHeader")
+	w.i32Field("type", 1, h.pageType)
+	w.i32Field("uncompressed_page_size", 2, h.uncompressedSize)
+	w.i32Field("compressed_page_size", 3, h.compressedSize)
+	if h.pageType == parquetPageTypeDictionary {
+		w.structField("dictionary_page_header", 7, func() {
+			w.i32Field("num_values", 1, h.numValues)
+			w.i32Field("encoding", 2, h.encoding)
+		})
+	} else {
+		w.structField("data_page_header", 5, func() {
+			w.i32Field("num_values", 1, h.numValues)
+			w.i32Field("encoding", 2, h.encoding)
+			w.i32Field("definition_level_encoding", 3, parquetEncodingRLE)
+			w.i32Field("repetition_level_encoding", 4, parquetEncodingRLE)
+		})
+	}
+	w.structEnd()
+	return w.err
+}
+
+// writeFileMetaData writes the FileMetaData describing all row groups
+// appended to the current file of s.
+func (w *parquetMetadataWriter) writeFileMetaData(s *ParquetSerializer) error {
+	w.structBegin("FileMetaData")
+	w.i32Field("version", 1, parquetFormatVersion)
+	// The schema is a tree flattened in depth-first order.
+	w.listField("schema", 2, thrift.STRUCT, len(s.schema), func() {
+		for i, e := range s.schema {
+			w.structBegin("SchemaElement")
+			if e.numChildren == 0 {
+				w.i32Field("type", 1, e.physicalType)
+			}
+			// The root has no repetition type.
+			if i > 0 {
+				w.i32Field("repetition_type", 3, e.repetitionType)
+			}
+			w.stringField("name", 4, e.name)
+			if e.numChildren > 0 {
+				w.i32Field("num_children", 5, e.numChildren)
+			}
+			if e.convertedType != parquetConvertedTypeNone {
+				w.i32Field("converted_type", 6, e.convertedType)
+			}
+			w.structEnd()
+		}
+	})
+	var numRows int64
+	for _, rowGroup := range s.rowGroups {
+		numRows += rowGroup.numRows
+	}
+	w.i64Field("num_rows", 3, numRows)
+	w.listField("row_groups", 4, thrift.STRUCT, len(s.rowGroups), func() {
+		for _, rowGroup := range s.rowGroups {
+			w.structBegin("RowGroup")
+			var totalByteSize int64
+			w.listField("columns", 1, thrift.STRUCT, len(rowGroup.columns), func() {
+				for i, c := range rowGroup.columns {
+					totalByteSize += c.uncompressedSize
+					w.writeColumnChunk(s.leaves[i], c)
+				}
+			})
+			w.i64Field("total_byte_size", 2, totalByteSize)
+			w.i64Field("num_rows", 3, rowGroup.numRows)
+			w.structEnd()
+		}
+	})
+	w.stringField("created_by", 6, parquetCreatedBy)
+	w.structEnd()
+	return w.err
+}
+
+// writeColumnChunk writes the ColumnChunk (including its ColumnMetaData) of
+// the given leaf column.
+func (w *parquetMetadataWriter) writeColumnChunk(leaf parquetLeafColumn, c parquetColumnChunk) {
+	w.structBegin("ColumnChunk")
+	w.i64Field("file_offset", 2, c.offset)
+	w.structField("meta_data", 3, func() {
+		w.i32Field("type", 1, leaf.physicalType)
+		w.listField("encodings", 2, thrift.I32, 2, func() {
+			w.i32(c.encoding)
+			w.i32(parquetEncodingRLE)
+		})
+		w.listField("path_in_schema", 3, thrift.STRING, len(leaf.path), func() {
+			for _, name := range leaf.path {
+				w.string(name)
+			}
+		})
+		w.i32Field("codec", 4, c.codec)
+		w.i64Field("num_values", 5, c.numValues)
+		w.i64Field("total_uncompressed_size", 6, c.uncompressedSize)
+		w.i64Field("total_compressed_size", 7, c.compressedSize)
+		w.i64Field("data_page_offset", 9, c.dataPageOffset)
+		if c.encoding == parquetEncodingPlainDictionary {
+			w.i64Field("dictionary_page_offset", 11, c.offset)
+		}
+	})
+	w.structEnd()
+}
+
+// readThriftStruct reads the fields of a struct and calls fn for each of them.
+// fn must consume the value of the field (possibly by skipping it).
+func readThriftStruct(p thrift.TProtocol, fn func(id int16, typ thrift.TType) error) error {
+	if _, err := p.ReadStructBegin(); err != nil {
+		return err
+	}
+	for {
+		_, typ, id, err := p.ReadFieldBegin()
+		if err != nil {
+			return err
+		}
+		if typ == thrift.STOP {
+			break
+		}
+		if err := fn(id, typ); err != nil {
+			return err
+		}
+		if err := p.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	return p.ReadStructEnd()
+}
+
+// readPageHeader reads the PageHeader of a data or a dictionary page.
+func readPageHeader(p thrift.TProtocol) (parquetPageHeader, error) {
+	h := parquetPageHeader{pageType: -1}
+	readI32 := func(dst *int32) error {
+		var err error
+		*dst, err = p.ReadI32()
+		return err
+	}
+	readPageTypeHeader := func() error {
+		return readThriftStruct(p, func(id int16, typ thrift.TType) error {
+			switch {
+			case id == 1 && typ == thrift.I32:
+				return readI32(&h.numValues)
+			case id == 2 && typ == thrift.I32:
+				return readI32(&h.encoding)
+			default:
+				return p.Skip(typ)
+			}
+		})
+	}
+	err := readThriftStruct(p, func(id int16, typ thrift.TType) error {
+		switch {
+		case id == 1 && typ == thrift.I32:
+			return readI32(&h.pageType)
+		case id == 2 && typ == thrift.I32:
+			return readI32(&h.uncompressedSize)
+		case id == 3 && typ == thrift.I32:
+			return readI32(&h.compressedSize)
+		case (id == 5 || id == 7) && typ == thrift.STRUCT:
+			return readPageTypeHeader()
+		default:
+			return p.Skip(typ)
+		}
+	})
+	if err != nil {
+		return h, err
+	}
+	switch h.pageType {
+	case parquetPageTypeData:
+		if h.encoding != parquetEncodingPlain && h.encoding != parquetEncodingPlainDictionary {
+			return h, errors.Errorf("unsupported parquet data page encoding %d", h.encoding)
+		}
+	case parquetPageTypeDictionary:
+		if h.encoding != parquetEncodingPlainDictionary && h.encoding != parquetEncodingPlain {
+			return h, errors.Errorf("unsupported parquet dictionary page encoding %d", h.encoding)
+		}
+	default:
+		return h, errors.Errorf("unsupported parquet page type %d", h.pageType)
+	}
+	return h, nil
+}
@@ -0,0 +1,293 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colserde_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/colserde"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParquetRoundtrip(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	typs, b := randomBatch(testAllocator)
+	// The timestamps are stored with the microsecond precision of the SQL
+	// timestamps.
+	for i, typ := range typs {
+		if typ == coltypes.Timestamp {
+			col := b.ColVec(i).Timestamp()
+			for j := range col {
+				col[j] = col[j].Round(time.Microsecond)
+			}
+		}
+	}
+
+	const numBatches = 3
+	var rowGroups bytes.Buffer
+	s, err := colserde.NewParquetSerializer(&rowGroups, typs)
+	require.NoError(t, err)
+
+	// Write the same file twice to make sure that the serializer can be reused
+	// across files.
+	for file := 0; file < 2; file++ {
+		rowGroups.Reset()
+		var header, footer bytes.Buffer
+		n, err := s.StartFile(&header)
+		require.NoError(t, err)
+		require.Equal(t, header.Len(), n)
+
+		originals := make([]coldata.Batch, numBatches)
+		for i := range originals {
+			originals[i] = colexec.CopyBatch(testAllocator, b)
+			require.NoError(t, s.AppendBatch(b))
+		}
+		n, err = s.FinishFile(&footer)
+		require.NoError(t, err)
+		require.Equal(t, footer.Len(), n)
+
+		// The file must be framed by the magic and end with the length of the
+		// footer metadata.
+		const magic = "PAR1"
+		require.Equal(t, magic, header.String())
+		footerBytes := footer.Bytes()
+		require.True(t, len(footerBytes) >= 8)
+		require.Equal(t, magic, string(footerBytes[len(footerBytes)-4:]))
+		metadataLen := binary.LittleEndian.Uint32(footerBytes[len(footerBytes)-8:])
+		require.Equal(t, len(footerBytes)-8, int(metadataLen))
+
+		// Parts of the deserialization modify things in place, so run it twice
+		// to make sure those modifications don't leak back to the buffer.
+		for i := 0; i < 2; i++ {
+			func() {
+				d, err := colserde.NewParquetDeserializerFromBytes(rowGroups.Bytes(), typs)
+				require.NoError(t, err)
+				defer func() { require.NoError(t, d.Close()) }()
+				require.Equal(t, typs, d.Typs())
+				require.Equal(t, numBatches, d.NumBatches())
+				for batchIdx := 0; batchIdx < numBatches; batchIdx++ {
+					roundtrip := coldata.NewMemBatchWithSize(nil, 0)
+					require.NoError(t, d.GetBatch(batchIdx, roundtrip))
+					coldata.AssertEquivalentBatches(t, originals[batchIdx], roundtrip)
+				}
+			}()
+		}
+	}
+}
+
+// readThriftValue reads a value of the given type. The structs are read into
+// maps from the field IDs to the values of the fields.
+func readThriftValue(p thrift.TProtocol, typ thrift.TType) (interface{}, error) {
+	switch typ {
+	case thrift.BOOL:
+		return p.ReadBool()
+	case thrift.I32:
+		return p.ReadI32()
+	case thrift.I64:
+		return p.ReadI64()
+	case thrift.STRING:
+		return p.ReadString()
+	case thrift.LIST:
+		elemType, size, err := p.ReadListBegin()
+		if err != nil {
+			return nil, err
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			if list[i], err = readThriftValue(p, elemType); err != nil {
+				return nil, err
+			}
+		}
+		return list, p.ReadListEnd()
+	case thrift.STRUCT:
+		if _, err := p.ReadStructBegin(); err != nil {
+			return nil, err
+		}
+		fields := make(map[int16]interface{})
+		for {
+			_, fieldType, id, err := p.ReadFieldBegin()
+			if err != nil {
+				return nil, err
+			}
+			if fieldType == thrift.STOP {
+				break
+			}
+			if fields[id], err = readThriftValue(p, fieldType); err != nil {
+				return nil, err
+			}
+			if err := p.ReadFieldEnd(); err != nil {
+				return nil, err
+			}
+		}
+		return fields, p.ReadStructEnd()
+	default:
+		return nil, p.Skip(typ)
+	}
+}
+
+// readThriftStruct reads a struct encoded with the Thrift compact protocol
+// from the beginning of buf and returns it along with its encoded length.
+func readThriftStruct(t *testing.T, buf []byte) (map[int16]interface{}, int) {
+	r := &thrift.TMemoryBuffer{Buffer: bytes.NewBuffer(buf)}
+	v, err := readThriftValue(thrift.NewTCompactProtocol(r), thrift.STRUCT)
+	require.NoError(t, err)
+	return v.(map[int16]interface{}), len(buf) - r.Len()
+}
+
+// TestParquetFooter reads a finished file the way the Parquet readers do, that
+// is by following the metadata in the footer rather than by decoding the page
+// headers in sequence like the ParquetDeserializer does.
+func TestParquetFooter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	typs := []coltypes.T{coltypes.Int64, coltypes.Bytes, coltypes.Timestamp, coltypes.Interval}
+	const n = 100
+	const nullIdx = 7
+	b := testAllocator.NewMemBatchWithSize(typs, n)
+	b.SetLength(n)
+	ints := b.ColVec(0).Int64()
+	for i := 0; i < n; i++ {
+		ints[i] = int64(i * i)
+		b.ColVec(1).Bytes().Set(i, []byte(fmt.Sprintf("value%d", i%3)))
+		b.ColVec(2).Timestamp()[i] = timeutil.Unix(int64(i), 0)
+		b.ColVec(3).Interval()[i] = duration.MakeDuration(int64(i), int64(i), int64(i))
+	}
+	b.ColVec(0).Nulls().SetNull(nullIdx)
+
+	var header, rowGroups, footer bytes.Buffer
+	s, err := colserde.NewParquetSerializer(&rowGroups, typs)
+	require.NoError(t, err)
+	_, err = s.StartFile(&header)
+	require.NoError(t, err)
+	require.NoError(t, s.AppendBatch(b))
+	_, err = s.FinishFile(&footer)
+	require.NoError(t, err)
+	file := append(append(header.Bytes(), rowGroups.Bytes()...), footer.Bytes()...)
+
+	metadataLen := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	metadata, _ := readThriftStruct(t, file[len(file)-8-metadataLen:len(file)-8])
+	require.Equal(t, int64(n), metadata[3] /* num_rows */)
+
+	// The schema is the root followed by the columns in depth-first order.
+	type schemaElement struct {
+		name          string
+		physicalType  interface{}
+		convertedType interface{}
+		numChildren   interface{}
+	}
+	var schema []schemaElement
+	for _, e := range metadata[2].([]interface{}) {
+		fields := e.(map[int16]interface{})
+		schema = append(schema, schemaElement{
+			name:          fields[4].(string),
+			physicalType:  fields[1],
+			convertedType: fields[6],
+			numChildren:   fields[5],
+		})
+	}
+	const (
+		int64Type       = int32(2)
+		byteArrayType   = int32(6)
+		timestampMicros = int32(10)
+		plainDictionary = int32(2)
+		snappyCodec     = int32(1)
+		dictionaryPage  = int32(2)
+	)
+	require.Equal(t, []schemaElement{
+		{name: "schema", numChildren: int32(4)},
+		{name: "col0", physicalType: int64Type},
+		{name: "col1", physicalType: byteArrayType},
+		{name: "col2", physicalType: int64Type, convertedType: timestampMicros},
+		{name: "col3", numChildren: int32(3)},
+		{name: "months", physicalType: int64Type},
+		{name: "days", physicalType: int64Type},
+		{name: "nanos", physicalType: int64Type},
+	}, schema)
+
+	rowGroupsMetadata := metadata[4].([]interface{})
+	require.Equal(t, 1, len(rowGroupsMetadata))
+	columns := rowGroupsMetadata[0].(map[int16]interface{})[1].([]interface{})
+	require.Equal(t, 6, len(columns))
+	for i, c := range columns {
+		chunk := c.(map[int16]interface{})[3 /* meta_data */].(map[int16]interface{})
+		require.Equal(t, int64(n), chunk[5 /* num_values */])
+
+		// Read the pages of the chunk starting at the offsets in the footer.
+		offset := int(chunk[9 /* data_page_offset */].(int64))
+		if dictOffset, ok := chunk[11 /* dictionary_page_offset */]; ok {
+			offset = int(dictOffset.(int64))
+		}
+		end := offset + int(chunk[7 /* total_compressed_size */].(int64))
+		var dictionary, data []byte
+		for offset < end {
+			pageHeader, headerLen := readThriftStruct(t, file[offset:])
+			offset += headerLen
+			body := file[offset : offset+int(pageHeader[3 /* compressed_page_size */].(int32))]
+			offset += len(body)
+			if chunk[4 /* codec */] == snappyCodec {
+				body, err = snappy.Decode(nil, body)
+				require.NoError(t, err)
+			}
+			if pageHeader[1] /* type */ == dictionaryPage {
+				dictPageHeader := pageHeader[7 /* dictionary_page_header */].(map[int16]interface{})
+				require.Equal(t, int32(3), dictPageHeader[1 /* num_values */])
+				dictionary = body
+				continue
+			}
+			dataPageHeader := pageHeader[5 /* data_page_header */].(map[int16]interface{})
+			require.Equal(t, int32(n), dataPageHeader[1 /* num_values */])
+			if dictionary != nil {
+				require.Equal(t, plainDictionary, dataPageHeader[2 /* encoding */])
+			}
+			data = body
+		}
+		require.Equal(t, end, offset)
+		require.NotNil(t, data)
+
+		switch i {
+		case 0:
+			// The PLAIN encoded non-null values follow the definition levels.
+			levelsLen := int(binary.LittleEndian.Uint32(data))
+			values := data[4+levelsLen:]
+			require.Equal(t, (n-1)*8, len(values))
+			for j := 0; j < n; j++ {
+				if j == nullIdx {
+					continue
+				}
+				require.Equal(t, ints[j], int64(binary.LittleEndian.Uint64(values)))
+				values = values[8:]
+			}
+		case 1:
+			// The bytes have few distinct values, so they are dictionary encoded.
+			require.Contains(t, chunk[2 /* encodings */], plainDictionary)
+			require.NotNil(t, dictionary)
+			for j := 0; j < 3; j++ {
+				v := []byte(fmt.Sprintf("value%d", j))
+				require.Equal(t, uint32(len(v)), binary.LittleEndian.Uint32(dictionary))
+				require.Equal(t, v, dictionary[4:4+len(v)])
+				dictionary = dictionary[4+len(v):]
+			}
+		default:
+			require.Nil(t, dictionary)
+		}
+	}
+}
@@ -96,6 +96,10 @@ func (s *StatementStatistics) Add(other *StatementStatistics) {
 
 	s.BytesRead += other.BytesRead
 	s.RowsRead += other.RowsRead
+	s.SpillCount += other.SpillCount
+	if other.MaxBytesSpilled > s.MaxBytesSpilled {
+		s.MaxBytesSpilled = other.MaxBytesSpilled
+	}
 	s.Count += other.Count
 }
 
@@ -113,5 +117,7 @@ func (s *StatementStatistics) AlmostEqual(other *StatementStatistics, eps float6
 		s.OverheadLat.AlmostEqual(other.OverheadLat, eps) &&
 		s.SensitiveInfo.Equal(other.SensitiveInfo) &&
 		s.BytesRead == other.BytesRead &&
-		s.RowsRead == other.RowsRead
+		s.RowsRead == other.RowsRead &&
+		s.SpillCount == other.SpillCount &&
+		s.MaxBytesSpilled == other.MaxBytesSpilled
 }
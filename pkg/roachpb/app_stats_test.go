@@ -81,3 +81,22 @@ func TestAddNumericStats(t *testing.T) {
 		t.Fatalf("a.Add(b) should match add(a, b): %+v vs %+v", a, combined)
 	}
 }
+
+func TestAddStatementStatisticsSpills(t *testing.T) {
+	a := StatementStatistics{Count: 3, SpillCount: 1, MaxBytesSpilled: 10}
+	b := StatementStatistics{Count: 2, SpillCount: 2, MaxBytesSpilled: 30}
+
+	a.Add(&b)
+	if a.SpillCount != 3 {
+		t.Fatalf("expected SpillCount 3, got %d", a.SpillCount)
+	}
+	if a.MaxBytesSpilled != 30 {
+		t.Fatalf("expected MaxBytesSpilled 30, got %d", a.MaxBytesSpilled)
+	}
+
+	// Adding statistics with fewer bytes spilled must not lower the maximum.
+	a.Add(&StatementStatistics{Count: 1, MaxBytesSpilled: 20})
+	if a.MaxBytesSpilled != 30 {
+		t.Fatalf("expected MaxBytesSpilled 30, got %d", a.MaxBytesSpilled)
+	}
+}
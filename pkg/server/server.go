@@ -605,6 +605,8 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 
 	distSQLMetrics := execinfra.MakeDistSQLMetrics(cfg.HistogramWindowInterval())
 	s.registry.AddMetricStruct(distSQLMetrics)
+	vecWorkerPool := execinfra.NewVectorizedWorkerPool(st, &distSQLMetrics)
+	s.stopper.AddCloser(vecWorkerPool)
 
 	// Set up Lease Manager
 	var lmKnobs sql.LeaseManagerTestingKnobs
@@ -666,6 +668,10 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 
 		ExternalStorage:        externalStorage,
 		ExternalStorageFromURI: externalStorageFromURI,
+
+		FlowProgressRegistry: execinfra.NewFlowProgressRegistry(),
+		SpillRegistry:        execinfra.NewSpillRegistry(),
+		VectorizedWorkerPool: vecWorkerPool,
 	}
 	if distSQLTestingKnobs := s.cfg.TestingKnobs.DistSQL; distSQLTestingKnobs != nil {
 		distSQLCfg.TestingKnobs = *distSQLTestingKnobs.(*execinfra.TestingKnobs)
@@ -840,6 +846,7 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 		stats.DefaultAsOfTime,
 	)
 	execCfg.StatsRefresher = s.statsRefresher
+	s.distSQLServer.ServerConfig.CardinalityObserver = s.statsRefresher
 
 	// Set up internal memory metrics for use by internal SQL executors.
 	s.sqlMemMetrics = sql.MakeMemMetrics("sql", cfg.HistogramWindowInterval())
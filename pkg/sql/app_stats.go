@@ -130,7 +130,7 @@ func (a *appStats) recordStatement(
 	numRows int,
 	err error,
 	parseLat, planLat, runLat, svcLat, ovhLat float64,
-	bytesRead, rowsRead int64,
+	stats topLevelQueryStats,
 ) {
 	if !stmtStatsEnable.Get(&a.st.SV) {
 		return
@@ -165,8 +165,14 @@ func (a *appStats) recordStatement(
 	s.data.RunLat.Record(s.data.Count, runLat)
 	s.data.ServiceLat.Record(s.data.Count, svcLat)
 	s.data.OverheadLat.Record(s.data.Count, ovhLat)
-	s.data.BytesRead = bytesRead
-	s.data.RowsRead = rowsRead
+	s.data.BytesRead = stats.bytesRead
+	s.data.RowsRead = stats.rowsRead
+	if stats.spillCount > 0 {
+		s.data.SpillCount++
+	}
+	if stats.bytesSpilled > s.data.MaxBytesSpilled {
+		s.data.MaxBytesSpilled = stats.bytesSpilled
+	}
 	s.Unlock()
 }
 
@@ -523,6 +529,7 @@ func quantizeCounts(d *roachpb.StatementStatistics) {
 	d.MaxRetries = telemetry.Bucket10(d.MaxRetries)
 
 	d.FirstAttemptCount = int64((float64(d.FirstAttemptCount) / float64(oldCount)) * float64(newCount))
+	d.SpillCount = int64((float64(d.SpillCount) / float64(oldCount)) * float64(newCount))
 }
 
 // FailedHashedValue is used as a default return value for when HashForReporting
@@ -0,0 +1,120 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colcontainer
+
+import (
+	"os"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/cockroachdb/cockroach/pkg/storage/fs"
+	"github.com/cockroachdb/errors"
+)
+
+// DiskFaultKind describes the kind of fault that a DiskFaultInjector injects
+// into the I/O of DiskQueues.
+type DiskFaultKind int
+
+const (
+	// DiskFaultWriteError makes writes to the files of a DiskQueue fail with a
+	// generic I/O error without writing anything.
+	DiskFaultWriteError DiskFaultKind = iota
+	// DiskFaultNoSpace makes writes to the files of a DiskQueue write only a
+	// part of the data and then fail with ENOSPC, just like writes to a full
+	// disk do.
+	DiskFaultNoSpace
+	// DiskFaultShortRead makes reads from the files of a DiskQueue return only
+	// a part of the requested data without an error.
+	DiskFaultShortRead
+)
+
+// ErrInjectedDiskFault is the error returned by the writes that fail because
+// of DiskFaultWriteError.
+var ErrInjectedDiskFault = errors.New("injected disk fault")
+
+// DiskFaultInjector is a testing knob that injects faults into the I/O
+// performed by DiskQueues. The same DiskFaultInjector can be shared by all
+// DiskQueues of an operator (via DiskQueueCfg), in which case the operations
+// are counted across all of them.
+type DiskFaultInjector struct {
+	// Kind is the kind of fault to inject.
+	Kind DiskFaultKind
+	// AfterNumOps is the number of operations of the affected kind (writes for
+	// DiskFaultWriteError and DiskFaultNoSpace, reads for DiskFaultShortRead)
+	// that succeed before the faults are injected. Once the first fault has
+	// been injected, all subsequent operations of the affected kind fail too.
+	AfterNumOps int
+
+	// numOps and numInjected are accessed atomically.
+	numOps      int64
+	numInjected int64
+}
+
+// NumInjected returns the number of faults that have been injected so far.
+func (i *DiskFaultInjector) NumInjected() int {
+	return int(atomic.LoadInt64(&i.numInjected))
+}
+
+// maybeInject returns whether a fault should be injected into the current
+// operation which is a write if isWrite is true and a read otherwise.
+func (i *DiskFaultInjector) maybeInject(isWrite bool) bool {
+	if isWrite != (i.Kind != DiskFaultShortRead) {
+		return false
+	}
+	if atomic.AddInt64(&i.numOps, 1) <= int64(i.AfterNumOps) {
+		return false
+	}
+	atomic.AddInt64(&i.numInjected, 1)
+	return true
+}
+
+// wrap returns f with faults injected into its I/O.
+func (i *DiskFaultInjector) wrap(name string, f fs.File) fs.File {
+	return &faultyFile{File: f, name: name, injector: i}
+}
+
+// faultyFile is an fs.File that injects the faults according to its
+// DiskFaultInjector.
+type faultyFile struct {
+	fs.File
+	name     string
+	injector *DiskFaultInjector
+}
+
+var _ fs.File = &faultyFile{}
+
+func (f *faultyFile) Write(p []byte) (int, error) {
+	if !f.injector.maybeInject(true /* isWrite */) {
+		return f.File.Write(p)
+	}
+	if f.injector.Kind == DiskFaultWriteError {
+		return 0, ErrInjectedDiskFault
+	}
+	n, err := f.File.Write(p[:len(p)/2])
+	if err != nil {
+		return n, err
+	}
+	return n, &os.PathError{Op: "write", Path: f.name, Err: syscall.ENOSPC}
+}
+
+func (f *faultyFile) Read(p []byte) (int, error) {
+	if f.injector.maybeInject(false /* isWrite */) {
+		p = p[:len(p)/2]
+	}
+	return f.File.Read(p)
+}
+
+func (f *faultyFile) ReadAt(p []byte, off int64) (int, error) {
+	if f.injector.maybeInject(false /* isWrite */) {
+		p = p[:len(p)/2]
+	}
+	return f.File.ReadAt(p, off)
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colcontainer_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/testutils/colcontainerutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskQueueFaultInjection(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	rng, _ := randutil.NewPseudoRand()
+	ctx := context.Background()
+	for _, kind := range []colcontainer.DiskFaultKind{
+		colcontainer.DiskFaultWriteError,
+		colcontainer.DiskFaultNoSpace,
+		colcontainer.DiskFaultShortRead,
+	} {
+		injector := &colcontainer.DiskFaultInjector{Kind: kind, AfterNumOps: rng.Intn(8)}
+		t.Run(fmt.Sprintf("kind=%d/afterNumOps=%d", kind, injector.AfterNumOps), func(t *testing.T) {
+			op := colexec.NewRandomDataOp(testAllocator, rng, colexec.RandomDataOpArgs{
+				NumBatches: 64,
+				BatchSize:  coldata.BatchSize(),
+				Nulls:      true,
+			})
+			typs := op.Typs()
+
+			cfg := queueCfg
+			// Use a small buffer so that the queue flushes to disk often.
+			cfg.BufferSizeBytes = 1 << 10
+			cfg.TestingKnobs.DiskFaultInjector = injector
+			q, err := colcontainer.NewDiskQueue(typs, cfg)
+			require.NoError(t, err)
+
+			for {
+				b := op.Next(ctx)
+				if err = q.Enqueue(b); err != nil || b.Length() == 0 {
+					break
+				}
+			}
+			if err == nil {
+				b := coldata.NewMemBatch(typs)
+				for {
+					var ok bool
+					if ok, err = q.Dequeue(b); err != nil || !ok || b.Length() == 0 {
+						break
+					}
+				}
+			}
+			require.Error(t, err)
+			require.NotZero(t, injector.NumInjected())
+			switch kind {
+			case colcontainer.DiskFaultWriteError:
+				require.True(t, errors.Is(err, colcontainer.ErrInjectedDiskFault), "unexpected error %v", err)
+			case colcontainer.DiskFaultNoSpace:
+				require.Equal(t, pgcode.DiskFull, pgerror.GetPGCode(err), "unexpected error %v", err)
+			}
+
+			// Closing the queue must remove all of its files even though the queue
+			// will hit the fault again when flushing the buffered writes.
+			_ = q.Close()
+			directories, err := queueCfg.FS.ListDir(queueCfg.Path)
+			require.NoError(t, err)
+			require.Equal(t, 0, len(directories))
+		})
+	}
+}
@@ -15,10 +15,12 @@ import (
 	"io"
 	"path/filepath"
 	"strconv"
+	"syscall"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
-	"github.com/cockroachdb/cockroach/pkg/col/colserde"
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/storage/fs"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
@@ -64,9 +66,12 @@ type diskQueueWriter struct {
 	// compress writes (i.e. don't bother measuring whether compression passes
 	// a certain threshold of size improvement before writing compressed bytes).
 	testingKnobAlwaysCompress bool
-	buffer                    bytes.Buffer
-	wrapped                   io.Writer
-	scratch                   struct {
+	// raw specifies whether the buffered bytes should be written out as is.
+	// This is the case for formats that already compress their data.
+	raw     bool
+	buffer  bytes.Buffer
+	wrapped io.Writer
+	scratch struct {
 		// blockType is a single byte that specifies whether the following block on
 		// disk (i.e. compressedBuf in memory) is compressed or not. It is an array
 		// due to having to pass this byte in as a slice to Write.
@@ -93,9 +98,18 @@ func (w *diskQueueWriter) reset(wrapped io.Writer) {
 
 // compressAndFlush compresses all buffered bytes and writes them to the wrapped
 // io.Writer. The number of total bytes written to the wrapped writer is
-// returned if no error occurred, otherwise 0, err is returned.
+// returned if no error occurred, otherwise 0, err is returned. If the writer is
+// raw, the buffered bytes are written without any framing.
 func (w *diskQueueWriter) compressAndFlush() (int, error) {
 	b := w.buffer.Bytes()
+	if w.raw {
+		n, err := w.wrapped.Write(b)
+		if err != nil {
+			return 0, err
+		}
+		w.buffer.Reset()
+		return n, nil
+	}
 	compressed := snappy.Encode(w.scratch.compressedBuf, b)
 	w.scratch.compressedBuf = compressed[:cap(compressed)]
 
@@ -163,10 +177,13 @@ type diskQueue struct {
 	state      diskQueueState
 	rewindable bool
 
+	// onWriteCb is the callback returned by cfg.NewQueueWriteCb, if any.
+	onWriteCb func(bytesWritten int)
+
 	// done is set when a coldata.ZeroBatch has been Enqueued.
 	done bool
 
-	serializer *colserde.FileSerializer
+	serializer diskQueueSerializer
 	// numBufferedBatches is the number of batches buffered that haven't been
 	// flushed to disk. This is useful for a reader to determine whether to flush
 	// or not, since the number of buffered bytes will always be > 0 even though
@@ -179,7 +196,7 @@ type diskQueue struct {
 	writeFileIdx      int
 	writeFile         fs.File
 	deserializerState struct {
-		*colserde.FileDeserializer
+		diskQueueDeserializer
 		curBatch int
 	}
 	// readFileIdx is an index into the current file in files the deserializer is
@@ -282,17 +299,32 @@ type DiskQueueCfg struct {
 	// MaxFileSizeBytes is the maximum size an on-disk file should reach before
 	// rolling over to a new one.
 	MaxFileSizeBytes int
+	// Format is the on-disk format of the files written by a DiskQueue.
+	Format DiskQueueFormat
 
 	// OnNewDiskQueueCb is an optional callback function that will be called when
 	// NewDiskQueue is called.
 	OnNewDiskQueueCb func()
+	// OnWriteCb is an optional callback function that will be called with the
+	// number of bytes written to disk every time a DiskQueue flushes its
+	// buffered writes.
+	OnWriteCb func(bytesWritten int)
+	// NewQueueWriteCb is an optional function that will be called when
+	// NewDiskQueue is called. The callback that it returns, if not nil, will be
+	// called with the number of bytes written to disk by the new DiskQueue
+	// only, which allows for tracking the sizes of the individual queues.
+	NewQueueWriteCb func() func(bytesWritten int)
 
 	// TestingKnobs are used to test the queue implementation.
 	TestingKnobs struct {
 		// AlwaysCompress, if true, will skip a check that determines whether
 		// compression is used for a given write or not given the percentage size
-		// improvement. This allows us to test compression.
+		// improvement. This allows us to test compression. It has no effect on
+		// DiskQueueFormatParquet, which compresses every page separately.
 		AlwaysCompress bool
+		// DiskFaultInjector, if set, injects faults into the I/O performed on
+		// the files of the queue.
+		DiskFaultInjector *DiskFaultInjector
 	}
 }
 
@@ -351,11 +383,19 @@ func newDiskQueue(typs []coltypes.T, cfg DiskQueueCfg) (*diskQueue, error) {
 		files:            make([]file, 0, 4),
 		writeBufferLimit: cfg.BufferSizeBytes / 3,
 	}
+	if cfg.NewQueueWriteCb != nil {
+		d.onWriteCb = cfg.NewQueueWriteCb()
+	}
 	// Refer to the DiskQueueCacheMode comment for why this division of
 	// BufferSizeBytes.
 	if d.cfg.CacheMode != DiskQueueCacheModeDefault {
 		d.writeBufferLimit = d.cfg.BufferSizeBytes / 2
 	}
+	if len(typs) == 0 {
+		// A Parquet row group without any columns cannot record its number of
+		// rows, so fall back to Arrow.
+		d.cfg.Format = DiskQueueFormatArrow
+	}
 	if err := cfg.FS.CreateDir(filepath.Join(cfg.Path, d.dirName)); err != nil {
 		return nil, err
 	}
@@ -374,39 +414,43 @@ func (d *diskQueue) CloseRead() error {
 }
 
 func (d *diskQueue) closeFileDeserializer() error {
-	if d.deserializerState.FileDeserializer != nil {
+	if d.deserializerState.diskQueueDeserializer != nil {
 		if err := d.deserializerState.Close(); err != nil {
 			return err
 		}
 	}
-	d.deserializerState.FileDeserializer = nil
+	d.deserializerState.diskQueueDeserializer = nil
 	return nil
 }
 
+// Close closes the queue and removes all of its files. If an error is
+// encountered, Close will attempt to remove the files anyway and will return
+// the last error encountered.
 func (d *diskQueue) Close() error {
+	var lastErr error
 	if d.serializer != nil {
 		if err := d.writeFooterAndFlush(); err != nil {
-			return err
+			lastErr = err
 		}
 		d.serializer = nil
 	}
 	if err := d.closeFileDeserializer(); err != nil {
-		return err
+		lastErr = err
 	}
 	if d.writeFile != nil {
 		if err := d.writeFile.Close(); err != nil {
-			return err
+			lastErr = err
 		}
 		d.writeFile = nil
 	}
 	// The readFile will be removed below in DeleteDirAndFiles.
 	if err := d.CloseRead(); err != nil {
-		return err
+		lastErr = err
 	}
 	if err := d.cfg.FS.DeleteDirAndFiles(filepath.Join(d.cfg.Path, d.dirName)); err != nil {
-		return err
+		lastErr = err
 	}
-	return nil
+	return lastErr
 }
 
 // rotateFile performs file rotation for the diskQueue. i.e. it creates a new
@@ -421,11 +465,18 @@ func (d *diskQueue) rotateFile() error {
 	if err != nil {
 		return err
 	}
+	if injector := d.cfg.TestingKnobs.DiskFaultInjector; injector != nil {
+		f = injector.wrap(fName, f)
+	}
 	d.seqNo++
 
 	if d.serializer == nil {
-		writer := &diskQueueWriter{testingKnobAlwaysCompress: d.cfg.TestingKnobs.AlwaysCompress, wrapped: f}
-		d.serializer, err = colserde.NewFileSerializer(writer, d.typs)
+		writer := &diskQueueWriter{
+			testingKnobAlwaysCompress: d.cfg.TestingKnobs.AlwaysCompress,
+			raw:                       d.cfg.Format == DiskQueueFormatParquet,
+			wrapped:                   f,
+		}
+		d.serializer, err = newDiskQueueSerializer(d.cfg.Format, writer, d.typs)
 		if err != nil {
 			return err
 		}
@@ -434,9 +485,10 @@ func (d *diskQueue) rotateFile() error {
 		if err := d.writeFooterAndFlush(); err != nil {
 			return err
 		}
-		if err := d.resetWriters(f); err != nil {
+		if err := d.finishWriteFile(); err != nil {
 			return err
 		}
+		d.writer.reset(f)
 	}
 
 	if d.writeFile != nil {
@@ -449,24 +501,80 @@ func (d *diskQueue) rotateFile() error {
 	d.writeFileIdx = len(d.files)
 	d.files = append(d.files, file{name: fName, offsets: make([]int, 1, 16)})
 	d.writeFile = f
+	headerLen, err := d.serializer.startFile(f)
+	if err != nil {
+		return maybeDiskFullErr(err)
+	}
+	// The header is not part of any region, so the first region starts after
+	// it.
+	d.files[d.writeFileIdx].offsets[0] = headerLen
+	d.accountForFileBytes(headerLen)
+	return d.serializer.startRegion(d.writer)
+}
+
+// finishWriteFile writes the footer of the file currently being written to.
+// All regions must have been flushed before.
+func (d *diskQueue) finishWriteFile() error {
+	footerLen, err := d.serializer.finishFile(d.writeFile)
+	if err != nil {
+		return maybeDiskFullErr(err)
+	}
+	d.accountForFileBytes(footerLen)
 	return nil
 }
 
+// accountForFileBytes accounts for bytes that have been written to the current
+// write file outside of any region.
+func (d *diskQueue) accountForFileBytes(n int) {
+	if n == 0 {
+		return
+	}
+	d.reportWrite(n)
+	d.files[d.writeFileIdx].totalSize += n
+}
+
+// reportWrite notifies the write callbacks that n bytes have been written to
+// disk.
+func (d *diskQueue) reportWrite(n int) {
+	if d.cfg.OnWriteCb != nil {
+		d.cfg.OnWriteCb(n)
+	}
+	if d.onWriteCb != nil {
+		d.onWriteCb(n)
+	}
+}
+
 func (d *diskQueue) resetWriters(f fs.File) error {
 	d.writer.reset(f)
-	return d.serializer.Reset(d.writer)
+	return d.serializer.startRegion(d.writer)
+}
+
+// maybeDiskFullErr marks the given error as a disk full error if the disk has
+// run out of space.
+func maybeDiskFullErr(err error) error {
+	if errors.Is(err, syscall.ENOSPC) {
+		// Running out of disk space is not a bug, so we make sure that it is
+		// reported to the client as such.
+		err = pgerror.WithCandidateCode(err, pgcode.DiskFull)
+	}
+	return err
 }
 
 func (d *diskQueue) writeFooterAndFlush() error {
-	err := d.serializer.Finish()
+	err := d.serializer.finishRegion()
 	if err != nil {
 		return err
 	}
 	written, err := d.writer.compressAndFlush()
 	if err != nil {
-		return err
+		return maybeDiskFullErr(err)
 	}
 	d.numBufferedBatches = 0
+	if written == 0 {
+		// Nothing was buffered, so there is no region to read back.
+		return nil
+	}
+	d.reportWrite(written)
 	// Append offset for the readers.
 	d.files[d.writeFileIdx].totalSize += written
 	d.files[d.writeFileIdx].offsets = append(d.files[d.writeFileIdx].offsets, d.files[d.writeFileIdx].totalSize)
@@ -491,6 +599,9 @@ func (d *diskQueue) Enqueue(b coldata.Batch) error {
 		if err := d.writeFooterAndFlush(); err != nil {
 			return err
 		}
+		if err := d.finishWriteFile(); err != nil {
+			return err
+		}
 		if err := d.writeFile.Close(); err != nil {
 			return err
 		}
@@ -511,7 +622,7 @@ func (d *diskQueue) Enqueue(b coldata.Batch) error {
 		}
 		return nil
 	}
-	if err := d.serializer.AppendBatch(b); err != nil {
+	if err := d.serializer.appendBatch(b); err != nil {
 		return err
 	}
 	d.numBufferedBatches++
@@ -532,7 +643,7 @@ func (d *diskQueue) Enqueue(b coldata.Batch) error {
 }
 
 func (d *diskQueue) maybeInitDeserializer() (bool, error) {
-	if d.deserializerState.FileDeserializer != nil {
+	if d.deserializerState.diskQueueDeserializer != nil {
 		return true, nil
 	}
 	if d.readFileIdx >= len(d.files) {
@@ -570,6 +681,9 @@ func (d *diskQueue) maybeInitDeserializer() (bool, error) {
 		if err != nil {
 			return false, err
 		}
+		if injector := d.cfg.TestingKnobs.DiskFaultInjector; injector != nil {
+			f = injector.wrap(fileToRead.name, f)
+		}
 		d.readFile = f
 	}
 	readRegionStart := fileToRead.offsets[fileToRead.curOffsetIdx]
@@ -590,8 +704,12 @@ func (d *diskQueue) maybeInitDeserializer() (bool, error) {
 		return false, errors.Errorf("expected to read %d bytes but read %d", len(d.writer.scratch.compressedBuf), n)
 	}
 
-	blockType := d.writer.scratch.compressedBuf[0]
-	compressedBytes := d.writer.scratch.compressedBuf[1:]
+	blockType := snappyUncompressedBlock
+	compressedBytes := d.writer.scratch.compressedBuf
+	if !d.writer.raw {
+		blockType = compressedBytes[0]
+		compressedBytes = compressedBytes[1:]
+	}
 	var decompressedBytes []byte
 	if blockType == snappyCompressedBlock {
 		decompressedBytes, err = snappy.Decode(d.scratchDecompressedReadBytes, compressedBytes)
@@ -615,11 +733,11 @@ func (d *diskQueue) maybeInitDeserializer() (bool, error) {
 		decompressedBytes = d.scratchDecompressedReadBytes
 	}
 
-	deserializer, err := colserde.NewFileDeserializerFromBytes(decompressedBytes)
+	deserializer, err := newDiskQueueDeserializer(d.cfg.Format, decompressedBytes, d.typs)
 	if err != nil {
 		return false, err
 	}
-	d.deserializerState.FileDeserializer = deserializer
+	d.deserializerState.diskQueueDeserializer = deserializer
 	d.deserializerState.curBatch = 0
 	if d.deserializerState.NumBatches() == 0 {
 		// Zero batches to deserialize in this region. This shouldn't happen but we
@@ -654,7 +772,7 @@ func (d *diskQueue) Dequeue(b coldata.Batch) (bool, error) {
 	}
 	d.state = diskQueueStateDequeueing
 
-	if d.deserializerState.FileDeserializer != nil && d.deserializerState.curBatch >= d.deserializerState.NumBatches() {
+	if d.deserializerState.diskQueueDeserializer != nil && d.deserializerState.curBatch >= d.deserializerState.NumBatches() {
 		// Finished all the batches, set the deserializer to nil to initialize a new
 		// one to read the next region.
 		if err := d.closeFileDeserializer(); err != nil {
@@ -0,0 +1,152 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colcontainer
+
+import (
+	"io"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/colserde"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/errors"
+)
+
+// DiskQueueFormat specifies the on-disk format of the files written by a
+// DiskQueue.
+type DiskQueueFormat int
+
+const (
+	// DiskQueueFormatArrow is the default format. Every region of a file is a
+	// standalone Arrow IPC file that is compressed as a whole using snappy.
+	DiskQueueFormatArrow DiskQueueFormat = iota
+	// DiskQueueFormatParquet writes every file as a Parquet file, with one row
+	// group per enqueued batch. Pages are encoded and compressed per column, so
+	// regions are written out as is. Once a file has been finished, it can be
+	// inspected with any Parquet reader.
+	DiskQueueFormatParquet
+)
+
+// diskQueueSerializer abstracts the way coldata.Batches are encoded by a
+// diskQueue. A file is made up of a header, any number of regions, and a
+// footer. The header and the footer are written directly to the file, while
+// the regions are written to the diskQueueWriter and read back separately.
+type diskQueueSerializer interface {
+	// startFile writes the header of a new file to f and returns the number
+	// of bytes written.
+	startFile(f io.Writer) (int, error)
+	// startRegion prepares for a new region to be written to w.
+	startRegion(w io.Writer) error
+	// appendBatch adds a batch to the current region.
+	appendBatch(b coldata.Batch) error
+	// finishRegion writes out any trailing bytes of the current region.
+	finishRegion() error
+	// finishFile writes the footer of the current file to f and returns the
+	// number of bytes written. All regions must have been flushed to f before.
+	finishFile(f io.Writer) (int, error)
+}
+
+// diskQueueDeserializer reads back the batches of a single region.
+type diskQueueDeserializer interface {
+	NumBatches() int
+	GetBatch(batchIdx int, b coldata.Batch) error
+	Close() error
+}
+
+var _ diskQueueDeserializer = &colserde.FileDeserializer{}
+var _ diskQueueDeserializer = &colserde.ParquetDeserializer{}
+
+func newDiskQueueSerializer(
+	format DiskQueueFormat, w io.Writer, typs []coltypes.T,
+) (diskQueueSerializer, error) {
+	switch format {
+	case DiskQueueFormatArrow:
+		return &arrowDiskQueueSerializer{typs: typs}, nil
+	case DiskQueueFormatParquet:
+		s, err := colserde.NewParquetSerializer(w, typs)
+		if err != nil {
+			return nil, err
+		}
+		return parquetDiskQueueSerializer{ParquetSerializer: s}, nil
+	default:
+		return nil, errors.Errorf("unknown disk queue format %d", format)
+	}
+}
+
+func newDiskQueueDeserializer(
+	format DiskQueueFormat, buf []byte, typs []coltypes.T,
+) (diskQueueDeserializer, error) {
+	if format == DiskQueueFormatParquet {
+		return colserde.NewParquetDeserializerFromBytes(buf, typs)
+	}
+	return colserde.NewFileDeserializerFromBytes(buf)
+}
+
+// arrowDiskQueueSerializer writes every region as an Arrow IPC file. Files
+// have neither a header nor a footer.
+type arrowDiskQueueSerializer struct {
+	typs []coltypes.T
+	*colserde.FileSerializer
+}
+
+var _ diskQueueSerializer = &arrowDiskQueueSerializer{}
+
+func (s *arrowDiskQueueSerializer) startFile(io.Writer) (int, error) {
+	return 0, nil
+}
+
+func (s *arrowDiskQueueSerializer) startRegion(w io.Writer) error {
+	if s.FileSerializer == nil {
+		var err error
+		s.FileSerializer, err = colserde.NewFileSerializer(w, s.typs)
+		return err
+	}
+	return s.Reset(w)
+}
+
+func (s *arrowDiskQueueSerializer) appendBatch(b coldata.Batch) error {
+	return s.AppendBatch(b)
+}
+
+func (s *arrowDiskQueueSerializer) finishRegion() error {
+	return s.Finish()
+}
+
+func (s *arrowDiskQueueSerializer) finishFile(io.Writer) (int, error) {
+	return 0, nil
+}
+
+// parquetDiskQueueSerializer writes every file as a Parquet file. Regions are
+// sequences of row groups.
+type parquetDiskQueueSerializer struct {
+	*colserde.ParquetSerializer
+}
+
+var _ diskQueueSerializer = parquetDiskQueueSerializer{}
+
+func (s parquetDiskQueueSerializer) startFile(f io.Writer) (int, error) {
+	return s.StartFile(f)
+}
+
+func (s parquetDiskQueueSerializer) startRegion(io.Writer) error {
+	return nil
+}
+
+func (s parquetDiskQueueSerializer) appendBatch(b coldata.Batch) error {
+	return s.AppendBatch(b)
+}
+
+func (s parquetDiskQueueSerializer) finishRegion() error {
+	return nil
+}
+
+func (s parquetDiskQueueSerializer) finishFile(f io.Writer) (int, error) {
+	return s.FinishFile(f)
+}
@@ -37,6 +37,10 @@ func TestDiskQueue(t *testing.T) {
 		for _, bufferSizeBytes := range []int{0, 16<<10 + rng.Intn(1<<20) /* 16 KiB up to 1 MiB */} {
 			for _, maxFileSizeBytes := range []int{10 << 10 /* 10 KiB */, 1<<20 + rng.Intn(64<<20) /* 1 MiB up to 64 MiB */} {
 				alwaysCompress := rng.Float64() < 0.5
+				format := colcontainer.DiskQueueFormatArrow
+				if rng.Float64() < 0.5 {
+					format = colcontainer.DiskQueueFormatParquet
+				}
 				diskQueueCacheMode := colcontainer.DiskQueueCacheModeDefault
 				// testReuseCache will test the reuse cache modes.
 				testReuseCache := rng.Float64() < 0.5
@@ -57,8 +61,8 @@ func TestDiskQueue(t *testing.T) {
 					prefix, suffix = "Rewindable/", ""
 				}
 				numBatches := 1 + rng.Intn(1024)
-				t.Run(fmt.Sprintf("%sDiskQueueCacheMode=%d/AlwaysCompress=%t%s/NumBatches=%d",
-					prefix, diskQueueCacheMode, alwaysCompress, suffix, numBatches), func(t *testing.T) {
+				t.Run(fmt.Sprintf("%sDiskQueueCacheMode=%d/Format=%d/AlwaysCompress=%t%s/NumBatches=%d",
+					prefix, diskQueueCacheMode, format, alwaysCompress, suffix, numBatches), func(t *testing.T) {
 					// Create random input.
 					batches := make([]coldata.Batch, 0, numBatches)
 					op := colexec.NewRandomDataOp(testAllocator, rng, colexec.RandomDataOpArgs{
@@ -79,6 +83,7 @@ func TestDiskQueue(t *testing.T) {
 						}
 						queueCfg.MaxFileSizeBytes = maxFileSizeBytes
 					}
+					queueCfg.Format = format
 					queueCfg.TestingKnobs.AlwaysCompress = alwaysCompress
 
 					// Create queue.
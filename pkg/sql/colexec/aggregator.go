@@ -418,9 +418,9 @@ func (a *orderedAggregator) Next(ctx context.Context) coldata.Batch {
 
 // reset resets the orderedAggregator for another run. Primarily used for
 // benchmarks.
-func (a *orderedAggregator) reset() {
-	if resetter, ok := a.input.(resetter); ok {
-		resetter.reset()
+func (a *orderedAggregator) Reset(ctx context.Context) {
+	if r, ok := a.input.(Resetter); ok {
+		r.Reset(ctx)
 	}
 	a.done = false
 	a.seenNonEmptyBatch = false
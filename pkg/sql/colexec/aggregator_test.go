@@ -21,7 +21,6 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
-	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 )
 
 var (
@@ -558,7 +557,7 @@ func TestAggregatorRandom(t *testing.T) {
 
 	// This test aggregates random inputs, keeping track of the expected results
 	// to make sure the aggregations are correct.
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(t)
 	for _, groupSize := range []int{1, 2, coldata.BatchSize() / 4, coldata.BatchSize() / 2} {
 		if groupSize == 0 {
 			// We might be varying coldata.BatchSize() so that when it is divided by
@@ -674,7 +673,7 @@ func TestAggregatorRandom(t *testing.T) {
 }
 
 func BenchmarkAggregator(b *testing.B) {
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(b)
 	ctx := context.Background()
 
 	for _, aggFn := range []execinfrapb.AggregatorSpec_Func{
@@ -765,8 +764,8 @@ func BenchmarkAggregator(b *testing.B) {
 										// Only count the int64 column.
 										b.SetBytes(int64(8 * nTuples))
 										for i := 0; i < b.N; i++ {
-											a.(resetter).reset()
-											source.reset()
+											a.(Resetter).Reset(ctx)
+											source.Reset(ctx)
 											// Exhaust aggregator until all batches have been read.
 											for b := a.Next(ctx); b.Length() != 0; b = a.Next(ctx) {
 											}
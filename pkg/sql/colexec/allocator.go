@@ -201,6 +201,19 @@ func (a *Allocator) PerformOperation(destVecs []coldata.Vec, operation func()) {
 	}
 }
 
+// AdjustMemoryUsage adjusts the number of bytes currently allocated through
+// this allocator by delta. It should be used to account for the memory of
+// objects other than batches and vectors that the caller owns.
+func (a *Allocator) AdjustMemoryUsage(delta int64) {
+	if delta > 0 {
+		if err := a.acc.Grow(a.ctx, delta); err != nil {
+			execerror.VectorizedInternalPanic(err)
+		}
+	} else {
+		a.acc.Shrink(a.ctx, -delta)
+	}
+}
+
 // Used returns the number of bytes currently allocated through this allocator.
 func (a *Allocator) Used() int64 {
 	return a.acc.Used()
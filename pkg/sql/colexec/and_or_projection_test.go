@@ -23,7 +23,6 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
-	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 )
 
 type andOrTestCase struct {
@@ -237,7 +236,7 @@ func benchmarkLogicalProjOp(
 			Settings: st,
 		},
 	}
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(b)
 
 	batch := testAllocator.NewMemBatch([]coltypes.T{coltypes.Bool, coltypes.Bool})
 	col1 := batch.ColVec(0).Bool()
@@ -19,7 +19,9 @@ import (
 )
 
 // CancelChecker is an Operator that checks whether query cancellation has
-// occurred. The check happens on every batch.
+// occurred. The check happens on every batch. It is also used (not as an
+// Operator) by the operators that perform long-running operations (see check
+// and checkWork).
 type CancelChecker struct {
 	OneInputNode
 	NonExplainable
@@ -27,6 +29,9 @@ type CancelChecker struct {
 	// Number of times check() has been called since last context cancellation
 	// check.
 	callsSinceLastCheck uint32
+	// Number of units of work accounted for by checkWork() since last context
+	// cancellation check.
+	workSinceLastCheck int
 }
 
 // Init is part of the Operator interface.
@@ -65,6 +70,21 @@ func (c *CancelChecker) check(ctx context.Context) {
 	c.callsSinceLastCheck++
 }
 
+// checkWork accounts for n units of work (for example, the number of tuples
+// processed in a single iteration of a loop) and panics with a query canceled
+// error if the associated query has been canceled. The check is performed
+// once at least cancelCheckInterval units of work have been done since the
+// last check, so it is cheap enough to be used in every loop that performs
+// O(n) work, regardless of whether the loop processes a tuple or a batch at a
+// time.
+func (c *CancelChecker) checkWork(ctx context.Context, n int) {
+	c.workSinceLastCheck += n
+	if c.workSinceLastCheck >= cancelCheckInterval {
+		c.workSinceLastCheck = 0
+		c.checkEveryCall(ctx)
+	}
+}
+
 // checkEveryCall panics with query canceled error (which will be caught at the
 // materializer level and will be propagated forward as metadata) if the
 // associated query has been canceled. The check is performed on every call.
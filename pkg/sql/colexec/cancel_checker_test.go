@@ -14,8 +14,10 @@ import (
 	"context"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/errors"
@@ -35,3 +37,38 @@ func TestCancelChecker(t *testing.T) {
 	})
 	require.True(t, errors.Is(err, sqlbase.QueryCanceledError))
 }
+
+// TestCancelCheckerCheckWork verifies that checkWork observes the cancellation
+// once enough work has been accounted for.
+func TestCancelCheckerCheckWork(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var c CancelChecker
+	require.NotPanics(t, func() { c.checkWork(ctx, cancelCheckInterval-1) })
+	err := execerror.CatchVectorizedRuntimeError(func() {
+		c.checkWork(ctx, 1)
+	})
+	require.True(t, errors.Is(err, sqlbase.QueryCanceledError))
+}
+
+// TestSorterObservesCancellation verifies that the sorter doesn't keep on
+// buffering its (infinite) input once the query has been canceled.
+func TestSorterObservesCancellation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx, cancel := context.WithCancel(context.Background())
+	typs := []coltypes.T{coltypes.Int64}
+	batch := testAllocator.NewMemBatch(typs)
+	batch.SetLength(coldata.BatchSize())
+	sorter, err := NewSorter(
+		testAllocator, NewRepeatableBatchSource(testAllocator, batch), typs,
+		[]execinfrapb.Ordering_Column{{ColIdx: 0}},
+	)
+	require.NoError(t, err)
+	sorter.Init()
+	cancel()
+	err = execerror.CatchVectorizedRuntimeError(func() {
+		sorter.Next(ctx)
+	})
+	require.True(t, errors.Is(err, sqlbase.QueryCanceledError))
+}
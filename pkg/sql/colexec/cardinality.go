@@ -0,0 +1,82 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+)
+
+// cardinalityCounterOp is an Operator that counts the tuples produced by its
+// input and, once the input has been exhausted, reports their number as the
+// observed cardinality of the processor the input has been planned for. It is
+// planned on top of the joiners (before the post-processing) so that the
+// gateway can compare the cardinalities of the joins against the optimizer's
+// estimates.
+type cardinalityCounterOp struct {
+	OneInputNode
+	NonExplainable
+
+	processorID int32
+	numTuples   int64
+	exhausted   bool
+}
+
+var _ Operator = &cardinalityCounterOp{}
+var _ execinfrapb.MetadataSource = &cardinalityCounterOp{}
+
+// newCardinalityCounterOp returns a new cardinalityCounterOp that reports the
+// cardinality of input as the one of the processor with the given ID.
+func newCardinalityCounterOp(input Operator, processorID int32) *cardinalityCounterOp {
+	return &cardinalityCounterOp{
+		OneInputNode: NewOneInputNode(input),
+		processorID:  processorID,
+	}
+}
+
+// Init is part of the Operator interface.
+func (c *cardinalityCounterOp) Init() {
+	c.input.Init()
+}
+
+// Next is part of the Operator interface.
+func (c *cardinalityCounterOp) Next(ctx context.Context) coldata.Batch {
+	batch := c.input.Next(ctx)
+	c.numTuples += int64(batch.Length())
+	if batch.Length() == 0 {
+		c.exhausted = true
+	}
+	return batch
+}
+
+// DrainMeta is part of the MetadataSource interface.
+func (c *cardinalityCounterOp) DrainMeta(ctx context.Context) []execinfrapb.ProducerMetadata {
+	if !c.exhausted {
+		// The consumer has stopped reading before the input was exhausted, so
+		// the number of tuples is only a lower bound of the cardinality.
+		return nil
+	}
+	return []execinfrapb.ProducerMetadata{makeCardinalityMeta(c.processorID, c.numTuples)}
+}
+
+// makeCardinalityMeta returns the metadata through which the operators report
+// to the gateway that they have produced rowCount rows for the processor with
+// the given ID (and that there are no more rows to come).
+func makeCardinalityMeta(processorID int32, rowCount int64) execinfrapb.ProducerMetadata {
+	meta := execinfrapb.GetProducerMeta()
+	meta.Metrics = execinfrapb.GetMetricsMeta()
+	meta.Metrics.CardinalityObserved = true
+	meta.Metrics.ProcessorID = processorID
+	meta.Metrics.OutputRowCount = rowCount
+	return *meta
+}
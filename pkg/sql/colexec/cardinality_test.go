@@ -0,0 +1,46 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCardinalityCounter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	batch := testAllocator.NewMemBatch([]coltypes.T{coltypes.Int64})
+	batch.SetLength(coldata.BatchSize())
+	const numBatches = 3
+	counter := newCardinalityCounterOp(newFiniteBatchSource(batch, numBatches), 7 /* processorID */)
+	counter.Init()
+
+	// The cardinality isn't reported until the input has been exhausted.
+	for i := 0; i < numBatches; i++ {
+		require.Equal(t, coldata.BatchSize(), counter.Next(ctx).Length())
+	}
+	require.Empty(t, counter.DrainMeta(ctx))
+
+	require.Zero(t, counter.Next(ctx).Length())
+	meta := counter.DrainMeta(ctx)
+	require.Len(t, meta, 1)
+	require.NotNil(t, meta[0].Metrics)
+	require.True(t, meta[0].Metrics.CardinalityObserved)
+	require.Equal(t, int32(7), meta[0].Metrics.ProcessorID)
+	require.Equal(t, int64(numBatches*coldata.BatchSize()), meta[0].Metrics.OutputRowCount)
+}
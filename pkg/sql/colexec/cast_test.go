@@ -23,7 +23,6 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
-	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -71,7 +70,7 @@ func TestRandomizedCast(t *testing.T) {
 	}
 
 	evalCtx := tree.NewTestingEvalContext(cluster.MakeTestingClusterSettings())
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(t)
 
 	for _, c := range tc {
 		t.Run(fmt.Sprintf("%sTo%s", c.fromTyp.String(), c.toTyp.String()), func(t *testing.T) {
@@ -112,7 +111,7 @@ func TestRandomizedCast(t *testing.T) {
 
 func BenchmarkCastOp(b *testing.B) {
 	ctx := context.Background()
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(b)
 	for _, typePair := range [][]types.T{
 		{*types.Int, *types.Float},
 		{*types.Int, *types.Decimal},
@@ -46,9 +46,25 @@ type colBatchScan struct {
 	maxResults uint64
 	// init is true after Init() has been called.
 	init bool
+
+	// processorID is the ID of the TableReader that the colBatchScan has been
+	// planned for.
+	processorID int32
+	rowsRead    int64
+	// exhausted is true once the colBatchScan has read all of its spans, at
+	// which point the number of rows read is reported to the gateway.
+	exhausted bool
+
+	// progress, if set, is updated with the number of rows read.
+	progress *execinfra.FlowProgress
 }
 
 var _ Operator = &colBatchScan{}
+var _ flowProgressReporter = &colBatchScan{}
+
+func (s *colBatchScan) setFlowProgress(progress *execinfra.FlowProgress) {
+	s.progress = progress
+}
 
 func (s *colBatchScan) Init() {
 	s.ctx = context.Background()
@@ -65,6 +81,7 @@ func (s *colBatchScan) Init() {
 }
 
 func (s *colBatchScan) Next(ctx context.Context) coldata.Batch {
+	waitIfPaused(ctx, s.progress)
 	bat, err := s.rf.NextBatch(ctx)
 	if err != nil {
 		execerror.VectorizedInternalPanic(err)
@@ -72,6 +89,13 @@ func (s *colBatchScan) Next(ctx context.Context) coldata.Batch {
 	if bat.Selection() != nil {
 		execerror.VectorizedInternalPanic("unexpectedly a selection vector is set on the batch coming from CFetcher")
 	}
+	s.rowsRead += int64(bat.Length())
+	if s.progress != nil {
+		s.progress.AddRowsRead(int64(bat.Length()))
+	}
+	if bat.Length() == 0 {
+		s.exhausted = true
+	}
 	return bat
 }
 
@@ -93,6 +117,9 @@ func (s *colBatchScan) DrainMeta(ctx context.Context) []execinfrapb.ProducerMeta
 	if tfs := execinfra.GetLeafTxnFinalState(ctx, s.flowCtx.Txn); tfs != nil {
 		trailingMeta = append(trailingMeta, execinfrapb.ProducerMetadata{LeafTxnFinalState: tfs})
 	}
+	if s.exhausted {
+		trailingMeta = append(trailingMeta, makeCardinalityMeta(s.processorID, s.rowsRead))
+	}
 	return trailingMeta
 }
 
@@ -100,6 +127,7 @@ func (s *colBatchScan) DrainMeta(ctx context.Context) []execinfrapb.ProducerMeta
 func newColBatchScan(
 	allocator *Allocator,
 	flowCtx *execinfra.FlowCtx,
+	processorID int32,
 	spec *execinfrapb.TableReaderSpec,
 	post *execinfrapb.PostProcessSpec,
 ) (*colBatchScan, error) {
@@ -138,11 +166,12 @@ func newColBatchScan(
 		spans[i] = spec.Spans[i].Span
 	}
 	return &colBatchScan{
-		spans:      spans,
-		flowCtx:    flowCtx,
-		rf:         &fetcher,
-		limitHint:  limitHint,
-		maxResults: spec.MaxResults,
+		spans:       spans,
+		flowCtx:     flowCtx,
+		rf:          &fetcher,
+		limitHint:   limitHint,
+		maxResults:  spec.MaxResults,
+		processorID: processorID,
 	}, nil
 }
 
@@ -0,0 +1,330 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colbench_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colbench"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/testutils/colcontainerutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	numLineitemRows = 1 << 16
+	numOrdersRows   = 1 << 14
+)
+
+func TestGenerateBatches(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	rng, _ := randutil.NewPseudoRand()
+	const numRows = 3*coldata.BatchSize() + 1
+	for _, table := range []*colbench.TableSpec{&colbench.Lineitem, &colbench.Orders} {
+		t.Run(table.Name, func(t *testing.T) {
+			batches := colbench.GenerateBatches(testAllocator, rng, table, numRows)
+			source := colbench.NewBatchesSource(testAllocator, table.PhysicalTypes(), batches)
+			for colIdx, col := range table.Columns {
+				distinct := make(map[string]struct{})
+				numNulls, numRowsSeen := 0, 0
+				source.Reset(context.Background())
+				for b := source.Next(context.Background()); b.Length() > 0; b = source.Next(context.Background()) {
+					vec := b.ColVec(colIdx)
+					for i := 0; i < b.Length(); i++ {
+						numRowsSeen++
+						if vec.Nulls().NullAt(i) {
+							numNulls++
+							continue
+						}
+						distinct[valueString(vec, i)] = struct{}{}
+					}
+				}
+				require.Equal(t, numRows, numRowsSeen)
+				if col.NullProbability == 0 {
+					require.Zero(t, numNulls, "column %s", col.Name)
+				}
+				switch {
+				case col.Unique:
+					require.Equal(t, numRows, len(distinct), "column %s", col.Name)
+				case col.Cardinality > 0:
+					require.LessOrEqual(t, len(distinct), col.Cardinality, "column %s", col.Name)
+				}
+			}
+		})
+	}
+}
+
+// valueString returns the string representation of the ith value of vec.
+func valueString(vec coldata.Vec, i int) string {
+	switch vec.Type() {
+	case coltypes.Int64:
+		return fmt.Sprint(vec.Int64()[i])
+	case coltypes.Float64:
+		return fmt.Sprint(vec.Float64()[i])
+	case coltypes.Decimal:
+		return vec.Decimal()[i].String()
+	case coltypes.Bytes:
+		return string(vec.Bytes().Get(i))
+	default:
+		panic(fmt.Sprintf("unsupported type %s", vec.Type()))
+	}
+}
+
+// benchmarkOperator runs the operator described by spec on the given sources
+// b.N times.
+func benchmarkOperator(
+	b *testing.B,
+	flowCtx *execinfra.FlowCtx,
+	spec *execinfrapb.ProcessorSpec,
+	sources []*colbench.BatchesSource,
+	queueCfg colcontainer.DiskQueueCfg,
+) {
+	ctx := context.Background()
+	var numBytes int64
+	inputs := make([]colexec.Operator, len(sources))
+	for i, source := range sources {
+		numBytes += source.NumBytes()
+		inputs[i] = source
+	}
+	b.SetBytes(numBytes)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, source := range sources {
+			source.Reset(ctx)
+		}
+		args := colexec.NewColOperatorArgs{
+			Spec:                spec,
+			Inputs:              inputs,
+			StreamingMemAccount: testMemAcc,
+			DiskQueueCfg:        queueCfg,
+			FDSemaphore:         colexec.NewTestingSemaphore(256),
+		}
+		result, err := colexec.NewColOperator(ctx, flowCtx, args)
+		if err != nil {
+			b.Fatal(err)
+		}
+		result.Op.Init()
+		for out := result.Op.Next(ctx); out.Length() != 0; out = result.Op.Next(ctx) {
+		}
+		if c, ok := result.Op.(colexec.Closer); ok {
+			if err := c.Close(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+		for _, acc := range result.BufferingOpMemAccounts {
+			acc.Close(ctx)
+		}
+		for _, m := range result.BufferingOpMemMonitors {
+			m.Stop(ctx)
+		}
+	}
+}
+
+// BenchmarkTPCHShapedOperators benchmarks the major vectorized operators on
+// TPC-H shaped data, both in memory and (for the operators that can do so)
+// when spilling to disk.
+func BenchmarkTPCHShapedOperators(b *testing.B) {
+	defer leaktest.AfterTest(b)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	// The sorts spill to the temporary storage engine.
+	tempEngine, _, err := storage.NewPebbleTempEngine(ctx, base.DefaultTestTempStorageConfig(st), base.DefaultTestStoreSpec)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer tempEngine.Close()
+	diskMonitor := execinfra.NewTestDiskMonitor(ctx, st)
+	defer diskMonitor.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings:    st,
+			TempStorage: tempEngine,
+			DiskMonitor: diskMonitor,
+		},
+	}
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(b, false /* inMem */)
+	defer cleanup()
+
+	rng, _ := randutil.NewPseudoRand()
+	l, o := &colbench.Lineitem, &colbench.Orders
+	lineitem := colbench.NewBatchesSource(
+		testAllocator, l.PhysicalTypes(), colbench.GenerateBatches(testAllocator, rng, l, numLineitemRows),
+	)
+	orders := colbench.NewBatchesSource(
+		testAllocator, o.PhysicalTypes(), colbench.GenerateBatches(testAllocator, rng, o, numOrdersRows),
+	)
+
+	for _, tc := range []struct {
+		name     string
+		tables   []*colbench.TableSpec
+		sources  []*colbench.BatchesSource
+		core     execinfrapb.ProcessorCoreUnion
+		canSpill bool
+	}{
+		{
+			// The sort of TPC-H query 1.
+			name:    "sort",
+			tables:  []*colbench.TableSpec{l},
+			sources: []*colbench.BatchesSource{lineitem},
+			core: execinfrapb.ProcessorCoreUnion{Sorter: &execinfrapb.SorterSpec{
+				OutputOrdering: execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{
+					{ColIdx: l.ColIdx("l_returnflag")},
+					{ColIdx: l.ColIdx("l_linestatus")},
+					{ColIdx: l.ColIdx("l_shipdate"), Direction: execinfrapb.Ordering_Column_DESC},
+				}},
+			}},
+			canSpill: true,
+		},
+		{
+			// The sort on a wide string column.
+			name:    "sort-wide-strings",
+			tables:  []*colbench.TableSpec{l},
+			sources: []*colbench.BatchesSource{lineitem},
+			core: execinfrapb.ProcessorCoreUnion{Sorter: &execinfrapb.SorterSpec{
+				OutputOrdering: execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{
+					{ColIdx: l.ColIdx("l_comment")},
+				}},
+			}},
+			canSpill: true,
+		},
+		{
+			// The aggregation of TPC-H query 1.
+			name:    "hash-aggregator",
+			tables:  []*colbench.TableSpec{l},
+			sources: []*colbench.BatchesSource{lineitem},
+			core: execinfrapb.ProcessorCoreUnion{Aggregator: &execinfrapb.AggregatorSpec{
+				GroupCols: []uint32{l.ColIdx("l_returnflag"), l.ColIdx("l_linestatus")},
+				Aggregations: []execinfrapb.AggregatorSpec_Aggregation{
+					{Func: execinfrapb.AggregatorSpec_SUM, ColIdx: []uint32{l.ColIdx("l_quantity")}},
+					{Func: execinfrapb.AggregatorSpec_AVG, ColIdx: []uint32{l.ColIdx("l_extendedprice")}},
+					{Func: execinfrapb.AggregatorSpec_AVG, ColIdx: []uint32{l.ColIdx("l_discount")}},
+					{Func: execinfrapb.AggregatorSpec_COUNT_ROWS},
+				},
+			}},
+		},
+		{
+			// The aggregation with many groups of skewed sizes.
+			name:    "hash-aggregator-skewed-groups",
+			tables:  []*colbench.TableSpec{l},
+			sources: []*colbench.BatchesSource{lineitem},
+			core: execinfrapb.ProcessorCoreUnion{Aggregator: &execinfrapb.AggregatorSpec{
+				GroupCols: []uint32{l.ColIdx("l_suppkey")},
+				Aggregations: []execinfrapb.AggregatorSpec_Aggregation{
+					{Func: execinfrapb.AggregatorSpec_SUM, ColIdx: []uint32{l.ColIdx("l_quantity")}},
+					{Func: execinfrapb.AggregatorSpec_COUNT, ColIdx: []uint32{l.ColIdx("l_comment")}},
+				},
+			}},
+		},
+		{
+			name:    "unordered-distinct",
+			tables:  []*colbench.TableSpec{l},
+			sources: []*colbench.BatchesSource{lineitem},
+			core: execinfrapb.ProcessorCoreUnion{Distinct: &execinfrapb.DistinctSpec{
+				DistinctColumns: []uint32{l.ColIdx("l_suppkey"), l.ColIdx("l_shipmode")},
+			}},
+		},
+		{
+			// The join of TPC-H queries 3 and 4 with orders as the build side.
+			name:    "hash-joiner",
+			tables:  []*colbench.TableSpec{l, o},
+			sources: []*colbench.BatchesSource{lineitem, orders},
+			core: execinfrapb.ProcessorCoreUnion{HashJoiner: &execinfrapb.HashJoinerSpec{
+				LeftEqColumns:        []uint32{l.ColIdx("l_orderkey")},
+				RightEqColumns:       []uint32{o.ColIdx("o_orderkey")},
+				RightEqColumnsAreKey: true,
+				Type:                 sqlbase.JoinType_INNER,
+			}},
+			canSpill: true,
+		},
+	} {
+		spec := &execinfrapb.ProcessorSpec{Core: tc.core}
+		for _, table := range tc.tables {
+			spec.Input = append(spec.Input, execinfrapb.InputSyncSpec{ColumnTypes: table.LogicalTypes()})
+		}
+		for _, spill := range []bool{false, true} {
+			if spill && !tc.canSpill {
+				continue
+			}
+			b.Run(fmt.Sprintf("%s/spilled=%t", tc.name, spill), func(b *testing.B) {
+				flowCtx.Cfg.TestingKnobs.ForceDiskSpill = spill
+				benchmarkOperator(b, flowCtx, spec, tc.sources, queueCfg)
+			})
+		}
+	}
+}
+
+// BenchmarkTPCHShapedSpillWriter benchmarks writing TPC-H shaped batches to a
+// disk queue and reading them back.
+func BenchmarkTPCHShapedSpillWriter(b *testing.B) {
+	defer leaktest.AfterTest(b)()
+	ctx := context.Background()
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(b, false /* inMem */)
+	defer cleanup()
+
+	rng, _ := randutil.NewPseudoRand()
+	for _, table := range []*colbench.TableSpec{&colbench.Lineitem, &colbench.Orders} {
+		typs := table.PhysicalTypes()
+		source := colbench.NewBatchesSource(
+			testAllocator, typs, colbench.GenerateBatches(testAllocator, rng, table, numOrdersRows),
+		)
+		b.Run(table.Name, func(b *testing.B) {
+			b.SetBytes(source.NumBytes())
+			dequeued := testAllocator.NewMemBatch(typs)
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				source.Reset(ctx)
+				q, err := colcontainer.NewDiskQueue(typs, queueCfg)
+				if err != nil {
+					b.Fatal(err)
+				}
+				for {
+					batch := source.Next(ctx)
+					if err := q.Enqueue(batch); err != nil {
+						b.Fatal(err)
+					}
+					if batch.Length() == 0 {
+						break
+					}
+				}
+				for {
+					ok, err := q.Dequeue(dequeued)
+					if err != nil {
+						b.Fatal(err)
+					}
+					if !ok || dequeued.Length() == 0 {
+						break
+					}
+				}
+				if err := q.Close(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// Package colbench contains the microbenchmarks of the vectorized operators
+// (and of their spilling to disk) that run on realistic, TPC-H shaped data:
+// columns with skewed cardinalities, wide strings, and NULLs. The benchmarks
+// are meant to make the performance regressions of the hash table, the
+// sorter, and the spill writer measurable on every commit.
+package colbench
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// ColumnSpec describes the distribution of the values of a single column.
+type ColumnSpec struct {
+	Name string
+	Typ  *types.T
+	// Cardinality is the number of distinct non-NULL values in the column. Zero
+	// means that every row gets a fresh value.
+	Cardinality int
+	// Unique, if set, makes the values of the column be the ordinals of the rows
+	// (i.e. the column is a key). Cardinality and Skew are ignored.
+	Unique bool
+	// Skew, if positive, is the exponent of the Zipfian distribution (it must be
+	// greater than 1) that the values are chosen from. Otherwise, the values are
+	// chosen uniformly.
+	Skew float64
+	// NullProbability is the probability of a value being NULL.
+	NullProbability float64
+	// MinLen and MaxLen determine the range of lengths of string values.
+	MinLen, MaxLen int
+}
+
+// TableSpec describes the schema and the data distribution of a table.
+type TableSpec struct {
+	Name    string
+	Columns []ColumnSpec
+}
+
+// LogicalTypes returns the logical types of the columns of the table.
+func (t *TableSpec) LogicalTypes() []types.T {
+	typs := make([]types.T, len(t.Columns))
+	for i := range t.Columns {
+		typs[i] = *t.Columns[i].Typ
+	}
+	return typs
+}
+
+// PhysicalTypes returns the physical types of the columns of the table.
+func (t *TableSpec) PhysicalTypes() []coltypes.T {
+	typs, err := typeconv.FromColumnTypes(t.LogicalTypes())
+	if err != nil {
+		execerror.VectorizedInternalPanic(err)
+	}
+	return typs
+}
+
+// ColIdx returns the index of the column with the given name.
+func (t *TableSpec) ColIdx(name string) uint32 {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return uint32(i)
+		}
+	}
+	execerror.VectorizedInternalPanic(errors.AssertionFailedf("no column %q in %s", name, t.Name))
+	// This code is unreachable, but the compiler cannot infer that.
+	return 0
+}
+
+// Lineitem is shaped like the lineitem table of TPC-H, which is the largest
+// table of the benchmark and the probe side of most of its joins. Note that
+// unlike in TPC-H, l_comment can be NULL.
+var Lineitem = TableSpec{
+	Name: "lineitem",
+	Columns: []ColumnSpec{
+		{Name: "l_orderkey", Typ: types.Int, Cardinality: 1 << 14},
+		{Name: "l_partkey", Typ: types.Int, Cardinality: 200000},
+		{Name: "l_suppkey", Typ: types.Int, Cardinality: 10000, Skew: 1.1},
+		{Name: "l_quantity", Typ: types.Decimal, Cardinality: 50},
+		{Name: "l_extendedprice", Typ: types.Float, Cardinality: 1 << 20},
+		{Name: "l_discount", Typ: types.Float, Cardinality: 11},
+		{Name: "l_returnflag", Typ: types.String, Cardinality: 3, Skew: 2, MinLen: 1, MaxLen: 1},
+		{Name: "l_linestatus", Typ: types.String, Cardinality: 2, MinLen: 1, MaxLen: 1},
+		{Name: "l_shipdate", Typ: types.Date, Cardinality: 2526},
+		{Name: "l_shipmode", Typ: types.String, Cardinality: 7, Skew: 1.5, MinLen: 3, MaxLen: 7},
+		{Name: "l_comment", Typ: types.String, NullProbability: 0.1, MinLen: 10, MaxLen: 43},
+	},
+}
+
+// Orders is shaped like the orders table of TPC-H, which is usually the build
+// side of the joins with Lineitem. Note that unlike in TPC-H, o_comment can be
+// NULL.
+var Orders = TableSpec{
+	Name: "orders",
+	Columns: []ColumnSpec{
+		{Name: "o_orderkey", Typ: types.Int, Unique: true},
+		{Name: "o_custkey", Typ: types.Int, Cardinality: 100000, Skew: 1.2},
+		{Name: "o_orderstatus", Typ: types.String, Cardinality: 3, MinLen: 1, MaxLen: 1},
+		{Name: "o_totalprice", Typ: types.Float, Cardinality: 1 << 20},
+		{Name: "o_orderdate", Typ: types.Date, Cardinality: 2406},
+		{Name: "o_orderpriority", Typ: types.String, Cardinality: 5, MinLen: 5, MaxLen: 15},
+		{Name: "o_comment", Typ: types.String, NullProbability: 0.05, MinLen: 19, MaxLen: 78},
+	},
+}
+
+// tpchEpoch is the first date in the TPC-H data (1992-01-01) in days since the
+// Unix epoch.
+const tpchEpoch = 8035
+
+const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 "
+
+// columnGenerator generates the values of a single column according to its
+// ColumnSpec.
+type columnGenerator struct {
+	spec *ColumnSpec
+	rng  *rand.Rand
+	zipf *rand.Zipf
+	// dict contains all possible string values if the cardinality of a string
+	// column is limited.
+	dict [][]byte
+}
+
+func newColumnGenerator(rng *rand.Rand, spec *ColumnSpec) *columnGenerator {
+	g := &columnGenerator{spec: spec, rng: rng}
+	if spec.Skew > 0 && spec.Cardinality > 0 {
+		g.zipf = rand.NewZipf(rng, spec.Skew, 1 /* v */, uint64(spec.Cardinality-1))
+	}
+	if spec.Typ.Family() == types.StringFamily && spec.Cardinality > 0 {
+		g.dict = make([][]byte, spec.Cardinality)
+		for i := range g.dict {
+			g.dict[i] = g.randString()
+		}
+	}
+	return g
+}
+
+func (g *columnGenerator) randString() []byte {
+	n := g.spec.MinLen
+	if g.spec.MaxLen > n {
+		n += g.rng.Intn(g.spec.MaxLen - n + 1)
+	}
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = letters[g.rng.Intn(len(letters))]
+	}
+	return b
+}
+
+// ordinal returns the ordinal of the next value for the given row.
+func (g *columnGenerator) ordinal(rowIdx int) int {
+	switch {
+	case g.spec.Unique:
+		return rowIdx
+	case g.zipf != nil:
+		return int(g.zipf.Uint64())
+	case g.spec.Cardinality > 0:
+		return g.rng.Intn(g.spec.Cardinality)
+	default:
+		return g.rng.Int()
+	}
+}
+
+// fill sets the values of vec at positions [0, n) which correspond to the rows
+// starting from firstRowIdx.
+func (g *columnGenerator) fill(vec coldata.Vec, n int, firstRowIdx int) {
+	nulls := vec.Nulls()
+	for i := 0; i < n; i++ {
+		isNull := g.spec.NullProbability > 0 && g.rng.Float64() < g.spec.NullProbability
+		if isNull {
+			nulls.SetNull(i)
+		}
+		ord := g.ordinal(firstRowIdx + i)
+		switch vec.Type() {
+		case coltypes.Int64:
+			v := int64(ord)
+			if g.spec.Typ.Family() == types.DateFamily {
+				v = tpchEpoch + int64(ord)
+			}
+			vec.Int64()[i] = v
+		case coltypes.Float64:
+			vec.Float64()[i] = float64(ord) / 100
+		case coltypes.Decimal:
+			vec.Decimal()[i].SetFinite(int64(ord), -2)
+		case coltypes.Bytes:
+			// Bytes must be set in order, so we set a value even for NULLs.
+			switch {
+			case isNull:
+				vec.Bytes().Set(i, nil)
+			case g.dict != nil:
+				vec.Bytes().Set(i, g.dict[ord])
+			default:
+				vec.Bytes().Set(i, g.randString())
+			}
+		default:
+			execerror.VectorizedInternalPanic(errors.AssertionFailedf("unsupported type %s", vec.Type()))
+		}
+	}
+}
+
+// GenerateBatches returns numRows rows of table t split into full batches
+// (except for the last one).
+func GenerateBatches(
+	allocator *colexec.Allocator, rng *rand.Rand, t *TableSpec, numRows int,
+) []coldata.Batch {
+	typs := t.PhysicalTypes()
+	gens := make([]*columnGenerator, len(t.Columns))
+	for i := range gens {
+		gens[i] = newColumnGenerator(rng, &t.Columns[i])
+	}
+	var batches []coldata.Batch
+	for rowIdx := 0; rowIdx < numRows; rowIdx += coldata.BatchSize() {
+		n := numRows - rowIdx
+		if n > coldata.BatchSize() {
+			n = coldata.BatchSize()
+		}
+		batch := allocator.NewMemBatch(typs)
+		allocator.PerformOperation(batch.ColVecs(), func() {
+			for colIdx, vec := range batch.ColVecs() {
+				gens[colIdx].fill(vec, n, rowIdx)
+			}
+		})
+		batch.SetLength(n)
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// BatchesSource is an Operator that returns copies of the given batches and
+// can be reset to return them again. Copies are returned because the
+// operators are allowed to modify their input batches.
+type BatchesSource struct {
+	colexec.ZeroInputNode
+
+	typs    []coltypes.T
+	batches []coldata.Batch
+	output  coldata.Batch
+	idx     int
+}
+
+var _ colexec.Operator = &BatchesSource{}
+
+// NewBatchesSource returns a new BatchesSource over batches of the given
+// physical types.
+func NewBatchesSource(
+	allocator *colexec.Allocator, typs []coltypes.T, batches []coldata.Batch,
+) *BatchesSource {
+	return &BatchesSource{typs: typs, batches: batches, output: allocator.NewMemBatch(typs)}
+}
+
+// Init is part of the Operator interface.
+func (s *BatchesSource) Init() {}
+
+// Next is part of the Operator interface.
+func (s *BatchesSource) Next(context.Context) coldata.Batch {
+	if s.idx == len(s.batches) {
+		return coldata.ZeroBatch
+	}
+	batch := s.batches[s.idx]
+	s.idx++
+	s.output.ResetInternalBatch()
+	for i, typ := range s.typs {
+		// This Copy is outside of the allocator in order to reduce the
+		// performance impact of this operator on the benchmarks.
+		s.output.ColVec(i).Copy(coldata.CopySliceArgs{
+			SliceArgs: coldata.SliceArgs{
+				ColType:   typ,
+				Src:       batch.ColVec(i),
+				SrcEndIdx: batch.Length(),
+			},
+		})
+	}
+	s.output.SetLength(batch.Length())
+	return s.output
+}
+
+// Reset makes the source return all of its batches again.
+func (s *BatchesSource) Reset(context.Context) {
+	s.idx = 0
+}
+
+// NumBytes returns the approximate size (in bytes) of the data of all batches,
+// to be used with b.SetBytes.
+func (s *BatchesSource) NumBytes() int64 {
+	var n int64
+	for _, batch := range s.batches {
+		for _, vec := range batch.ColVecs() {
+			switch vec.Type() {
+			case coltypes.Bytes:
+				n += int64(vec.Bytes().Size())
+			case coltypes.Decimal:
+				// We count only the coefficient and the exponent of decimals.
+				n += int64(batch.Length()) * 12
+			default:
+				// All other types used in the tables are 8 bytes wide.
+				n += int64(batch.Length()) * 8
+			}
+		}
+	}
+	return n
+}
@@ -20,12 +20,18 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 )
 
 // Columnarizer turns an execinfra.RowSource input into an Operator output, by
 // reading the input in chunks of size coldata.BatchSize() and converting each
 // chunk into a coldata.Batch column by column.
+//
+// The output batch and the buffer of rows start out small and are grown by
+// doubling their capacity until it reaches coldata.BatchSize(), so that
+// inputs with few rows don't pay for full-sized batches. Both are reused
+// across calls to Next and accounted for by the allocator.
 type Columnarizer struct {
 	execinfra.ProcessorBase
 	NonExplainable
@@ -37,9 +43,13 @@ type Columnarizer struct {
 
 	buffered        sqlbase.EncDatumRows
 	batch           coldata.Batch
+	batchCapacity   int
 	accumulatedMeta []execinfrapb.ProducerMetadata
 	ctx             context.Context
 	typs            []coltypes.T
+	// datumToPhysicalFns contains the conversion function for each of the
+	// output columns.
+	datumToPhysicalFns []func(tree.Datum) (interface{}, error)
 }
 
 var _ Operator = &Columnarizer{}
@@ -81,10 +91,10 @@ func (c *Columnarizer) Init() {
 	// internal objects several times if Init method is called more than once, so
 	// we have this check in place.
 	if c.initStatus == OperatorNotInitialized {
-		c.batch = c.allocator.NewMemBatch(c.typs)
-		c.buffered = make(sqlbase.EncDatumRows, coldata.BatchSize())
-		for i := range c.buffered {
-			c.buffered[i] = make(sqlbase.EncDatumRow, len(c.typs))
+		columnTypes := c.OutputTypes()
+		c.datumToPhysicalFns = make([]func(tree.Datum) (interface{}, error), len(columnTypes))
+		for i := range columnTypes {
+			c.datumToPhysicalFns[i] = typeconv.GetDatumToPhysicalFn(&columnTypes[i])
 		}
 		c.accumulatedMeta = make([]execinfrapb.ProducerMetadata, 0, 1)
 		c.input.Start(c.ctx)
@@ -94,36 +104,69 @@ func (c *Columnarizer) Init() {
 
 // Next is part of the Operator interface.
 func (c *Columnarizer) Next(context.Context) coldata.Batch {
-	c.batch.ResetInternalBatch()
 	// Buffer up n rows.
 	nRows := 0
-	columnTypes := c.OutputTypes()
-	for ; nRows < coldata.BatchSize(); nRows++ {
+	for nRows < coldata.BatchSize() {
 		row, meta := c.input.Next()
 		if meta != nil {
 			c.accumulatedMeta = append(c.accumulatedMeta, *meta)
-			nRows--
 			continue
 		}
 		if row == nil {
 			break
 		}
+		if nRows == len(c.buffered) {
+			c.growBuffer()
+		}
 		// TODO(jordan): evaluate whether it's more efficient to skip the buffer
 		// phase.
 		copy(c.buffered[nRows], row)
+		nRows++
+	}
+	if nRows == 0 {
+		return coldata.ZeroBatch
 	}
 
-	// Write each column into the output batch.
-	for idx, ct := range columnTypes {
-		err := EncDatumRowsToColVec(c.allocator, c.buffered[:nRows], c.batch.ColVec(idx), idx, &ct, &c.da)
-		if err != nil {
-			execerror.VectorizedInternalPanic(err)
+	if c.batchCapacity < len(c.buffered) {
+		if c.batch != nil {
+			c.allocator.ReleaseBatch(c.batch)
 		}
+		c.batchCapacity = len(c.buffered)
+		c.batch = c.allocator.NewMemBatchWithSize(c.typs, c.batchCapacity)
+	} else {
+		c.batch.ResetInternalBatch()
+	}
+	// Write all columns into the output batch.
+	if err := EncDatumRowsToColVecs(
+		c.allocator, c.buffered[:nRows], c.batch, c.OutputTypes(), c.datumToPhysicalFns, &c.da,
+	); err != nil {
+		execerror.VectorizedInternalPanic(err)
 	}
 	c.batch.SetLength(nRows)
 	return c.batch
 }
 
+// growBuffer doubles the capacity of the buffer of rows (up to
+// coldata.BatchSize()) and accounts for the newly allocated rows.
+func (c *Columnarizer) growBuffer() {
+	newLen := 2 * len(c.buffered)
+	if newLen == 0 {
+		newLen = 1
+	}
+	if newLen > coldata.BatchSize() {
+		newLen = coldata.BatchSize()
+	}
+	oldLen := len(c.buffered)
+	// All rows are allocated from a single slice of EncDatums.
+	datums := make([]sqlbase.EncDatum, (newLen-oldLen)*len(c.typs))
+	c.allocator.AdjustMemoryUsage(int64(newLen-oldLen) *
+		int64(sqlbase.EncDatumRowOverhead+uintptr(len(c.typs))*sqlbase.EncDatumOverhead))
+	for i := oldLen; i < newLen; i++ {
+		c.buffered = append(c.buffered, datums[:len(c.typs):len(c.typs)])
+		datums = datums[len(c.typs):]
+	}
+}
+
 // Run is part of the execinfra.Processor interface.
 //
 // Columnarizers are not expected to be Run, so we prohibit calling this method
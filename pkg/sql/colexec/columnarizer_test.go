@@ -93,6 +93,70 @@ func TestColumnarizerDrainsAndClosesInput(t *testing.T) {
 	require.True(t, rb.Done)
 }
 
+func TestColumnarizerGrowsBatch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	typs := []types.T{*types.Int, *types.String}
+	nRows := 3*coldata.BatchSize() + 1
+	rows := sqlbase.MakeRepeatedIntRows(1 /* n */, nRows, len(typs))
+	for i := range rows {
+		rows[i][1] = sqlbase.DatumToEncDatum(types.String, tree.NewDString("a"))
+	}
+	input := execinfra.NewRepeatableRowSource(typs, rows)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		Cfg:     &execinfra.ServerConfig{Settings: st},
+		EvalCtx: &evalCtx,
+	}
+	memAcc := testMemMonitor.MakeBoundAccount()
+	defer memAcc.Close(ctx)
+	allocator := NewAllocator(ctx, &memAcc)
+
+	c, err := NewColumnarizer(ctx, allocator, flowCtx, 0 /* processorID */, input)
+	require.NoError(t, err)
+	c.Init()
+	// The first batch is expected to be as small as possible, and the
+	// following ones are expected to double in size until they reach
+	// coldata.BatchSize().
+	expectedLength := 1
+	foundRows := 0
+	var firstBatchUsage, fullBatchUsage int64
+	for {
+		batch := c.Next(ctx)
+		if batch.Length() == 0 {
+			break
+		}
+		require.Equal(t, expectedLength, batch.Length())
+		for i := 0; i < batch.Length(); i++ {
+			require.Equal(t, "a", string(batch.ColVec(1).Bytes().Get(i)))
+		}
+		foundRows += batch.Length()
+		if firstBatchUsage == 0 {
+			firstBatchUsage = memAcc.Used()
+		}
+		if batch.Length() == coldata.BatchSize() {
+			// The batch and the buffered rows are reused once they are full
+			// size, so the memory usage should stay the same.
+			if fullBatchUsage == 0 {
+				fullBatchUsage = memAcc.Used()
+			}
+			require.Equal(t, fullBatchUsage, memAcc.Used())
+		}
+		if expectedLength = 2 * expectedLength; expectedLength > coldata.BatchSize() {
+			expectedLength = coldata.BatchSize()
+		}
+		if foundRows+expectedLength > nRows {
+			expectedLength = nRows - foundRows
+		}
+	}
+	require.Equal(t, nRows, foundRows)
+	require.Less(t, firstBatchUsage, fullBatchUsage)
+}
+
 func BenchmarkColumnarize(b *testing.B) {
 	types := []types.T{*types.Int, *types.Int}
 	nRows := 10000
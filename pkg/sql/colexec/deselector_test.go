@@ -18,7 +18,6 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
-	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 )
 
 func TestDeselector(t *testing.T) {
@@ -74,7 +73,7 @@ func TestDeselector(t *testing.T) {
 }
 
 func BenchmarkDeselector(b *testing.B) {
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(b)
 	ctx := context.Background()
 
 	nCols := 1
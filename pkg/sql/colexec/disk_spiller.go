@@ -13,13 +13,21 @@ package colexec
 import (
 	"context"
 	"fmt"
-	"io"
-	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
-	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/opentracing/opentracing-go"
 )
 
 // bufferingInMemoryOperator is an Operator that buffers up intermediate tuples
@@ -39,6 +47,26 @@ type bufferingInMemoryOperator interface {
 	ExportBuffered(input Operator) coldata.Batch
 }
 
+// bufferingDoneNotifier is implemented by the bufferingInMemoryOperators that
+// buffer their whole input (or one of their inputs) before emitting any
+// output. Once they have done so, falling back to disk would emit some of the
+// tuples twice, so the disk spiller stops accepting the requests to spill
+// preemptively (although the operators can still fall back to disk if they
+// reach their memory limit before emitting anything).
+type bufferingDoneNotifier interface {
+	// setBufferingDoneCb sets the callback that is called every time the
+	// operator has buffered all of its input.
+	setBufferingDoneCb(func())
+}
+
+// bufferReleaser is implemented by the bufferingInMemoryOperators that can
+// release the tuples they have buffered once those have been exported.
+type bufferReleaser interface {
+	// releaseBuffered drops the references to the buffered tuples. The
+	// operator must remain usable after it has been reset.
+	releaseBuffered()
+}
+
 // oneInputDiskSpiller is an Operator that manages the fallback from a one
 // input in-memory buffering operator to a disk-backed one when the former hits
 // the memory limit.
@@ -73,36 +101,58 @@ type bufferingInMemoryOperator interface {
 // - the right side chain is bufferExportingOperator -> diskBackedOp. The
 //   former will first export all the buffered tuples from inMemoryOp and then
 //   will proceed on emitting from input.
+// - the metadata is drained from the side that was executed: if the spilling
+//   has occurred, diskBackedOp is drained first, and then the metadata that
+//   inMemoryOp produced before the spilling is drained via
+//   bufferExportingOperator; otherwise, inMemoryOp is drained directly.
 
 // newOneInputDiskSpiller returns a new oneInputDiskSpiller. It takes the
 // following arguments:
 // - inMemoryOp - the in-memory operator that will be consuming input and doing
 //   computations until it either successfully processes the whole input or
 //   reaches its memory limit.
-// - inMemoryMemMonitorName - the name of the memory monitor of the in-memory
-//   operator. diskSpiller will catch an OOM error only if this name is
-//   contained within the error message.
+// - inMemoryMemMonitorName - the full name of the memory monitor of the
+//   in-memory operator (see monitorName). diskSpiller will catch an OOM error
+//   only if it has been returned by this monitor.
+// - inMemoryMemLimit - the memory limit of the in-memory operator. It is only
+//   used for informational purposes.
+// - inMemoryMemAccount (when non-nil) - the memory account used exclusively by
+//   the in-memory operator. Once the disk spiller has spilled to disk, it
+//   clears this account on Close since the tuples buffered by the in-memory
+//   operator are no longer needed.
+// - diskQueueCfg - the config of the disk queues that the disk-backed operator
+//   will be using. The disk spiller hooks into the config in order to track
+//   the number of disk queues it creates and the number of bytes it spills.
 // - diskBackedOpConstructor - the function to construct the disk-backed
-//   operator when given an input operator. We take in a constructor rather
-//   than an already created operator in order to hide the complexity of buffer
-//   exporting operator that serves as the input to the disk-backed operator.
+//   operator when given an input operator and the config of the disk queues.
+//   We take in a constructor rather than an already created operator in order
+//   to hide the complexity of buffer exporting operator that serves as the
+//   input to the disk-backed operator.
 // - spillingCallbackFn will be called when the spilling from in-memory to disk
 //   backed operator occurs. It should only be set in tests.
 func newOneInputDiskSpiller(
 	input Operator,
 	inMemoryOp bufferingInMemoryOperator,
 	inMemoryMemMonitorName string,
-	diskBackedOpConstructor func(input Operator) Operator,
+	inMemoryMemLimit int64,
+	inMemoryMemAccount *mon.BoundAccount,
+	diskQueueCfg colcontainer.DiskQueueCfg,
+	diskBackedOpConstructor func(input Operator, diskQueueCfg colcontainer.DiskQueueCfg) Operator,
 	spillingCallbackFn func(),
 ) Operator {
-	diskBackedOpInput := newBufferExportingOperator(inMemoryOp, input)
-	return &diskSpillerBase{
+	diskBackedOpInput := newBufferExportingOperator(inMemoryOp, input, true /* drainsFirstSource */)
+	d := &diskSpillerBase{
 		inputs:                 []Operator{input},
+		diskBackedOpInputs:     []*bufferExportingOperator{diskBackedOpInput},
 		inMemoryOp:             inMemoryOp,
 		inMemoryMemMonitorName: inMemoryMemMonitorName,
-		diskBackedOp:           diskBackedOpConstructor(diskBackedOpInput),
+		inMemoryMemLimit:       inMemoryMemLimit,
+		inMemoryMemAccount:     inMemoryMemAccount,
 		spillingCallbackFn:     spillingCallbackFn,
 	}
+	d.diskBackedOp = diskBackedOpConstructor(diskBackedOpInput, d.trackDiskUsage(diskQueueCfg))
+	d.releaseBufferedOnceExported()
+	return d
 }
 
 // twoInputDiskSpiller is an Operator that manages the fallback from a two
@@ -140,39 +190,64 @@ func newOneInputDiskSpiller(
 // - the right side chain is bufferExportingOperators -> diskBackedOp. The
 //   former will first export all the buffered tuples from inMemoryOp and then
 //   will proceed on emitting from input.
+// - the metadata is drained the same way as in the case of
+//   oneInputDiskSpiller; note that only bufferExportingOperator1 drains the
+//   metadata of inMemoryOp.
 
 // newTwoInputDiskSpiller returns a new twoInputDiskSpiller. It takes the
 // following arguments:
 // - inMemoryOp - the in-memory operator that will be consuming inputs and
 //   doing computations until it either successfully processes the whole inputs
 //   or reaches its memory limit.
-// - inMemoryMemMonitorName - the name of the memory monitor of the in-memory
-//   operator. diskSpiller will catch an OOM error only if this name is
-//   contained within the error message.
+// - inMemoryMemMonitorName - the full name of the memory monitor of the
+//   in-memory operator (see monitorName). diskSpiller will catch an OOM error
+//   only if it has been returned by this monitor.
+// - inMemoryMemLimit - the memory limit of the in-memory operator. It is only
+//   used for informational purposes.
+// - inMemoryMemAccount (when non-nil) - the memory account used exclusively by
+//   the in-memory operator. Once the disk spiller has spilled to disk, it
+//   clears this account on Close since the tuples buffered by the in-memory
+//   operator are no longer needed.
+// - diskQueueCfg - the config of the disk queues that the disk-backed operator
+//   will be using. The disk spiller hooks into the config in order to track
+//   the number of disk queues it creates and the number of bytes it spills.
 // - diskBackedOpConstructor - the function to construct the disk-backed
-//   operator when given two input operators. We take in a constructor rather
-//   than an already created operator in order to hide the complexity of buffer
-//   exporting operators that serves as inputs to the disk-backed operator.
+//   operator when given two input operators and the config of the disk
+//   queues. We take in a constructor rather than an already created operator
+//   in order to hide the complexity of buffer exporting operators that serves
+//   as inputs to the disk-backed operator.
 // - spillingCallbackFn will be called when the spilling from in-memory to disk
 //   backed operator occurs. It should only be set in tests.
 func newTwoInputDiskSpiller(
 	inputOne, inputTwo Operator,
 	inMemoryOp bufferingInMemoryOperator,
 	inMemoryMemMonitorName string,
-	diskBackedOpConstructor func(inputOne, inputTwo Operator) Operator,
+	inMemoryMemLimit int64,
+	inMemoryMemAccount *mon.BoundAccount,
+	diskQueueCfg colcontainer.DiskQueueCfg,
+	diskBackedOpConstructor func(inputOne, inputTwo Operator, diskQueueCfg colcontainer.DiskQueueCfg) Operator,
 	spillingCallbackFn func(),
 ) Operator {
-	diskBackedOpInputOne := newBufferExportingOperator(inMemoryOp, inputOne)
-	diskBackedOpInputTwo := newBufferExportingOperator(inMemoryOp, inputTwo)
-	return &diskSpillerBase{
+	// Both buffer exporting operators share inMemoryOp, so only one of them
+	// drains its metadata.
+	diskBackedOpInputOne := newBufferExportingOperator(inMemoryOp, inputOne, true /* drainsFirstSource */)
+	diskBackedOpInputTwo := newBufferExportingOperator(inMemoryOp, inputTwo, false /* drainsFirstSource */)
+	d := &diskSpillerBase{
 		inputs:                 []Operator{inputOne, inputTwo},
+		diskBackedOpInputs:     []*bufferExportingOperator{diskBackedOpInputOne, diskBackedOpInputTwo},
 		inMemoryOp:             inMemoryOp,
 		inMemoryOpInitStatus:   OperatorNotInitialized,
 		inMemoryMemMonitorName: inMemoryMemMonitorName,
-		diskBackedOp:           diskBackedOpConstructor(diskBackedOpInputOne, diskBackedOpInputTwo),
+		inMemoryMemLimit:       inMemoryMemLimit,
+		inMemoryMemAccount:     inMemoryMemAccount,
 		distBackedOpInitStatus: OperatorNotInitialized,
 		spillingCallbackFn:     spillingCallbackFn,
 	}
+	d.diskBackedOp = diskBackedOpConstructor(
+		diskBackedOpInputOne, diskBackedOpInputTwo, d.trackDiskUsage(diskQueueCfg),
+	)
+	d.releaseBufferedOnceExported()
+	return d
 }
 
 // diskSpillerBase is the common base for the one-input and two-input disk
@@ -182,16 +257,254 @@ type diskSpillerBase struct {
 
 	inputs  []Operator
 	spilled bool
+	// diskBackedOpInputs are the inputs to diskBackedOp.
+	diskBackedOpInputs []*bufferExportingOperator
+	// numExported is the number of diskBackedOpInputs that have exported all
+	// of the tuples buffered by inMemoryOp.
+	numExported int
 
 	inMemoryOp             bufferingInMemoryOperator
 	inMemoryOpInitStatus   OperatorInitStatus
 	inMemoryMemMonitorName string
+	inMemoryMemLimit       int64
+	inMemoryMemAccount     *mon.BoundAccount
 	diskBackedOp           Operator
 	distBackedOpInitStatus OperatorInitStatus
 	spillingCallbackFn     func()
+	// numSpills is the number of times that the disk spiller has spilled to
+	// disk. Unlike spilled, it is not cleared on reset.
+	numSpills int64
+	// bytesSpilled is the number of bytes that the disk queues of diskBackedOp
+	// have written to disk.
+	bytesSpilled int64
+	// diskQueueSizes contains the number of bytes that each of the disk queues
+	// created by diskBackedOp has written to disk, in the order of creation.
+	diskQueueSizes []int64
+	// phaseStart is the time at which the current phase (either in-memory or
+	// disk-backed) has started. It is zero if no phase is in progress.
+	phaseStart time.Time
+	// inMemoryDuration and diskBackedDuration accumulate the wall times of the
+	// finished in-memory and disk-backed phases.
+	inMemoryDuration, diskBackedDuration time.Duration
+
+	// desc is the descriptor of the in-memory operator which is assigned to
+	// this disk spiller by the OperatorRegistry of the flow (if any).
+	desc OperatorDescriptor
+	// progress, if set, is notified when the spilling to disk occurs, and the
+	// disk-backed operator is paused while the flow is paused.
+	progress *execinfra.FlowProgress
+	// inSpillingPhase indicates whether progress has been notified that the
+	// disk spiller is in FlowPhaseSpilling, i.e. that the disk-backed operator
+	// hasn't been exhausted yet.
+	inSpillingPhase bool
+	// spillRegistry, if set, is the registry of the node through which the
+	// disk spiller can be asked to spill to disk before the in-memory operator
+	// reaches its memory limit.
+	spillRegistry *execinfra.SpillRegistry
+	// limitBeforeSpillRequest is the limit of the monitor of
+	// inMemoryMemAccount before it was lowered by RequestSpill (zero if it
+	// hasn't been lowered). It is accessed atomically.
+	limitBeforeSpillRequest int64
+}
+
+var _ ResettableOperator = &diskSpillerBase{}
+var _ operatorDescriptorSetter = &diskSpillerBase{}
+var _ MemoryLimitReporter = &diskSpillerBase{}
+var _ flowProgressReporter = &diskSpillerBase{}
+var _ execinfrapb.MetadataSource = &diskSpillerBase{}
+var _ Closer = &diskSpillerBase{}
+var _ execinfra.Spillable = &diskSpillerBase{}
+
+// attachSpillRegistry attaches registry to all disk spillers in the tree rooted
+// at root, so that they register themselves once they are initialized. Only
+// the disk spillers whose in-memory operators notify when they are done
+// buffering are registered since the spilling must not occur afterwards.
+func attachSpillRegistry(root execinfra.OpNode, registry *execinfra.SpillRegistry) {
+	if d, ok := root.(*diskSpillerBase); ok && d.inMemoryMemAccount != nil {
+		if n, ok := d.inMemoryOp.(bufferingDoneNotifier); ok {
+			d.spillRegistry = registry
+			n.setBufferingDoneCb(d.stopAcceptingSpillRequests)
+		}
+	}
+	// We use verbose traversal in order to reach all internal Operators.
+	const verbose = true
+	for i := 0; i < root.ChildCount(verbose); i++ {
+		attachSpillRegistry(root.Child(i, verbose), registry)
+	}
 }
 
-var _ resettableOperator = &diskSpillerBase{}
+// BufferedBytes is part of the execinfra.Spillable interface.
+func (d *diskSpillerBase) BufferedBytes() int64 {
+	return d.inMemoryMemAccount.Monitor().AllocBytes()
+}
+
+// RequestSpill is part of the execinfra.Spillable interface. It lowers the
+// limit of the monitor of the in-memory operator to the current usage, so the
+// next allocation of the in-memory operator fails with an out of memory error
+// coming from that monitor, and the disk spiller falls back to disk as if the
+// in-memory operator has reached its memory limit.
+func (d *diskSpillerBase) RequestSpill() {
+	m := d.inMemoryMemAccount.Monitor()
+	prev := m.SetLimit(m.AllocBytes())
+	atomic.CompareAndSwapInt64(&d.limitBeforeSpillRequest, 0, prev)
+}
+
+// releaseBufferedOnceExported makes d release the tuples buffered by the
+// in-memory operator (together with their memory) once all of them have been
+// exported to the disk-backed operator, rather than on Close.
+func (d *diskSpillerBase) releaseBufferedOnceExported() {
+	r, ok := d.inMemoryOp.(bufferReleaser)
+	if !ok || d.inMemoryMemAccount == nil {
+		return
+	}
+	for _, input := range d.diskBackedOpInputs {
+		input.onFirstSourceDone = func(ctx context.Context) {
+			d.numExported++
+			if d.numExported == len(d.diskBackedOpInputs) {
+				r.releaseBuffered()
+				d.inMemoryMemAccount.Clear(ctx)
+			}
+		}
+	}
+}
+
+// stopAcceptingSpillRequests is called once the in-memory operator has
+// buffered all of its input. The disk spiller unregisters from the
+// SpillRegistry, so that it is no longer asked to spill, and restores the
+// memory limit in case it has been asked to spill before the in-memory
+// operator has allocated any more memory. The latter is safe because
+// RequestSpill is only called while the disk spiller is registered.
+func (d *diskSpillerBase) stopAcceptingSpillRequests() {
+	d.spillRegistry.Unregister(d)
+	d.restoreMemoryLimit()
+}
+
+// restoreMemoryLimit restores the limit of the monitor of the in-memory
+// operator if it has been lowered by RequestSpill.
+func (d *diskSpillerBase) restoreMemoryLimit() {
+	if prev := atomic.SwapInt64(&d.limitBeforeSpillRequest, 0); prev != 0 {
+		d.inMemoryMemAccount.Monitor().SetLimit(prev)
+	}
+}
+
+// trackDiskUsage returns a copy of diskQueueCfg that updates the number of
+// bytes spilled by d, as well as the size of the disk queue that has written,
+// every time a disk queue writes to disk.
+func (d *diskSpillerBase) trackDiskUsage(
+	diskQueueCfg colcontainer.DiskQueueCfg,
+) colcontainer.DiskQueueCfg {
+	newQueueWriteCb := diskQueueCfg.NewQueueWriteCb
+	diskQueueCfg.NewQueueWriteCb = func() func(bytesWritten int) {
+		var queueWriteCb func(bytesWritten int)
+		if newQueueWriteCb != nil {
+			queueWriteCb = newQueueWriteCb()
+		}
+		queueIdx := len(d.diskQueueSizes)
+		d.diskQueueSizes = append(d.diskQueueSizes, 0)
+		return func(bytesWritten int) {
+			d.diskQueueSizes[queueIdx] += int64(bytesWritten)
+			if queueWriteCb != nil {
+				queueWriteCb(bytesWritten)
+			}
+		}
+	}
+	onWriteCb := diskQueueCfg.OnWriteCb
+	diskQueueCfg.OnWriteCb = func(bytesWritten int) {
+		d.bytesSpilled += int64(bytesWritten)
+		if onWriteCb != nil {
+			onWriteCb(bytesWritten)
+		}
+	}
+	return diskQueueCfg
+}
+
+// finishPhase adds the time elapsed since the start of the current phase to
+// the duration of the phase.
+func (d *diskSpillerBase) finishPhase() {
+	if d.phaseStart.IsZero() {
+		return
+	}
+	now := timeutil.Now()
+	if d.spilled {
+		d.diskBackedDuration += now.Sub(d.phaseStart)
+	} else {
+		d.inMemoryDuration += now.Sub(d.phaseStart)
+	}
+	d.phaseStart = time.Time{}
+}
+
+// spillStats returns the description of the spilling to disk done so far.
+func (d *diskSpillerBase) spillStats() spillStats {
+	return spillStats{
+		diskBackedStats:    collectDiskBackedStats(d.diskBackedOp),
+		numSpills:          d.numSpills,
+		diskQueueSizes:     d.diskQueueSizes,
+		bytesSpilled:       d.bytesSpilled,
+		inMemoryDuration:   d.inMemoryDuration,
+		diskBackedDuration: d.diskBackedDuration,
+	}
+}
+
+// DrainMeta is part of the MetadataSource interface. It propagates the
+// metadata of the operators that have been executed (the wrapped in-memory and
+// disk-backed operators are not MetadataSources of the flow on their own). If
+// the disk spiller has spilled to disk, it also reports how many times it has
+// done so and the number of bytes it has spilled so that the gateway can
+// include them into the statement statistics, and it describes the spilling
+// in a tag of the span of ctx (if it is being recorded) so that the
+// description is included in the statement diagnostics bundles.
+func (d *diskSpillerBase) DrainMeta(ctx context.Context) []execinfrapb.ProducerMetadata {
+	d.finishPhase()
+	var meta []execinfrapb.ProducerMetadata
+	if d.distBackedOpInitStatus == OperatorInitialized {
+		if src, ok := d.diskBackedOp.(execinfrapb.MetadataSource); ok {
+			meta = append(meta, src.DrainMeta(ctx)...)
+		}
+		// The in-memory operator might have produced metadata before the
+		// spilling occurred, and it is drained by the buffer exporting
+		// operators (unless diskBackedOp has already drained its inputs).
+		for _, input := range d.diskBackedOpInputs {
+			meta = append(meta, input.DrainMeta(ctx)...)
+		}
+	} else if d.inMemoryOpInitStatus == OperatorInitialized {
+		if src, ok := d.inMemoryOp.(execinfrapb.MetadataSource); ok {
+			meta = append(meta, src.DrainMeta(ctx)...)
+		}
+	}
+	if d.numSpills == 0 {
+		return meta
+	}
+	if sp := opentracing.SpanFromContext(ctx); sp != nil && tracing.IsRecording(sp) {
+		sp.SetTag(execinfrapb.SpillStatsTagPrefix+d.inMemoryMemMonitorName, d.spillStats().String())
+	}
+	spillMeta := execinfrapb.GetProducerMeta()
+	spillMeta.Metrics = execinfrapb.GetMetricsMeta()
+	spillMeta.Metrics.SpillCount = d.numSpills
+	spillMeta.Metrics.BytesSpilled = d.bytesSpilled
+	return append(meta, *spillMeta)
+}
+
+func (d *diskSpillerBase) setFlowProgress(progress *execinfra.FlowProgress) {
+	d.progress = progress
+}
+
+// finishSpillingPhase notifies progress (if set) that the disk spiller is done
+// with FlowPhaseSpilling.
+func (d *diskSpillerBase) finishSpillingPhase() {
+	if d.inSpillingPhase {
+		d.progress.FinishPhase(execinfra.FlowPhaseSpilling)
+		d.inSpillingPhase = false
+	}
+}
+
+func (d *diskSpillerBase) setOperatorDescriptor(desc OperatorDescriptor) {
+	d.desc = desc
+}
+
+// MemoryLimit implements the MemoryLimitReporter interface.
+func (d *diskSpillerBase) MemoryLimit() (int64, bool) {
+	return d.inMemoryMemLimit, true
+}
 
 func (d *diskSpillerBase) Init() {
 	if d.inMemoryOpInitStatus == OperatorInitialized {
@@ -204,11 +517,24 @@ func (d *diskSpillerBase) Init() {
 	// only on the latter is sufficient.
 	d.inMemoryOp.Init()
 	d.inMemoryOpInitStatus = OperatorInitialized
+	if d.spillRegistry != nil {
+		d.spillRegistry.Register(d)
+	}
 }
 
 func (d *diskSpillerBase) Next(ctx context.Context) coldata.Batch {
+	if d.phaseStart.IsZero() {
+		d.phaseStart = timeutil.Now()
+	}
 	if d.spilled {
-		return d.diskBackedOp.Next(ctx)
+		// The work of the disk-backed operator is the most expensive, so we
+		// pause it if requested.
+		waitIfPaused(ctx, d.progress)
+		batch := d.diskBackedOp.Next(ctx)
+		if batch.Length() == 0 {
+			d.finishSpillingPhase()
+		}
+		return batch
 	}
 	var batch coldata.Batch
 	if err := execerror.CatchVectorizedRuntimeError(
@@ -216,45 +542,92 @@ func (d *diskSpillerBase) Next(ctx context.Context) coldata.Batch {
 			batch = d.inMemoryOp.Next(ctx)
 		},
 	); err != nil {
-		if sqlbase.IsOutOfMemoryError(err) &&
-			strings.Contains(err.Error(), d.inMemoryMemMonitorName) {
+		// Only the errors returned by the monitor of the in-memory operator
+		// trigger the spilling (the errors of the other monitors, e.g. the
+		// ones of the inputs, are propagated).
+		if isOOMFromMonitor(err, d.inMemoryMemMonitorName) {
+			log.VEventf(
+				ctx, 1, "%s spilled to disk (monitor %s, requested %t)",
+				d.desc, d.inMemoryMemMonitorName,
+				atomic.LoadInt64(&d.limitBeforeSpillRequest) != 0,
+			)
+			if d.spillRegistry != nil {
+				d.spillRegistry.Unregister(d)
+			}
+			d.finishPhase()
+			d.phaseStart = timeutil.Now()
 			d.spilled = true
+			d.numSpills++
+			if d.progress != nil {
+				d.progress.StartPhase(execinfra.FlowPhaseSpilling)
+				d.inSpillingPhase = true
+			}
 			if d.spillingCallbackFn != nil {
 				d.spillingCallbackFn()
 			}
-			d.diskBackedOp.Init()
-			d.distBackedOpInitStatus = OperatorInitialized
-			return d.diskBackedOp.Next(ctx)
+			if err := execerror.CatchVectorizedRuntimeError(func() {
+				d.diskBackedOp.Init()
+				d.distBackedOpInitStatus = OperatorInitialized
+				batch = d.diskBackedOp.Next(ctx)
+			}); err != nil {
+				execerror.VectorizedInternalPanic(annotateOperatorError(err, d.diskBackedOp, execerror.PhaseSpill))
+			}
+			if batch.Length() == 0 {
+				d.finishSpillingPhase()
+			}
+			return batch
 		}
 		// Either not an out of memory error or an OOM error coming from a
 		// different operator, so we propagate it further.
-		execerror.VectorizedInternalPanic(err)
+		execerror.VectorizedInternalPanic(annotateOperatorError(err, d.inMemoryOp, execerror.PhaseNext))
 	}
 	return batch
 }
 
-func (d *diskSpillerBase) reset() {
+func (d *diskSpillerBase) Reset(ctx context.Context) {
 	for _, input := range d.inputs {
-		if r, ok := input.(resetter); ok {
-			r.reset()
+		if r, ok := input.(Resetter); ok {
+			r.Reset(ctx)
 		}
 	}
 	if d.inMemoryOpInitStatus == OperatorInitialized {
-		if r, ok := d.inMemoryOp.(resetter); ok {
-			r.reset()
+		if r, ok := d.inMemoryOp.(Resetter); ok {
+			r.Reset(ctx)
 		}
 	}
 	if d.distBackedOpInitStatus == OperatorInitialized {
-		if r, ok := d.diskBackedOp.(resetter); ok {
-			r.reset()
+		if r, ok := d.diskBackedOp.(Resetter); ok {
+			r.Reset(ctx)
 		}
 	}
+	d.finishPhase()
+	d.finishSpillingPhase()
 	d.spilled = false
+	d.numExported = 0
+	if d.inMemoryMemAccount != nil {
+		d.restoreMemoryLimit()
+	}
+	if d.spillRegistry != nil && d.inMemoryOpInitStatus == OperatorInitialized {
+		// The disk spiller might have been unregistered when it spilled to disk
+		// or asked to spill already.
+		d.spillRegistry.Register(d)
+	}
 }
 
-func (d *diskSpillerBase) Close() error {
-	if c, ok := d.diskBackedOp.(io.Closer); ok {
-		return c.Close()
+// Close is part of the Closer interface.
+func (d *diskSpillerBase) Close(ctx context.Context) error {
+	if d.spillRegistry != nil {
+		d.spillRegistry.Unregister(d)
+	}
+	d.finishSpillingPhase()
+	if d.spilled && d.inMemoryMemAccount != nil {
+		// All of the tuples buffered by the in-memory operator have been
+		// exported to the disk-backed operator, so we release the memory of the
+		// former.
+		d.inMemoryMemAccount.Clear(ctx)
+	}
+	if c, ok := d.diskBackedOp.(Closer); ok {
+		return c.Close(ctx)
 	}
 	return nil
 }
@@ -305,16 +678,29 @@ type bufferExportingOperator struct {
 	firstSource     bufferingInMemoryOperator
 	secondSource    Operator
 	firstSourceDone bool
+
+	// drainsFirstSource indicates whether the operator is responsible for
+	// draining the metadata of firstSource. Note that secondSource is never
+	// drained since it is drained independently as the input to the disk
+	// spiller.
+	drainsFirstSource bool
+	// drained is set once the metadata of firstSource has been drained.
+	drained bool
+	// onFirstSourceDone, if set, is called once firstSource has exported all
+	// of its buffered tuples.
+	onFirstSourceDone func(context.Context)
 }
 
-var _ resettableOperator = &bufferExportingOperator{}
+var _ ResettableOperator = &bufferExportingOperator{}
+var _ execinfrapb.MetadataSource = &bufferExportingOperator{}
 
 func newBufferExportingOperator(
-	firstSource bufferingInMemoryOperator, secondSource Operator,
-) Operator {
+	firstSource bufferingInMemoryOperator, secondSource Operator, drainsFirstSource bool,
+) *bufferExportingOperator {
 	return &bufferExportingOperator{
-		firstSource:  firstSource,
-		secondSource: secondSource,
+		firstSource:       firstSource,
+		secondSource:      secondSource,
+		drainsFirstSource: drainsFirstSource,
 	}
 }
 
@@ -330,17 +716,111 @@ func (b *bufferExportingOperator) Next(ctx context.Context) coldata.Batch {
 	batch := b.firstSource.ExportBuffered(b.secondSource)
 	if batch.Length() == 0 {
 		b.firstSourceDone = true
+		if b.onFirstSourceDone != nil {
+			b.onFirstSourceDone(ctx)
+		}
 		return b.secondSource.Next(ctx)
 	}
 	return batch
 }
 
-func (b *bufferExportingOperator) reset() {
-	if r, ok := b.firstSource.(resetter); ok {
-		r.reset()
+func (b *bufferExportingOperator) Reset(ctx context.Context) {
+	if r, ok := b.firstSource.(Resetter); ok {
+		r.Reset(ctx)
 	}
-	if r, ok := b.secondSource.(resetter); ok {
-		r.reset()
+	if r, ok := b.secondSource.(Resetter); ok {
+		r.Reset(ctx)
 	}
 	b.firstSourceDone = false
+	b.drained = false
+}
+
+// DrainMeta is part of the MetadataSource interface. The metadata of
+// firstSource is drained at most once, so it is safe for both the disk-backed
+// operator and the disk spiller to drain b.
+func (b *bufferExportingOperator) DrainMeta(ctx context.Context) []execinfrapb.ProducerMetadata {
+	if !b.drainsFirstSource || b.drained {
+		return nil
+	}
+	b.drained = true
+	if src, ok := b.firstSource.(execinfrapb.MetadataSource); ok {
+		return src.DrainMeta(ctx)
+	}
+	return nil
+}
+
+// spillForcingOperator is an Operator that is planned between the buffering
+// input of an in-memory operator and the operator itself when the spilling to
+// disk is forced after a certain number of tuples (see
+// getForceSpillAfterNumTuples). Once that many tuples have been consumed, it
+// emulates the in-memory operator reaching its memory limit by panicking with
+// the same error that the memory monitor of the operator would have returned,
+// so that the disk spiller falls back to the disk-backed operator.
+//
+// NOTE: the error is thrown before the next batch is requested from the
+// input, so no tuples are lost during the transition to disk.
+type spillForcingOperator struct {
+	OneInputNode
+	NonExplainable
+
+	memMonitorName      string
+	spillAfterNumTuples int
+	numTuples           int
+}
+
+var _ ResettableOperator = &spillForcingOperator{}
+
+// maybeForceSpilling returns input wrapped in a spillForcingOperator if the
+// spilling has been forced after a certain number of tuples, and input itself
+// otherwise. memMonitorName must be the name of the memory monitor of the
+// in-memory operator that input is fed into.
+func maybeForceSpilling(
+	flowCtx *execinfra.FlowCtx, input Operator, memMonitorName string,
+) Operator {
+	spillAfterNumTuples := getForceSpillAfterNumTuples(flowCtx)
+	if spillAfterNumTuples <= 0 {
+		return input
+	}
+	return &spillForcingOperator{
+		OneInputNode:        NewOneInputNode(input),
+		memMonitorName:      memMonitorName,
+		spillAfterNumTuples: spillAfterNumTuples,
+	}
+}
+
+// getForceSpillAfterNumTuples returns the number of tuples after which the
+// disk spillers are forced to fall back to disk. The testing knob takes
+// precedence over the session variable. Zero is returned if the spilling is not
+// forced.
+func getForceSpillAfterNumTuples(flowCtx *execinfra.FlowCtx) int {
+	if n := flowCtx.Cfg.TestingKnobs.ForceDiskSpillAfterNumTuples; n > 0 {
+		return n
+	}
+	if flowCtx.EvalCtx != nil && flowCtx.EvalCtx.SessionData != nil {
+		return flowCtx.EvalCtx.SessionData.VectorizeForceSpillAfterNumTuples
+	}
+	return 0
+}
+
+func (s *spillForcingOperator) Init() {
+	s.input.Init()
+}
+
+func (s *spillForcingOperator) Next(ctx context.Context) coldata.Batch {
+	if s.numTuples >= s.spillAfterNumTuples {
+		execerror.VectorizedInternalPanic(pgerror.Newf(
+			pgcode.OutOfMemory, "%s: memory budget exceeded: spilling forced after %d tuples",
+			s.memMonitorName, s.numTuples,
+		))
+	}
+	batch := s.input.Next(ctx)
+	s.numTuples += batch.Length()
+	return batch
+}
+
+func (s *spillForcingOperator) Reset(ctx context.Context) {
+	if r, ok := s.input.(Resetter); ok {
+		r.Reset(ctx)
+	}
+	s.numTuples = 0
 }
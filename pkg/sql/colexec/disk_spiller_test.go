@@ -0,0 +1,664 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils/colcontainerutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDiskSpillerReportsSpilling verifies that the disk spiller reports the
+// spilling to disk (and only it) as metadata.
+func TestDiskSpillerReportsSpilling(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	tempEngine, diskMonitor, cleanupTempStorage := newTestingTempStorage(t, st)
+	defer cleanupTempStorage()
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings:    st,
+			TempStorage: tempEngine,
+			DiskMonitor: diskMonitor,
+		},
+	}
+
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	for _, spillForced := range []bool{false, true} {
+		t.Run(fmt.Sprintf("spillForced=%t", spillForced), func(t *testing.T) {
+			flowCtx.Cfg.TestingKnobs.ForceDiskSpill = spillForced
+			input := newOpTestInput(1 /* batchSize */, tuples{{3}, {1}, {2}}, []coltypes.T{coltypes.Int64})
+			args := NewColOperatorArgs{
+				Spec: &execinfrapb.ProcessorSpec{
+					Input: []execinfrapb.InputSyncSpec{{ColumnTypes: []types.T{*types.Int}}},
+					Core: execinfrapb.ProcessorCoreUnion{
+						Sorter: &execinfrapb.SorterSpec{
+							OutputOrdering: execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}},
+						},
+					},
+				},
+				Inputs:              []Operator{input},
+				StreamingMemAccount: testMemAcc,
+				DiskQueueCfg:        queueCfg,
+			}
+			result, err := NewColOperator(ctx, flowCtx, args)
+			require.NoError(t, err)
+			defer func() {
+				for _, account := range result.BufferingOpMemAccounts {
+					account.Close(ctx)
+				}
+				for _, monitor := range result.BufferingOpMemMonitors {
+					monitor.Stop(ctx)
+				}
+			}()
+
+			result.Op.Init()
+			for b := result.Op.Next(ctx); b.Length() > 0; b = result.Op.Next(ctx) {
+			}
+
+			var spillCount, bytesSpilled int64
+			for _, src := range result.MetadataSources {
+				for _, meta := range src.DrainMeta(ctx) {
+					if meta.Metrics != nil {
+						spillCount += meta.Metrics.SpillCount
+						bytesSpilled += meta.Metrics.BytesSpilled
+					}
+				}
+			}
+			if spillForced {
+				require.Equal(t, int64(1), spillCount)
+				require.True(t, bytesSpilled > 0)
+			} else {
+				require.Zero(t, spillCount)
+				require.Zero(t, bytesSpilled)
+			}
+		})
+	}
+}
+
+// TestDiskSpillerReleasesResources verifies that the disk-backed operators do
+// not leak any resources after they have spilled to disk and have been
+// closed.
+func TestDiskSpillerReleasesResources(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	tempEngine, diskMonitor, cleanupTempStorage := newTestingTempStorage(t, st)
+	defer cleanupTempStorage()
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings:    st,
+			TempStorage: tempEngine,
+			DiskMonitor: diskMonitor,
+			TestingKnobs: execinfra.TestingKnobs{
+				ForceDiskSpill: true,
+			},
+		},
+	}
+
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	intCols := []types.T{*types.Int}
+	tups := tuples{{3}, {1}, {nil}, {2}, {1}}
+	for _, tc := range []struct {
+		name   string
+		core   execinfrapb.ProcessorCoreUnion
+		inputs []execinfrapb.InputSyncSpec
+	}{
+		{
+			name: "sort",
+			core: execinfrapb.ProcessorCoreUnion{
+				Sorter: &execinfrapb.SorterSpec{
+					OutputOrdering: execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}},
+				},
+			},
+			inputs: []execinfrapb.InputSyncSpec{{ColumnTypes: intCols}},
+		},
+		{
+			name: "hash-join",
+			core: execinfrapb.ProcessorCoreUnion{
+				HashJoiner: &execinfrapb.HashJoinerSpec{
+					LeftEqColumns:  []uint32{0},
+					RightEqColumns: []uint32{0},
+				},
+			},
+			inputs: []execinfrapb.InputSyncSpec{{ColumnTypes: intCols}, {ColumnTypes: intCols}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sem := NewTestingSemaphore(256)
+			tracker := newResourceTracker(t, queueCfg, sem)
+			inputs := make([]Operator, len(tc.inputs))
+			for i := range inputs {
+				inputs[i] = newOpTestInput(1 /* batchSize */, tups, []coltypes.T{coltypes.Int64})
+			}
+			var spilled bool
+			args := NewColOperatorArgs{
+				Spec: &execinfrapb.ProcessorSpec{
+					Input: tc.inputs,
+					Core:  tc.core,
+				},
+				Inputs:              inputs,
+				StreamingMemAccount: testMemAcc,
+				DiskQueueCfg:        tracker.diskQueueCfg,
+				FDSemaphore:         sem,
+			}
+			args.TestingKnobs.SpillingCallbackFn = func() { spilled = true }
+			result, err := NewColOperator(ctx, flowCtx, args)
+			tracker.trackMemory(result.BufferingOpMemAccounts, result.BufferingOpMemMonitors)
+			require.NoError(t, err)
+
+			result.Op.Init()
+			for b := result.Op.Next(ctx); b.Length() > 0; b = result.Op.Next(ctx) {
+			}
+			require.True(t, spilled)
+			tracker.closeAndVerify(ctx, result.Op)
+		})
+	}
+}
+
+// TestDiskSpillerForcedAfterNumTuples verifies that the disk spillers fall back
+// to disk once the in-memory operators have consumed the number of tuples
+// specified by the testing knob, and that the output is not affected.
+func TestDiskSpillerForcedAfterNumTuples(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	tempEngine, diskMonitor, cleanupTempStorage := newTestingTempStorage(t, st)
+	defer cleanupTempStorage()
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings:    st,
+			TempStorage: tempEngine,
+			DiskMonitor: diskMonitor,
+		},
+	}
+
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	intCols := []types.T{*types.Int}
+	tups := tuples{{3}, {1}, {nil}, {2}, {1}}
+	for _, tc := range []struct {
+		name     string
+		core     execinfrapb.ProcessorCoreUnion
+		inputs   []execinfrapb.InputSyncSpec
+		expected tuples
+		ordered  bool
+	}{
+		{
+			name: "sort",
+			core: execinfrapb.ProcessorCoreUnion{
+				Sorter: &execinfrapb.SorterSpec{
+					OutputOrdering: execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}},
+				},
+			},
+			inputs:   []execinfrapb.InputSyncSpec{{ColumnTypes: intCols}},
+			expected: tuples{{nil}, {1}, {1}, {2}, {3}},
+			ordered:  true,
+		},
+		{
+			name: "hash-join",
+			core: execinfrapb.ProcessorCoreUnion{
+				HashJoiner: &execinfrapb.HashJoinerSpec{
+					LeftEqColumns:  []uint32{0},
+					RightEqColumns: []uint32{0},
+				},
+			},
+			inputs:   []execinfrapb.InputSyncSpec{{ColumnTypes: intCols}, {ColumnTypes: intCols}},
+			expected: tuples{{3, 3}, {1, 1}, {1, 1}, {1, 1}, {1, 1}, {2, 2}},
+		},
+	} {
+		for _, spillAfterNumTuples := range []int{1, 3, len(tups), len(tups) + 1} {
+			t.Run(fmt.Sprintf("%s/spillAfter=%d", tc.name, spillAfterNumTuples), func(t *testing.T) {
+				flowCtx.Cfg.TestingKnobs.ForceDiskSpillAfterNumTuples = spillAfterNumTuples
+				sem := NewTestingSemaphore(256)
+				tracker := newResourceTracker(t, queueCfg, sem)
+				inputs := make([]Operator, len(tc.inputs))
+				for i := range inputs {
+					inputs[i] = newOpTestInput(1 /* batchSize */, tups, []coltypes.T{coltypes.Int64})
+				}
+				var spilled bool
+				args := NewColOperatorArgs{
+					Spec: &execinfrapb.ProcessorSpec{
+						Input: tc.inputs,
+						Core:  tc.core,
+					},
+					Inputs:              inputs,
+					StreamingMemAccount: testMemAcc,
+					DiskQueueCfg:        tracker.diskQueueCfg,
+					FDSemaphore:         sem,
+				}
+				args.TestingKnobs.SpillingCallbackFn = func() { spilled = true }
+				result, err := NewColOperator(ctx, flowCtx, args)
+				tracker.trackMemory(result.BufferingOpMemAccounts, result.BufferingOpMemMonitors)
+				require.NoError(t, err)
+
+				out := newOpTestOutput(result.Op, tc.expected)
+				if tc.ordered {
+					require.NoError(t, out.Verify())
+				} else {
+					require.NoError(t, out.VerifyAnyOrder())
+				}
+				// The spilling is forced only when the in-memory operator asks for
+				// more input after having consumed spillAfterNumTuples tuples.
+				require.Equal(t, spillAfterNumTuples <= len(tups), spilled)
+				tracker.closeAndVerify(ctx, result.Op)
+			})
+		}
+	}
+}
+
+// TestDiskSpillerAnnotatesErrors verifies that the errors propagated by the
+// disk spillers are annotated with the phase during which they occurred.
+func TestDiskSpillerAnnotatesErrors(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	tempEngine, diskMonitor, cleanupTempStorage := newTestingTempStorage(t, st)
+	defer cleanupTempStorage()
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings:    st,
+			TempStorage: tempEngine,
+			DiskMonitor: diskMonitor,
+		},
+	}
+
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	expectedErr := pgerror.New(pgcode.DivisionByZero, "division by zero")
+	for _, spillAfterNumTuples := range []int{0, 1} {
+		t.Run(fmt.Sprintf("spillAfter=%d", spillAfterNumTuples), func(t *testing.T) {
+			// The knob is disabled when spillAfterNumTuples is zero, so the error
+			// occurs in the in-memory sorter. Otherwise, the spilling is forced
+			// after the first tuple, and the error occurs while the disk-backed
+			// sorter consumes the rest of the input.
+			expectedPhase := execerror.PhaseNext
+			if spillAfterNumTuples > 0 {
+				expectedPhase = execerror.PhaseSpill
+			}
+			flowCtx.Cfg.TestingKnobs.ForceDiskSpillAfterNumTuples = spillAfterNumTuples
+			sem := NewTestingSemaphore(256)
+			tracker := newResourceTracker(t, queueCfg, sem)
+			// The input produces two batches and then encounters an error.
+			source := newOpTestInput(1 /* batchSize */, tuples{{2}, {1}}, []coltypes.T{coltypes.Int64})
+			source.Init()
+			numBatches := 0
+			input := &CallbackOperator{NextCb: func(ctx context.Context) coldata.Batch {
+				if numBatches == 2 {
+					execerror.VectorizedInternalPanic(expectedErr)
+				}
+				numBatches++
+				return source.Next(ctx)
+			}}
+			args := NewColOperatorArgs{
+				Spec: &execinfrapb.ProcessorSpec{
+					Input: []execinfrapb.InputSyncSpec{{ColumnTypes: []types.T{*types.Int}}},
+					Core: execinfrapb.ProcessorCoreUnion{
+						Sorter: &execinfrapb.SorterSpec{
+							OutputOrdering: execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}},
+						},
+					},
+				},
+				Inputs:              []Operator{input},
+				StreamingMemAccount: testMemAcc,
+				DiskQueueCfg:        tracker.diskQueueCfg,
+				FDSemaphore:         sem,
+			}
+			result, err := NewColOperator(ctx, flowCtx, args)
+			tracker.trackMemory(result.BufferingOpMemAccounts, result.BufferingOpMemMonitors)
+			require.NoError(t, err)
+
+			result.Op.Init()
+			err = execerror.CatchVectorizedRuntimeError(func() {
+				for b := result.Op.Next(ctx); b.Length() > 0; b = result.Op.Next(ctx) {
+				}
+			})
+			require.True(t, errors.Is(err, expectedErr), "unexpected error %v", err)
+			require.Equal(t, execerror.ClassUser, execerror.Classify(err))
+			opName, phase, ok := execerror.GetOperatorContext(err)
+			require.True(t, ok)
+			require.NotEmpty(t, opName)
+			require.Equal(t, expectedPhase, phase)
+			tracker.closeAndVerify(ctx, result.Op)
+		})
+	}
+}
+
+// testMetadataSource is a MetadataSource that emits a single piece of metadata
+// (an error with the name of the source) every time it is drained.
+type testMetadataSource struct {
+	name      string
+	numDrains int
+}
+
+func (s *testMetadataSource) DrainMeta(context.Context) []execinfrapb.ProducerMetadata {
+	s.numDrains++
+	return []execinfrapb.ProducerMetadata{{Err: errors.New(s.name)}}
+}
+
+// TestDiskSpillerDrainsMeta verifies that the disk spiller propagates the
+// metadata of the in-memory operator and, if it has spilled to disk, of the
+// disk-backed operator.
+func TestDiskSpillerDrainsMeta(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	const memMonitorName = "test-in-memory"
+	typs := []coltypes.T{coltypes.Int64}
+	for _, spillForced := range []bool{false, true} {
+		t.Run(fmt.Sprintf("spillForced=%t", spillForced), func(t *testing.T) {
+			flowCtx.Cfg.TestingKnobs.ForceDiskSpillAfterNumTuples = 0
+			if spillForced {
+				flowCtx.Cfg.TestingKnobs.ForceDiskSpillAfterNumTuples = 1
+			}
+			input := newOpTestInput(1 /* batchSize */, tuples{{3}, {1}, {2}}, typs)
+			sorter, err := NewSorter(
+				testAllocator, maybeForceSpilling(flowCtx, input, memMonitorName), typs,
+				[]execinfrapb.Ordering_Column{{ColIdx: 0}},
+			)
+			require.NoError(t, err)
+			inMemoryMeta := &testMetadataSource{name: "in-memory"}
+			diskBackedMeta := &testMetadataSource{name: "disk-backed"}
+			inMemoryOp := &struct {
+				bufferingInMemoryOperator
+				*testMetadataSource
+			}{sorter.(bufferingInMemoryOperator), inMemoryMeta}
+			spiller := newOneInputDiskSpiller(
+				input, inMemoryOp, memMonitorName, 0 /* inMemoryMemLimit */, nil, /* inMemoryMemAccount */
+				colcontainer.DiskQueueCfg{},
+				func(input Operator, _ colcontainer.DiskQueueCfg) Operator {
+					return &struct {
+						Operator
+						*testMetadataSource
+					}{NewNoop(input), diskBackedMeta}
+				},
+				nil, /* spillingCallbackFn */
+			)
+			spiller.Init()
+			for b := spiller.Next(ctx); b.Length() > 0; b = spiller.Next(ctx) {
+			}
+
+			var names []string
+			var spillCount int64
+			for _, meta := range spiller.(execinfrapb.MetadataSource).DrainMeta(ctx) {
+				if meta.Metrics != nil {
+					spillCount += meta.Metrics.SpillCount
+					continue
+				}
+				names = append(names, meta.Err.Error())
+			}
+			if spillForced {
+				require.Equal(t, []string{"disk-backed", "in-memory"}, names)
+				require.Equal(t, int64(1), spillCount)
+			} else {
+				require.Equal(t, []string{"in-memory"}, names)
+				require.Zero(t, spillCount)
+			}
+			require.Equal(t, 1, inMemoryMeta.numDrains)
+		})
+	}
+}
+
+// TestDiskSpillerPauses verifies that the disk spiller doesn't produce batches
+// from the disk-backed operator while the flow is paused.
+func TestDiskSpillerPauses(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+			TestingKnobs: execinfra.TestingKnobs{
+				ForceDiskSpill: true,
+			},
+		},
+	}
+
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	sem := NewTestingSemaphore(256)
+	tracker := newResourceTracker(t, queueCfg, sem)
+	progress := execinfra.NewFlowProgress(execinfrapb.FlowID{}, "" /* queryID */, 0 /* estimatedRowCount */)
+	args := NewColOperatorArgs{
+		Spec: &execinfrapb.ProcessorSpec{
+			Input: []execinfrapb.InputSyncSpec{{ColumnTypes: []types.T{*types.Int}}},
+			Core: execinfrapb.ProcessorCoreUnion{
+				Sorter: &execinfrapb.SorterSpec{
+					OutputOrdering: execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}},
+				},
+			},
+		},
+		Inputs:              []Operator{newOpTestInput(1 /* batchSize */, tuples{{3}, {1}, {2}}, []coltypes.T{coltypes.Int64})},
+		StreamingMemAccount: testMemAcc,
+		DiskQueueCfg:        tracker.diskQueueCfg,
+		FDSemaphore:         sem,
+		FlowProgress:        progress,
+	}
+	result, err := NewColOperator(ctx, flowCtx, args)
+	tracker.trackMemory(result.BufferingOpMemAccounts, result.BufferingOpMemMonitors)
+	require.NoError(t, err)
+
+	result.Op.Init()
+	// The first batch is produced as part of the spilling.
+	require.Equal(t, 3, result.Op.Next(ctx).Length())
+	require.NotEqual(t, execinfra.FlowPhaseRunning, progress.Phase())
+	progress.Pause()
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	err = execerror.CatchVectorizedRuntimeError(func() {
+		result.Op.Next(cancelCtx)
+	})
+	require.True(t, errors.Is(err, sqlbase.QueryCanceledError), "unexpected error %v", err)
+	// Once the flow is resumed, the spiller proceeds where it has stopped.
+	progress.Resume()
+	require.Equal(t, 0, result.Op.Next(ctx).Length())
+	// The flow is back to running once the disk-backed operator is exhausted.
+	require.Equal(t, execinfra.FlowPhaseRunning, progress.Phase())
+	tracker.closeAndVerify(ctx, result.Op)
+}
+
+// TestDiskSpillerSpillsWhenRequested verifies that the disk spiller falls back
+// to disk once it has been asked to spill through the SpillRegistry, even
+// though the in-memory operator hasn't reached its memory limit.
+func TestDiskSpillerSpillsWhenRequested(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	registry := execinfra.NewSpillRegistry()
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings:      st,
+			SpillRegistry: registry,
+		},
+	}
+
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	const numBatches = 16
+	batch := testAllocator.NewMemBatch([]coltypes.T{coltypes.Int64})
+	for i, col := 0, batch.ColVec(0).Int64(); i < coldata.BatchSize(); i++ {
+		col[i] = int64(coldata.BatchSize() - i)
+	}
+	batch.SetLength(coldata.BatchSize())
+	batchesReturned := 0
+	input := &CallbackOperator{
+		NextCb: func(ctx context.Context) coldata.Batch {
+			if batchesReturned == numBatches {
+				return coldata.ZeroBatch
+			}
+			batchesReturned++
+			if batchesReturned == numBatches/2 {
+				// The node came under memory pressure while the sorter was
+				// buffering its input.
+				n, bytes := registry.RequestSpills(1 /* fraction */)
+				require.Equal(t, 1, n)
+				require.True(t, bytes > 0)
+			}
+			return batch
+		},
+	}
+
+	sem := NewTestingSemaphore(256)
+	tracker := newResourceTracker(t, queueCfg, sem)
+	var spilled bool
+	args := NewColOperatorArgs{
+		Spec: &execinfrapb.ProcessorSpec{
+			Input: []execinfrapb.InputSyncSpec{{ColumnTypes: []types.T{*types.Int}}},
+			Core: execinfrapb.ProcessorCoreUnion{
+				Sorter: &execinfrapb.SorterSpec{
+					OutputOrdering: execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}},
+				},
+			},
+		},
+		Inputs:              []Operator{input},
+		StreamingMemAccount: testMemAcc,
+		DiskQueueCfg:        tracker.diskQueueCfg,
+		FDSemaphore:         sem,
+	}
+	args.TestingKnobs.SpillingCallbackFn = func() { spilled = true }
+	result, err := NewColOperator(ctx, flowCtx, args)
+	tracker.trackMemory(result.BufferingOpMemAccounts, result.BufferingOpMemMonitors)
+	require.NoError(t, err)
+
+	result.Op.Init()
+	numTuples := 0
+	for b := result.Op.Next(ctx); b.Length() > 0; b = result.Op.Next(ctx) {
+		numTuples += b.Length()
+	}
+	require.True(t, spilled)
+	require.Equal(t, numBatches*coldata.BatchSize(), numTuples)
+	tracker.closeAndVerify(ctx, result.Op)
+	// The disk spiller has unregistered itself, so there is nothing left to be
+	// asked to spill.
+	n, _ := registry.RequestSpills(1 /* fraction */)
+	require.Zero(t, n)
+}
+
+// TestDiskSpillerIgnoresRequestsAfterBuffering verifies that the disk spiller
+// is no longer asked to spill once the in-memory operator has buffered all of
+// its input, since the latter might have emitted some tuples already.
+func TestDiskSpillerIgnoresRequestsAfterBuffering(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	registry := execinfra.NewSpillRegistry()
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings:      st,
+			SpillRegistry: registry,
+		},
+	}
+
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	intCols := []types.T{*types.Int}
+	leftTups := make(tuples, 4*coldata.BatchSize())
+	for i := range leftTups {
+		leftTups[i] = tuple{i % 4}
+	}
+	rightTups := tuples{{0}, {1}, {2}, {3}}
+	sem := NewTestingSemaphore(256)
+	tracker := newResourceTracker(t, queueCfg, sem)
+	var spilled bool
+	args := NewColOperatorArgs{
+		Spec: &execinfrapb.ProcessorSpec{
+			Input: []execinfrapb.InputSyncSpec{{ColumnTypes: intCols}, {ColumnTypes: intCols}},
+			Core: execinfrapb.ProcessorCoreUnion{
+				HashJoiner: &execinfrapb.HashJoinerSpec{
+					LeftEqColumns:  []uint32{0},
+					RightEqColumns: []uint32{0},
+				},
+			},
+		},
+		Inputs: []Operator{
+			newOpTestInput(coldata.BatchSize(), leftTups, []coltypes.T{coltypes.Int64}),
+			newOpTestInput(1 /* batchSize */, rightTups, []coltypes.T{coltypes.Int64}),
+		},
+		StreamingMemAccount: testMemAcc,
+		DiskQueueCfg:        tracker.diskQueueCfg,
+		FDSemaphore:         sem,
+	}
+	args.TestingKnobs.SpillingCallbackFn = func() { spilled = true }
+	result, err := NewColOperator(ctx, flowCtx, args)
+	tracker.trackMemory(result.BufferingOpMemAccounts, result.BufferingOpMemMonitors)
+	require.NoError(t, err)
+
+	result.Op.Init()
+	numTuples := result.Op.Next(ctx).Length()
+	require.True(t, numTuples > 0)
+	// The hash table has been built, so the disk spiller has unregistered
+	// itself.
+	n, _ := registry.RequestSpills(1 /* fraction */)
+	require.Zero(t, n)
+	for b := result.Op.Next(ctx); b.Length() > 0; b = result.Op.Next(ctx) {
+		numTuples += b.Length()
+	}
+	require.False(t, spilled)
+	require.Equal(t, len(leftTups), numTuples)
+	tracker.closeAndVerify(ctx, result.Op)
+}
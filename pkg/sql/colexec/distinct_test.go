@@ -18,13 +18,20 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils/colcontainerutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
-	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDistinct(t *testing.T) {
 	defer leaktest.AfterTest(t)()
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(t)
 	tcs := []struct {
 		distinctCols            []uint32
 		colTypes                []coltypes.T
@@ -140,8 +147,84 @@ func TestDistinct(t *testing.T) {
 	}
 }
 
+func TestDiskBackedUnorderedDistinct(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	tempEngine, diskMonitor, cleanupTempStorage := newTestingTempStorage(t, st)
+	defer cleanupTempStorage()
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings:    st,
+			TempStorage: tempEngine,
+			DiskMonitor: diskMonitor,
+		},
+	}
+	flowCtx.Cfg.TestingKnobs.ForceDiskSpill = true
+
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	var (
+		memAccounts []*mon.BoundAccount
+		memMonitors []*mon.BytesMonitor
+	)
+	spilled := false
+	runTests(
+		t,
+		[]tuples{{
+			{1, 2, 3},
+			{nil, 2, 3},
+			{1, 2, 4},
+			{1, 2, 3},
+			{nil, nil, 3},
+			{nil, 2, 5},
+			{2, 1, 3},
+			{nil, nil, 6},
+		}},
+		tuples{
+			{1, 2, 3},
+			{nil, 2, 3},
+			{nil, nil, 3},
+			{2, 1, 3},
+		},
+		unorderedVerifier,
+		func(input []Operator) (Operator, error) {
+			spec := &execinfrapb.ProcessorSpec{
+				Input: []execinfrapb.InputSyncSpec{{
+					ColumnTypes: []types.T{*types.Int, *types.Int, *types.Int},
+				}},
+				Core: execinfrapb.ProcessorCoreUnion{
+					Distinct: &execinfrapb.DistinctSpec{DistinctColumns: []uint32{0, 1}},
+				},
+			}
+			args := NewColOperatorArgs{
+				Spec:                spec,
+				Inputs:              input,
+				StreamingMemAccount: testMemAcc,
+				DiskQueueCfg:        queueCfg,
+			}
+			args.TestingKnobs.SpillingCallbackFn = func() { spilled = true }
+			result, err := NewColOperator(ctx, flowCtx, args)
+			memAccounts = append(memAccounts, result.BufferingOpMemAccounts...)
+			memMonitors = append(memMonitors, result.BufferingOpMemMonitors...)
+			return result.Op, err
+		})
+	require.True(t, spilled)
+	for _, account := range memAccounts {
+		account.Close(ctx)
+	}
+	for _, monitor := range memMonitors {
+		monitor.Stop(ctx)
+	}
+	require.Equal(t, int64(0), diskMonitor.AllocBytes())
+}
+
 func BenchmarkDistinct(b *testing.B) {
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(b)
 	ctx := context.Background()
 
 	distinctConstructors := []func(*Allocator, Operator, []uint32, int, []coltypes.T) (Operator, error){
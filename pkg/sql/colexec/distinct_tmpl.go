@@ -51,7 +51,7 @@ func OrderedDistinctColsToOperators(
 	}
 	var (
 		err error
-		r   resettableOperator
+		r   ResettableOperator
 		ok  bool
 	)
 	for i := range distinctCols {
@@ -60,20 +60,20 @@ func OrderedDistinctColsToOperators(
 			return nil, nil, err
 		}
 	}
-	if r, ok = input.(resettableOperator); !ok {
-		execerror.VectorizedInternalPanic("unexpectedly an ordered distinct is not a resetter")
+	if r, ok = input.(ResettableOperator); !ok {
+		execerror.VectorizedInternalPanic("unexpectedly an ordered distinct is not a Resetter")
 	}
 	distinctChain := &distinctChainOps{
-		resettableOperator: r,
+		ResettableOperator: r,
 	}
 	return distinctChain, distinctCol, nil
 }
 
 type distinctChainOps struct {
-	resettableOperator
+	ResettableOperator
 }
 
-var _ resettableOperator = &distinctChainOps{}
+var _ ResettableOperator = &distinctChainOps{}
 
 // NewOrderedDistinct creates a new ordered distinct operator on the given
 // input columns with the given coltypes.
@@ -207,17 +207,17 @@ type sortedDistinct_TYPEOp struct {
 	lastValNull bool
 }
 
-var _ resettableOperator = &sortedDistinct_TYPEOp{}
+var _ ResettableOperator = &sortedDistinct_TYPEOp{}
 
 func (p *sortedDistinct_TYPEOp) Init() {
 	p.input.Init()
 }
 
-func (p *sortedDistinct_TYPEOp) reset() {
+func (p *sortedDistinct_TYPEOp) Reset(ctx context.Context) {
 	p.foundFirstRow = false
 	p.lastValNull = false
-	if resetter, ok := p.input.(resetter); ok {
-		resetter.reset()
+	if r, ok := p.input.(Resetter); ok {
+		r.Reset(ctx)
 	}
 }
 
@@ -0,0 +1,217 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package execerror
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/errors"
+	"github.com/gogo/protobuf/proto"
+)
+
+// Phase is the phase of the lifecycle of an operator during which an error
+// occurred.
+type Phase int
+
+const (
+	// PhaseUnknown is used when the phase is not known.
+	PhaseUnknown Phase = iota
+	// PhaseInit is the initialization of the operator.
+	PhaseInit
+	// PhaseNext is the production of the output batches.
+	PhaseNext
+	// PhaseSpill is the transition from an in-memory operator to the
+	// disk-backed one.
+	PhaseSpill
+	// PhaseClose is the release of the resources held by the operator.
+	PhaseClose
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseInit:
+		return "init"
+	case PhaseNext:
+		return "next"
+	case PhaseSpill:
+		return "spill-transition"
+	case PhaseClose:
+		return "close"
+	default:
+		return "unknown"
+	}
+}
+
+// Class is the classification of an error that occurred in the vectorized
+// engine. It should be used instead of the inspection of error messages when
+// deciding how an error needs to be handled.
+type Class int
+
+const (
+	// ClassInternal is an unexpected error, i.e. a bug.
+	ClassInternal Class = iota
+	// ClassUser is an expected error caused by the query or the data (e.g. a
+	// division by zero).
+	ClassUser
+	// ClassMemory is an error caused by exceeding a memory budget.
+	ClassMemory
+	// ClassDisk is an error caused by exceeding a disk budget or by a failure
+	// of the temporary storage.
+	ClassDisk
+	// ClassStorage is an error that originated below the SQL layer (see
+	// StorageError).
+	ClassStorage
+	// ClassCanceled is an error caused by the cancellation of the query.
+	ClassCanceled
+	// ClassTimeout is an error caused by a timeout (e.g. the statement timeout
+	// or a deadline of a context).
+	ClassTimeout
+)
+
+func (c Class) String() string {
+	switch c {
+	case ClassUser:
+		return "user"
+	case ClassMemory:
+		return "memory"
+	case ClassDisk:
+		return "disk"
+	case ClassStorage:
+		return "storage"
+	case ClassCanceled:
+		return "canceled"
+	case ClassTimeout:
+		return "timeout"
+	default:
+		return "internal"
+	}
+}
+
+// Classify returns the class of err. Note that the errors with a pg code that
+// is not listed explicitly are caused by the query or the data.
+func Classify(err error) Class {
+	var storageErr *StorageError
+	if errors.As(err, &storageErr) {
+		return ClassStorage
+	}
+	// The statement timeout is also reported with the QueryCanceled code, so
+	// it is checked first.
+	if errors.Is(err, sqlbase.QueryTimeoutError) || errors.Is(err, context.DeadlineExceeded) {
+		return ClassTimeout
+	}
+	if errors.Is(err, context.Canceled) {
+		return ClassCanceled
+	}
+	switch pgerror.GetPGCode(err) {
+	case pgcode.QueryCanceled:
+		return ClassCanceled
+	case pgcode.OutOfMemory:
+		return ClassMemory
+	case pgcode.DiskFull, pgcode.Io:
+		return ClassDisk
+	case pgcode.Internal:
+		return ClassInternal
+	case pgcode.Uncategorized:
+		var pathErr *os.PathError
+		if errors.As(err, &pathErr) {
+			// The files are only used by the vectorized engine for the
+			// temporary storage.
+			return ClassDisk
+		}
+		return ClassInternal
+	}
+	return ClassUser
+}
+
+// withOperatorContext is an error wrapper that records the operator in which
+// the error occurred and the phase of the lifecycle of that operator. It
+// doesn't change the message nor the pg code of the wrapped error.
+type withOperatorContext struct {
+	cause  error
+	opName string
+	phase  Phase
+}
+
+var _ error = (*withOperatorContext)(nil)
+var _ errors.SafeDetailer = (*withOperatorContext)(nil)
+var _ fmt.Formatter = (*withOperatorContext)(nil)
+var _ errors.Formatter = (*withOperatorContext)(nil)
+
+func (w *withOperatorContext) Error() string { return w.cause.Error() }
+func (w *withOperatorContext) Cause() error  { return w.cause }
+func (w *withOperatorContext) Unwrap() error { return w.cause }
+func (w *withOperatorContext) SafeDetails() []string {
+	return []string{w.opName, strconv.Itoa(int(w.phase))}
+}
+
+func (w *withOperatorContext) Format(s fmt.State, verb rune) { errors.FormatError(w, s, verb) }
+
+func (w *withOperatorContext) FormatError(p errors.Printer) (next error) {
+	if p.Detail() {
+		p.Printf("in vectorized operator %s during %s", w.opName, w.phase)
+	}
+	return w.cause
+}
+
+// decodeWithOperatorContext is a custom decoder that will be used when
+// decoding withOperatorContext error objects.
+func decodeWithOperatorContext(
+	_ context.Context, cause error, _ string, details []string, _ proto.Message,
+) error {
+	w := &withOperatorContext{cause: cause}
+	if len(details) > 0 {
+		w.opName = details[0]
+	}
+	if len(details) > 1 {
+		phase, _ := strconv.Atoi(details[1])
+		w.phase = Phase(phase)
+	}
+	return w
+}
+
+func init() {
+	errors.RegisterWrapperDecoder(errors.GetTypeKey((*withOperatorContext)(nil)), decodeWithOperatorContext)
+}
+
+// WithOperatorContext annotates err with the name of the operator in which it
+// occurred and the phase of the lifecycle of that operator. If err has
+// already been annotated (i.e. by an operator closer to the origin of the
+// error), it is returned unchanged. A nil err is returned as nil.
+func WithOperatorContext(err error, opName string, phase Phase) error {
+	if err == nil {
+		return nil
+	}
+	if _, _, ok := GetOperatorContext(err); ok {
+		return err
+	}
+	var storageErr *StorageError
+	if errors.As(err, &storageErr) {
+		// StorageErrors are propagated unchanged.
+		return err
+	}
+	return &withOperatorContext{cause: err, opName: opName, phase: phase}
+}
+
+// GetOperatorContext returns the name of the operator and the phase with
+// which err has been annotated by WithOperatorContext, if any.
+func GetOperatorContext(err error) (opName string, phase Phase, ok bool) {
+	var w *withOperatorContext
+	if errors.As(err, &w) {
+		return w.opName, w.phase, true
+	}
+	return "", PhaseUnknown, false
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package execerror
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithOperatorContext(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+
+	require.NoError(t, WithOperatorContext(nil, "sort", PhaseNext))
+
+	origErr := pgerror.New(pgcode.DivisionByZero, "division by zero")
+	err := WithOperatorContext(origErr, "sort", PhaseSpill)
+	// Neither the message nor the code are changed by the annotation.
+	require.Equal(t, origErr.Error(), err.Error())
+	require.Equal(t, pgcode.DivisionByZero, pgerror.GetPGCode(err))
+	require.True(t, errors.Is(err, origErr))
+	require.Contains(t, fmt.Sprintf("%+v", err), "in vectorized operator sort during spill-transition")
+
+	// The annotation closest to the origin of the error is preserved.
+	err = WithOperatorContext(errors.Wrap(err, "wrapped"), "materializer", PhaseNext)
+	opName, phase, ok := GetOperatorContext(err)
+	require.True(t, ok)
+	require.Equal(t, "sort", opName)
+	require.Equal(t, PhaseSpill, phase)
+
+	// The annotation survives the network.
+	decoded := errors.DecodeError(ctx, errors.EncodeError(ctx, err))
+	opName, phase, ok = GetOperatorContext(decoded)
+	require.True(t, ok)
+	require.Equal(t, "sort", opName)
+	require.Equal(t, PhaseSpill, phase)
+	require.Equal(t, err.Error(), decoded.Error())
+
+	// StorageErrors are not annotated.
+	storageErr := NewStorageError(errors.New("storage"))
+	require.Equal(t, error(storageErr), WithOperatorContext(storageErr, "sort", PhaseNext))
+	_, _, ok = GetOperatorContext(errors.New("not annotated"))
+	require.False(t, ok)
+}
+
+func TestClassify(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	for _, tc := range []struct {
+		err      error
+		expected Class
+	}{
+		{err: errors.New("uncategorized"), expected: ClassInternal},
+		{err: errors.AssertionFailedf("assertion"), expected: ClassInternal},
+		{err: pgerror.New(pgcode.DivisionByZero, "division by zero"), expected: ClassUser},
+		{err: pgerror.New(pgcode.OutOfMemory, "memory budget exceeded"), expected: ClassMemory},
+		{err: pgerror.New(pgcode.DiskFull, "disk budget exceeded"), expected: ClassDisk},
+		{err: errors.Wrap(&os.PathError{Op: "open", Path: "f", Err: os.ErrNotExist}, "queue"), expected: ClassDisk},
+		{err: NewStorageError(errors.New("storage")), expected: ClassStorage},
+		{err: sqlbase.QueryCanceledError, expected: ClassCanceled},
+		{err: errors.Wrap(context.Canceled, "flow"), expected: ClassCanceled},
+		{err: sqlbase.QueryTimeoutError, expected: ClassTimeout},
+		{err: errors.Wrap(context.DeadlineExceeded, "flow"), expected: ClassTimeout},
+		{
+			err:      WithOperatorContext(pgerror.New(pgcode.OutOfMemory, "memory budget exceeded"), "sort", PhaseNext),
+			expected: ClassMemory,
+		},
+	} {
+		require.Equal(t, tc.expected, Classify(tc.err), "unexpected class of %v", tc.err)
+	}
+}
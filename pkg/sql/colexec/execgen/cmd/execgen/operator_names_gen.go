@@ -0,0 +1,287 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+const (
+	colexecDir        = "pkg/sql/colexec"
+	operatorNamesFile = "operator_names.eg.go"
+)
+
+const operatorNamesTemplate = `
+package colexec
+
+import "github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+
+func init() {
+	for _, n := range []struct {
+		op   execinfra.OpNode
+		name string
+	}{
+		{{range .}}
+		{op: &{{.TypeName}}{}, name: "{{.Name}}"},
+		{{end}}
+	} {
+		RegisterOperatorName(n.op, n.name)
+	}
+}
+`
+
+// operatorNameOverrides contains the names of the OpNodes that differ from
+// the ones derived from their type names. These names have been exposed in
+// the diagnostics before the names were generated, so they are kept stable.
+var operatorNameOverrides = map[string]string{
+	"CancelChecker":              "cancel-checker",
+	"bufferExportingOperator":    "buffer-exporting",
+	"chunker":                    "chunker-spooler",
+	"chunkerOperator":            "chunker",
+	"defaultBuiltinFuncOperator": "builtin-func",
+	"diskSpillerBase":            "disk-spiller",
+	"distinctChainOps":           "distinct-chain",
+	"isNullProjOp":               "is-null-projection",
+	"isNullSelOp":                "is-null-selection",
+	"mergeJoinFullOuterOp":       "merge-joiner-full-outer",
+	"mergeJoinInnerOp":           "merge-joiner-inner",
+	"mergeJoinLeftAntiOp":        "merge-joiner-left-anti",
+	"mergeJoinLeftOuterOp":       "merge-joiner-left-outer",
+	"mergeJoinLeftSemiOp":        "merge-joiner-left-semi",
+	"mergeJoinRightOuterOp":      "merge-joiner-right-outer",
+	"partitionerToOperator":      "partitioner",
+	"topKSorter":                 "topk-sort",
+	"VectorizedStatsCollector":   "stats-collector",
+}
+
+// opNodeHelpers are the types that implement execinfra.OpNode only in order
+// to be embedded into the actual OpNodes, so they are not registered.
+var opNodeHelpers = map[string]struct{}{
+	"OneInputNode":  {},
+	"ZeroInputNode": {},
+	"twoInputNode":  {},
+}
+
+type operatorName struct {
+	TypeName string
+	Name     string
+}
+
+// genOperatorNames creates a file that registers the names of all OpNodes
+// defined in the colexec package. The OpNodes generated from the templates
+// are found by running all other generators, and the hand-written ones are
+// found by parsing the non-generated files of the package.
+func genOperatorNames(wr io.Writer) error {
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for filename, e := range generators {
+		if filename == operatorNamesFile {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := e.fn(&buf); err != nil {
+			return err
+		}
+		f, err := parser.ParseFile(fset, filename, buf.Bytes(), 0 /* mode */)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+	}
+	paths, err := filepath.Glob(filepath.Join(colexecDir, "*.go"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") || strings.HasSuffix(path, "_tmpl.go") ||
+			strings.HasSuffix(path, ".eg.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil /* src */, 0 /* mode */)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+	}
+
+	typeNames := findOpNodeTypes(files)
+	names := make([]operatorName, len(typeNames))
+	for i, typeName := range typeNames {
+		name, ok := operatorNameOverrides[typeName]
+		if !ok {
+			name = deriveOperatorName(typeName)
+		}
+		names[i] = operatorName{TypeName: typeName, Name: name}
+	}
+
+	tmpl, err := template.New("operator_names").Parse(operatorNamesTemplate)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(wr, names)
+}
+
+// findOpNodeTypes returns the sorted names of the struct types declared in
+// the colexec files whose pointers implement execinfra.OpNode, either by
+// declaring ChildCount themselves or by embedding exactly one type that
+// implements it.
+func findOpNodeTypes(files []*ast.File) []string {
+	// embedded maps the names of the struct and interface types to the names
+	// of the types they embed.
+	embedded := make(map[string][]string)
+	structs := make(map[string]struct{})
+	opNodes := map[string]struct{}{"execinfra.OpNode": {}}
+	for _, f := range files {
+		if f.Name.Name != "colexec" {
+			// Some generators (like the one of coldata.Vec) produce files of
+			// other packages.
+			continue
+		}
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv != nil && decl.Name.Name == "ChildCount" {
+					opNodes[receiverTypeName(decl.Recv.List[0].Type)] = struct{}{}
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					typeSpec, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					name := typeSpec.Name.Name
+					switch typ := typeSpec.Type.(type) {
+					case *ast.StructType:
+						structs[name] = struct{}{}
+						for _, field := range typ.Fields.List {
+							if len(field.Names) == 0 {
+								embedded[name] = append(embedded[name], embeddedTypeName(field.Type))
+							}
+						}
+					case *ast.InterfaceType:
+						for _, method := range typ.Methods.List {
+							if len(method.Names) == 0 {
+								embedded[name] = append(embedded[name], embeddedTypeName(method.Type))
+							} else if method.Names[0].Name == "ChildCount" {
+								opNodes[name] = struct{}{}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// Propagate the implementation of OpNode through the embeddings until the
+	// fixed point is reached. If a type embeds several OpNodes (and doesn't
+	// declare ChildCount itself), the methods are ambiguous, so the type
+	// doesn't implement OpNode.
+	for changed := true; changed; {
+		changed = false
+		for name, embeds := range embedded {
+			if _, ok := opNodes[name]; ok {
+				continue
+			}
+			numEmbeddedOpNodes := 0
+			for _, e := range embeds {
+				if _, ok := opNodes[e]; ok {
+					numEmbeddedOpNodes++
+				}
+			}
+			if numEmbeddedOpNodes == 1 {
+				opNodes[name] = struct{}{}
+				changed = true
+			}
+		}
+	}
+
+	var typeNames []string
+	for name := range structs {
+		if _, ok := opNodes[name]; !ok {
+			continue
+		}
+		if _, ok := opNodeHelpers[name]; ok {
+			continue
+		}
+		typeNames = append(typeNames, name)
+	}
+	sort.Strings(typeNames)
+	return typeNames
+}
+
+// receiverTypeName returns the name of the type of a method receiver.
+func receiverTypeName(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// embeddedTypeName returns the name of an embedded type, qualified with the
+// package name if the type is declared in another package.
+func embeddedTypeName(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.SelectorExpr:
+		if pkg, ok := expr.X.(*ast.Ident); ok {
+			return pkg.Name + "." + expr.Sel.Name
+		}
+	}
+	return ""
+}
+
+// deriveOperatorName derives the name of an OpNode from its type name by
+// dropping the "Op" or "Operator" suffix and converting the rest to kebab
+// case, for example "projEQInt64Int64ConstOp" becomes
+// "proj-eq-int64-int64-const".
+func deriveOperatorName(typeName string) string {
+	for _, suffix := range []string{"Operator", "Op"} {
+		if trimmed := strings.TrimSuffix(typeName, suffix); trimmed != typeName && trimmed != "" {
+			typeName = trimmed
+			break
+		}
+	}
+	runes := []rune(typeName)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			// A new word starts at an upper case letter unless it continues an
+			// acronym (like "EQ" in "projEQInt64").
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				b.WriteByte('-')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+func init() {
+	registerGenerator(genOperatorNames, operatorNamesFile, "")
+}
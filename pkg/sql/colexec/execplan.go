@@ -97,7 +97,20 @@ type NewColOperatorArgs struct {
 	ProcessorConstructor execinfra.ProcessorConstructor
 	DiskQueueCfg         colcontainer.DiskQueueCfg
 	FDSemaphore          semaphore.Semaphore
-	TestingKnobs         struct {
+	// OperatorRegistry, if set, will be used to assign IDs to all the
+	// Operators that are created. If it is not set, the Operators are
+	// registered with a new registry.
+	OperatorRegistry *OperatorRegistry
+	// FlowProgress, if set, will be updated by the Operators that are created
+	// to report the progress of the flow.
+	FlowProgress *execinfra.FlowProgress
+	// RunInRowEngine, if set, indicates that the processor core should be
+	// wrapped and run in the row engine even if it is supported by the
+	// vectorized engine (for example, because it is expected to process too
+	// few rows for the vectorization to pay off).
+	RunInRowEngine bool
+
+	TestingKnobs struct {
 		// UseStreamingMemAccountForBuffering specifies whether to use
 		// StreamingMemAccount when creating buffering operators and should only be
 		// set to 'true' in tests. The idea behind this flag is reducing the number
@@ -112,12 +125,9 @@ type NewColOperatorArgs struct {
 		// DiskSpillingDisabled specifies whether only in-memory operators should
 		// be created.
 		DiskSpillingDisabled bool
-		// NumForcedRepartitions specifies a number of "repartitions" that a
-		// disk-backed operator should be forced to perform. "Repartition" can mean
-		// different things depending on the operator (for example, for hash joiner
-		// it is dividing original partition into multiple new partitions; for
-		// sorter it is merging already created partitions into new one before
-		// proceeding to the next partition from the input).
+		// NumForcedRepartitions specifies a number of "repartitions" that the
+		// external hash joiner should be forced to perform (i.e. the number of
+		// times it divides an original partition into multiple new partitions).
 		NumForcedRepartitions int
 		// DelegateFDAcquisitions should be observed by users of a
 		// PartitionedDiskQueue. During normal operations, these should acquire the
@@ -146,6 +156,10 @@ type NewColOperatorResult struct {
 	CanRunInAutoMode       bool
 	BufferingOpMemMonitors []*mon.BytesMonitor
 	BufferingOpMemAccounts []*mon.BoundAccount
+	// ToClose contains all Closers that have been planned. The caller is
+	// responsible for closing them (e.g. by registering them with Closers) once
+	// the operators are no longer used.
+	ToClose []Closer
 }
 
 // resetToState resets r to the state specified in arg. arg may be a shallow
@@ -175,6 +189,18 @@ func (r *NewColOperatorResult) resetToState(ctx context.Context, arg NewColOpera
 			m.Stop(ctx)
 		}
 	}
+	// Close Closers that are not present in arg.ToClose.
+	closers := make(map[Closer]struct{})
+	for _, c := range arg.ToClose {
+		closers[c] = struct{}{}
+	}
+	for _, c := range r.ToClose {
+		if _, ok := closers[c]; !ok {
+			if err := c.Close(ctx); err != nil {
+				log.VEventf(ctx, 1, "error closing %T: %v", c, err)
+			}
+		}
+	}
 
 	// Shallow copy over the rest.
 	*r = arg
@@ -281,14 +307,11 @@ func isSupported(spec *execinfrapb.ProcessorSpec) (bool, error) {
 // according to ordering.
 // - matchLen specifies the length of the prefix of ordering columns the input
 // is already ordered on.
-// - maxNumberPartitions (when non-zero) overrides the semi-dynamically
-// computed maximum number of partitions that the external sorter will have
-// at once.
-// - processorID is the ProcessorID of the processor core that requested
-// creation of this operator. It is used only to distinguish memory monitors.
 // - post describes the post-processing spec of the processor. It will be used
 // to determine whether top K sort can be planned. If you want the general sort
 // operator, then pass in empty struct.
+// - name is the monitorName of the operator that requested creation of the
+// sort. The monitors of the sorters are named after its children.
 func (r *NewColOperatorResult) createDiskBackedSort(
 	ctx context.Context,
 	flowCtx *execinfra.FlowCtx,
@@ -297,18 +320,45 @@ func (r *NewColOperatorResult) createDiskBackedSort(
 	inputTypes []coltypes.T,
 	ordering execinfrapb.Ordering,
 	matchLen uint32,
-	maxNumberPartitions int,
-	processorID int32,
 	post *execinfrapb.PostProcessSpec,
-	memMonitorNamePrefix string,
+	name monitorName,
 ) (Operator, error) {
 	streamingMemAccount := args.StreamingMemAccount
 	useStreamingMemAccountForBuffering := args.TestingKnobs.UseStreamingMemAccountForBuffering
+	// The sorts can only fall back to disk when the temporary storage engine
+	// is available since the external sorter sorts its input in the engine.
+	canSpill := flowCtx.Cfg.TempStorage != nil && flowCtx.Cfg.DiskMonitor != nil
 	var (
-		sorterMemMonitorName string
-		inMemorySorter       Operator
-		err                  error
+		sorterName     monitorName
+		inMemorySorter Operator
+		// inMemorySorterMemAccount is the memory account used exclusively by
+		// the in-memory sorter. It is left nil when the sorter shares the
+		// streaming memory account.
+		inMemorySorterMemAccount *mon.BoundAccount
+		err                      error
 	)
+	// createInMemorySorterMemAccount returns the memory account to be used by
+	// the in-memory sorter. If the sorter cannot spill to disk, the account is
+	// unlimited, like the ones of the other buffering operators without disk
+	// spilling.
+	createInMemorySorterMemAccount := func() *mon.BoundAccount {
+		if useStreamingMemAccountForBuffering {
+			return streamingMemAccount
+		}
+		if !canSpill {
+			return r.createBufferingUnlimitedMemAccount(ctx, flowCtx, sorterName)
+		}
+		inMemorySorterMemAccount = r.createMemAccountForSpillStrategy(
+			ctx, flowCtx, sorterName,
+		)
+		return inMemorySorterMemAccount
+	}
+	maybeForceSorterSpilling := func(input Operator) Operator {
+		if !canSpill {
+			return input
+		}
+		return maybeForceSpilling(flowCtx, input, sorterName.withKind(monitorKindLimited))
+	}
 	if len(ordering.Columns) == int(matchLen) {
 		// The input is already fully ordered, so there is nothing to sort.
 		return input, nil
@@ -316,50 +366,29 @@ func (r *NewColOperatorResult) createDiskBackedSort(
 	if matchLen > 0 {
 		// The input is already partially ordered. Use a chunks sorter to avoid
 		// loading all the rows into memory.
-		sorterMemMonitorName = fmt.Sprintf("%ssort-chunks-%d", memMonitorNamePrefix, processorID)
-		var sortChunksMemAccount *mon.BoundAccount
-		if useStreamingMemAccountForBuffering {
-			sortChunksMemAccount = streamingMemAccount
-		} else {
-			sortChunksMemAccount = r.createMemAccountForSpillStrategy(
-				ctx, flowCtx, sorterMemMonitorName,
-			)
-		}
+		sorterName = name.child("sort-chunks")
 		inMemorySorter, err = NewSortChunks(
-			NewAllocator(ctx, sortChunksMemAccount), input, inputTypes,
+			NewAllocator(ctx, createInMemorySorterMemAccount()),
+			maybeForceSorterSpilling(input), inputTypes,
 			ordering.Columns, int(matchLen),
 		)
 	} else if post.Limit != 0 && post.Filter.Empty() && post.Limit+post.Offset < math.MaxUint16 {
 		// There is a limit specified with no post-process filter, so we know
 		// exactly how many rows the sorter should output. Choose a top K sorter,
 		// which uses a heap to avoid storing more rows than necessary.
-		sorterMemMonitorName = fmt.Sprintf("%stopk-sort-%d", memMonitorNamePrefix, processorID)
-		var topKSorterMemAccount *mon.BoundAccount
-		if useStreamingMemAccountForBuffering {
-			topKSorterMemAccount = streamingMemAccount
-		} else {
-			topKSorterMemAccount = r.createMemAccountForSpillStrategy(
-				ctx, flowCtx, sorterMemMonitorName,
-			)
-		}
+		sorterName = name.child("topk-sort")
 		k := uint16(post.Limit + post.Offset)
 		inMemorySorter = NewTopKSorter(
-			NewAllocator(ctx, topKSorterMemAccount), input, inputTypes,
+			NewAllocator(ctx, createInMemorySorterMemAccount()),
+			maybeForceSorterSpilling(input), inputTypes,
 			ordering.Columns, k,
 		)
 	} else {
 		// No optimizations possible. Default to the standard sort operator.
-		sorterMemMonitorName = fmt.Sprintf("%ssort-all-%d", memMonitorNamePrefix, processorID)
-		var sorterMemAccount *mon.BoundAccount
-		if useStreamingMemAccountForBuffering {
-			sorterMemAccount = streamingMemAccount
-		} else {
-			sorterMemAccount = r.createMemAccountForSpillStrategy(
-				ctx, flowCtx, sorterMemMonitorName,
-			)
-		}
+		sorterName = name.child("sort-all")
 		inMemorySorter, err = NewSorter(
-			NewAllocator(ctx, sorterMemAccount), input, inputTypes, ordering.Columns,
+			NewAllocator(ctx, createInMemorySorterMemAccount()),
+			maybeForceSorterSpilling(input), inputTypes, ordering.Columns,
 		)
 	}
 	if err != nil {
@@ -368,48 +397,37 @@ func (r *NewColOperatorResult) createDiskBackedSort(
 	if inMemorySorter == nil {
 		return nil, errors.AssertionFailedf("unexpectedly inMemorySorter is nil")
 	}
+	if !canSpill {
+		return inMemorySorter, nil
+	}
 	// NOTE: when spilling to disk, we're using the same general external
 	// sorter regardless of which sorter variant we have instantiated (i.e.
 	// we don't take advantage of the limits and of partial ordering). We
 	// could improve this.
-	return newOneInputDiskSpiller(
+	diskSpiller := newOneInputDiskSpiller(
 		input, inMemorySorter.(bufferingInMemoryOperator),
-		sorterMemMonitorName,
-		func(input Operator) Operator {
-			monitorNamePrefix := fmt.Sprintf("%sexternal-sorter", memMonitorNamePrefix)
-			// We are using an unlimited memory monitor here because external
-			// sort itself is responsible for making sure that we stay within
-			// the memory limit.
+		sorterName.withKind(monitorKindLimited), execinfra.GetWorkMemLimit(flowCtx.Cfg),
+		inMemorySorterMemAccount,
+		args.DiskQueueCfg,
+		func(input Operator, diskQueueCfg colcontainer.DiskQueueCfg) Operator {
+			externalSorterName := name.child("external-sorter")
+			// The external sorter only buffers a single output batch in memory.
 			unlimitedAllocator := NewAllocator(
 				ctx, r.createBufferingUnlimitedMemAccount(
-					ctx, flowCtx, monitorNamePrefix,
+					ctx, flowCtx, externalSorterName,
 				))
-			standaloneMemAccount := r.createStandaloneMemAccount(
-				ctx, flowCtx, monitorNamePrefix,
-			)
-			// Make a copy of the DiskQueueCfg and set defaults for the sorter.
-			// The cache mode is chosen to reuse the cache to have a smaller
-			// cache per partition without affecting performance.
-			diskQueueCfg := args.DiskQueueCfg
-			diskQueueCfg.CacheMode = colcontainer.DiskQueueCacheModeReuseCache
-			diskQueueCfg.SetDefaultBufferSizeBytesForCacheMode()
-			if args.TestingKnobs.NumForcedRepartitions != 0 {
-				maxNumberPartitions = args.TestingKnobs.NumForcedRepartitions
-			}
 			return newExternalSorter(
-				ctx,
-				unlimitedAllocator,
-				standaloneMemAccount,
-				input, inputTypes, ordering,
-				execinfra.GetWorkMemLimit(flowCtx.Cfg),
-				maxNumberPartitions,
-				args.TestingKnobs.DelegateFDAcquisitions,
-				diskQueueCfg,
-				args.FDSemaphore,
+				unlimitedAllocator, input, inputTypes, ordering,
+				r.createDiskMonitor(ctx, flowCtx, externalSorterName),
+				flowCtx.Cfg.TempStorage, diskQueueCfg.OnWriteCb,
 			)
 		},
 		args.TestingKnobs.SpillingCallbackFn,
-	), nil
+	)
+	// The disk spiller reports the number of bytes it has spilled as metadata.
+	r.MetadataSources = append(r.MetadataSources, diskSpiller.(execinfrapb.MetadataSource))
+	r.ToClose = append(r.ToClose, diskSpiller.(Closer))
+	return diskSpiller, nil
 }
 
 // createAndWrapRowSource takes a processor spec, creating the row source and
@@ -520,6 +538,9 @@ func NewColOperator(
 	resultPreSpecPlanningStateShallowCopy := result
 
 	supported, err := isSupported(spec)
+	if supported && args.RunInRowEngine {
+		supported, err = false, errors.New("the row engine was chosen for the processor")
+	}
 	if !supported {
 		// We refuse to wrap LocalPlanNode processor (which is a DistSQL wrapper
 		// around a planNode) because it creates complications, and a flow with
@@ -564,7 +585,7 @@ func NewColOperator(
 				return result, err
 			}
 			var scanOp *colBatchScan
-			scanOp, err = newColBatchScan(NewAllocator(ctx, streamingMemAccount), flowCtx, core.TableReader, post)
+			scanOp, err = newColBatchScan(NewAllocator(ctx, streamingMemAccount), flowCtx, spec.ProcessorID, core.TableReader, post)
 			if err != nil {
 				return result, err
 			}
@@ -572,7 +593,7 @@ func NewColOperator(
 			result.MetadataSources = append(result.MetadataSources, scanOp)
 			// colBatchScan is wrapped with a cancel checker below, so we need to
 			// log its creation separately.
-			log.VEventf(ctx, 1, "made op %T\n", result.Op)
+			log.VEventf(ctx, 1, "made op %s\n", OperatorName(result.Op))
 
 			// We want to check for cancellation once per input batch, and wrapping
 			// only colBatchScan with a CancelChecker allows us to do just that.
@@ -661,7 +682,9 @@ func NewColOperator(
 					// The row execution engine also gives an unlimited amount (that still
 					// needs to be approved by the upstream monitor, so not really
 					// "unlimited") amount of memory to the aggregator.
-					hashAggregatorMemAccount = result.createBufferingUnlimitedMemAccount(ctx, flowCtx, "hash-aggregator")
+					hashAggregatorMemAccount = result.createBufferingUnlimitedMemAccount(
+						ctx, flowCtx, makeMonitorName(flowCtx, spec.ProcessorID, "hash-aggregator"),
+					)
 				}
 				result.Op, err = NewHashAggregator(
 					NewAllocator(ctx, hashAggregatorMemAccount), inputs[0], typs, aggFns,
@@ -695,8 +718,19 @@ func NewColOperator(
 				result.Op, err = NewOrderedDistinct(inputs[0], core.Distinct.OrderedColumns, typs)
 				result.IsStreaming = true
 			} else {
+				distinctName := makeMonitorName(flowCtx, spec.ProcessorID, "distinct")
+				// The unordered distinct can only fall back to disk when the
+				// temporary storage engine is available since the disk-backed
+				// distinct sorts its input in the engine.
+				canSpill := !useStreamingMemAccountForBuffering &&
+					!args.TestingKnobs.DiskSpillingDisabled &&
+					flowCtx.Cfg.TempStorage != nil && flowCtx.Cfg.DiskMonitor != nil
 				distinctMemAccount := streamingMemAccount
-				if !useStreamingMemAccountForBuffering {
+				if canSpill {
+					distinctMemAccount = result.createMemAccountForSpillStrategy(
+						ctx, flowCtx, distinctName,
+					)
+				} else if !useStreamingMemAccountForBuffering {
 					// Create an unlimited mem account explicitly even though there is no
 					// disk spilling because the memory usage of an unordered distinct
 					// operator is proportional to the number of distinct tuples, not the
@@ -704,16 +738,54 @@ func NewColOperator(
 					// The row execution engine also gives an unlimited amount (that still
 					// needs to be approved by the upstream monitor, so not really
 					// "unlimited") amount of memory to the unordered distinct operator.
-					distinctMemAccount = result.createBufferingUnlimitedMemAccount(ctx, flowCtx, "distinct")
+					distinctMemAccount = result.createBufferingUnlimitedMemAccount(
+						ctx, flowCtx, distinctName,
+					)
 				}
 				// TODO(yuzefovich): we have an implementation of partially ordered
 				// distinct, and we should plan it when we have non-empty ordered
 				// columns and we think that the probability of distinct tuples in the
 				// input is about 0.01 or less.
-				result.Op = NewUnorderedDistinct(
-					NewAllocator(ctx, distinctMemAccount), inputs[0],
-					core.Distinct.DistinctColumns, typs, hashTableNumBuckets,
-				)
+				distinctCols := core.Distinct.DistinctColumns
+				if !canSpill {
+					result.Op = NewUnorderedDistinct(
+						NewAllocator(ctx, distinctMemAccount), inputs[0],
+						distinctCols, typs, hashTableNumBuckets,
+					)
+				} else {
+					distinctMemMonitorName := distinctName.withKind(monitorKindLimited)
+					inMemoryDistinct := NewUnorderedDistinct(
+						NewAllocator(ctx, distinctMemAccount),
+						maybeForceSpilling(flowCtx, inputs[0], distinctMemMonitorName),
+						distinctCols, typs, hashTableNumBuckets,
+					)
+					result.Op = newOneInputDiskSpiller(
+						inputs[0], inMemoryDistinct.(bufferingInMemoryOperator),
+						distinctMemMonitorName, execinfra.GetWorkMemLimit(flowCtx.Cfg),
+						distinctMemAccount, args.DiskQueueCfg,
+						func(input Operator, diskQueueCfg colcontainer.DiskQueueCfg) Operator {
+							diskBackedDistinctName := distinctName.child("disk-backed-distinct")
+							unlimitedAllocator := NewAllocator(
+								ctx, result.createBufferingUnlimitedMemAccount(
+									ctx, flowCtx, diskBackedDistinctName,
+								))
+							diskBackedDistinct, err := newDiskBackedUnorderedDistinct(
+								unlimitedAllocator, input, distinctCols, typs,
+								result.createDiskMonitor(ctx, flowCtx, diskBackedDistinctName),
+								flowCtx.Cfg.TempStorage, diskQueueCfg.OnWriteCb,
+							)
+							if err != nil {
+								execerror.VectorizedInternalPanic(err)
+							}
+							return diskBackedDistinct
+						},
+						args.TestingKnobs.SpillingCallbackFn,
+					)
+					// The disk spiller reports the number of bytes it has spilled as
+					// metadata.
+					result.MetadataSources = append(result.MetadataSources, result.Op.(execinfrapb.MetadataSource))
+					result.ToClose = append(result.ToClose, result.Op.(Closer))
+				}
 			}
 
 		case core.Ordinality != nil:
@@ -739,14 +811,19 @@ func NewColOperator(
 				return result, err
 			}
 
-			hashJoinerMemMonitorName := fmt.Sprintf("hash-joiner-%d", spec.ProcessorID)
-			var hashJoinerMemAccount *mon.BoundAccount
+			hashJoinerName := makeMonitorName(flowCtx, spec.ProcessorID, "hash-joiner")
+			hashJoinerMemMonitorName := hashJoinerName.withKind(monitorKindLimited)
+			// inMemoryHashJoinerMemAccount is the memory account used exclusively
+			// by the in-memory hash joiner. It is left nil when the hash joiner
+			// shares the streaming memory account.
+			var hashJoinerMemAccount, inMemoryHashJoinerMemAccount *mon.BoundAccount
 			if useStreamingMemAccountForBuffering {
 				hashJoinerMemAccount = streamingMemAccount
 			} else {
 				hashJoinerMemAccount = result.createMemAccountForSpillStrategy(
-					ctx, flowCtx, hashJoinerMemMonitorName,
+					ctx, flowCtx, hashJoinerName,
 				)
+				inMemoryHashJoinerMemAccount = hashJoinerMemAccount
 			}
 			// It is valid for empty set of equality columns to be considered as
 			// "key" (for example, the input has at most 1 row). However, hash
@@ -764,28 +841,36 @@ func NewColOperator(
 			if err != nil {
 				return result, err
 			}
-			inMemoryHashJoiner := newHashJoiner(
-				NewAllocator(ctx, hashJoinerMemAccount), hjSpec, inputs[0], inputs[1],
-			)
 			if args.TestingKnobs.DiskSpillingDisabled {
 				// We will not be creating a disk-backed hash joiner because we're
 				// running a test that explicitly asked for only in-memory hash
 				// joiner.
-				result.Op = inMemoryHashJoiner
+				result.Op = newHashJoiner(
+					NewAllocator(ctx, hashJoinerMemAccount), hjSpec, inputs[0], inputs[1],
+				)
 			} else {
+				// Only the right input is buffered by the in-memory hash joiner, so
+				// that is where the spilling might be forced. The left input is
+				// consumed only once the hash table has been built, after which
+				// the hash joiner can no longer fall back to disk.
+				inMemoryHashJoiner := newHashJoiner(
+					NewAllocator(ctx, hashJoinerMemAccount), hjSpec, inputs[0],
+					maybeForceSpilling(flowCtx, inputs[1], hashJoinerMemMonitorName),
+				)
 				result.Op = newTwoInputDiskSpiller(
 					inputs[0], inputs[1], inMemoryHashJoiner.(bufferingInMemoryOperator),
-					hashJoinerMemMonitorName,
-					func(inputOne, inputTwo Operator) Operator {
-						monitorNamePrefix := "external-hash-joiner"
+					hashJoinerMemMonitorName, execinfra.GetWorkMemLimit(flowCtx.Cfg),
+					inMemoryHashJoinerMemAccount, args.DiskQueueCfg,
+					func(inputOne, inputTwo Operator, diskQueueCfg colcontainer.DiskQueueCfg) Operator {
+						externalHashJoinerName := hashJoinerName.child("external-hash-joiner")
 						unlimitedAllocator := NewAllocator(
 							ctx, result.createBufferingUnlimitedMemAccount(
-								ctx, flowCtx, monitorNamePrefix,
+								ctx, flowCtx, externalHashJoinerName,
 							))
-						// Make a copy of the DiskQueueCfg and set defaults for the hash
-						// joiner. The cache mode is chosen to automatically close the cache
-						// belonging to partitions at a parent level when repartitioning.
-						diskQueueCfg := args.DiskQueueCfg
+						// Set defaults for the hash joiner on the copy of the
+						// DiskQueueCfg. The cache mode is chosen to automatically close
+						// the cache belonging to partitions at a parent level when
+						// repartitioning.
 						diskQueueCfg.CacheMode = colcontainer.DiskQueueCacheModeClearAndReuseCache
 						diskQueueCfg.SetDefaultBufferSizeBytesForCacheMode()
 						return newExternalHashJoiner(
@@ -794,19 +879,11 @@ func NewColOperator(
 							execinfra.GetWorkMemLimit(flowCtx.Cfg),
 							diskQueueCfg,
 							args.FDSemaphore,
-							func(input Operator, inputTypes []coltypes.T, orderingCols []execinfrapb.Ordering_Column, maxNumberPartitions int) (Operator, error) {
-								sortArgs := args
-								if !args.TestingKnobs.DelegateFDAcquisitions {
-									// Set the FDSemaphore to nil. This indicates that no FDs
-									// should be acquired. The external hash joiner will do this
-									// up front.
-									sortArgs.FDSemaphore = nil
-								}
+							func(input Operator, inputTypes []coltypes.T, orderingCols []execinfrapb.Ordering_Column) (Operator, error) {
 								return result.createDiskBackedSort(
-									ctx, flowCtx, sortArgs, input, inputTypes,
-									execinfrapb.Ordering{Columns: orderingCols},
-									0 /* matchLen */, maxNumberPartitions, spec.ProcessorID,
-									&execinfrapb.PostProcessSpec{}, monitorNamePrefix+"-")
+									ctx, flowCtx, args, input, inputTypes,
+									execinfrapb.Ordering{Columns: orderingCols}, 0, /* matchLen */
+									&execinfrapb.PostProcessSpec{}, externalHashJoinerName)
 							},
 							args.TestingKnobs.NumForcedRepartitions,
 							args.TestingKnobs.DelegateFDAcquisitions,
@@ -814,6 +891,10 @@ func NewColOperator(
 					},
 					args.TestingKnobs.SpillingCallbackFn,
 				)
+				// The disk spiller reports the number of bytes it has spilled as
+				// metadata.
+				result.MetadataSources = append(result.MetadataSources, result.Op.(execinfrapb.MetadataSource))
+				result.ToClose = append(result.ToClose, result.Op.(Closer))
 				// A hash joiner can run in auto mode because it falls back to disk if
 				// there is not enough memory available.
 				result.CanRunInAutoMode = true
@@ -825,6 +906,7 @@ func NewColOperator(
 					return result, err
 				}
 			}
+			result.planCardinalityCounter(spec.ProcessorID)
 
 		case core.MergeJoiner != nil:
 			if err := checkNumIn(inputs, 2); err != nil {
@@ -865,7 +947,7 @@ func NewColOperator(
 			// limit, and it will fall back to disk if necessary.
 			unlimitedAllocator := NewAllocator(
 				ctx, result.createBufferingUnlimitedMemAccount(
-					ctx, flowCtx, "merge-joiner",
+					ctx, flowCtx, makeMonitorName(flowCtx, spec.ProcessorID, "merge-joiner"),
 				))
 			result.Op, err = newMergeJoinOp(
 				unlimitedAllocator, execinfra.GetWorkMemLimit(flowCtx.Cfg),
@@ -876,6 +958,8 @@ func NewColOperator(
 			if err != nil {
 				return result, err
 			}
+			// The merge joiner might spill its buffered groups to disk.
+			result.ToClose = append(result.ToClose, result.Op.(Closer))
 
 			result.ColumnTypes = append(leftLogTypes, rightLogTypes...)
 
@@ -884,6 +968,7 @@ func NewColOperator(
 					return result, err
 				}
 			}
+			result.planCardinalityCounter(spec.ProcessorID)
 
 			// Merge joiner can run in auto mode because it falls back to disk if
 			// there is not enough memory available.
@@ -902,8 +987,8 @@ func NewColOperator(
 			ordering := core.Sorter.OutputOrdering
 			matchLen := core.Sorter.OrderingMatchLen
 			result.Op, err = result.createDiskBackedSort(
-				ctx, flowCtx, args, input, inputTypes, ordering, matchLen, 0, /* maxNumberPartitions */
-				spec.ProcessorID, post, "", /* memMonitorNamePrefix */
+				ctx, flowCtx, args, input, inputTypes, ordering, matchLen,
+				post, makeMonitorName(flowCtx, spec.ProcessorID, "sorter"),
 			)
 			result.ColumnTypes = spec.Input[0].ColumnTypes
 			// A sorter can run in auto mode because it falls back to disk if there
@@ -914,14 +999,17 @@ func NewColOperator(
 			if err := checkNumIn(inputs, 1); err != nil {
 				return result, err
 			}
-			memMonitorsPrefix := "window-"
+			windowerName := makeMonitorName(flowCtx, spec.ProcessorID, "windower")
 			input := inputs[0]
 			result.ColumnTypes = spec.Input[0].ColumnTypes
 			// Most supported window functions can run in auto mode because they are
 			// streaming operators and internally they might use a sorter which can
 			// fall back to disk if needed.
 			canRunInAutoMode := true
-			for _, wf := range core.Windower.WindowFns {
+			for wfIdx, wf := range core.Windower.WindowFns {
+				// Each of the window functions plans its own operators, so they
+				// need separate monitors.
+				windowFnName := windowerName.child(fmt.Sprintf("window-fn-%d", wfIdx))
 				var typs []coltypes.T
 				typs, err = typeconv.FromColumnTypes(result.ColumnTypes)
 				if err != nil {
@@ -942,8 +1030,7 @@ func NewColOperator(
 							return result.createDiskBackedSort(
 								ctx, flowCtx, args, input, inputTypes,
 								execinfrapb.Ordering{Columns: orderingCols}, 0, /* matchLen */
-								0 /* maxNumberPartitions */, spec.ProcessorID,
-								&execinfrapb.PostProcessSpec{}, memMonitorsPrefix)
+								&execinfrapb.PostProcessSpec{}, windowFnName)
 						},
 					)
 					// Window partitioner will append a boolean column.
@@ -953,8 +1040,8 @@ func NewColOperator(
 					if len(wf.Ordering.Columns) > 0 {
 						input, err = result.createDiskBackedSort(
 							ctx, flowCtx, args, input, typs,
-							wf.Ordering, 0 /* matchLen */, 0, /* maxNumberPartitions */
-							spec.ProcessorID, &execinfrapb.PostProcessSpec{}, memMonitorsPrefix,
+							wf.Ordering, 0, /* matchLen */
+							&execinfrapb.PostProcessSpec{}, windowFnName,
 						)
 					}
 				}
@@ -991,7 +1078,9 @@ func NewColOperator(
 						//  can still run plans that include these window functions with a
 						//  low memory limit to test disk spilling of other components for
 						//  the time being.
-						memAccount = result.createBufferingUnlimitedMemAccount(ctx, flowCtx, memMonitorsPrefix+"relative-rank")
+						memAccount = result.createBufferingUnlimitedMemAccount(
+							ctx, flowCtx, windowFnName.child("relative-rank"),
+						)
 					}
 					result.Op, err = NewRelativeRankOperator(
 						NewAllocator(ctx, memAccount), input, typs, windowFn, wf.Ordering.Columns,
@@ -1040,7 +1129,7 @@ func NewColOperator(
 	if sMem, ok := result.Op.(InternalMemoryOperator); ok {
 		result.InternalMemUsage += sMem.InternalMemoryUsage()
 	}
-	log.VEventf(ctx, 1, "made op %T\n", result.Op)
+	log.VEventf(ctx, 1, "made op %s\n", OperatorName(result.Op))
 
 	// Note: at this point, it is legal for ColumnTypes to be empty (it is
 	// legal for empty rows to be passed between processors).
@@ -1083,9 +1172,37 @@ func NewColOperator(
 		// The result can be updated with the post process result.
 		result.updateWithPostProcessResult(ppr)
 	}
+	if err == nil {
+		registry := args.OperatorRegistry
+		if registry == nil {
+			registry = NewOperatorRegistry()
+		}
+		registry.RegisterTree(result.Op)
+	}
+	if err == nil && args.FlowProgress != nil {
+		attachFlowProgress(result.Op, args.FlowProgress)
+	}
+	if err == nil && flowCtx.Cfg != nil && flowCtx.Cfg.SpillRegistry != nil {
+		attachSpillRegistry(result.Op, flowCtx.Cfg.SpillRegistry)
+	}
+	if err == nil && flowCtx.MaterializationObserver != nil {
+		attachMaterializationHook(result.Op, &materializationHook{
+			observer:                flowCtx.MaterializationObserver,
+			processorID:             spec.ProcessorID,
+			inputEstimatedRowCounts: flowCtx.ProcessorInputEstimatedRowCounts[spec.ProcessorID],
+		})
+	}
 	return result, err
 }
 
+// planCardinalityCounter plans a cardinalityCounterOp on top of r.Op that
+// reports the number of tuples produced by r.Op to the gateway.
+func (r *NewColOperatorResult) planCardinalityCounter(processorID int32) {
+	counter := newCardinalityCounterOp(r.Op, processorID)
+	r.Op = counter
+	r.MetadataSources = append(r.MetadataSources, counter)
+}
+
 // planAndMaybeWrapOnExprAsFilter plans a joiner ON expression as a filter. If
 // the filter is unsupported, it is planned as a wrapped noop processor with
 // the filter as a post-processing stage.
@@ -1210,24 +1327,39 @@ func (r *postProcessResult) planPostProcessSpec(
 // of a memory usage limit separately.
 // The receiver is updated to have a reference to the unlimited memory monitor.
 func (r *NewColOperatorResult) createBufferingUnlimitedMemMonitor(
-	ctx context.Context, flowCtx *execinfra.FlowCtx, name string,
+	ctx context.Context, flowCtx *execinfra.FlowCtx, name monitorName,
 ) *mon.BytesMonitor {
 	bufferingOpUnlimitedMemMonitor := execinfra.NewMonitor(
-		ctx, flowCtx.EvalCtx.Mon, name+"-unlimited",
+		ctx, flowCtx.EvalCtx.Mon, name.withKind(monitorKindUnlimited),
 	)
 	r.BufferingOpMemMonitors = append(r.BufferingOpMemMonitors, bufferingOpUnlimitedMemMonitor)
 	return bufferingOpUnlimitedMemMonitor
 }
 
+// createDiskMonitor instantiates a disk monitor to be used by an Operator that
+// spills to the temporary storage engine. The receiver is updated to have a
+// reference to the monitor, so that it is stopped along with the memory
+// monitors.
+func (r *NewColOperatorResult) createDiskMonitor(
+	ctx context.Context, flowCtx *execinfra.FlowCtx, name monitorName,
+) *mon.BytesMonitor {
+	diskMonitor := execinfra.NewMonitor(ctx, flowCtx.Cfg.DiskMonitor, name.withKind(monitorKindDisk))
+	r.BufferingOpMemMonitors = append(r.BufferingOpMemMonitors, diskMonitor)
+	return diskMonitor
+}
+
 // createMemAccountForSpillStrategy instantiates a memory monitor and a memory
 // account to be used with a buffering Operator that can fall back to disk.
 // The default memory limit is used, if flowCtx.Cfg.ForceDiskSpill is used, this
 // will be 1. The receiver is updated to have references to both objects.
+// The name of the monitor is name.withKind(monitorKindLimited), and this is
+// the name the disk spiller of the operator should be matching OOM errors
+// against.
 func (r *NewColOperatorResult) createMemAccountForSpillStrategy(
-	ctx context.Context, flowCtx *execinfra.FlowCtx, name string,
+	ctx context.Context, flowCtx *execinfra.FlowCtx, name monitorName,
 ) *mon.BoundAccount {
 	bufferingOpMemMonitor := execinfra.NewLimitedMonitor(
-		ctx, flowCtx.EvalCtx.Mon, flowCtx.Cfg, name+"-limited",
+		ctx, flowCtx.EvalCtx.Mon, flowCtx.Cfg, name.withKind(monitorKindLimited),
 	)
 	r.BufferingOpMemMonitors = append(r.BufferingOpMemMonitors, bufferingOpMemMonitor)
 	bufferingMemAccount := bufferingOpMemMonitor.MakeBoundAccount()
@@ -1241,7 +1373,7 @@ func (r *NewColOperatorResult) createMemAccountForSpillStrategy(
 // returned account is only "unlimited" in that it does not have a hard limit
 // that it enforces, but a limit might be enforced by a root monitor.
 func (r *NewColOperatorResult) createBufferingUnlimitedMemAccount(
-	ctx context.Context, flowCtx *execinfra.FlowCtx, name string,
+	ctx context.Context, flowCtx *execinfra.FlowCtx, name monitorName,
 ) *mon.BoundAccount {
 	bufferingOpUnlimitedMemMonitor := r.createBufferingUnlimitedMemMonitor(ctx, flowCtx, name)
 	bufferingMemAccount := bufferingOpUnlimitedMemMonitor.MakeBoundAccount()
@@ -1249,31 +1381,6 @@ func (r *NewColOperatorResult) createBufferingUnlimitedMemAccount(
 	return &bufferingMemAccount
 }
 
-// createStandaloneMemAccount instantiates an unlimited memory monitor and a
-// memory account that have a standalone budget. This means that the memory
-// registered with these objects is *not* reported to the root monitor (i.e.
-// it will not count towards max-sql-memory). Use it only when the memory in
-// use is accounted for with a different memory monitor. The receiver is
-// updated to have references to both objects.
-func (r *NewColOperatorResult) createStandaloneMemAccount(
-	ctx context.Context, flowCtx *execinfra.FlowCtx, name string,
-) *mon.BoundAccount {
-	standaloneMemMonitor := mon.MakeMonitor(
-		name+"-standalone",
-		mon.MemoryResource,
-		nil,           /* curCount */
-		nil,           /* maxHist */
-		-1,            /* increment: use default increment */
-		math.MaxInt64, /* noteworthy */
-		flowCtx.Cfg.Settings,
-	)
-	r.BufferingOpMemMonitors = append(r.BufferingOpMemMonitors, &standaloneMemMonitor)
-	standaloneMemMonitor.Start(ctx, nil, mon.MakeStandaloneBudget(math.MaxInt64))
-	standaloneMemAccount := standaloneMemMonitor.MakeBoundAccount()
-	r.BufferingOpMemAccounts = append(r.BufferingOpMemAccounts, &standaloneMemAccount)
-	return &standaloneMemAccount
-}
-
 type postProcessResult struct {
 	Op               Operator
 	ColumnTypes      []types.T
@@ -0,0 +1,335 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// errInjectedSpill is the error with which fuzzInput panics at the injected
+// spill point.
+var errInjectedSpill = errors.New("injected spill")
+
+// fuzzInput is a resettable Operator that emits Int64 tuples in batches of
+// random sizes. It can be configured to panic with errInjectedSpill instead of
+// emitting a batch, which simulates the operator consuming fuzzInput hitting
+// its memory limit at a batch boundary.
+type fuzzInput struct {
+	ZeroInputNode
+
+	rng   *rand.Rand
+	typs  []coltypes.T
+	batch coldata.Batch
+
+	tups tuples
+	idx  int
+	// spillAfter is the number of batches emitted before the injected spill
+	// occurs. A negative value means that no spill will be injected.
+	spillAfter int
+	numEmitted int
+
+	// nextTups and nextSpillAfter will be used after the next reset.
+	nextTups       tuples
+	nextSpillAfter int
+}
+
+var _ ResettableOperator = &fuzzInput{}
+
+func newFuzzInput(rng *rand.Rand, typs []coltypes.T, tups tuples, spillAfter int) *fuzzInput {
+	return &fuzzInput{rng: rng, typs: typs, tups: tups, spillAfter: spillAfter}
+}
+
+func (f *fuzzInput) Init() {
+	f.batch = testAllocator.NewMemBatch(f.typs)
+}
+
+func (f *fuzzInput) Next(context.Context) coldata.Batch {
+	if f.numEmitted == f.spillAfter {
+		f.spillAfter = -1
+		execerror.VectorizedInternalPanic(errInjectedSpill)
+	}
+	n := 1 + f.rng.Intn(coldata.BatchSize())
+	if n > len(f.tups)-f.idx {
+		n = len(f.tups) - f.idx
+	}
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	f.batch.ResetInternalBatch()
+	for colIdx := range f.typs {
+		vec := f.batch.ColVec(colIdx)
+		col := vec.Int64()
+		for i := 0; i < n; i++ {
+			if v := f.tups[f.idx+i][colIdx]; v == nil {
+				vec.Nulls().SetNull(i)
+			} else {
+				col[i] = v.(int64)
+			}
+		}
+	}
+	f.batch.SetLength(n)
+	f.idx += n
+	f.numEmitted++
+	return f.batch
+}
+
+func (f *fuzzInput) Reset(context.Context) {
+	f.tups, f.spillAfter = f.nextTups, f.nextSpillAfter
+	f.idx, f.numEmitted = 0, 0
+}
+
+// exportBufferedFuzzCase describes a bufferingInMemoryOperator implementation
+// to be fuzzed.
+type exportBufferedFuzzCase struct {
+	name string
+	// numInputs is the number of inputs of the operator. The last input is the
+	// one that is buffered by the operator.
+	numInputs int
+	// sortedInput indicates whether the input tuples must be sorted.
+	sortedInput bool
+	// topK, if positive, indicates that the operator is allowed to discard the
+	// buffered tuples that cannot appear in its output, so only the first topK
+	// tuples in the sorted order must be preserved.
+	topK int
+	// onlySpillsBeforeOutput indicates that the operator is expected to hit
+	// the memory limit only before it has emitted any output.
+	onlySpillsBeforeOutput bool
+	constructor            func(allocator *Allocator, inputs []Operator) bufferingInMemoryOperator
+}
+
+// TestExportBufferedFuzz drives bufferingInMemoryOperator implementations
+// through random sequences of Next, ExportBuffered, and reset calls with
+// random spill points (both at the batch boundaries and in the middle of
+// processing a batch because of the memory limit) and verifies that the
+// tuples emitted before the spill together with the exported tuples and the
+// remaining input are the same as the original input.
+func TestExportBufferedFuzz(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	rng, _ := newRandForTest(t)
+
+	typs := []coltypes.T{coltypes.Int64, coltypes.Int64}
+	// We use all columns for ordering so that the output is deterministic.
+	ordCols := []execinfrapb.Ordering_Column{{ColIdx: 0}, {ColIdx: 1}}
+	const k = 10
+	hjSpec, err := makeHashJoinerSpec(
+		sqlbase.JoinType_INNER, []uint32{0}, []uint32{0}, typs, typs, false, /* rightDistinct */
+	)
+	require.NoError(t, err)
+
+	for _, tc := range []exportBufferedFuzzCase{
+		{
+			name:      "sort",
+			numInputs: 1,
+			constructor: func(allocator *Allocator, inputs []Operator) bufferingInMemoryOperator {
+				op, err := NewSorter(allocator, inputs[0], typs, ordCols)
+				require.NoError(t, err)
+				return op.(bufferingInMemoryOperator)
+			},
+		},
+		{
+			name:        "sort-chunks",
+			numInputs:   1,
+			sortedInput: true,
+			constructor: func(allocator *Allocator, inputs []Operator) bufferingInMemoryOperator {
+				op, err := NewSortChunks(allocator, inputs[0], typs, ordCols, 1 /* matchLen */)
+				require.NoError(t, err)
+				return op.(bufferingInMemoryOperator)
+			},
+		},
+		{
+			name:      "topk-sort",
+			numInputs: 1,
+			topK:      k,
+			constructor: func(allocator *Allocator, inputs []Operator) bufferingInMemoryOperator {
+				return NewTopKSorter(allocator, inputs[0], typs, ordCols, k).(bufferingInMemoryOperator)
+			},
+		},
+		{
+			name:                   "hash-joiner",
+			numInputs:              2,
+			onlySpillsBeforeOutput: true,
+			constructor: func(allocator *Allocator, inputs []Operator) bufferingInMemoryOperator {
+				return newHashJoiner(allocator, hjSpec, inputs[0], inputs[1]).(bufferingInMemoryOperator)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			const numRuns = 20
+			for run := 0; run < numRuns; run++ {
+				runExportBufferedFuzz(ctx, t, rng, tc, typs)
+			}
+		})
+	}
+}
+
+func runExportBufferedFuzz(
+	ctx context.Context, t *testing.T, rng *rand.Rand, tc exportBufferedFuzzCase, typs []coltypes.T,
+) {
+	// Half of the time the memory limit is small enough for the operator to
+	// spill in the middle of processing a batch.
+	limit := int64(1 << 30)
+	if rng.Float64() < 0.5 {
+		limit = 1 + rng.Int63n(64<<10)
+	}
+	memMonitor := mon.MakeMonitorInheritWithLimit("export-buffered-fuzz", limit, testMemMonitor)
+	memMonitor.Start(ctx, testMemMonitor, mon.BoundAccount{})
+	defer memMonitor.Stop(ctx)
+	memAcc := memMonitor.MakeBoundAccount()
+	defer memAcc.Close(ctx)
+
+	generateTuples := func() tuples {
+		tups := make(tuples, rng.Intn(4*coldata.BatchSize()))
+		for i := range tups {
+			tups[i] = make(tuple, len(typs))
+			for j := range tups[i] {
+				if rng.Float64() >= nullProbability {
+					tups[i][j] = int64(rng.Intn(16))
+				}
+			}
+		}
+		if tc.sortedInput {
+			tups = tups.sort()
+		}
+		return tups
+	}
+	generateSpillAfter := func() int {
+		if rng.Float64() < 0.5 {
+			return -1
+		}
+		return rng.Intn(8)
+	}
+
+	inputs := make([]*fuzzInput, tc.numInputs)
+	opInputs := make([]Operator, tc.numInputs)
+	for i := range inputs {
+		spillAfter := -1
+		if i == len(inputs)-1 {
+			spillAfter = generateSpillAfter()
+		}
+		inputs[i] = newFuzzInput(rng, typs, generateTuples(), spillAfter)
+		inputs[i].Init()
+		opInputs[i] = inputs[i]
+	}
+	bufferedInput := inputs[len(inputs)-1]
+	op := tc.constructor(NewAllocator(ctx, &memAcc), opInputs)
+	if err := execerror.CatchVectorizedRuntimeError(op.Init); err != nil {
+		// The memory limit is too small even for the initialization.
+		require.True(t, sqlbase.IsOutOfMemoryError(err), "unexpected error %v", err)
+		return
+	}
+
+	numRounds := 1
+	if _, ok := op.(Resetter); ok {
+		numRounds += rng.Intn(3)
+	}
+	for round := 0; round < numRounds; round++ {
+		if round > 0 {
+			for _, input := range inputs {
+				input.nextTups = generateTuples()
+				input.nextSpillAfter = -1
+			}
+			bufferedInput.nextSpillAfter = generateSpillAfter()
+			op.(Resetter).Reset(ctx)
+		}
+		original := bufferedInput.tups
+
+		// Call Next until either the operator is exhausted or it spills.
+		var (
+			emitted tuples
+			spilled bool
+		)
+		for {
+			var b coldata.Batch
+			if err := execerror.CatchVectorizedRuntimeError(func() { b = op.Next(ctx) }); err != nil {
+				require.True(t, errors.Is(err, errInjectedSpill) || sqlbase.IsOutOfMemoryError(err),
+					"unexpected error %v", err)
+				spilled = true
+				break
+			}
+			if b.Length() == 0 {
+				break
+			}
+			if tc.numInputs == 1 {
+				for i := 0; i < b.Length(); i++ {
+					emitted = append(emitted, getTupleFromBatch(b, i))
+				}
+			} else {
+				emitted = append(emitted, tuple{})
+			}
+			if rng.Float64() < 0.1 && numRounds > round+1 {
+				// Occasionally abandon the operator in the middle of emitting the
+				// output in order to exercise resetting it in that state.
+				break
+			}
+		}
+		if !spilled {
+			continue
+		}
+		if tc.onlySpillsBeforeOutput {
+			require.Zero(t, len(emitted), "%s spilled after emitting output", tc.name)
+		}
+
+		// Export all buffered tuples. Once ExportBuffered returns a zero-length
+		// batch, it must keep on doing so.
+		var exported tuples
+		for b := op.ExportBuffered(bufferedInput); b.Length() > 0; b = op.ExportBuffered(bufferedInput) {
+			for i := 0; i < b.Length(); i++ {
+				exported = append(exported, getTupleFromBatch(b, i))
+			}
+		}
+		for i := rng.Intn(3); i > 0; i-- {
+			require.Equal(t, 0, op.ExportBuffered(bufferedInput).Length())
+		}
+
+		// Drain the remaining input.
+		var remaining tuples
+		for b := bufferedInput.Next(ctx); b.Length() > 0; b = bufferedInput.Next(ctx) {
+			for i := 0; i < b.Length(); i++ {
+				remaining = append(remaining, getTupleFromBatch(b, i))
+			}
+		}
+
+		var actual tuples
+		if tc.numInputs == 1 {
+			actual = append(actual, emitted...)
+		}
+		actual = append(actual, exported...)
+		actual = append(actual, remaining...)
+		desc := fmt.Sprintf("%s: emitted %d, exported %d, and remaining %d tuples out of %d",
+			tc.name, len(emitted), len(exported), len(remaining), len(original))
+		if tc.topK > 0 {
+			expected, actual := original.sort(), actual.sort()
+			if len(expected) > tc.topK {
+				expected = expected[:tc.topK]
+			}
+			if len(actual) > tc.topK {
+				actual = actual[:tc.topK]
+			}
+			require.NoError(t, assertTuplesOrderedEqual(expected, actual), desc)
+		} else {
+			require.NoError(t, assertTuplesSetsEqual(original, actual), desc)
+		}
+	}
+}
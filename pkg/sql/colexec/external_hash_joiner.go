@@ -13,7 +13,6 @@ package colexec
 import (
 	"context"
 	"fmt"
-	"io"
 	"math"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
@@ -97,24 +96,18 @@ const (
 	// externalHJDiskQueuesMemFraction determines the fraction of the available
 	// RAM that is allocated for the in-memory cache of disk queues.
 	externalHJDiskQueuesMemFraction = 0.5
-	// We need at least two buckets per side to make progress. However, the
-	// minimum number of partitions necessary are the partitions in use during a
-	// fallback to sort and merge join. We'll be using the minimum necessary per
-	// input + 2 (1 for each spilling queue that the merge joiner uses). For
-	// clarity this is what happens:
+	// We need at least two buckets per side to make progress. The fallback to
+	// sort and merge join doesn't need more file descriptors than that:
 	// - The 2 partitions that need to be sorted + merged will use an FD each: 2
-	//   FDs. Meanwhile, each sorter will use up to externalSorterMinPartitions to
-	//   sort and partition this input. At this stage 2 + 2 *
-	//   externalMinPartitions FDs are used.
+	//   FDs. The sorters store the partitions in the temporary storage engine
+	//   which manages its own files, so they don't use any FDs from the
+	//   semaphore.
 	// - Once the inputs (the hash joiner partitions) are finished, both FDs will
 	//   be released. The merge joiner will now be in use, which uses two
-	//   spillingQueues with 1 FD each for a total of 2. Since each sorter will
-	//   use externalSorterMinPartitions, the FDs used at this stage are 2 +
-	//   (2 * externalSorterMinPartitions) as well. Note that as soon as the
+	//   spillingQueues with 1 FD each for a total of 2. Note that as soon as the
 	//   sorter emits its first batch, it must be the case that the input to it
 	//   has returned a zero batch, and thus the FD has been closed.
-	sortMergeNonSortMinFDsOpen = 2
-	externalHJMinPartitions    = sortMergeNonSortMinFDsOpen + (externalSorterMinPartitions * 2)
+	externalHJMinPartitions = 4
 )
 
 // externalHashJoiner is an operator that performs Grace hash join algorithm
@@ -156,6 +149,7 @@ type externalHashJoiner struct {
 	closed             bool
 	state              externalHashJoinerState
 	unlimitedAllocator *Allocator
+	cancelChecker      CancelChecker
 	spec               hashJoinerSpec
 	diskQueueCfg       colcontainer.DiskQueueCfg
 
@@ -194,6 +188,9 @@ type externalHashJoiner struct {
 	// recursively repartition another partition because the latter was too big
 	// to join.
 	numRepartitions int
+	// stats accumulates the number of partitions as well as the maximum depth
+	// of the recursive repartitioning.
+	stats diskBackedStats
 	// scratch and recursiveScratch are helper structs. Note that batches in
 	// scratch are fully-allocated whereas batches in recursiveScratch are
 	// simply "skeletons". The latter are intended to be used to dequeue into
@@ -210,7 +207,7 @@ type externalHashJoiner struct {
 	inMemHashJoiner                   *hashJoiner
 	// diskBackedSortMerge is a side chain of disk-backed sorters that feed into
 	// disk-backed merge joiner which the external hash joiner can fall back to.
-	diskBackedSortMerge resettableOperator
+	diskBackedSortMerge ResettableOperator
 
 	memState struct {
 		// maxRightPartitionSizeToJoin indicates the maximum memory size of a
@@ -235,10 +232,15 @@ type externalHashJoiner struct {
 }
 
 var _ Operator = &externalHashJoiner{}
+var _ diskBackedStatsReporter = &externalHashJoiner{}
+var _ Closer = &externalHashJoiner{}
 
 type externalHJPartitionInfo struct {
 	rightMemSize       int64
 	rightParentMemSize int64
+	// depth is the number of times that the tuples of the partition have been
+	// repartitioned (zero for the partitions of the initial partitioning).
+	depth int
 }
 
 type joinSide int
@@ -266,7 +268,7 @@ func newExternalHashJoiner(
 	memoryLimit int64,
 	diskQueueCfg colcontainer.DiskQueueCfg,
 	fdSemaphore semaphore.Semaphore,
-	createReusableDiskBackedSorter func(input Operator, inputTypes []coltypes.T, orderingCols []execinfrapb.Ordering_Column, maxNumberPartitions int) (Operator, error),
+	createReusableDiskBackedSorter func(input Operator, inputTypes []coltypes.T, orderingCols []execinfrapb.Ordering_Column) (Operator, error),
 	numForcedRepartitions int,
 	delegateFDAcquisitions bool,
 ) Operator {
@@ -315,27 +317,16 @@ func newExternalHashJoiner(
 		}
 		return res
 	}
-	// We need to allocate 2 FDs for reading the partitions (reused by the merge
-	// joiner) that we need to join using sort + merge join strategy, and all
-	// others are divided between the two inputs.
-	externalSorterMaxNumberPartitions := (maxNumberActivePartitions - sortMergeNonSortMinFDsOpen) / 2
-	if externalSorterMaxNumberPartitions < externalSorterMinPartitions {
-		// This code gets a maximum number of partitions based on the semaphore
-		// limit. In tests, this limit is set artificially low to catch any
-		// violations of the limit, resulting in possibly computing a low number of
-		// partitions for the sorter, which we overwrite here.
-		externalSorterMaxNumberPartitions = externalSorterMinPartitions
-	}
 	leftOrdering := makeOrderingCols(spec.left.eqCols)
 	leftPartitionSorter, err := createReusableDiskBackedSorter(
-		leftJoinerInput, spec.left.sourceTypes, leftOrdering, externalSorterMaxNumberPartitions,
+		leftJoinerInput, spec.left.sourceTypes, leftOrdering,
 	)
 	if err != nil {
 		execerror.VectorizedInternalPanic(err)
 	}
 	rightOrdering := makeOrderingCols(spec.right.eqCols)
 	rightPartitionSorter, err := createReusableDiskBackedSorter(
-		rightJoinerInput, spec.right.sourceTypes, rightOrdering, externalSorterMaxNumberPartitions,
+		rightJoinerInput, spec.right.sourceTypes, rightOrdering,
 	)
 	if err != nil {
 		execerror.VectorizedInternalPanic(err)
@@ -459,6 +450,7 @@ func (hj *externalHashJoiner) partitionBatch(
 			if !ok {
 				partitionInfo = &externalHJPartitionInfo{}
 				hj.partitionsToJoinUsingInMemHash[partitionIdx] = partitionInfo
+				hj.stats.numPartitions++
 			}
 			if side == rightSide {
 				partitionInfo.rightParentMemSize = parentMemSize
@@ -512,6 +504,7 @@ StateChanged:
 				}
 				hj.fdState.acquiredFDs = toAcquire
 			}
+			hj.cancelChecker.checkWork(ctx, leftBatch.Length()+rightBatch.Length())
 			hj.partitionBatch(ctx, leftBatch, leftSide, math.MaxInt64)
 			hj.partitionBatch(ctx, rightBatch, rightSide, math.MaxInt64)
 
@@ -549,6 +542,7 @@ StateChanged:
 						if batch.Length() == 0 {
 							break
 						}
+						hj.cancelChecker.checkWork(ctx, batch.Length())
 						hj.partitionBatch(ctx, batch, side, memSize)
 					}
 					// We're done reading from this partition, and it will never be read
@@ -573,6 +567,10 @@ StateChanged:
 				for idx := 0; idx < hj.numBuckets; idx++ {
 					newPartitionIdx := hj.partitionIdxOffset + idx
 					if partitionInfo, ok := hj.partitionsToJoinUsingInMemHash[newPartitionIdx]; ok {
+						partitionInfo.depth = parentPartitionInfo.depth + 1
+						if partitionInfo.depth > hj.stats.maxRepartitioningDepth {
+							hj.stats.maxRepartitioningDepth = partitionInfo.depth
+						}
 						before, after := partitionInfo.rightParentMemSize, partitionInfo.rightMemSize
 						if before > 0 {
 							sizeDecrease := 1.0 - float64(after)/float64(before)
@@ -607,7 +605,7 @@ StateChanged:
 					// Update the inputs to in-memory hash joiner and reset the latter.
 					hj.leftJoinerInput.partitionIdx = partitionIdx
 					hj.rightJoinerInput.partitionIdx = partitionIdx
-					hj.inMemHashJoiner.reset()
+					hj.inMemHashJoiner.Reset(ctx)
 					delete(hj.partitionsToJoinUsingInMemHash, partitionIdx)
 					hj.state = externalHJJoining
 					continue StateChanged
@@ -666,7 +664,7 @@ StateChanged:
 			// Update the inputs to sort + merge joiner and reset that chain.
 			hj.leftJoinerInput.partitionIdx = partitionIdx
 			hj.rightJoinerInput.partitionIdx = partitionIdx
-			hj.diskBackedSortMerge.reset()
+			hj.diskBackedSortMerge.Reset(ctx)
 			hj.state = externalHJSortMergeJoining
 			continue
 
@@ -687,7 +685,7 @@ StateChanged:
 			return b
 
 		case externalHJFinished:
-			if err := hj.Close(); err != nil {
+			if err := hj.Close(ctx); err != nil {
 				execerror.VectorizedInternalPanic(err)
 			}
 			return coldata.ZeroBatch
@@ -697,7 +695,14 @@ StateChanged:
 	}
 }
 
-func (hj *externalHashJoiner) Close() error {
+func (hj *externalHashJoiner) getDiskBackedStats() diskBackedStats {
+	s := hj.stats
+	s.numRepartitions = hj.numRepartitions
+	return s
+}
+
+// Close is part of the Closer interface.
+func (hj *externalHashJoiner) Close(ctx context.Context) error {
 	if hj.closed {
 		return nil
 	}
@@ -708,8 +713,8 @@ func (hj *externalHashJoiner) Close() error {
 	if err := hj.rightPartitioner.Close(); err != nil && retErr == nil {
 		retErr = err
 	}
-	if c, ok := hj.diskBackedSortMerge.(io.Closer); ok {
-		if err := c.Close(); err != nil && retErr == nil {
+	if c, ok := hj.diskBackedSortMerge.(Closer); ok {
+		if err := c.Close(ctx); err != nil && retErr == nil {
 			retErr = err
 		}
 	}
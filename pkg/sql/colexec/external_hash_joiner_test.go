@@ -19,14 +19,16 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/testutils/colcontainerutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
-	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/marusama/semaphore"
 	"github.com/stretchr/testify/require"
 )
@@ -50,7 +52,7 @@ func TestExternalHashJoiner(t *testing.T) {
 		memAccounts []*mon.BoundAccount
 		memMonitors []*mon.BytesMonitor
 	)
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(t)
 	// Test the case in which the default memory is used as well as the case in
 	// which the joiner spills to disk.
 	for _, spillForced := range []bool{false, true} {
@@ -120,10 +122,15 @@ func TestExternalHashJoinerFallbackToSortMergeJoin(t *testing.T) {
 	st := cluster.MakeTestingClusterSettings()
 	evalCtx := tree.MakeTestingEvalContext(st)
 	defer evalCtx.Stop(ctx)
+	// The sorts of the fallback spill to the temporary storage engine.
+	tempEngine, diskMonitor, cleanupTempStorage := newTestingTempStorage(t, st)
+	defer cleanupTempStorage()
 	flowCtx := &execinfra.FlowCtx{
 		EvalCtx: &evalCtx,
 		Cfg: &execinfra.ServerConfig{
-			Settings: st,
+			Settings:    st,
+			TempStorage: tempEngine,
+			DiskMonitor: diskMonitor,
 			TestingKnobs: execinfra.TestingKnobs{
 				ForceDiskSpill:   true,
 				MemoryLimitBytes: 1,
@@ -175,6 +182,106 @@ func TestExternalHashJoinerFallbackToSortMergeJoin(t *testing.T) {
 	require.Equal(t, expectedTuplesCount, actualTuplesCount)
 }
 
+func TestExternalHashJoinerDiskFaults(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+			TestingKnobs: execinfra.TestingKnobs{
+				ForceDiskSpill:   true,
+				MemoryLimitBytes: 1,
+			},
+		},
+	}
+	rng, _ := newRandForTest(t)
+	sourceTypes := []coltypes.T{coltypes.Int64}
+	batch := testAllocator.NewMemBatch(sourceTypes)
+	col := batch.ColVec(0).Int64()
+	for i := range col {
+		col[i] = int64(i % 16)
+	}
+	batch.SetLength(coldata.BatchSize())
+	spec := createSpecForHashJoiner(joinTestCase{
+		joinType:     sqlbase.JoinType_INNER,
+		leftTypes:    sourceTypes,
+		leftOutCols:  []uint32{0},
+		leftEqCols:   []uint32{0},
+		rightTypes:   sourceTypes,
+		rightOutCols: []uint32{0},
+		rightEqCols:  []uint32{0},
+	})
+
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	for _, kind := range []colcontainer.DiskFaultKind{
+		colcontainer.DiskFaultWriteError,
+		colcontainer.DiskFaultNoSpace,
+		colcontainer.DiskFaultShortRead,
+	} {
+		injector := &colcontainer.DiskFaultInjector{Kind: kind, AfterNumOps: rng.Intn(4)}
+		t.Run(fmt.Sprintf("kind=%d/afterNumOps=%d", kind, injector.AfterNumOps), func(t *testing.T) {
+			const nBatches = 4
+			cfg := queueCfg
+			cfg.TestingKnobs.DiskFaultInjector = injector
+			sem := NewTestingSemaphore(externalHJMinPartitions)
+			hj, accounts, monitors, err := createDiskBackedHashJoiner(
+				ctx, flowCtx, spec,
+				[]Operator{newFiniteBatchSource(batch, nBatches), newFiniteBatchSource(batch, nBatches)},
+				func() {}, cfg, 0 /* numForcedRepartitions */, false, /* delegateFDAcquisitions */
+				sem,
+			)
+			defer func() {
+				for _, memAccount := range accounts {
+					memAccount.Close(ctx)
+				}
+				for _, memMonitor := range monitors {
+					memMonitor.Stop(ctx)
+				}
+			}()
+			require.NoError(t, err)
+			verifyDiskFaultHandling(t, hj, queueCfg, injector, sem)
+		})
+	}
+}
+
+// verifyDiskFaultHandling drains op, a disk-backed operator that is forced to
+// spill to disk with injector set on its DiskQueueCfg, and verifies that the
+// injected fault is surfaced as an error and that, once op is closed, it
+// neither leaves any files behind nor holds on to any file descriptors.
+func verifyDiskFaultHandling(
+	t *testing.T,
+	op Operator,
+	queueCfg colcontainer.DiskQueueCfg,
+	injector *colcontainer.DiskFaultInjector,
+	sem semaphore.Semaphore,
+) {
+	ctx := context.Background()
+	op.Init()
+	err := execerror.CatchVectorizedRuntimeError(func() {
+		for b := op.Next(ctx); b.Length() > 0; b = op.Next(ctx) {
+		}
+	})
+	require.Error(t, err)
+	require.NotZero(t, injector.NumInjected())
+	if injector.Kind == colcontainer.DiskFaultNoSpace {
+		require.Equal(t, pgcode.DiskFull, pgerror.GetPGCode(err), "unexpected error %v", err)
+	}
+
+	// The operator will likely hit the fault again when closing, so we ignore
+	// the error but make sure that the cleanup has been performed.
+	_ = op.(Closer).Close(ctx)
+	directories, err := queueCfg.FS.ListDir(queueCfg.Path)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(directories), "disk queue directories left behind: %v", directories)
+	require.Equal(t, 0, sem.GetCount(), "sem still reports open FDs")
+}
+
 func BenchmarkExternalHashJoiner(b *testing.B) {
 	ctx := context.Background()
 	st := cluster.MakeTestingClusterSettings()
@@ -16,325 +16,220 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
-	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/diskmap"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowcontainer"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
 	"github.com/cockroachdb/errors"
-	"github.com/marusama/semaphore"
 )
 
 // externalSorterState indicates the current state of the external sorter.
 type externalSorterState int
 
 const (
-	// externalSorterNewPartition indicates that the next batch we read should
-	// start a new partition. A zero-length batch in this state indicates that
-	// the input to the external sorter has been fully consumed and we should
-	// proceed to merging the partitions.
-	externalSorterNewPartition externalSorterState = iota
-	// externalSorterSpillPartition indicates that the next batch we read should
-	// be added to the last partition so far. A zero-length batch in this state
-	// indicates that the end of the partition has been reached and we should
-	// transition to starting a new partition. If maxNumberPartitions is reached
-	// in this state, the sorter will transition to externalSorterRepeatedMerging
-	// to reduce the number of partitions.
-	externalSorterSpillPartition
-	// externalSorterRepeatedMerging indicates that we need to merge
-	// maxNumberPartitions into one and spill that new partition to disk. When
-	// finished, the sorter will transition to externalSorterNewPartition.
-	externalSorterRepeatedMerging
-	// externalSorterFinalMerging indicates that we have fully consumed the input
-	// and can merge all of the partitions in one step. We then transition to
-	// externalSorterEmitting state.
-	externalSorterFinalMerging
-	// externalSorterEmitting indicates that we are ready to emit output. A zero-
-	// length batch in this state indicates that we have emitted all tuples and
-	// should transition to externalSorterFinished state.
+	// externalSorterSpooling indicates that the sorter is writing the tuples
+	// from its input into the temporary storage engine. A zero-length batch in
+	// this state indicates that the input has been fully consumed and we should
+	// transition to externalSorterEmitting state.
+	externalSorterSpooling externalSorterState = iota
+	// externalSorterEmitting indicates that the sorter is reading the tuples
+	// back in sorted order. Once all tuples have been emitted, the sorter
+	// transitions to externalSorterFinished state.
 	externalSorterEmitting
-	// externalSorterFinished indicates that all tuples from all partitions have
-	// been emitted and from now on only a zero-length batch will be emitted by
-	// the external sorter. This state is also responsible for closing the
-	// partitions.
+	// externalSorterFinished indicates that all tuples have been emitted and
+	// from now on only a zero-length batch will be emitted. This state is also
+	// responsible for releasing the disk space used by the sorter.
 	externalSorterFinished
 )
 
-// In order to make progress when merging we have to merge at least two
-// partitions into a new third one.
-const externalSorterMinPartitions = 3
-
-// externalSorter is an Operator that performs external merge sort. It works in
-// two stages:
-// 1. it will use a combination of an input partitioner and in-memory sorter to
-// divide up all batches from the input into partitions, sort each partition in
-// memory, and write sorted partitions to disk
-// 2. it will use OrderedSynchronizer to merge the partitions.
-//
-// The (simplified) diagram of the components involved is as follows:
-//
-//                      input
-//                        |
-//                        ↓
-//                 input partitioner
-//                        |
-//                        ↓
-//                 in-memory sorter
-//                        |
-//                        ↓
-//    ------------------------------------------
-//   |             external sorter              |
-//   |             ---------------              |
-//   |                                          |
-//   | partition1     partition2 ... partitionN |
-//   |     |              |              |      |
-//   |     ↓              ↓              ↓      |
-//   |      merger (ordered synchronizer)       |
-//    ------------------------------------------
-//                        |
-//                        ↓
-//                      output
+// externalSorter is an Operator that performs a disk-backed sort by writing
+// all tuples into a SortedDiskMap of the temporary storage engine (usually a
+// temp Pebble instance) with the ordering columns encoded as the key. The
+// sorted runs are flushed, compacted and merged by the LSM of the engine, and
+// the sorter simply iterates over the keys in order, so there are no
+// partitions to keep track of and the file descriptors and the block cache are
+// managed by the engine itself.
 //
-// There are a couple of implicit upstream links in the setup:
-// - input partitioner checks the allocator used by the in-memory sorter to see
-// whether a new partition must be started
-// - external sorter resets in-memory sorter (which, in turn, resets input
-// partitioner) once the full partition has been spilled to disk.
-//
-// What is hidden in the diagram is the fact that at some point we might need
-// to merge several partitions into a new one that we spill to disk in order to
-// reduce the number of "active" partitions. This requirement comes from the
-// need to limit the number of "active" partitions because each partition uses
-// some amount of RAM for its buffer. This is determined by
-// maxNumberPartitions variable.
+// The tuples are converted to rows in order to be stored, which is slower per
+// tuple than writing the batches directly, but the sorter doesn't need to
+// repartition regardless of the size of the input.
 type externalSorter struct {
 	OneInputNode
 	NonExplainable
 
-	closed             bool
-	unlimitedAllocator *Allocator
-	state              externalSorterState
-	inputTypes         []coltypes.T
-	ordering           execinfrapb.Ordering
-	inMemSorter        resettableOperator
-	inMemSorterInput   *inputPartitioningOperator
-	partitioner        colcontainer.PartitionedQueue
-	partitionerCreator func() colcontainer.PartitionedQueue
-	// numPartitions is the current number of partitions.
-	numPartitions int
-	// firstPartitionIdx is the index of the first partition to merge next.
-	firstPartitionIdx   int
-	maxNumberPartitions int
+	closed        bool
+	allocator     *Allocator
+	state         externalSorterState
+	cancelChecker CancelChecker
+	inputTypes    []coltypes.T
+	// columnTypes are the SQL types that the tuples are converted to before
+	// being stored. The conversion from the physical types is lossless, so
+	// the exact logical types of the input are not needed.
+	columnTypes []types.T
+	// datumToPhysicalFns contains the conversion function for each of the
+	// columnTypes.
+	datumToPhysicalFns []func(tree.Datum) (interface{}, error)
+	ordering           sqlbase.ColumnOrdering
 
-	// fdState is used to acquire file descriptors up front.
-	fdState struct {
-		fdSemaphore semaphore.Semaphore
-		acquiredFDs int
-	}
+	diskMonitor *mon.BytesMonitor
+	engine      diskmap.Factory
+	// onWriteCb, if set, is called with the number of bytes that were written
+	// to the temporary storage engine once the input has been consumed.
+	onWriteCb func(bytesWritten int)
 
-	emitter Operator
+	rows *rowcontainer.DiskRowContainer
+	it   rowcontainer.RowIterator
 
-	testingKnobs struct {
-		// delegateFDAcquisitions if true, means that a test wants to force the
-		// PartitionedDiskQueues to track the number of file descriptors the hash
-		// joiner will open/close. This disables the default behavior of acquiring
-		// all file descriptors up front in Next.
-		delegateFDAcquisitions bool
+	// progress, if set, is notified when the sorter starts and finishes
+	// reading the sorted rows back, and the reading is paused while the flow
+	// is paused.
+	progress *execinfra.FlowProgress
+	// merging indicates whether progress has been notified that the external
+	// sorter is in FlowPhaseMergingRuns.
+	merging bool
+
+	output  coldata.Batch
+	scratch struct {
+		// row is used to convert a single tuple into a row to be spooled.
+		row sqlbase.EncDatumRow
+		// rows is used to buffer the rows that are read back before they are
+		// converted into the output batch.
+		rows sqlbase.EncDatumRows
+		// rowsMemUsage is the memory footprint of rows that has been registered
+		// with the allocator.
+		rowsMemUsage int64
 	}
+	datumAlloc sqlbase.DatumAlloc
 }
 
-var _ resettableOperator = &externalSorter{}
+var _ ResettableOperator = &externalSorter{}
+var _ flowProgressReporter = &externalSorter{}
+var _ Closer = &externalSorter{}
 
-// newExternalSorter returns a disk-backed general sort operator.
-// - ctx is the same context that standaloneMemAccount was created with.
-// - unlimitedAllocator must have been created with a memory account derived
-// from an unlimited memory monitor. It will be used by several internal
-// components of the external sort which is responsible for making sure that
-// the components stay within the memory limit.
-// - standaloneMemAccount must be a memory account derived from an unlimited
-// memory monitor with a standalone budget. It will be used by
-// inputPartitioningOperator to "partition" the input according to memory
-// limit. The budget *must* be standalone because we don't want to double
-// count the memory (the memory under the batches will be accounted for with
-// the unlimitedAllocator).
-// - maxNumberPartitions (when non-zero) overrides the semi-dynamically
-// computed maximum number of partitions to have at once.
-// - delegateFDAcquisitions specifies whether the external sorter should let
-// the partitioned disk queue acquire file descriptors instead of acquiring
-// them up front in Next. This should only be true in tests.
+// newExternalSorter returns a disk-backed general sort operator that uses the
+// given temporary storage engine to store and sort the tuples.
+// - allocator must have been created with a memory account derived from an
+// unlimited memory monitor since it is only used for the output batch and the
+// rows of a single batch that are read back.
+// - diskMonitor is used to account for the disk space used by the sorter.
+// - onWriteCb (when non-nil) is called with the number of bytes the sorter
+// has spilled.
 func newExternalSorter(
-	ctx context.Context,
-	unlimitedAllocator *Allocator,
-	standaloneMemAccount *mon.BoundAccount,
+	allocator *Allocator,
 	input Operator,
 	inputTypes []coltypes.T,
 	ordering execinfrapb.Ordering,
-	memoryLimit int64,
-	maxNumberPartitions int,
-	delegateFDAcquisitions bool,
-	diskQueueCfg colcontainer.DiskQueueCfg,
-	fdSemaphore semaphore.Semaphore,
+	diskMonitor *mon.BytesMonitor,
+	engine diskmap.Factory,
+	onWriteCb func(bytesWritten int),
 ) Operator {
-	if diskQueueCfg.CacheMode != colcontainer.DiskQueueCacheModeReuseCache {
-		execerror.VectorizedInternalPanic(errors.Errorf("external sorter instantiated with suboptimal disk queue cache mode: %d", diskQueueCfg.CacheMode))
-	}
-	if diskQueueCfg.BufferSizeBytes > 0 && maxNumberPartitions == 0 {
-		// With the default limit of 256 file descriptors, this results in 16
-		// partitions. This is a hard maximum of partitions that will be used by the
-		// external sorter
-		// TODO(asubiotto): this number should be tuned.
-		maxNumberPartitions = fdSemaphore.GetLimit() / 16
+	if engine == nil {
+		execerror.VectorizedInternalPanic(errors.AssertionFailedf(
+			"external sorter instantiated without a temporary storage engine",
+		))
 	}
-	if maxNumberPartitions < externalSorterMinPartitions {
-		maxNumberPartitions = externalSorterMinPartitions
+	columnTypes := typeconv.ToColumnTypes(inputTypes)
+	datumToPhysicalFns := make([]func(tree.Datum) (interface{}, error), len(columnTypes))
+	for i := range columnTypes {
+		datumToPhysicalFns[i] = typeconv.GetDatumToPhysicalFn(&columnTypes[i])
 	}
-	// Each disk queue will use up to BufferSizeBytes of RAM, so we reduce the
-	// memoryLimit of the partitions to sort in memory by those cache sizes. To be
-	// safe, we also estimate the size of the output batch and subtract that as
-	// well.
-	batchMemSize := estimateBatchSizeBytes(inputTypes, coldata.BatchSize())
-	// Reserve a certain amount of memory for the partition caches.
-	memoryLimit -= int64((maxNumberPartitions * diskQueueCfg.BufferSizeBytes) + batchMemSize)
-	if memoryLimit < 1 {
-		// If the memory limit is 0, the input partitioning operator will return a
-		// zero-length batch, so make it at least 1.
-		memoryLimit = 1
-	}
-	inputPartitioner := newInputPartitioningOperator(ctx, input, standaloneMemAccount, memoryLimit)
-	inMemSorter, err := newSorter(
-		unlimitedAllocator, newAllSpooler(unlimitedAllocator, inputPartitioner, inputTypes),
-		inputTypes, ordering.Columns,
-	)
-	if err != nil {
-		execerror.VectorizedInternalPanic(err)
+	return &externalSorter{
+		OneInputNode:       NewOneInputNode(input),
+		allocator:          allocator,
+		inputTypes:         inputTypes,
+		columnTypes:        columnTypes,
+		datumToPhysicalFns: datumToPhysicalFns,
+		ordering:           execinfrapb.ConvertToColumnOrdering(ordering),
+		diskMonitor:        diskMonitor,
+		engine:             engine,
+		onWriteCb:          onWriteCb,
 	}
-	partitionedDiskQueueSemaphore := fdSemaphore
-	if !delegateFDAcquisitions {
-		// To avoid deadlocks with other disk queues, we manually attempt to acquire
-		// the maximum number of descriptors all at once in Next. Passing in a nil
-		// semaphore indicates that the caller will do the acquiring.
-		partitionedDiskQueueSemaphore = nil
+}
+
+func (s *externalSorter) setFlowProgress(progress *execinfra.FlowProgress) {
+	s.progress = progress
+}
+
+// startMerging notifies the progress of the flow (if set) that the external
+// sorter is reading the sorted rows back from the engine.
+func (s *externalSorter) startMerging() {
+	if s.progress != nil && !s.merging {
+		s.progress.StartPhase(execinfra.FlowPhaseMergingRuns)
+		s.merging = true
 	}
-	es := &externalSorter{
-		OneInputNode:       NewOneInputNode(inMemSorter),
-		unlimitedAllocator: unlimitedAllocator,
-		inMemSorter:        inMemSorter,
-		inMemSorterInput:   inputPartitioner.(*inputPartitioningOperator),
-		partitionerCreator: func() colcontainer.PartitionedQueue {
-			return colcontainer.NewPartitionedDiskQueue(inputTypes, diskQueueCfg, partitionedDiskQueueSemaphore, colcontainer.PartitionerStrategyCloseOnNewPartition)
-		},
-		inputTypes:          inputTypes,
-		ordering:            ordering,
-		maxNumberPartitions: maxNumberPartitions,
+}
+
+// finishMerging notifies the progress of the flow (if set) that the external
+// sorter is done reading the sorted rows back from the engine.
+func (s *externalSorter) finishMerging() {
+	if s.merging {
+		s.progress.FinishPhase(execinfra.FlowPhaseMergingRuns)
+		s.merging = false
 	}
-	es.fdState.fdSemaphore = fdSemaphore
-	es.testingKnobs.delegateFDAcquisitions = delegateFDAcquisitions
-	return es
 }
 
 func (s *externalSorter) Init() {
 	s.input.Init()
-	s.state = externalSorterNewPartition
+	s.state = externalSorterSpooling
 }
 
 func (s *externalSorter) Next(ctx context.Context) coldata.Batch {
 	for {
 		switch s.state {
-		case externalSorterNewPartition:
-			b := s.input.Next(ctx)
-			if b.Length() == 0 {
-				// The input has been fully exhausted, and it is always the case that
-				// the number of partitions is less than the maximum number since
-				// externalSorterSpillPartition will check and re-merge if not.
-				// Proceed to the final merging state.
-				s.state = externalSorterFinalMerging
-				continue
-			}
-			newPartitionIdx := s.firstPartitionIdx + s.numPartitions
-			if s.partitioner == nil {
-				s.partitioner = s.partitionerCreator()
+		case externalSorterSpooling:
+			if s.rows == nil {
+				rows := rowcontainer.MakeDiskRowContainer(s.diskMonitor, s.columnTypes, s.ordering, s.engine)
+				s.rows = &rows
 			}
-			if err := s.partitioner.Enqueue(ctx, newPartitionIdx, b); err != nil {
-				execerror.VectorizedInternalPanic(err)
-			}
-			s.state = externalSorterSpillPartition
-			continue
-		case externalSorterSpillPartition:
-			curPartitionIdx := s.firstPartitionIdx + s.numPartitions
 			b := s.input.Next(ctx)
 			if b.Length() == 0 {
-				// The partition has been fully spilled, so we reset the in-memory
-				// sorter (which will do the "shallow" reset of
-				// inputPartitioningOperator).
-				s.inMemSorterInput.interceptReset = true
-				s.inMemSorter.reset()
-				s.numPartitions++
-				if s.numPartitions == s.maxNumberPartitions-1 {
-					// We have reached the maximum number of active partitions that we
-					// know that we'll be able to merge without exceeding the limit, so
-					// we need to merge all of them and spill the new partition to disk
-					// before we can proceed on consuming the input.
-					s.state = externalSorterRepeatedMerging
-					continue
+				if s.onWriteCb != nil {
+					s.onWriteCb(int(s.diskMonitor.AllocBytes()))
 				}
-				s.state = externalSorterNewPartition
+				s.it = s.rows.NewFinalIterator(ctx)
+				s.it.Rewind()
+				s.startMerging()
+				s.state = externalSorterEmitting
 				continue
 			}
-			if !s.testingKnobs.delegateFDAcquisitions && s.fdState.fdSemaphore != nil && s.fdState.acquiredFDs == 0 {
-				toAcquire := s.maxNumberPartitions
-				if err := s.fdState.fdSemaphore.Acquire(ctx, toAcquire); err != nil {
-					execerror.VectorizedInternalPanic(err)
-				}
-				s.fdState.acquiredFDs = toAcquire
-			}
-			if err := s.partitioner.Enqueue(ctx, curPartitionIdx, b); err != nil {
+			s.cancelChecker.checkWork(ctx, b.Length())
+			if err := s.spool(ctx, b); err != nil {
 				execerror.VectorizedInternalPanic(err)
 			}
 			continue
-		case externalSorterRepeatedMerging:
-			// We will merge all partitions in range [s.firstPartitionIdx,
-			// s.firstPartitionIdx+s.numPartitions) and will spill all the resulting
-			// batches into a new partition with the next available index.
-			merger := s.createMergerForPartitions(s.firstPartitionIdx, s.numPartitions)
-			merger.Init()
-			newPartitionIdx := s.firstPartitionIdx + s.numPartitions
-			for b := merger.Next(ctx); b.Length() > 0; b = merger.Next(ctx) {
-				if err := s.partitioner.Enqueue(ctx, newPartitionIdx, b); err != nil {
-					execerror.VectorizedInternalPanic(err)
-				}
-			}
-			// Reclaim disk space by closing the inactive read partitions. Since the
-			// merger must have exhausted all inputs, this is all the partitions just
-			// read from.
-			if err := s.partitioner.CloseInactiveReadPartitions(); err != nil {
+		case externalSorterEmitting:
+			waitIfPaused(ctx, s.progress)
+			n, err := s.readRows()
+			if err != nil {
 				execerror.VectorizedInternalPanic(err)
 			}
-			s.firstPartitionIdx += s.numPartitions
-			s.numPartitions = 1
-			s.state = externalSorterNewPartition
-			continue
-		case externalSorterFinalMerging:
-			if s.numPartitions == 0 {
+			if n == 0 {
+				s.finishMerging()
 				s.state = externalSorterFinished
 				continue
-			} else if s.numPartitions == 1 {
-				s.emitter = newPartitionerToOperator(
-					s.unlimitedAllocator, s.inputTypes, s.partitioner, s.firstPartitionIdx,
-				)
+			}
+			s.cancelChecker.checkWork(ctx, n)
+			if s.output == nil {
+				s.output = s.allocator.NewMemBatch(s.inputTypes)
 			} else {
-				s.emitter = s.createMergerForPartitions(s.firstPartitionIdx, s.numPartitions)
+				s.output.ResetInternalBatch()
 			}
-			s.emitter.Init()
-			s.state = externalSorterEmitting
-			continue
-		case externalSorterEmitting:
-			b := s.emitter.Next(ctx)
-			if b.Length() == 0 {
-				s.state = externalSorterFinished
-				continue
+			if err := EncDatumRowsToColVecs(
+				s.allocator, s.scratch.rows[:n], s.output, s.columnTypes,
+				s.datumToPhysicalFns, &s.datumAlloc,
+			); err != nil {
+				execerror.VectorizedInternalPanic(err)
 			}
-			return b
+			s.output.SetLength(n)
+			return s.output
 		case externalSorterFinished:
-			if err := s.Close(); err != nil {
+			if err := s.Close(ctx); err != nil {
 				execerror.VectorizedInternalPanic(err)
 			}
 			return coldata.ZeroBatch
@@ -344,144 +239,100 @@ func (s *externalSorter) Next(ctx context.Context) coldata.Batch {
 	}
 }
 
-func (s *externalSorter) reset() {
-	if r, ok := s.input.(resetter); ok {
-		r.reset()
+// spool converts all tuples of the batch into rows and adds them to the row
+// container.
+func (s *externalSorter) spool(ctx context.Context, b coldata.Batch) error {
+	if s.scratch.row == nil {
+		s.scratch.row = make(sqlbase.EncDatumRow, len(s.inputTypes))
 	}
-	s.state = externalSorterNewPartition
-	if err := s.Close(); err != nil {
-		execerror.VectorizedInternalPanic(err)
+	sel := b.Selection()
+	for i, n := 0, b.Length(); i < n; i++ {
+		rowIdx := i
+		if sel != nil {
+			rowIdx = sel[i]
+		}
+		for colIdx := range s.inputTypes {
+			ct := &s.columnTypes[colIdx]
+			datum := PhysicalTypeColElemToDatum(b.ColVec(colIdx), rowIdx, s.datumAlloc, ct)
+			s.scratch.row[colIdx] = sqlbase.DatumToEncDatum(ct, datum)
+		}
+		if err := s.rows.AddRow(ctx, s.scratch.row); err != nil {
+			return err
+		}
 	}
-	s.closed = false
-	s.firstPartitionIdx = 0
-	s.numPartitions = 0
+	return nil
 }
 
-func (s *externalSorter) Close() error {
-	if s.closed {
-		return nil
+// readRows reads up to coldata.BatchSize() rows from the row container into
+// s.scratch.rows and returns the number of rows read.
+func (s *externalSorter) readRows() (int, error) {
+	if s.scratch.rows == nil {
+		s.scratch.rows = make(sqlbase.EncDatumRows, coldata.BatchSize())
+		for i := range s.scratch.rows {
+			s.scratch.rows[i] = make(sqlbase.EncDatumRow, len(s.inputTypes))
+		}
 	}
-	var err error
-	if s.partitioner != nil {
-		err = s.partitioner.Close()
-		s.partitioner = nil
+	n := 0
+	for ; n < len(s.scratch.rows); s.it.Next() {
+		if ok, err := s.it.Valid(); err != nil {
+			return 0, err
+		} else if !ok {
+			break
+		}
+		row, err := s.it.Row()
+		if err != nil {
+			return 0, err
+		}
+		// The row is only valid until the iterator is moved, so we have to copy
+		// it.
+		copy(s.scratch.rows[n], row)
+		n++
 	}
-	if !s.testingKnobs.delegateFDAcquisitions && s.fdState.fdSemaphore != nil && s.fdState.acquiredFDs > 0 {
-		s.fdState.fdSemaphore.Release(s.fdState.acquiredFDs)
-		s.fdState.acquiredFDs = 0
+	// The rows reference the encoded values of the tuples, so their footprint
+	// changes with every read.
+	var memUsage int64
+	for _, r := range s.scratch.rows {
+		memUsage += int64(r.Size())
 	}
-	s.closed = true
-	return err
+	s.allocator.AdjustMemoryUsage(memUsage - s.scratch.rowsMemUsage)
+	s.scratch.rowsMemUsage = memUsage
+	return n, nil
 }
 
-// createMergerForPartitions creates an ordered synchronizer that will merge
-// partitions in [firstIdx, firstIdx+numPartitions) range.
-func (s *externalSorter) createMergerForPartitions(firstIdx, numPartitions int) Operator {
-	syncInputs := make([]Operator, numPartitions)
-	for i := range syncInputs {
-		syncInputs[i] = newPartitionerToOperator(
-			s.unlimitedAllocator, s.inputTypes, s.partitioner, firstIdx+i,
-		)
+func (s *externalSorter) Reset(ctx context.Context) {
+	if r, ok := s.input.(Resetter); ok {
+		r.Reset(ctx)
 	}
-	return NewOrderedSynchronizer(
-		s.unlimitedAllocator,
-		syncInputs,
-		s.inputTypes,
-		execinfrapb.ConvertToColumnOrdering(s.ordering),
-	)
-}
-
-func newInputPartitioningOperator(
-	ctx context.Context, input Operator, standaloneMemAccount *mon.BoundAccount, memoryLimit int64,
-) resettableOperator {
-	return &inputPartitioningOperator{
-		OneInputNode:         NewOneInputNode(input),
-		ctx:                  ctx,
-		standaloneMemAccount: standaloneMemAccount,
-		memoryLimit:          memoryLimit,
+	s.state = externalSorterSpooling
+	if err := s.Close(ctx); err != nil {
+		execerror.VectorizedInternalPanic(err)
 	}
+	s.closed = false
 }
 
-// inputPartitioningOperator is an operator that returns the batches from its
-// input until the standalone allocator reaches the memory limit. From that
-// point, the operator returns a zero-length batch (until it is reset).
-type inputPartitioningOperator struct {
-	OneInputNode
-	NonExplainable
-
-	ctx                  context.Context
-	standaloneMemAccount *mon.BoundAccount
-	memoryLimit          int64
-	// interceptReset determines whether the reset method will be called on
-	// the input to this operator when the latter is being reset. This field is
-	// managed by externalSorter.
-	// NOTE: this field itself is set to 'false' when inputPartitioningOperator
-	// is being reset, regardless of the original value.
-	//
-	// The reason for having this knob is that we need two kinds of behaviors
-	// when resetting the inputPartitioningOperator:
-	// 1. ("shallow" reset) we need to clear the memory account because the
-	// external sorter is moving on spilling the data into a new partition.
-	// However, we *cannot* propagate the reset further up because it might
-	// delete the data that the external sorter has not yet spilled. This
-	// behavior is needed in externalSorter when resetting the in-memory sorter
-	// when spilling the next "chunk" of data into the new partition.
-	// 2. ("deep" reset) we need to do the full reset of the whole chain of
-	// operators. This behavior is needed when the whole external sorter is
-	// being reset.
-	interceptReset bool
-}
-
-var _ resettableOperator = &inputPartitioningOperator{}
-
-func (o *inputPartitioningOperator) Init() {
-	o.input.Init()
-}
-
-func (o *inputPartitioningOperator) Next(ctx context.Context) coldata.Batch {
-	if o.standaloneMemAccount.Used() >= o.memoryLimit {
-		return coldata.ZeroBatch
-	}
-	b := o.input.Next(ctx)
-	if b.Length() == 0 {
-		return b
-	}
-	// We cannot use Allocator.RetainBatch here because that method looks at the
-	// capacities of the vectors. However, this operator is an input to sortOp
-	// which will spool all the tuples and buffer them (by appending into the
-	// buffered batch), so we need to account for memory proportionally to the
-	// length of the batch. (Note: this is not exactly true for Bytes type, but
-	// it's ok if we have some deviation. This numbers matter only to understand
-	// when to start a new partition, and the memory will be actually accounted
-	// for correctly.)
-	length := int64(b.Length())
-	usesSel := b.Selection() != nil
-	b.SetSelection(true)
-	selCapacity := cap(b.Selection())
-	b.SetSelection(usesSel)
-	batchMemSize := int64(0)
-	if selCapacity > 0 {
-		batchMemSize = selVectorSize(selCapacity) * length / int64(selCapacity)
+// Close is part of the Closer interface.
+func (s *externalSorter) Close(ctx context.Context) error {
+	if s.closed {
+		return nil
 	}
-	for _, vec := range b.ColVecs() {
-		if vec.Type() == coltypes.Bytes {
-			batchMemSize += int64(vec.Bytes().ProportionalSize(length))
-		} else {
-			batchMemSize += getVecMemoryFootprint(vec) * length / int64(vec.Capacity())
-		}
+	s.finishMerging()
+	if s.it != nil {
+		s.it.Close()
+		s.it = nil
 	}
-	if err := o.standaloneMemAccount.Grow(o.ctx, batchMemSize); err != nil {
-		execerror.VectorizedInternalPanic(err)
+	if s.rows != nil {
+		s.rows.Close(ctx)
+		s.rows = nil
 	}
-	return b
-}
-
-func (o *inputPartitioningOperator) reset() {
-	if !o.interceptReset {
-		if r, ok := o.input.(resetter); ok {
-			r.reset()
+	// The rows themselves are kept around to be reused after a reset, but we
+	// stop accounting for the values they reference.
+	for _, r := range s.scratch.rows {
+		for i := range r {
+			r[i] = sqlbase.EncDatum{}
 		}
 	}
-	o.interceptReset = false
-	o.standaloneMemAccount.Shrink(o.ctx, o.standaloneMemAccount.Used())
+	s.allocator.AdjustMemoryUsage(-s.scratch.rowsMemUsage)
+	s.scratch.rowsMemUsage = 0
+	s.closed = true
+	return nil
 }
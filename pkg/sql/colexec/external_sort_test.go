@@ -15,7 +15,9 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/diskmap"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
@@ -23,25 +25,44 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/testutils/colcontainerutils"
-	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
-	"github.com/cockroachdb/cockroach/pkg/util/randutil"
-	"github.com/marusama/semaphore"
 	"github.com/stretchr/testify/require"
 )
 
+// newTestingTempStorage returns a temporary storage engine and a disk monitor
+// that can be used to set up the disk-backed operators that spill to the
+// engine (like the external sorter) as well as a function that must be called
+// once the caller is done with them.
+func newTestingTempStorage(
+	t testing.TB, st *cluster.Settings,
+) (diskmap.Factory, *mon.BytesMonitor, func()) {
+	ctx := context.Background()
+	tempEngine, _, err := storage.NewPebbleTempEngine(ctx, base.DefaultTestTempStorageConfig(st), base.DefaultTestStoreSpec)
+	require.NoError(t, err)
+	diskMonitor := execinfra.NewTestDiskMonitor(ctx, st)
+	return tempEngine, diskMonitor, func() {
+		diskMonitor.Stop(ctx)
+		tempEngine.Close()
+	}
+}
+
 func TestExternalSort(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	ctx := context.Background()
 	st := cluster.MakeTestingClusterSettings()
 	evalCtx := tree.MakeTestingEvalContext(st)
 	defer evalCtx.Stop(ctx)
+	tempEngine, diskMonitor, cleanupTempStorage := newTestingTempStorage(t, st)
+	defer cleanupTempStorage()
 	flowCtx := &execinfra.FlowCtx{
 		EvalCtx: &evalCtx,
 		Cfg: &execinfra.ServerConfig{
-			Settings: st,
+			Settings:    st,
+			TempStorage: tempEngine,
+			DiskMonitor: diskMonitor,
 		},
 	}
 
@@ -53,61 +74,26 @@ func TestExternalSort(t *testing.T) {
 		memMonitors []*mon.BytesMonitor
 	)
 	// Test the case in which the default memory is used as well as the case in
-	// which the joiner spills to disk.
+	// which the sorter spills to disk.
 	for _, spillForced := range []bool{false, true} {
 		flowCtx.Cfg.TestingKnobs.ForceDiskSpill = spillForced
-		if spillForced {
-			// In order to increase test coverage of recursive merging, we have the
-			// lowest possible memory limit - this will force creating partitions
-			// consisting of a single batch.
-			flowCtx.Cfg.TestingKnobs.MemoryLimitBytes = 1
-		} else {
-			flowCtx.Cfg.TestingKnobs.MemoryLimitBytes = 0
-		}
 		for _, tcs := range [][]sortTestCase{sortAllTestCases, topKSortTestCases, sortChunksTestCases} {
 			for _, tc := range tcs {
 				t.Run(fmt.Sprintf("spillForced=%t/%s", spillForced, tc.description), func(t *testing.T) {
-					// Unfortunately, there is currently no better way to check that a
-					// sorter does not have leftover file descriptors other than appending
-					// each semaphore used to this slice on construction. This is because
-					// some tests don't fully drain the input, making intercepting the
-					// sorter.Close() method not a useful option, since it is impossible
-					// to check between an expected case where more than 0 FDs are open
-					// (e.g. in verifySelAndNullResets, where the sorter is not fully
-					// drained so Close must be called explicitly) and an unexpected one.
-					// These cases happen during normal execution when a limit is
-					// satisfied, but flows will call Close explicitly on Cleanup.
-					// TODO(asubiotto): Not implemented yet, currently we rely on the
-					//  flow tracking open FDs and releasing any leftovers.
-					var semsToCheck []semaphore.Semaphore
 					runTests(
 						t,
 						[]tuples{tc.tuples},
 						tc.expected,
 						orderedVerifier,
 						func(input []Operator) (Operator, error) {
-							// A sorter should never exceed externalSorterMinPartitions, even
-							// during repartitioning. A panic will happen if a sorter requests
-							// more than this number of file descriptors.
-							sem := NewTestingSemaphore(externalSorterMinPartitions)
-							// If a limit is satisfied before the sorter is drained of all its
-							// tuples, the sorter will not close its partitioner. During a
-							// flow this will happen in Cleanup, since there is no way to tell
-							// an operator that Next won't be called again.
-							if tc.k == 0 || int(tc.k) >= len(tc.tuples) {
-								semsToCheck = append(semsToCheck, sem)
-							}
 							sorter, accounts, monitors, err := createDiskBackedSorter(
-								ctx, flowCtx, input, tc.logTypes, tc.ordCols, tc.matchLen, tc.k, func() {},
-								externalSorterMinPartitions, false /* delegateFDAcquisition */, queueCfg, sem,
+								ctx, flowCtx, input, tc.logTypes, tc.ordCols, tc.matchLen, tc.k,
+								func() {}, queueCfg,
 							)
 							memAccounts = append(memAccounts, accounts...)
 							memMonitors = append(memMonitors, monitors...)
 							return sorter, err
 						})
-					for i, sem := range semsToCheck {
-						require.Equal(t, 0, sem.GetCount(), "sem still reports open FDs at index %d", i)
-					}
 				})
 			}
 		}
@@ -118,6 +104,9 @@ func TestExternalSort(t *testing.T) {
 	for _, monitor := range memMonitors {
 		monitor.Stop(ctx)
 	}
+	// Some of the test runs don't fully drain the sorter, so this also makes
+	// sure that closing the sorter releases the disk space.
+	require.Equal(t, int64(0), diskMonitor.AllocBytes())
 }
 
 func TestExternalSortRandomized(t *testing.T) {
@@ -126,13 +115,17 @@ func TestExternalSortRandomized(t *testing.T) {
 	st := cluster.MakeTestingClusterSettings()
 	evalCtx := tree.MakeTestingEvalContext(st)
 	defer evalCtx.Stop(ctx)
+	tempEngine, diskMonitor, cleanupTempStorage := newTestingTempStorage(t, st)
+	defer cleanupTempStorage()
 	flowCtx := &execinfra.FlowCtx{
 		EvalCtx: &evalCtx,
 		Cfg: &execinfra.ServerConfig{
-			Settings: st,
+			Settings:    st,
+			TempStorage: tempEngine,
+			DiskMonitor: diskMonitor,
 		},
 	}
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(t)
 	nTups := coldata.BatchSize()*4 + 1
 	maxCols := 2
 	// TODO(yuzefovich): randomize types as well.
@@ -148,45 +141,20 @@ func TestExternalSortRandomized(t *testing.T) {
 		memAccounts []*mon.BoundAccount
 		memMonitors []*mon.BytesMonitor
 	)
-	// Interesting disk spilling scenarios:
-	// 1) The sorter is forced to spill to disk as soon as possible.
-	// 2) The memory limit is dynamically set to repartition twice, this will also
-	//    allow the in-memory sorter to spool several batches before hitting the
-	//    memory limit.
 	colTyps, err := typeconv.FromColumnTypes(logTypes)
 	require.NoError(t, err)
-	// memoryToSort is the total amount of memory that will be sorted in this
-	// test.
-	memoryToSort := (nTups / coldata.BatchSize()) * estimateBatchSizeBytes(colTyps, coldata.BatchSize())
-	// partitionSize will be the memory limit passed in to tests with a memory
-	// limit. With a maximum number of partitions of 2 this will result in
-	// repartitioning twice. To make this a total amount of memory, we also need
-	// to add the cache sizes of the queues.
-	partitionSize := int64(memoryToSort/4) + int64(externalSorterMinPartitions*queueCfg.BufferSizeBytes)
-	for _, tk := range []execinfra.TestingKnobs{{ForceDiskSpill: true}, {MemoryLimitBytes: partitionSize}} {
+	// Interesting disk spilling scenarios:
+	// 1) The sorter is forced to spill to disk as soon as possible.
+	// 2) The memory limit allows the in-memory sorter to spool a couple of
+	//    batches before hitting the limit.
+	memoryLimit := int64(2 * estimateBatchSizeBytes(colTyps, coldata.BatchSize()))
+	for _, tk := range []execinfra.TestingKnobs{{ForceDiskSpill: true}, {MemoryLimitBytes: memoryLimit}} {
 		flowCtx.Cfg.TestingKnobs = tk
 		for nCols := 1; nCols <= maxCols; nCols++ {
 			for nOrderingCols := 1; nOrderingCols <= nCols; nOrderingCols++ {
-				namePrefix := "MemoryLimit=" + humanizeutil.IBytes(tk.MemoryLimitBytes)
-				if tk.ForceDiskSpill {
-					namePrefix = "ForceDiskSpill=true"
-				}
-				delegateFDAcquisition := rng.Float64() < 0.5
-				name := fmt.Sprintf("%s/nCols=%d/nOrderingCols=%d/delegateFDAcquisition=%t", namePrefix, nCols, nOrderingCols, delegateFDAcquisition)
+				name := fmt.Sprintf("ForceDiskSpill=%t/nCols=%d/nOrderingCols=%d", tk.ForceDiskSpill, nCols, nOrderingCols)
 				t.Run(name, func(t *testing.T) {
-					// Unfortunately, there is currently no better way to check that a
-					// sorter does not have leftover file descriptors other than appending
-					// each semaphore used to this slice on construction. This is because
-					// some tests don't fully drain the input, making intercepting the
-					// sorter.Close() method not a useful option, since it is impossible
-					// to check between an expected case where more than 0 FDs are open
-					// (e.g. in verifySelAndNullResets, where the sorter is not fully
-					// drained so Close must be called explicitly) and an unexpected one.
-					// These cases happen during normal execution when a limit is
-					// satisfied, but flows will call Close explicitly on Cleanup.
-					// TODO(asubiotto): Not implemented yet, currently we rely on the
-					//  flow tracking open FDs and releasing any leftovers.
-					var semsToCheck []semaphore.Semaphore
+					spilled := false
 					tups, expected, ordCols := generateRandomDataForTestSort(rng, nTups, nCols, nOrderingCols)
 					runTests(
 						t,
@@ -194,19 +162,15 @@ func TestExternalSortRandomized(t *testing.T) {
 						expected,
 						orderedVerifier,
 						func(input []Operator) (Operator, error) {
-							sem := NewTestingSemaphore(externalSorterMinPartitions)
-							semsToCheck = append(semsToCheck, sem)
 							sorter, accounts, monitors, err := createDiskBackedSorter(
 								ctx, flowCtx, input, logTypes[:nCols], ordCols,
-								0 /* matchLen */, 0 /* k */, func() {},
-								externalSorterMinPartitions, delegateFDAcquisition, queueCfg, sem)
+								0 /* matchLen */, 0 /* k */, func() { spilled = true }, queueCfg,
+							)
 							memAccounts = append(memAccounts, accounts...)
 							memMonitors = append(memMonitors, monitors...)
 							return sorter, err
 						})
-					for i, sem := range semsToCheck {
-						require.Equal(t, 0, sem.GetCount(), "sem still reports open FDs at index %d", i)
-					}
+					require.True(t, spilled)
 				})
 			}
 		}
@@ -217,6 +181,7 @@ func TestExternalSortRandomized(t *testing.T) {
 	for _, monitor := range memMonitors {
 		monitor.Stop(ctx)
 	}
+	require.Equal(t, int64(0), diskMonitor.AllocBytes())
 }
 
 func BenchmarkExternalSort(b *testing.B) {
@@ -225,13 +190,17 @@ func BenchmarkExternalSort(b *testing.B) {
 	st := cluster.MakeTestingClusterSettings()
 	evalCtx := tree.MakeTestingEvalContext(st)
 	defer evalCtx.Stop(ctx)
+	tempEngine, diskMonitor, cleanupTempStorage := newTestingTempStorage(b, st)
+	defer cleanupTempStorage()
 	flowCtx := &execinfra.FlowCtx{
 		EvalCtx: &evalCtx,
 		Cfg: &execinfra.ServerConfig{
-			Settings: st,
+			Settings:    st,
+			TempStorage: tempEngine,
+			DiskMonitor: diskMonitor,
 		},
 	}
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(b)
 	var (
 		memAccounts []*mon.BoundAccount
 		memMonitors []*mon.BytesMonitor
@@ -270,13 +239,9 @@ func BenchmarkExternalSort(b *testing.B) {
 					for n := 0; n < b.N; n++ {
 						source := newFiniteBatchSource(batch, nBatches)
 						var spilled bool
-						// TODO(yuzefovich): do not specify maxNumberPartitions (let the
-						// external sorter figure out that number itself) once we pass in
-						// filled-in disk queue config.
 						sorter, accounts, monitors, err := createDiskBackedSorter(
 							ctx, flowCtx, []Operator{source}, logTypes, ordCols,
-							0 /* matchLen */, 0 /* k */, func() { spilled = true },
-							64 /* maxNumberPartitions */, false /* delegateFDAcquisitions */, queueCfg, &TestingSemaphore{},
+							0 /* matchLen */, 0 /* k */, func() { spilled = true }, queueCfg,
 						)
 						memAccounts = append(memAccounts, accounts...)
 						memMonitors = append(memMonitors, monitors...)
@@ -304,7 +269,8 @@ func BenchmarkExternalSort(b *testing.B) {
 
 // createDiskBackedSorter is a helper function that instantiates a disk-backed
 // sort operator. The desired memory limit must have been already set on
-// flowCtx. It returns an operator and an error as well as memory monitors and
+// flowCtx, and the sorter spills only if flowCtx has the temporary storage
+// set up. It returns an operator and an error as well as memory monitors and
 // memory accounts that will need to be closed once the caller is done with the
 // operator.
 func createDiskBackedSorter(
@@ -316,10 +282,7 @@ func createDiskBackedSorter(
 	matchLen int,
 	k uint16,
 	spillingCallbackFn func(),
-	maxNumberPartitions int,
-	delegateFDAcquisitions bool,
 	diskQueueCfg colcontainer.DiskQueueCfg,
-	testingSemaphore semaphore.Semaphore,
 ) (Operator, []*mon.BoundAccount, []*mon.BytesMonitor, error) {
 	sorterSpec := &execinfrapb.SorterSpec{
 		OutputOrdering:   execinfrapb.Ordering{Columns: ordCols},
@@ -339,14 +302,8 @@ func createDiskBackedSorter(
 		Inputs:              input,
 		StreamingMemAccount: testMemAcc,
 		DiskQueueCfg:        diskQueueCfg,
-		FDSemaphore:         testingSemaphore,
 	}
-	// External sorter relies on different memory accounts to
-	// understand when to start a new partition, so we will not use
-	// the streaming memory account.
 	args.TestingKnobs.SpillingCallbackFn = spillingCallbackFn
-	args.TestingKnobs.NumForcedRepartitions = maxNumberPartitions
-	args.TestingKnobs.DelegateFDAcquisitions = delegateFDAcquisitions
 	result, err := NewColOperator(ctx, flowCtx, args)
 	return result.Op, result.BufferingOpMemAccounts, result.BufferingOpMemMonitors, err
 }
@@ -0,0 +1,53 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// flowProgressReporter is implemented by Operators that report the progress
+// of the flow they are a part of (for example, the number of rows read or
+// whether they had to spill to disk). Such Operators also cooperate when the
+// flow is paused by calling waitIfPaused before producing a batch.
+type flowProgressReporter interface {
+	setFlowProgress(*execinfra.FlowProgress)
+}
+
+// waitIfPaused blocks while the flow tracked by progress (if set) is paused.
+// It panics with a query canceled error if ctx is canceled while waiting.
+func waitIfPaused(ctx context.Context, progress *execinfra.FlowProgress) {
+	if progress == nil {
+		return
+	}
+	if err := progress.WaitIfPaused(ctx); err != nil {
+		execerror.NonVectorizedPanic(sqlbase.QueryCanceledError)
+	}
+}
+
+// attachFlowProgress attaches progress to all Operators in the tree rooted at
+// root that report the progress of the flow. Setting the progress is
+// idempotent, so it is ok for the tree to contain Operators that have already
+// been visited.
+func attachFlowProgress(root execinfra.OpNode, progress *execinfra.FlowProgress) {
+	if r, ok := root.(flowProgressReporter); ok {
+		r.setFlowProgress(progress)
+	}
+	// We use verbose traversal in order to reach all internal Operators.
+	const verbose = true
+	for i := 0; i < root.ChildCount(verbose); i++ {
+		attachFlowProgress(root.Child(i, verbose), progress)
+	}
+}
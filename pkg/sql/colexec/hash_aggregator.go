@@ -149,6 +149,7 @@ type hashAggregator struct {
 }
 
 var _ Operator = &hashAggregator{}
+var _ MemoryLimitReporter = &hashAggregator{}
 
 // NewHashAggregator creates a hash aggregator on the given grouping columns.
 // The input specifications to this function are the same as that of the
@@ -239,6 +240,13 @@ func NewHashAggregator(
 	}, nil
 }
 
+// MemoryLimit implements the MemoryLimitReporter interface. The hash
+// aggregator is only bounded by its parent memory monitor and doesn't spill to
+// disk.
+func (op *hashAggregator) MemoryLimit() (int64, bool) {
+	return 0, false
+}
+
 func (op *hashAggregator) Init() {
 	op.input.Init()
 	op.output.Batch = op.allocator.NewMemBatch(op.outputTypes)
@@ -500,9 +508,9 @@ func (op *hashAggregator) onlineAgg() {
 
 // reset resets the hashAggregator for another run. Primarily used for
 // benchmarks.
-func (op *hashAggregator) reset() {
-	if r, ok := op.input.(resetter); ok {
-		r.reset()
+func (op *hashAggregator) Reset(ctx context.Context) {
+	if r, ok := op.input.(Resetter); ok {
+		r.Reset(ctx)
 	}
 
 	op.aggFuncMap = hashAggFuncMap{}
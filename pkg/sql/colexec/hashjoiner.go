@@ -168,6 +168,11 @@ type hashJoiner struct {
 	// outputBatchSize specifies the desired length of the output batch which by
 	// default is coldata.BatchSize() but can be varied in tests.
 	outputBatchSize int
+	// materializationHook, if set, is notified once the hash table has been
+	// built.
+	materializationHook *materializationHook
+	// bufferingDoneCb, if set, is called once the hash table has been built.
+	bufferingDoneCb func()
 
 	// probeState is used in hjProbing state.
 	probeState struct {
@@ -212,7 +217,25 @@ type hashJoiner struct {
 }
 
 var _ bufferingInMemoryOperator = &hashJoiner{}
-var _ resetter = &hashJoiner{}
+var _ bufferingDoneNotifier = &hashJoiner{}
+var _ bufferReleaser = &hashJoiner{}
+var _ Resetter = &hashJoiner{}
+var _ materializationReporter = &hashJoiner{}
+
+func (hj *hashJoiner) setBufferingDoneCb(cb func()) {
+	hj.bufferingDoneCb = cb
+}
+
+func (hj *hashJoiner) releaseBuffered() {
+	hj.ht.release()
+	hj.exportBufferedState.rightWindowedBatch = hj.allocator.NewMemBatchWithSize(hj.spec.right.sourceTypes, 0 /* size */)
+}
+
+func (hj *hashJoiner) setMaterializationHook(hook *materializationHook) {
+	if hj.materializationHook == nil {
+		hj.materializationHook = hook
+	}
+}
 
 func (hj *hashJoiner) Init() {
 	hj.inputOne.Init()
@@ -259,6 +282,8 @@ func (hj *hashJoiner) Next(ctx context.Context) coldata.Batch {
 
 func (hj *hashJoiner) build(ctx context.Context) {
 	hj.ht.build(ctx, hj.inputTwo)
+	// The hash table is built from the right input of the processor.
+	hj.materializationHook.reportMaterialized(ctx, "hash join build", 1 /* inputIdx */, hj.ht.vals.Length())
 
 	if !hj.spec.rightDistinct {
 		hj.ht.maybeAllocateSameAndVisited()
@@ -274,6 +299,9 @@ func (hj *hashJoiner) build(ctx context.Context) {
 		}
 	}
 
+	if hj.bufferingDoneCb != nil {
+		hj.bufferingDoneCb()
+	}
 	hj.state = hjProbing
 }
 
@@ -410,7 +438,7 @@ func (hj *hashJoiner) exec(ctx context.Context) {
 					// buckets. If the key is found or end of next chain is reached, the key is
 					// removed from the toCheck array.
 					nToCheck = hj.ht.distinctCheck(hj.probeState.keyTypes, nToCheck, sel)
-					hj.ht.findNext(hj.ht.buildScratch.next, nToCheck)
+					hj.ht.findNext(ctx, hj.ht.buildScratch.next, nToCheck)
 				}
 
 				nResults = hj.distinctCollect(batch, batchSize, sel)
@@ -419,7 +447,7 @@ func (hj *hashJoiner) exec(ctx context.Context) {
 					// Continue searching for the build table matching keys while the toCheck
 					// array is non-empty.
 					nToCheck = hj.ht.check(hj.ht.probeScratch.keys, hj.probeState.keyTypes, hj.ht.keyCols, nToCheck, sel)
-					hj.ht.findNext(hj.ht.buildScratch.next, nToCheck)
+					hj.ht.findNext(ctx, hj.ht.buildScratch.next, nToCheck)
 				}
 
 				// We're processing a new batch, so we'll reset the index to start
@@ -570,14 +598,14 @@ func (hj *hashJoiner) resetOutput() {
 	}
 }
 
-func (hj *hashJoiner) reset() {
+func (hj *hashJoiner) Reset(ctx context.Context) {
 	for _, input := range []Operator{hj.inputOne, hj.inputTwo} {
-		if r, ok := input.(resetter); ok {
-			r.reset()
+		if r, ok := input.(Resetter); ok {
+			r.Reset(ctx)
 		}
 	}
 	hj.state = hjBuilding
-	hj.ht.reset()
+	hj.ht.Reset()
 	copy(hj.probeState.buildIdx[:coldata.BatchSize()], zeroIntColumn)
 	copy(hj.probeState.probeIdx[:coldata.BatchSize()], zeroIntColumn)
 	if hj.spec.left.outer {
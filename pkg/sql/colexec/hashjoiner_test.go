@@ -986,7 +986,11 @@ func TestHashJoiner(t *testing.T) {
 					if err != nil {
 						return nil, err
 					}
-					if hj, ok := result.Op.(*hashJoiner); ok {
+					op := result.Op
+					if c, ok := op.(*cardinalityCounterOp); ok {
+						op = c.input
+					}
+					if hj, ok := op.(*hashJoiner); ok {
 						hj.outputBatchSize = outputBatchSize
 					}
 					return result.Op, nil
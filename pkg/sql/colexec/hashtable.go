@@ -152,7 +152,7 @@ type hashTable struct {
 	mode hashTableMode
 }
 
-var _ resetter = &hashTable{}
+var _ Resetter = &hashTable{}
 
 func newHashTable(
 	allocator *Allocator,
@@ -217,6 +217,7 @@ func (ht *hashTable) build(ctx context.Context, input Operator) {
 				break
 			}
 
+			ht.cancelChecker.checkWork(ctx, batch.Length())
 			ht.loadBatch(batch)
 		}
 
@@ -236,6 +237,7 @@ func (ht *hashTable) build(ctx context.Context, input Operator) {
 				break
 			}
 
+			ht.cancelChecker.checkWork(ctx, batch.Length())
 			srcVecs := batch.ColVecs()
 			targetVecs := ht.vals.ColVecs()
 
@@ -248,13 +250,17 @@ func (ht *hashTable) build(ctx context.Context, input Operator) {
 			copy(ht.probeScratch.hashBuffer, ht.probeScratch.next[1:])
 			ht.buildNextChains(ctx, ht.probeScratch.first, ht.probeScratch.next, 1, batch.Length())
 
-			ht.removeDuplicates(batch, ht.probeScratch.keys, ht.probeScratch.first, ht.probeScratch.next, ht.checkProbeForDistinct)
+			ht.removeDuplicates(ctx, batch, ht.probeScratch.keys, ht.probeScratch.first, ht.probeScratch.next, ht.checkProbeForDistinct)
 
 			// We only check duplicates when there is tuple buffered.
 			if ht.vals.Length() > 0 {
-				ht.removeDuplicates(batch, ht.probeScratch.keys, ht.buildScratch.first, ht.buildScratch.next, ht.checkBuildForDistinct)
+				ht.removeDuplicates(ctx, batch, ht.probeScratch.keys, ht.buildScratch.first, ht.buildScratch.next, ht.checkBuildForDistinct)
 			}
 
+			numBuffered := ht.vals.Length()
+			// The length of the buffered tuples is updated within the operation
+			// so that the appended tuples are not lost if the memory limit is
+			// reached (the distinct tuples can then be exported).
 			ht.allocator.PerformOperation(targetVecs, func() {
 				for i, typ := range ht.valTypes {
 					targetVecs[i].Append(
@@ -262,16 +268,16 @@ func (ht *hashTable) build(ctx context.Context, input Operator) {
 							ColType:   typ,
 							Src:       srcVecs[i],
 							Sel:       batch.Selection(),
-							DestIdx:   ht.vals.Length(),
+							DestIdx:   numBuffered,
 							SrcEndIdx: batch.Length(),
 						},
 					)
 				}
+				ht.vals.SetLength(numBuffered + batch.Length())
 			})
 
 			ht.buildScratch.next = append(ht.buildScratch.next, ht.probeScratch.hashBuffer[:batch.Length()]...)
-			ht.buildNextChains(ctx, ht.buildScratch.first, ht.buildScratch.next, ht.vals.Length()+1, batch.Length())
-			ht.vals.SetLength(ht.vals.Length() + batch.Length())
+			ht.buildNextChains(ctx, ht.buildScratch.first, ht.buildScratch.next, numBuffered+1, batch.Length())
 		}
 	default:
 		execerror.VectorizedInternalPanic(fmt.Sprintf("hashTable in unhandled state"))
@@ -289,6 +295,7 @@ func (ht *hashTable) build(ctx context.Context, input Operator) {
 // vector.
 // NOTE: *first* and *next* vectors should be properly populated.
 func (ht *hashTable) removeDuplicates(
+	ctx context.Context,
 	batch coldata.Batch,
 	keyCols []coldata.Vec,
 	first, next []uint64,
@@ -306,7 +313,7 @@ func (ht *hashTable) removeDuplicates(
 		// Continue searching for the build table matching keys while the toCheck
 		// array is non-empty.
 		nToCheck = duplicatesChecker(keyCols, nToCheck, sel)
-		ht.findNext(next, nToCheck)
+		ht.findNext(ctx, next, nToCheck)
 	}
 
 	ht.updateSel(batch)
@@ -447,22 +454,35 @@ func (ht *hashTable) lookupInitial(
 }
 
 // findNext determines the id of the next key inside the groupID buckets for
-// each equality column key in toCheck.
-func (ht *hashTable) findNext(next []uint64, nToCheck uint64) {
+// each equality column key in toCheck. It is called on every iteration of the
+// collision checking loops, which might take many iterations if the hash
+// chains are long, so it also checks for cancellation.
+func (ht *hashTable) findNext(ctx context.Context, next []uint64, nToCheck uint64) {
+	ht.cancelChecker.checkWork(ctx, int(nToCheck))
 	for i := uint64(0); i < nToCheck; i++ {
 		ht.probeScratch.groupID[ht.probeScratch.toCheck[i]] =
 			next[ht.probeScratch.groupID[ht.probeScratch.toCheck[i]]]
 	}
 }
 
-// reset resets the hashTable for reuse.
+// release drops the references to the buffered tuples and to the slices
+// sized by their number. The hash table can be used again after it has been
+// reset.
+func (ht *hashTable) release() {
+	ht.vals = ht.allocator.NewMemBatchWithSize(ht.valTypes, 0 /* initialSize */)
+	ht.buildScratch.next = nil
+	ht.same = nil
+	ht.visited = nil
+}
+
+// Reset resets the hashTable for reuse.
 // NOTE: memory that already has been allocated for ht.vals is *not* released.
 // However, resetting the length of ht.vals to zero doesn't confuse the
 // allocator - it is smart enough to look at the capacities of the allocated
 // vectors, and the capacities would stay the same until an actual new
 // allocation is needed, and at that time the allocator will update the memory
 // account accordingly.
-func (ht *hashTable) reset() {
+func (ht *hashTable) Reset() {
 	for n := 0; n < len(ht.buildScratch.first); n += copy(ht.buildScratch.first[n:], zeroUint64Column) {
 	}
 	ht.vals.ResetInternalBatch()
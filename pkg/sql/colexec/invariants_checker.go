@@ -16,18 +16,33 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/errors"
 )
 
 // invariantsChecker is a helper Operator that will check that invariants that
 // are present in the vectorized engine are maintained on all batches. It
 // should be planned between other Operators in tests.
+//
+// Apart from the invariants of the batches, it also checks that the consumer
+// of the input respects the lifecycle of an Operator:
+// - Init is called before Next,
+// - Next is not called once a zero-length batch has been returned (unless the
+//   input has been reset since),
+// - Close is called at most once (see AssertClosedExactlyOnce for verifying
+//   that it has been called at all).
 type invariantsChecker struct {
 	OneInputNode
 
 	expectedBatchWidth int
+
+	initialized bool
+	exhausted   bool
+	numCloses   int
 }
 
-var _ Operator = invariantsChecker{}
+var _ ResettableOperator = &invariantsChecker{}
+var _ Closer = &invariantsChecker{}
 
 // NewInvariantsChecker creates a new invariantsChecker.
 func NewInvariantsChecker(input Operator, expectedBatchWidth int) Operator {
@@ -37,14 +52,24 @@ func NewInvariantsChecker(input Operator, expectedBatchWidth int) Operator {
 	}
 }
 
-func (i invariantsChecker) Init() {
+func (i *invariantsChecker) Init() {
+	i.initialized = true
 	i.input.Init()
 }
 
-func (i invariantsChecker) Next(ctx context.Context) coldata.Batch {
+func (i *invariantsChecker) Next(ctx context.Context) coldata.Batch {
+	if !i.initialized {
+		panic(fmt.Sprintf("Next is called on %s before Init", OperatorName(i.input)))
+	}
+	if i.exhausted {
+		panic(fmt.Sprintf(
+			"Next is called on %s after it has returned a zero-length batch", OperatorName(i.input),
+		))
+	}
 	b := i.input.Next(ctx)
 	n := b.Length()
 	if n == 0 {
+		i.exhausted = true
 		return b
 	}
 	if i.expectedBatchWidth != b.Width() {
@@ -61,3 +86,44 @@ func (i invariantsChecker) Next(ctx context.Context) coldata.Batch {
 	}
 	return b
 }
+
+func (i *invariantsChecker) Reset(ctx context.Context) {
+	if r, ok := i.input.(Resetter); ok {
+		r.Reset(ctx)
+	}
+	i.exhausted = false
+}
+
+// Close is part of the Closer interface. The call is propagated to the input
+// if it is a Closer. An error is returned if Close has already been called.
+func (i *invariantsChecker) Close(ctx context.Context) error {
+	i.numCloses++
+	if i.numCloses > 1 {
+		return errors.AssertionFailedf("%s is closed %d times", OperatorName(i.input), i.numCloses)
+	}
+	if c, ok := i.input.(Closer); ok {
+		return c.Close(ctx)
+	}
+	return nil
+}
+
+// AssertClosedExactlyOnce walks the tree of operators rooted at root and
+// returns an error if any of the invariantsCheckers in it wraps a Closer that
+// has not been closed exactly once. It should be called after the flow that
+// root belongs to has been cleaned up.
+func AssertClosedExactlyOnce(root execinfra.OpNode) error {
+	if i, ok := root.(*invariantsChecker); ok {
+		if _, isCloser := i.input.(Closer); isCloser && i.numCloses != 1 {
+			return errors.AssertionFailedf(
+				"%s is expected to be closed exactly once, but it was closed %d times",
+				OperatorName(i.input), i.numCloses,
+			)
+		}
+	}
+	for nth := 0; nth < root.ChildCount(true /* verbose */); nth++ {
+		if err := AssertClosedExactlyOnce(root.Child(nth, true /* verbose */)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
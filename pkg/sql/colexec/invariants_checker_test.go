@@ -0,0 +1,164 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils/colcontainerutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+// closerTestOp is a Closer that passes through the batches of its input.
+type closerTestOp struct {
+	noopOperator
+}
+
+func (c *closerTestOp) Close(context.Context) error {
+	return nil
+}
+
+func TestInvariantsCheckerLifecycle(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	tups := tuples{{1}, {2}}
+	typs := []coltypes.T{coltypes.Int64}
+
+	t.Run("NextBeforeInit", func(t *testing.T) {
+		checker := NewInvariantsChecker(newOpTestInput(1 /* batchSize */, tups, typs), len(typs))
+		require.Panics(t, func() { checker.Next(ctx) })
+	})
+
+	t.Run("NextAfterZeroBatch", func(t *testing.T) {
+		input := newOpTestInput(1 /* batchSize */, tups, typs)
+		checker := NewInvariantsChecker(input, len(typs))
+		checker.Init()
+		for b := checker.Next(ctx); b.Length() > 0; b = checker.Next(ctx) {
+		}
+		require.Panics(t, func() { checker.Next(ctx) })
+		// Once reset, the checker should allow for the input to be consumed
+		// again.
+		checker.(Resetter).Reset(ctx)
+		require.NotPanics(t, func() {
+			for b := checker.Next(ctx); b.Length() > 0; b = checker.Next(ctx) {
+			}
+		})
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		closer := &closerTestOp{noopOperator: noopOperator{OneInputNode: NewOneInputNode(
+			newOpTestInput(1 /* batchSize */, tups, typs),
+		)}}
+		root := NewInvariantsChecker(NewInvariantsChecker(closer, len(typs)), len(typs))
+		require.Error(t, AssertClosedExactlyOnce(root))
+		require.NoError(t, root.(Closer).Close(ctx))
+		require.NoError(t, AssertClosedExactlyOnce(root))
+		require.Error(t, root.(Closer).Close(ctx))
+		require.Error(t, AssertClosedExactlyOnce(root))
+	})
+}
+
+// TestInvariantsCheckerDiskSpiller verifies that the operators in the chain of
+// a disk spiller (the in-memory operator, the buffer-exporting operator, and
+// the disk-backed operator) respect the lifecycle of their inputs regardless
+// of when the spilling to disk occurs.
+func TestInvariantsCheckerDiskSpiller(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	tempEngine, diskMonitor, cleanupTempStorage := newTestingTempStorage(t, st)
+	defer cleanupTempStorage()
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings:    st,
+			TempStorage: tempEngine,
+			DiskMonitor: diskMonitor,
+		},
+	}
+
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	intCols := []types.T{*types.Int}
+	typs := []coltypes.T{coltypes.Int64}
+	tups := tuples{{3}, {1}, {nil}, {2}, {1}}
+	for _, tc := range []struct {
+		name   string
+		core   execinfrapb.ProcessorCoreUnion
+		inputs []execinfrapb.InputSyncSpec
+	}{
+		{
+			name: "sort",
+			core: execinfrapb.ProcessorCoreUnion{
+				Sorter: &execinfrapb.SorterSpec{
+					OutputOrdering: execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}},
+				},
+			},
+			inputs: []execinfrapb.InputSyncSpec{{ColumnTypes: intCols}},
+		},
+		{
+			name: "hash-join",
+			core: execinfrapb.ProcessorCoreUnion{
+				HashJoiner: &execinfrapb.HashJoinerSpec{
+					LeftEqColumns:  []uint32{0},
+					RightEqColumns: []uint32{0},
+				},
+			},
+			inputs: []execinfrapb.InputSyncSpec{{ColumnTypes: intCols}, {ColumnTypes: intCols}},
+		},
+	} {
+		// Zero means that the spilling is forced immediately.
+		for _, spillAfterNumTuples := range []int{0, 1, 3, len(tups) + 1} {
+			t.Run(fmt.Sprintf("%s/spillAfter=%d", tc.name, spillAfterNumTuples), func(t *testing.T) {
+				flowCtx.Cfg.TestingKnobs.ForceDiskSpill = spillAfterNumTuples == 0
+				flowCtx.Cfg.TestingKnobs.ForceDiskSpillAfterNumTuples = spillAfterNumTuples
+				sem := NewTestingSemaphore(256)
+				tracker := newResourceTracker(t, queueCfg, sem)
+				inputs := make([]Operator, len(tc.inputs))
+				for i := range inputs {
+					inputs[i] = NewInvariantsChecker(newOpTestInput(1 /* batchSize */, tups, typs), len(typs))
+				}
+				args := NewColOperatorArgs{
+					Spec: &execinfrapb.ProcessorSpec{
+						Input: tc.inputs,
+						Core:  tc.core,
+					},
+					Inputs:              inputs,
+					StreamingMemAccount: testMemAcc,
+					DiskQueueCfg:        tracker.diskQueueCfg,
+					FDSemaphore:         sem,
+				}
+				result, err := NewColOperator(ctx, flowCtx, args)
+				tracker.trackMemory(result.BufferingOpMemAccounts, result.BufferingOpMemMonitors)
+				require.NoError(t, err)
+
+				root := NewInvariantsChecker(result.Op, len(result.ColumnTypes))
+				root.Init()
+				for b := root.Next(ctx); b.Length() > 0; b = root.Next(ctx) {
+				}
+				tracker.closeAndVerify(ctx, root)
+				require.NoError(t, AssertClosedExactlyOnce(root))
+			})
+		}
+	}
+}
@@ -98,7 +98,7 @@ func TestLikeOperators(t *testing.T) {
 }
 
 func BenchmarkLikeOps(b *testing.B) {
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(b)
 	ctx := context.Background()
 
 	batch := testAllocator.NewMemBatch([]coltypes.T{coltypes.Bytes})
@@ -12,7 +12,6 @@ package colexec
 
 import (
 	"context"
-	"io"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 )
@@ -31,6 +30,7 @@ type limitOp struct {
 }
 
 var _ Operator = &limitOp{}
+var _ Closer = &limitOp{}
 
 // NewLimitOp returns a new limit operator with the given limit.
 func NewLimitOp(input Operator, limit int) Operator {
@@ -64,17 +64,12 @@ func (c *limitOp) Next(ctx context.Context) coldata.Batch {
 	return bat
 }
 
-// Close is a temporary method to support the specific case in which an upstream
-// operator must be Closed (e.g. an external sorter) to assert a certain state
-// during tests.
-// TODO(asubiotto): This method only exists because an external sorter is
-//  wrapped with a limit op when doing a top K sort and some tests that don't
-//  exhaust the sorter (e.g. allNullsInjection) need to close the operator
-//  explicitly. This should be removed once we have a better way of closing
-//  operators even when they are not exhausted.
-func (c *limitOp) Close() error {
-	if closer, ok := c.input.(io.Closer); ok {
-		return closer.Close()
+// Close is part of the Closer interface. The call is propagated to the input,
+// which allows for closing an external sorter that is wrapped with a limit op
+// when doing a top K sort in the tests that don't use the Closers of the flow.
+func (c *limitOp) Close(ctx context.Context) error {
+	if closer, ok := c.input.(Closer); ok {
+		return closer.Close(ctx)
 	}
 	return nil
 }
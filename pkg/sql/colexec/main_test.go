@@ -13,6 +13,8 @@ package colexec
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
+	"math/rand"
 	"os"
 	"testing"
 
@@ -34,10 +36,17 @@ var (
 	// and a memory account bound to it for use in tests.
 	testMemMonitor *mon.BytesMonitor
 	testMemAcc     *mon.BoundAccount
+
+	// testSeed is the seed from which all randomness of the tests in this
+	// package is derived (see newRandForTest). It is drawn from the global
+	// random number generator seeded by randutil.SeedForTests, so a failure
+	// can be reproduced by setting COCKROACH_RANDOM_SEED to the logged seed.
+	testSeed int64
 )
 
 func TestMain(m *testing.M) {
 	randutil.SeedForTests()
+	testSeed = rand.Int63()
 	os.Exit(func() int {
 		ctx := context.Background()
 		testMemMonitor = execinfra.NewTestMemMonitor(ctx, cluster.MakeTestingClusterSettings())
@@ -58,10 +67,22 @@ func TestMain(m *testing.M) {
 func generateBatchSize() int {
 	randomizeBatchSize := envutil.EnvOrDefaultBool("COCKROACH_RANDOMIZE_BATCH_SIZE", true)
 	if randomizeBatchSize {
-		rng, _ := randutil.NewPseudoRand()
+		rng := rand.New(rand.NewSource(testSeed))
 		batchSize := coldata.MinBatchSize +
 			rng.Intn(coldata.MaxBatchSize-coldata.MinBatchSize)
 		return batchSize
 	}
 	return coldata.BatchSize()
 }
+
+// newRandForTest returns a random number generator for tb along with its seed.
+// The seed is derived from testSeed and the name of tb, so all of the random
+// choices made by a test (the contents of the batches, the selection vectors,
+// the injected spill points, etc) can be reproduced by rerunning only that
+// test with the same COCKROACH_RANDOM_SEED.
+func newRandForTest(tb testing.TB) (*rand.Rand, int64) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(tb.Name()))
+	seed := testSeed ^ int64(h.Sum64())
+	return rand.New(rand.NewSource(seed)), seed
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+)
+
+// materializationHook reports the materialization points reached by the
+// operators planned for a single processor to the MaterializationObserver of
+// the flow.
+type materializationHook struct {
+	observer    execinfra.MaterializationObserver
+	processorID int32
+	// inputEstimatedRowCounts contains the optimizer's estimates of the inputs
+	// of the processor (zero if unknown).
+	inputEstimatedRowCounts []uint64
+}
+
+// materializationReporter is implemented by the in-memory Operators that fully
+// materialize their input (or one of their inputs) before producing any
+// output, at which point they report the number of materialized tuples.
+type materializationReporter interface {
+	// setMaterializationHook sets the hook if it hasn't been set yet.
+	setMaterializationHook(*materializationHook)
+}
+
+// attachMaterializationHook attaches hook to all Operators in the tree rooted
+// at root that report their materialization points and don't have a hook yet.
+// Since the processors are planned after their inputs, the Operators of the
+// inputs keep the hooks of their own processors.
+//
+// The disk-backed Operators are skipped since they only materialize parts of
+// their input at a time (for example, the partitions of an external sort).
+func attachMaterializationHook(root execinfra.OpNode, hook *materializationHook) {
+	if r, ok := root.(materializationReporter); ok {
+		r.setMaterializationHook(hook)
+	}
+	if d, ok := root.(*diskSpillerBase); ok {
+		attachMaterializationHook(d.inMemoryOp, hook)
+		for _, input := range d.inputs {
+			attachMaterializationHook(input, hook)
+		}
+		return
+	}
+	// We use verbose traversal in order to reach all internal Operators.
+	const verbose = true
+	for i := 0; i < root.ChildCount(verbose); i++ {
+		attachMaterializationHook(root.Child(i, verbose), hook)
+	}
+}
+
+// reportMaterialized reports that the Operator opName has materialized
+// numTuples tuples of the input of the processor with index inputIdx. It is
+// safe to call reportMaterialized on a nil hook.
+func (h *materializationHook) reportMaterialized(
+	ctx context.Context, opName string, inputIdx int, numTuples int,
+) {
+	if h == nil {
+		return
+	}
+	var estimate uint64
+	if inputIdx < len(h.inputEstimatedRowCounts) {
+		estimate = h.inputEstimatedRowCounts[inputIdx]
+	}
+	h.observer.OnMaterialized(ctx, execinfra.MaterializationPoint{
+		ProcessorID:       h.processorID,
+		Operator:          opName,
+		Input:             inputIdx,
+		EstimatedRowCount: estimate,
+		ObservedRowCount:  uint64(numTuples),
+	})
+}
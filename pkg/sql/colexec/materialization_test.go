@@ -0,0 +1,137 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils/colcontainerutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+type testMaterializationObserver struct {
+	points []execinfra.MaterializationPoint
+}
+
+func (o *testMaterializationObserver) OnMaterialized(
+	_ context.Context, point execinfra.MaterializationPoint,
+) {
+	o.points = append(o.points, point)
+}
+
+// TestMaterializationObserver verifies that the in-memory operators report the
+// cardinalities observed at their materialization points along with the
+// estimates of the materialized inputs.
+func TestMaterializationObserver(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	const processorID = 3
+	intCols := []types.T{*types.Int}
+	leftTups := tuples{{1}, {2}}
+	rightTups := tuples{{3}, {1}, {2}, {1}}
+	for _, tc := range []struct {
+		name     string
+		core     execinfrapb.ProcessorCoreUnion
+		inputs   []execinfrapb.InputSyncSpec
+		operator string
+		input    int
+		observed uint64
+	}{
+		{
+			name: "sort",
+			core: execinfrapb.ProcessorCoreUnion{
+				Sorter: &execinfrapb.SorterSpec{
+					OutputOrdering: execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}},
+				},
+			},
+			inputs:   []execinfrapb.InputSyncSpec{{ColumnTypes: intCols}},
+			operator: "sort",
+			input:    0,
+			observed: uint64(len(leftTups)),
+		},
+		{
+			name: "hash-join",
+			core: execinfrapb.ProcessorCoreUnion{
+				HashJoiner: &execinfrapb.HashJoinerSpec{
+					LeftEqColumns:  []uint32{0},
+					RightEqColumns: []uint32{0},
+				},
+			},
+			inputs:   []execinfrapb.InputSyncSpec{{ColumnTypes: intCols}, {ColumnTypes: intCols}},
+			operator: "hash join build",
+			input:    1,
+			observed: uint64(len(rightTups)),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			observer := &testMaterializationObserver{}
+			flowCtx := &execinfra.FlowCtx{
+				EvalCtx: &evalCtx,
+				Cfg: &execinfra.ServerConfig{
+					Settings: st,
+				},
+				ProcessorInputEstimatedRowCounts: map[int32][]uint64{processorID: {1, 2}},
+				MaterializationObserver:          observer,
+			}
+			inputs := []Operator{newOpTestInput(1 /* batchSize */, leftTups, []coltypes.T{coltypes.Int64})}
+			if len(tc.inputs) > 1 {
+				inputs = append(inputs, newOpTestInput(1 /* batchSize */, rightTups, []coltypes.T{coltypes.Int64}))
+			}
+			sem := NewTestingSemaphore(256)
+			tracker := newResourceTracker(t, queueCfg, sem)
+			result, err := NewColOperator(ctx, flowCtx, NewColOperatorArgs{
+				Spec: &execinfrapb.ProcessorSpec{
+					Input:       tc.inputs,
+					Core:        tc.core,
+					ProcessorID: processorID,
+				},
+				Inputs:              inputs,
+				StreamingMemAccount: testMemAcc,
+				DiskQueueCfg:        tracker.diskQueueCfg,
+				FDSemaphore:         sem,
+			})
+			tracker.trackMemory(result.BufferingOpMemAccounts, result.BufferingOpMemMonitors)
+			require.NoError(t, err)
+
+			result.Op.Init()
+			err = execerror.CatchVectorizedRuntimeError(func() {
+				for b := result.Op.Next(ctx); b.Length() > 0; b = result.Op.Next(ctx) {
+				}
+			})
+			require.NoError(t, err)
+			expected := execinfra.MaterializationPoint{
+				ProcessorID:       processorID,
+				Operator:          tc.operator,
+				Input:             tc.input,
+				EstimatedRowCount: uint64(tc.input + 1),
+				ObservedRowCount:  tc.observed,
+			}
+			require.Equal(t, []execinfra.MaterializationPoint{expected}, observer.points)
+			tracker.closeAndVerify(ctx, result.Op)
+		})
+	}
+}
@@ -12,7 +12,6 @@ package colexec
 
 import (
 	"context"
-	"io"
 	"unsafe"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
@@ -95,7 +94,7 @@ type mjBuilderCrossProductState struct {
 // mjBufferedGroup is a helper struct that stores information about the tuples
 // from both inputs for the buffered group.
 type mjBufferedGroup struct {
-	*spillingQueue
+	*SpillingQueue
 	// firstTuple stores a single tuple that was first in the buffered group.
 	firstTuple []coldata.Vec
 	numTuples  int
@@ -109,11 +108,11 @@ func (bg *mjBufferedGroup) reset() {
 }
 
 func (bg *mjBufferedGroup) close() error {
-	if bg.spillingQueue != nil {
-		if err := bg.spillingQueue.close(); err != nil {
+	if bg.SpillingQueue != nil {
+		if err := bg.SpillingQueue.Close(); err != nil {
 			return err
 		}
-		bg.spillingQueue = nil
+		bg.SpillingQueue = nil
 	}
 	return nil
 }
@@ -230,7 +229,7 @@ func newMergeJoinOp(
 	rightTypes []coltypes.T,
 	leftOrdering []execinfrapb.Ordering_Column,
 	rightOrdering []execinfrapb.Ordering_Column,
-) (resettableOperator, error) {
+) (ResettableOperator, error) {
 	base, err := newMergeJoinBase(
 		unlimitedAllocator, memoryLimit, diskQueueCfg, fdSemaphore, joinType,
 		left, right, leftTypes, rightTypes, leftOrdering, rightOrdering,
@@ -382,21 +381,23 @@ type mergeJoinBase struct {
 		// used to select out the tuples that belong to the buffered batch before
 		// enqueueing them into corresponding mjBufferedGroups. These are lazily
 		// instantiated.
-		// TODO(yuzefovich): uncomment when spillingQueue actually copies the
+		// TODO(yuzefovich): uncomment when SpillingQueue actually copies the
 		// enqueued batches when those are kept in memory.
 		//lBufferedGroupBatch coldata.Batch
 		//rBufferedGroupBatch coldata.Batch
 	}
 }
 
-var _ resetter = &mergeJoinBase{}
+var _ Resetter = &mergeJoinBase{}
+var _ MemoryLimitReporter = &mergeJoinBase{}
+var _ Closer = &mergeJoinBase{}
 
-func (o *mergeJoinBase) reset() {
-	if r, ok := o.left.source.(resetter); ok {
-		r.reset()
+func (o *mergeJoinBase) Reset(ctx context.Context) {
+	if r, ok := o.left.source.(Resetter); ok {
+		r.Reset(ctx)
 	}
-	if r, ok := o.right.source.(resetter); ok {
-		r.reset()
+	if r, ok := o.right.source.(Resetter); ok {
+		r.Reset(ctx)
 	}
 	o.outputReady = false
 	o.state = mjEntry
@@ -412,6 +413,11 @@ func (o *mergeJoinBase) InternalMemoryUsage() int {
 	return 8 * coldata.BatchSize() * sizeOfGroup // o.groups
 }
 
+// MemoryLimit implements the MemoryLimitReporter interface.
+func (o *mergeJoinBase) MemoryLimit() (int64, bool) {
+	return o.memoryLimit, true
+}
+
 func (o *mergeJoinBase) Init() {
 	o.initWithOutputBatchSize(coldata.BatchSize())
 }
@@ -477,13 +483,13 @@ func (o *mergeJoinBase) appendToBufferedGroup(
 	if input == &o.left {
 		sourceTypes = o.left.sourceTypes
 		bufferedGroup = &o.proberState.lBufferedGroup
-		if bufferedGroup.spillingQueue == nil {
-			bufferedGroup.spillingQueue = newSpillingQueue(
+		if bufferedGroup.SpillingQueue == nil {
+			bufferedGroup.SpillingQueue = NewSpillingQueue(
 				o.unlimitedAllocator, o.left.sourceTypes, o.memoryLimit,
 				o.diskQueueCfg, o.fdSemaphore, coldata.BatchSize(),
 			)
 		}
-		// TODO(yuzefovich): uncomment when spillingQueue actually copies the
+		// TODO(yuzefovich): uncomment when SpillingQueue actually copies the
 		// enqueued batches when those are kept in memory.
 		//if o.scratch.lBufferedGroupBatch == nil {
 		//	o.scratch.lBufferedGroupBatch = o.unlimitedAllocator.NewMemBatch(o.left.sourceTypes)
@@ -492,13 +498,13 @@ func (o *mergeJoinBase) appendToBufferedGroup(
 	} else {
 		sourceTypes = o.right.sourceTypes
 		bufferedGroup = &o.proberState.rBufferedGroup
-		if bufferedGroup.spillingQueue == nil {
-			bufferedGroup.spillingQueue = newRewindableSpillingQueue(
+		if bufferedGroup.SpillingQueue == nil {
+			bufferedGroup.SpillingQueue = NewRewindableSpillingQueue(
 				o.unlimitedAllocator, o.right.sourceTypes, o.memoryLimit,
 				o.diskQueueCfg, o.fdSemaphore, coldata.BatchSize(),
 			)
 		}
-		// TODO(yuzefovich): uncomment when spillingQueue actually copies the
+		// TODO(yuzefovich): uncomment when SpillingQueue actually copies the
 		// enqueued batches when those are kept in memory.
 		//if o.scratch.rBufferedGroupBatch == nil {
 		//	o.scratch.rBufferedGroupBatch = o.unlimitedAllocator.NewMemBatch(o.right.sourceTypes)
@@ -544,7 +550,7 @@ func (o *mergeJoinBase) appendToBufferedGroup(
 	})
 	scratchBatch.SetSelection(false)
 	scratchBatch.SetLength(groupLength)
-	if err := bufferedGroup.enqueue(ctx, scratchBatch); err != nil {
+	if err := bufferedGroup.Enqueue(ctx, scratchBatch); err != nil {
 		execerror.VectorizedInternalPanic(err)
 	}
 }
@@ -611,7 +617,7 @@ func (o *mergeJoinBase) completeBufferedGroup(
 	}
 
 	isBufferedGroupComplete := false
-	input.distincter.(resetter).reset()
+	input.distincter.(Resetter).Reset(ctx)
 	// Ignore the first row of the distincter in the first pass since we already
 	// know that we are in the same group and, thus, the row is not distinct,
 	// regardless of what the distincter outputs.
@@ -686,26 +692,27 @@ func (o *mergeJoinBase) finishProbe(ctx context.Context) {
 	)
 }
 
-func (o *mergeJoinBase) Close() error {
+// Close is part of the Closer interface.
+func (o *mergeJoinBase) Close(ctx context.Context) error {
 	var lastErr error
 	for _, op := range []Operator{o.left.source, o.right.source} {
-		if c, ok := op.(io.Closer); ok {
-			if err := c.Close(); err != nil {
+		if c, ok := op.(Closer); ok {
+			if err := c.Close(ctx); err != nil {
 				lastErr = err
 			}
 		}
 	}
-	if o.proberState.lBufferedGroup.spillingQueue != nil {
+	if o.proberState.lBufferedGroup.SpillingQueue != nil {
 		if err := o.proberState.lBufferedGroup.close(); err != nil {
 			lastErr = err
 		}
-		o.proberState.lBufferedGroup.spillingQueue = nil
+		o.proberState.lBufferedGroup.SpillingQueue = nil
 	}
-	if o.proberState.rBufferedGroup.spillingQueue != nil {
+	if o.proberState.rBufferedGroup.SpillingQueue != nil {
 		if err := o.proberState.rBufferedGroup.close(); err != nil {
 			lastErr = err
 		}
-		o.proberState.rBufferedGroup.spillingQueue = nil
+		o.proberState.rBufferedGroup.SpillingQueue = nil
 	}
 	return lastErr
 }
@@ -27,7 +27,6 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
-	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -1801,7 +1800,7 @@ type expectedGroup struct {
 func newBatchesOfRandIntRows(
 	nTuples int, typs []coltypes.T, maxRunLength int64, skipValues bool, randomIncrement int64,
 ) ([]coldata.Vec, []coldata.Vec, []expectedGroup) {
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(t)
 	lCols := []coldata.Vec{testAllocator.NewMemColumn(typs[0], nTuples)}
 	lCol := lCols[0].Int64()
 	rCols := []coldata.Vec{testAllocator.NewMemColumn(typs[0], nTuples)}
@@ -792,7 +792,7 @@ func (o *mergeJoin_JOIN_TYPE_STRINGOp) buildLeftBufferedGroup(
 	var err error
 	currentBatch := o.builderState.lBufferedGroupBatch
 	if currentBatch == nil {
-		currentBatch, err = bufferedGroup.dequeue()
+		currentBatch, err = bufferedGroup.Dequeue()
 		if err != nil {
 			execerror.VectorizedInternalPanic(err)
 		}
@@ -874,7 +874,7 @@ func (o *mergeJoin_JOIN_TYPE_STRINGOp) buildLeftBufferedGroup(
 				// We have processed all tuples in the current batch from the
 				// buffered group, so we need to dequeue the next one.
 				o.unlimitedAllocator.ReleaseBatch(currentBatch)
-				currentBatch, err = bufferedGroup.dequeue()
+				currentBatch, err = bufferedGroup.Dequeue()
 				if err != nil {
 					execerror.VectorizedInternalPanic(err)
 				}
@@ -1083,7 +1083,7 @@ func (o *mergeJoin_JOIN_TYPE_STRINGOp) buildRightBufferedGroup(
 			for ; o.builderState.right.numRepeatsIdx < rightGroup.numRepeats; o.builderState.right.numRepeatsIdx++ {
 				currentBatch := o.builderState.rBufferedGroupBatch
 				if currentBatch == nil {
-					currentBatch, err = bufferedGroup.dequeue()
+					currentBatch, err = bufferedGroup.Dequeue()
 					if err != nil {
 						execerror.VectorizedInternalPanic(err)
 					}
@@ -1145,7 +1145,7 @@ func (o *mergeJoin_JOIN_TYPE_STRINGOp) buildRightBufferedGroup(
 					// We have fully processed the current batch, so we need to get the
 					// next one.
 					o.unlimitedAllocator.ReleaseBatch(currentBatch)
-					currentBatch, err = bufferedGroup.dequeue()
+					currentBatch, err = bufferedGroup.Dequeue()
 					if err != nil {
 						execerror.VectorizedInternalPanic(err)
 					}
@@ -1155,7 +1155,7 @@ func (o *mergeJoin_JOIN_TYPE_STRINGOp) buildRightBufferedGroup(
 				}
 				// We have fully processed all the batches from the buffered group, so
 				// we need to rewind it.
-				if err := bufferedGroup.rewind(); err != nil {
+				if err := bufferedGroup.Rewind(); err != nil {
 					execerror.VectorizedInternalPanic(err)
 				}
 				o.builderState.rBufferedGroupBatch = nil
@@ -0,0 +1,145 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+// metamorphicTestInput is an Operator that emits the given tuples in batches
+// of randomly varying sizes (biased towards the boundary sizes), randomly
+// with or without a selection vector. Once all tuples have been emitted, it
+// keeps on returning zero-length batches that are different from
+// coldata.ZeroBatch and that randomly have a selection vector set, so that
+// the operators that check for the end of their input by anything other
+// than the length of the batch are caught.
+//
+// Note that zero-length batches cannot be inserted in the middle of the input
+// since a zero-length batch signals that the input has been exhausted.
+type metamorphicTestInput struct {
+	*opTestInput
+
+	rng *rand.Rand
+	// exhausted is set once the first zero-length batch has been emitted.
+	exhausted bool
+}
+
+var _ Operator = &metamorphicTestInput{}
+
+// newMetamorphicTestInput returns a new metamorphicTestInput that emits tuples
+// of the given type schema (typs can be nil, see newOpTestInput).
+func newMetamorphicTestInput(
+	rng *rand.Rand, tuples tuples, typs []coltypes.T,
+) *metamorphicTestInput {
+	return &metamorphicTestInput{
+		opTestInput: newOpTestSelInput(rng, 1 /* batchSize */, tuples, typs),
+		rng:         rng,
+	}
+}
+
+// randomBatchSize returns a random batch size in [1, coldata.BatchSize()]
+// range. Half of the time one of the boundary sizes is returned.
+func (s *metamorphicTestInput) randomBatchSize() int {
+	maxBatchSize := coldata.BatchSize()
+	if s.rng.Float64() < 0.5 {
+		boundarySizes := []int{1, 2, maxBatchSize - 1, maxBatchSize}
+		size := boundarySizes[s.rng.Intn(len(boundarySizes))]
+		if size < 1 {
+			size = 1
+		}
+		return size
+	}
+	return 1 + s.rng.Intn(maxBatchSize)
+}
+
+func (s *metamorphicTestInput) Next(ctx context.Context) coldata.Batch {
+	if !s.exhausted {
+		s.batchSize = s.randomBatchSize()
+		s.useSel = s.rng.Float64() < 0.5
+		if b := s.opTestInput.Next(ctx); b.Length() > 0 {
+			return b
+		}
+		s.exhausted = true
+	}
+	s.batch.ResetInternalBatch()
+	s.batch.SetSelection(s.rng.Float64() < 0.5)
+	s.batch.SetLength(0)
+	return s.batch
+}
+
+// runMetamorphicTests runs test several times on metamorphicTestInputs
+// constructed from tups. Every run is a separate subtest with its own random
+// number generator (see newRandForTest). Once test returns, the output of the
+// operator under test is expected to be exhausted, so it is verified that
+// the operator keeps on returning zero-length batches.
+// - test is a function that takes a list of input Operators, returns the
+//   operator under test and performs testing with t.
+func runMetamorphicTests(
+	t *testing.T,
+	tups []tuples,
+	typs [][]coltypes.T,
+	test func(t *testing.T, inputs []Operator) Operator,
+) {
+	const numRuns = 3
+	for run := 0; run < numRuns; run++ {
+		t.Run(fmt.Sprintf("run=%d", run), func(t *testing.T) {
+			rng, _ := newRandForTest(t)
+			inputSources := make([]Operator, len(tups))
+			var inputTypes []coltypes.T
+			for i, tup := range tups {
+				if typs != nil {
+					inputTypes = typs[i]
+				}
+				inputSources[i] = newMetamorphicTestInput(rng, tup, inputTypes)
+			}
+			op := test(t, inputSources)
+			ctx := context.Background()
+			for i := 0; i < 2; i++ {
+				require.Equal(t, 0, op.Next(ctx).Length(),
+					"operator returned a non-empty batch after its output had been exhausted")
+			}
+		})
+	}
+}
+
+func TestMetamorphicTestInput(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	rng, _ := newRandForTest(t)
+	nTups := 1 + rng.Intn(3*coldata.BatchSize())
+	tups := make(tuples, nTups)
+	for i := range tups {
+		if rng.Float64() < 0.1 {
+			tups[i] = tuple{nil}
+		} else {
+			tups[i] = tuple{int64(i)}
+		}
+	}
+
+	input := newMetamorphicTestInput(rng, tups, []coltypes.T{coltypes.Int64})
+	out := newOpTestOutput(input, tups)
+	require.NoError(t, out.Verify())
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		b := input.Next(ctx)
+		require.Equal(t, 0, b.Length())
+		require.True(t, b != coldata.ZeroBatch)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+)
+
+// monitorName is the hierarchical name shared by all memory and disk monitors
+// created for a single operator instance during the planning. It has the form
+//
+//   flow-<flowID>/<processorID>/<operator>[/<operator>...]
+//
+// where the operators after the first one are the operators that are planned
+// internally by their parent (for example, the sorters of an external hash
+// joiner). The name of each monitor is the monitorName followed by the kind of
+// the monitor, e.g.
+//
+//   flow-1a2b3c4d/3/hash-joiner/external-hash-joiner/sort-all/limited
+//
+// Since the names are generated rather than chosen at each call site, the
+// monitors of different operators never have the same name, so an OOM error
+// can always be attributed to the operator that caused it.
+type monitorName string
+
+// These are the kinds of the monitors that are appended to a monitorName.
+const (
+	monitorKindLimited   = "limited"
+	monitorKindUnlimited = "unlimited"
+	monitorKindDisk      = "disk"
+)
+
+// makeMonitorName returns the monitorName of the operator with the given name
+// that is planned for the processor processorID of the flow.
+func makeMonitorName(flowCtx *execinfra.FlowCtx, processorID int32, opName string) monitorName {
+	return monitorName(fmt.Sprintf("flow-%s/%d/%s", flowCtx.ID.Short(), processorID, opName))
+}
+
+// child returns the monitorName of an operator planned internally by the
+// operator with name n.
+func (n monitorName) child(opName string) monitorName {
+	return n + "/" + monitorName(opName)
+}
+
+// withKind returns the name of the monitor of the given kind.
+func (n monitorName) withKind(kind string) string {
+	return string(n) + "/" + kind
+}
+
+// isOOMFromMonitor returns whether err is an out of memory error that has
+// been returned by the monitor named fullMonitorName.
+func isOOMFromMonitor(err error, fullMonitorName string) bool {
+	if execerror.Classify(err) != execerror.ClassMemory {
+		return false
+	}
+	name, ok := mon.BudgetExceededMonitorName(err)
+	return ok && name == fullMonitorName
+}
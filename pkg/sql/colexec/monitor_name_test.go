@@ -0,0 +1,64 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMonitorNamesDontCollide verifies that an OOM error returned by the
+// monitor of one operator is not attributed to the monitors of the operators
+// with similar names.
+func TestMonitorNamesDontCollide(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	flowCtx := &execinfra.FlowCtx{ID: execinfrapb.FlowID{UUID: uuid.MakeV4()}}
+
+	sorter := makeMonitorName(flowCtx, 1 /* processorID */, "sorter").child("sort-all")
+	names := []string{
+		sorter.withKind(monitorKindLimited),
+		makeMonitorName(flowCtx, 12 /* processorID */, "sorter").child("sort-all").withKind(monitorKindLimited),
+		makeMonitorName(flowCtx, 1 /* processorID */, "hash-joiner").
+			child("external-hash-joiner").child("sort-all").withKind(monitorKindLimited),
+		sorter.withKind(monitorKindUnlimited),
+	}
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	for i, name := range names {
+		m := mon.MakeMonitor(
+			name, mon.MemoryResource, nil /* curCount */, nil, /* maxHist */
+			-1 /* increment */, math.MaxInt64 /* noteworthy */, st,
+		)
+		m.Start(ctx, nil /* pool */, mon.MakeStandaloneBudget(0))
+		acc := m.MakeBoundAccount()
+		err := acc.Grow(ctx, 1)
+		require.Error(t, err)
+		for j, other := range names {
+			require.Equal(t, i == j, isOOMFromMonitor(err, other), "error %v, monitor %s", err, other)
+		}
+		// The monitor name is carried by the error itself, so it is not
+		// inferred from a message that merely mentions the name.
+		require.False(t, isOOMFromMonitor(errors.Wrap(err, names[(i+1)%len(names)]), names[(i+1)%len(names)]))
+		acc.Close(ctx)
+		m.Stop(ctx)
+	}
+}
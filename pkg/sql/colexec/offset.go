@@ -78,6 +78,6 @@ func (c *offsetOp) Next(ctx context.Context) coldata.Batch {
 
 // Reset resets the offsetOp for another run. Primarily used for
 // benchmarks.
-func (c *offsetOp) Reset() {
+func (c *offsetOp) Reset(context.Context) {
 	c.seen = 0
 }
@@ -73,7 +73,7 @@ func BenchmarkOffset(b *testing.B) {
 	// Set throughput proportional to size of the selection vector.
 	b.SetBytes(int64(2 * coldata.BatchSize()))
 	for i := 0; i < b.N; i++ {
-		o.(*offsetOp).Reset()
+		o.(*offsetOp).Reset(ctx)
 		o.Next(ctx)
 	}
 }
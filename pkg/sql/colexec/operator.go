@@ -65,6 +65,18 @@ type NonExplainable interface {
 	nonExplainableMarker()
 }
 
+// MemoryLimitReporter is implemented by buffering Operators that know the
+// memory budget they have been planned with. It is used to annotate the output
+// of EXPLAIN (VEC, VERBOSE) so that the spilling behavior of a plan can be
+// predicted before running the query.
+type MemoryLimitReporter interface {
+	// MemoryLimit returns the memory budget of the Operator as well as whether
+	// the Operator can fall back to disk once the budget is exhausted. A
+	// non-positive limit indicates that the Operator doesn't enforce a limit on
+	// its own and is only bounded by its parent memory monitor.
+	MemoryLimit() (limit int64, diskFallback bool)
+}
+
 // NewOneInputNode returns an execinfra.OpNode with a single Operator input.
 func NewOneInputNode(input Operator) OneInputNode {
 	return OneInputNode{input: input}
@@ -150,16 +162,128 @@ type InternalMemoryOperator interface {
 	InternalMemoryUsage() int
 }
 
-// resetter is an interface that operators can implement if they can be reset
+// Resetter is an interface that operators can implement if they can be reset
 // either for reusing (to keep the already allocated memory) or during tests.
-type resetter interface {
-	reset()
+//
+// Reset brings the operator back into the state it was in right after Init,
+// as if none of its input had been consumed, so that Next can be called
+// again without calling Init first. The call is propagated to all inputs that
+// are Resetters themselves, so resetting the root of a tree resets the whole
+// tree. The semantics are as follows:
+// - all of the state derived from the input (buffered tuples, hash tables,
+//   partially computed aggregates, etc.) is cleared,
+// - the memory allocated for that state (batches, vectors, and internal
+//   slices) as well as the memory accounts are retained to be reused,
+// - the resources that are tied to the consumed input and that are not needed
+//   to start from scratch (e.g. the partitions spilled to disk and the file
+//   descriptors used for them) are released.
+// Reset must not be called concurrently with Next nor after the operator has
+// been closed (see Closer).
+type Resetter interface {
+	Reset(ctx context.Context)
 }
 
-// resettableOperator is an Operator that can be reset.
-type resettableOperator interface {
+// ResettableOperator is an Operator that can be reset.
+type ResettableOperator interface {
 	Operator
-	resetter
+	Resetter
+}
+
+// Closer is an object that holds resources (e.g. open files or acquired file
+// descriptors) that need to be released once it is no longer used. Close must
+// be safe to call more than once. Every Closer planned by NewColOperator is
+// added to NewColOperatorResult.ToClose, so that the flow can close it even if
+// the flow exits on an error or a panic; operators that wrap a Closer may
+// additionally propagate the call to it.
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// Closers is a registry of Closers that guarantees that every registered
+// Closer is closed exactly once.
+type Closers struct {
+	closers []Closer
+	closed  bool
+}
+
+// Add registers closers.
+func (c *Closers) Add(closers ...Closer) {
+	c.closers = append(c.closers, closers...)
+}
+
+// CloseAll closes all registered Closers in the reverse order of their
+// registration (so that the consumers are closed before their inputs). All
+// Closers are closed even if some of them return an error or panic, and the
+// first error encountered is returned. Subsequent calls are noops.
+func (c *Closers) CloseAll(ctx context.Context) error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	var retErr error
+	for i := len(c.closers) - 1; i >= 0; i-- {
+		closer := c.closers[i]
+		var err error
+		if panicErr := execerror.CatchVectorizedRuntimeError(func() {
+			err = closer.Close(ctx)
+		}); panicErr != nil {
+			err = panicErr
+		}
+		if err != nil && retErr == nil {
+			if op, ok := closer.(execinfra.OpNode); ok {
+				err = annotateOperatorError(err, op, execerror.PhaseClose)
+			}
+			retErr = err
+		}
+	}
+	c.closers = nil
+	return retErr
+}
+
+// annotateOperatorError annotates err (unless it has already been annotated)
+// with the name of op and the phase of its lifecycle during which err
+// occurred.
+func annotateOperatorError(err error, op execinfra.OpNode, phase execerror.Phase) error {
+	return execerror.WithOperatorContext(err, OperatorName(op), phase)
+}
+
+// operatorErrorAnnotator is an Operator that annotates the errors that occur
+// in its input with the name of an operator. It is planned on the boundaries
+// between the operators so that an error is attributed to the operator it
+// originated from rather than to the operator that caught it (the annotation
+// closest to the origin of an error wins).
+type operatorErrorAnnotator struct {
+	OneInputNode
+	NonExplainable
+	opName string
+}
+
+var _ Operator = &operatorErrorAnnotator{}
+
+// NewOperatorErrorAnnotator returns an Operator that annotates the errors that
+// occur in input with the name of op (which is usually input itself or the
+// root of input before it was wrapped).
+func NewOperatorErrorAnnotator(input Operator, op execinfra.OpNode) Operator {
+	return &operatorErrorAnnotator{
+		OneInputNode: NewOneInputNode(input),
+		opName:       OperatorName(op),
+	}
+}
+
+func (a *operatorErrorAnnotator) Init() {
+	if err := execerror.CatchVectorizedRuntimeError(a.input.Init); err != nil {
+		execerror.VectorizedInternalPanic(execerror.WithOperatorContext(err, a.opName, execerror.PhaseInit))
+	}
+}
+
+func (a *operatorErrorAnnotator) Next(ctx context.Context) coldata.Batch {
+	var batch coldata.Batch
+	if err := execerror.CatchVectorizedRuntimeError(func() {
+		batch = a.input.Next(ctx)
+	}); err != nil {
+		execerror.VectorizedInternalPanic(execerror.WithOperatorContext(err, a.opName, execerror.PhaseNext))
+	}
+	return batch
 }
 
 type noopOperator struct {
@@ -182,9 +306,9 @@ func (n *noopOperator) Next(ctx context.Context) coldata.Batch {
 	return n.input.Next(ctx)
 }
 
-func (n *noopOperator) reset() {
-	if r, ok := n.input.(resetter); ok {
-		r.reset()
+func (n *noopOperator) Reset(ctx context.Context) {
+	if r, ok := n.input.(Resetter); ok {
+		r.Reset(ctx)
 	}
 }
 
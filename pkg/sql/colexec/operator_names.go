@@ -0,0 +1,191 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// operatorNames maps the types of OpNodes to their human-readable names. It is
+// populated during package initialization and is read-only afterwards. The
+// names of all OpNodes of this package are registered in operator_names.eg.go
+// which is generated by execgen.
+var operatorNames = make(map[reflect.Type]string)
+
+// RegisterOperatorName registers name as the stable human-readable name of all
+// OpNodes that have the same type as op. Operator names are used throughout
+// the diagnostics of the vectorized engine (EXPLAIN (VEC), tracing, stats,
+// spilling events), so they should not be changed lightly. This function must
+// only be called during package initialization.
+func RegisterOperatorName(op execinfra.OpNode, name string) {
+	typ := reflect.TypeOf(op)
+	if existing, ok := operatorNames[typ]; ok {
+		execerror.VectorizedInternalPanic(fmt.Sprintf(
+			"name for %s has already been registered as %q", typ, existing,
+		))
+	}
+	operatorNames[typ] = name
+}
+
+// OperatorName returns the human-readable name of op. If no name has been
+// registered for the type of op, the unqualified name of the type is returned.
+func OperatorName(op execinfra.OpNode) string {
+	typ := reflect.TypeOf(op)
+	if name, ok := operatorNames[typ]; ok {
+		return name
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Name()
+}
+
+// OperatorID is an identifier of an Operator that is unique within a single
+// flow.
+type OperatorID int32
+
+// OperatorDescriptor describes an Operator that has been registered with an
+// OperatorRegistry.
+type OperatorDescriptor struct {
+	ID   OperatorID
+	Name string
+}
+
+// String returns the representation of the descriptor that is used in the
+// diagnostics of the vectorized engine.
+func (d OperatorDescriptor) String() string {
+	return fmt.Sprintf("%s#%d", d.Name, d.ID)
+}
+
+// operatorDescriptorSetter is implemented by Operators that want to know
+// their own descriptor (for example, in order to include it into the events
+// they log). The non-explainable Operators are given the descriptor of the
+// Operator they are attributed to.
+type operatorDescriptorSetter interface {
+	setOperatorDescriptor(OperatorDescriptor)
+}
+
+// OperatorRegistry assigns OperatorIDs to the Operators of a flow as they are
+// constructed. A single registry is shared by all Operators of a flow, so that
+// IDs can be used to cross-reference the diagnostics emitted by these
+// Operators. Only the OpNodes shown by non-verbose EXPLAIN (VEC) get IDs of
+// their own; the internal ones (like disk spillers or stats collectors) are
+// attributed to the OpNode that EXPLAIN (VEC) shows in their place. The
+// construction of a flow is deterministic, so the IDs match the ones shown by
+// EXPLAIN (VEC) for the same flow.
+type OperatorRegistry struct {
+	mu struct {
+		syncutil.Mutex
+		nextID OperatorID
+		ops    map[execinfra.OpNode]OperatorDescriptor
+	}
+}
+
+// NewOperatorRegistry returns a new empty OperatorRegistry.
+func NewOperatorRegistry() *OperatorRegistry {
+	r := &OperatorRegistry{}
+	r.mu.nextID = 1
+	r.mu.ops = make(map[execinfra.OpNode]OperatorDescriptor)
+	return r
+}
+
+// RegisterTree registers op as well as all OpNodes in the tree rooted at op
+// that haven't been registered yet. It should be called right after op has
+// been constructed: since the inputs of op have been constructed (and
+// registered) before op itself, the IDs follow the order of construction.
+// The OpNodes in the tree that are registered by this call are numbered in
+// postorder.
+func (r *OperatorRegistry) RegisterTree(op execinfra.OpNode) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.registerTreeLocked(op, make(map[execinfra.OpNode]struct{}))
+}
+
+// registerTreeLocked registers the OpNodes in the tree rooted at node that
+// haven't been registered or visited yet.
+func (r *OperatorRegistry) registerTreeLocked(
+	node execinfra.OpNode, visited map[execinfra.OpNode]struct{},
+) {
+	if vsc, ok := node.(*VectorizedStatsCollector); ok {
+		// VectorizedStatsCollectors are transparent, so that the IDs don't
+		// depend on whether the stats are collected.
+		r.registerTreeLocked(vsc.Operator, visited)
+		vsc.OperatorDesc = r.describeLocked(vsc.Operator)
+		return
+	}
+	comparable := reflect.TypeOf(node).Comparable()
+	if comparable {
+		if _, ok := r.mu.ops[node]; ok {
+			// All the OpNodes below node have been registered before node.
+			return
+		}
+		if _, ok := visited[node]; ok {
+			return
+		}
+		visited[node] = struct{}{}
+	}
+	for i := 0; i < node.ChildCount(true /* verbose */); i++ {
+		r.registerTreeLocked(node.Child(i, true /* verbose */), visited)
+	}
+	var desc OperatorDescriptor
+	if _, nonExplainable := node.(NonExplainable); nonExplainable || !comparable {
+		// Some OpNodes (like fnOp) cannot be used as map keys, so, similarly
+		// to the non-explainable ones, we don't assign IDs to them.
+		desc = r.describeLocked(node)
+	} else {
+		desc = OperatorDescriptor{ID: r.mu.nextID, Name: OperatorName(node)}
+		r.mu.nextID++
+		r.mu.ops[node] = desc
+	}
+	if s, ok := node.(operatorDescriptorSetter); ok {
+		s.setOperatorDescriptor(desc)
+	}
+}
+
+// describeLocked returns the descriptor of the OpNode that is shown by
+// non-verbose EXPLAIN (VEC) in place of node. If that OpNode hasn't been
+// registered, the descriptor only contains its name.
+func (r *OperatorRegistry) describeLocked(node execinfra.OpNode) OperatorDescriptor {
+	node = explainableOpNode(node)
+	if reflect.TypeOf(node).Comparable() {
+		if desc, ok := r.mu.ops[node]; ok {
+			return desc
+		}
+	}
+	return OperatorDescriptor{Name: OperatorName(node)}
+}
+
+// explainableOpNode returns the first OpNode that is shown by non-verbose
+// EXPLAIN (VEC) when descending from node (which is usually node itself).
+func explainableOpNode(node execinfra.OpNode) execinfra.OpNode {
+	for {
+		if _, nonExplainable := node.(NonExplainable); !nonExplainable || node.ChildCount(false /* verbose */) == 0 {
+			return node
+		}
+		node = node.Child(0, false /* verbose */)
+	}
+}
+
+// Lookup returns the descriptor of op if op has been registered.
+func (r *OperatorRegistry) Lookup(op execinfra.OpNode) (OperatorDescriptor, bool) {
+	if !reflect.TypeOf(op).Comparable() {
+		return OperatorDescriptor{}, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	desc, ok := r.mu.ops[op]
+	return desc, ok
+}
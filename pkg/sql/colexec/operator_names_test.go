@@ -0,0 +1,85 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/stretchr/testify/require"
+)
+
+// testOpNode is an OpNode that is declared in a test file, so its name is not
+// registered.
+type testOpNode struct {
+	ZeroInputNode
+}
+
+func TestOperatorName(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	source := NewRepeatableBatchSource(testAllocator, testAllocator.NewMemBatch([]coltypes.T{coltypes.Int64}))
+	require.Equal(t, "noop", OperatorName(NewNoop(source)))
+	require.Equal(t, "limit", OperatorName(NewLimitOp(source, 1)))
+	require.Equal(t, "repeatable-batch-source", OperatorName(source))
+	// The names of the generated Operators are registered too.
+	require.Equal(t, "proj-plus-int64-int64", OperatorName(&projPlusInt64Int64Op{}))
+	require.Equal(t, "merge-joiner-inner", OperatorName(&mergeJoinInnerOp{}))
+	// Unregistered types fall back to the unqualified type name.
+	require.Equal(t, "testOpNode", OperatorName(&testOpNode{}))
+
+	require.Panics(t, func() { RegisterOperatorName(&noopOperator{}, "duplicate") })
+}
+
+func TestOperatorRegistry(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	typs := []coltypes.T{coltypes.Int64, coltypes.Int64}
+	r := NewOperatorRegistry()
+	// The Operators are registered as they are constructed, so the IDs follow
+	// the order of construction.
+	source := NewRepeatableBatchSource(testAllocator, testAllocator.NewMemBatchWithSize(typs, coldata.BatchSize()))
+	r.RegisterTree(source)
+	proj := NewSimpleProjectOp(source, len(typs), []uint32{1})
+	limit := NewLimitOp(proj, 1)
+	r.RegisterTree(limit)
+	for op, expected := range map[Operator]string{
+		source: "repeatable-batch-source#1",
+		limit:  "limit#2",
+	} {
+		desc, ok := r.Lookup(op)
+		require.True(t, ok)
+		require.Equal(t, expected, desc.String())
+	}
+	// The non-explainable Operators don't get IDs of their own.
+	_, ok := r.Lookup(proj)
+	require.False(t, ok)
+
+	// Registering the same Operators again must not change their descriptors.
+	r.RegisterTree(limit)
+	desc, _ := r.Lookup(limit)
+	require.Equal(t, "limit#2", desc.String())
+
+	// The IDs don't depend on whether the stats are collected: the stats
+	// collectors get the descriptors of the Operators they wrap.
+	vsc := NewVectorizedStatsCollector(limit, 0 /* id */, false /* isStall */, timeutil.NewStopWatch())
+	r.RegisterTree(vsc)
+	require.Equal(t, "limit#2", vsc.OperatorDesc.String())
+	_, ok = r.Lookup(vsc)
+	require.False(t, ok)
+	outerLimit := NewLimitOp(vsc, 1)
+	r.RegisterTree(outerLimit)
+	desc, _ = r.Lookup(outerLimit)
+	require.Equal(t, "limit#3", desc.String())
+}
@@ -0,0 +1,105 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// countingCloser is a Closer that counts the number of times it has been
+// closed and, optionally, returns an error or panics when closed.
+type countingCloser struct {
+	numCloses int
+	err       error
+	panics    bool
+}
+
+func (c *countingCloser) Close(context.Context) error {
+	c.numCloses++
+	if c.panics {
+		execerror.VectorizedInternalPanic(c.err)
+	}
+	return c.err
+}
+
+func TestClosers(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+
+	var order []int
+	var orderingClosers []Closer
+	for i := 0; i < 3; i++ {
+		i := i
+		orderingClosers = append(orderingClosers, &callbackCloser{cb: func() { order = append(order, i) }})
+	}
+	errPanic := errors.New("panic")
+	errReturned := errors.New("returned")
+	panicking := &countingCloser{err: errPanic, panics: true}
+	failing := &countingCloser{err: errReturned}
+	ok := &countingCloser{}
+
+	var closers Closers
+	closers.Add(orderingClosers...)
+	closers.Add(failing, panicking, ok)
+	// The Closers are closed in the reverse order, so the panic is encountered
+	// before the returned error.
+	err := closers.CloseAll(ctx)
+	require.True(t, errors.Is(err, errPanic), "unexpected error %v", err)
+	require.NoError(t, closers.CloseAll(ctx))
+	for _, c := range []*countingCloser{panicking, failing, ok} {
+		require.Equal(t, 1, c.numCloses)
+	}
+	require.Equal(t, []int{2, 1, 0}, order)
+}
+
+// callbackCloser is a Closer that calls cb when closed.
+type callbackCloser struct {
+	cb func()
+}
+
+func (c *callbackCloser) Close(context.Context) error {
+	c.cb()
+	return nil
+}
+
+// TestOperatorErrorAnnotator verifies that an error is attributed to the
+// operator closest to its origin rather than to the root of the tree in which
+// it was caught.
+func TestOperatorErrorAnnotator(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+
+	expectedErr := pgerror.New(pgcode.DivisionByZero, "division by zero")
+	failing := &CallbackOperator{NextCb: func(context.Context) coldata.Batch {
+		execerror.VectorizedInternalPanic(expectedErr)
+		// This code is unreachable, but the compiler cannot infer that.
+		return nil
+	}}
+	inner := NewOperatorErrorAnnotator(failing, &limitOp{})
+	root := NewOperatorErrorAnnotator(NewNoop(inner), &offsetOp{})
+	root.Init()
+	err := execerror.CatchVectorizedRuntimeError(func() { root.Next(ctx) })
+	require.True(t, errors.Is(err, expectedErr), "unexpected error %v", err)
+	opName, phase, ok := execerror.GetOperatorContext(err)
+	require.True(t, ok)
+	require.Equal(t, "limit", opName)
+	require.Equal(t, execerror.PhaseNext, phase)
+	require.Equal(t, pgcode.DivisionByZero, pgerror.GetPGCode(err))
+}
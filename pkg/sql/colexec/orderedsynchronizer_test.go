@@ -12,7 +12,6 @@ package colexec
 
 import (
 	"context"
-	"math/rand"
 	"sort"
 	"testing"
 
@@ -154,6 +153,7 @@ func TestOrderedSync(t *testing.T) {
 
 func TestOrderedSyncRandomInput(t *testing.T) {
 	defer leaktest.AfterTest(t)()
+	rng, _ := newRandForTest(t)
 	numInputs := 3
 	inputLen := 1024
 	batchSize := int(16)
@@ -164,7 +164,7 @@ func TestOrderedSyncRandomInput(t *testing.T) {
 	// Generate a random slice of sorted ints.
 	randInts := make([]int, inputLen)
 	for i := range randInts {
-		randInts[i] = rand.Int()
+		randInts[i] = rng.Int()
 	}
 	sort.Ints(randInts)
 
@@ -174,7 +174,7 @@ func TestOrderedSyncRandomInput(t *testing.T) {
 	for i := range expected {
 		t := tuple{randInts[i]}
 		expected[i] = t
-		sourceIdx := rand.Int() % 3
+		sourceIdx := rng.Int() % 3
 		if i < numInputs {
 			// Make sure each input has at least one row.
 			sourceIdx = i
@@ -101,6 +101,10 @@ type OrderedSynchronizer struct {
 	//                       |  2nd among all Int64's
 	//                       1st among all Int64's
 	outColsMap []int
+	// cancelChecker is used while merging the inputs since a single call to
+	// Next might need to read a new batch from every input (which, in case of
+	// the external sorter, means reading from disk).
+	cancelChecker CancelChecker
 }
 
 var _ Operator = &OrderedSynchronizer{}
@@ -134,6 +138,7 @@ func (o *OrderedSynchronizer) Next(ctx context.Context) coldata.Batch {
 		o.heap = make([]int, 0, len(o.inputs))
 		for i := range o.inputs {
 			o.inputBatches[i] = o.inputs[i].Next(ctx)
+			o.cancelChecker.checkWork(ctx, o.inputBatches[i].Length())
 			o.updateComparators(i)
 			if o.inputBatches[i].Length() > 0 {
 				o.heap = append(o.heap, i)
@@ -181,6 +186,7 @@ func (o *OrderedSynchronizer) Next(ctx context.Context) coldata.Batch {
 				o.inputIndices[minBatch]++
 			} else {
 				o.inputBatches[minBatch] = o.inputs[minBatch].Next(ctx)
+				o.cancelChecker.checkWork(ctx, o.inputBatches[minBatch].Length())
 				o.inputIndices[minBatch] = 0
 				o.updateComparators(minBatch)
 			}
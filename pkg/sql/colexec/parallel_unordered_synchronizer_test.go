@@ -22,7 +22,6 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
-	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -35,7 +34,7 @@ func TestParallelUnorderedSynchronizer(t *testing.T) {
 	)
 
 	var (
-		rng, _     = randutil.NewPseudoRand()
+		rng, _     = newRandForTest(t)
 		typs       = []coltypes.T{coltypes.Int64}
 		numInputs  = rng.Intn(maxInputs) + 1
 		numBatches = rng.Intn(maxBatches) + 1
@@ -0,0 +1,266 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/apd"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// canParseWithoutEvalCtx returns whether string representations of values of
+// type t can be parsed by the parse operator without an EvalContext.
+func canParseWithoutEvalCtx(t *types.T) bool {
+	switch t.Family() {
+	case types.BoolFamily, types.IntFamily, types.FloatFamily, types.DecimalFamily, types.StringFamily:
+		return true
+	}
+	return false
+}
+
+// NewParseOp returns an Operator that parses the string representations in
+// the Bytes column at colIdx into values of type toType and writes them into
+// the column at outputIdx, which is added to the batch if necessary. The
+// width, precision, and scale of toType are enforced the same way they are
+// when inserting into a column of that type.
+//
+// The most common types are parsed directly into the output column; any other
+// type supported by the vectorized engine is parsed as a datum first, which
+// requires evalCtx to be non-nil.
+//
+// Note that the parse operator implements the semantics of the imports rather
+// than those of the casts (which, for example, don't enforce the width of the
+// integer types), so it must not be used to plan casts.
+//
+// If onErr is nil, an error parsing any of the values is returned as a query
+// error. Otherwise, onErr is called with the index of the offending tuple and
+// the error, and the tuple is removed from the selection vector of the batch.
+func NewParseOp(
+	allocator *Allocator,
+	input Operator,
+	colIdx int,
+	outputIdx int,
+	toType *types.T,
+	evalCtx *tree.EvalContext,
+	onErr func(rowIdx int, err error),
+) (Operator, error) {
+	outputType := typeconv.FromColumnType(toType)
+	if outputType == coltypes.Unhandled {
+		return nil, errors.Errorf("unhandled parse TO type: %s", toType)
+	}
+	p := &parseOp{
+		OneInputNode: NewOneInputNode(input),
+		allocator:    allocator,
+		colIdx:       colIdx,
+		outputIdx:    outputIdx,
+		toType:       toType,
+		outputType:   outputType,
+		evalCtx:      evalCtx,
+		onErr:        onErr,
+	}
+	if !canParseWithoutEvalCtx(toType) {
+		if evalCtx == nil {
+			return nil, errors.Errorf("parsing %s requires an EvalContext", toType)
+		}
+		p.datumToPhysical = typeconv.GetDatumToPhysicalFn(toType)
+	}
+	return p, nil
+}
+
+// parseOp is the Operator returned by NewParseOp.
+type parseOp struct {
+	OneInputNode
+	allocator  *Allocator
+	colIdx     int
+	outputIdx  int
+	toType     *types.T
+	outputType coltypes.T
+	evalCtx    *tree.EvalContext
+	onErr      func(rowIdx int, err error)
+	// datumToPhysical is set if the values are parsed as datums.
+	datumToPhysical func(tree.Datum) (interface{}, error)
+
+	// rejected contains the increasing indices of the tuples of the current
+	// batch that couldn't be parsed.
+	rejected []int
+	scratch  struct {
+		decimal tree.DDecimal
+	}
+}
+
+var _ Operator = &parseOp{}
+
+func (p *parseOp) Init() {
+	p.input.Init()
+}
+
+func (p *parseOp) Next(ctx context.Context) coldata.Batch {
+	batch := p.input.Next(ctx)
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	p.allocator.MaybeAddColumn(batch, p.outputType, p.outputIdx)
+	vec := batch.ColVec(p.colIdx)
+	projVec := batch.ColVec(p.outputIdx)
+	p.rejected = p.rejected[:0]
+	p.allocator.PerformOperation([]coldata.Vec{projVec}, func() {
+		if sel := batch.Selection(); sel != nil {
+			for _, i := range sel[:n] {
+				p.parseAt(vec, projVec, i)
+			}
+		} else {
+			for i := 0; i < n; i++ {
+				p.parseAt(vec, projVec, i)
+			}
+		}
+	})
+	if len(p.rejected) > 0 {
+		p.deselectRejected(batch)
+	}
+	return batch
+}
+
+// parseAt parses the value at index i of vec into projVec.
+func (p *parseOp) parseAt(vec, projVec coldata.Vec, i int) {
+	if vec.Nulls().NullAt(i) {
+		projVec.Nulls().SetNull(i)
+		return
+	}
+	if err := p.parse(projVec, i, vec.Bytes().Get(i)); err != nil {
+		if p.onErr == nil {
+			execerror.NonVectorizedPanic(err)
+		}
+		p.onErr(i, err)
+		p.rejected = append(p.rejected, i)
+		projVec.Nulls().SetNull(i)
+	}
+}
+
+// parse parses b as a value of the output type and sets it at index i of
+// projVec.
+func (p *parseOp) parse(projVec coldata.Vec, i int, b []byte) error {
+	if p.datumToPhysical != nil {
+		d, err := sqlbase.ParseDatumStringAs(p.toType, string(b), p.evalCtx)
+		if err != nil {
+			return err
+		}
+		if d, err = sqlbase.LimitValueWidth(p.toType, d, nil /* name */); err != nil {
+			return err
+		}
+		v, err := p.datumToPhysical(d)
+		if err != nil {
+			return err
+		}
+		coldata.SetValueAt(projVec, v, i, p.outputType)
+		return nil
+	}
+	switch p.toType.Family() {
+	case types.BoolFamily:
+		d, err := tree.ParseDBool(string(b))
+		if err != nil {
+			return err
+		}
+		projVec.Bool()[i] = bool(*d)
+	case types.IntFamily:
+		s := string(b)
+		v, err := strconv.ParseInt(s, 0, 64)
+		if err != nil {
+			// Use the datum parsing to get the same error as the row engine.
+			_, err = tree.ParseDInt(s)
+			return err
+		}
+		switch p.outputType {
+		case coltypes.Int16:
+			if int64(int16(v)) != v {
+				return p.intOutOfRangeErr()
+			}
+			projVec.Int16()[i] = int16(v)
+		case coltypes.Int32:
+			if int64(int32(v)) != v {
+				return p.intOutOfRangeErr()
+			}
+			projVec.Int32()[i] = int32(v)
+		default:
+			projVec.Int64()[i] = v
+		}
+	case types.FloatFamily:
+		s := string(b)
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			_, err = tree.ParseDFloat(s)
+			return err
+		}
+		projVec.Float64()[i] = v
+	case types.DecimalFamily:
+		d := &p.scratch.decimal
+		if err := d.SetString(string(b)); err != nil {
+			return err
+		}
+		if d.Form == apd.Finite && p.toType.Precision() > 0 {
+			if err := tree.LimitDecimalWidth(&d.Decimal, int(p.toType.Precision()), int(p.toType.Scale())); err != nil {
+				return errors.Wrapf(err, "type %s", p.toType.SQLString())
+			}
+		}
+		projVec.Decimal()[i].Set(&d.Decimal)
+	case types.StringFamily:
+		if p.toType.Width() > 0 && utf8.RuneCount(b) > int(p.toType.Width()) {
+			return pgerror.Newf(pgcode.StringDataRightTruncation,
+				"value too long for type %s", p.toType.SQLString())
+		}
+		projVec.Bytes().Set(i, b)
+	default:
+		execerror.VectorizedInternalPanic(errors.Errorf("unhandled parse TO type: %s", p.toType))
+	}
+	return nil
+}
+
+func (p *parseOp) intOutOfRangeErr() error {
+	return pgerror.Newf(pgcode.NumericValueOutOfRange,
+		"integer out of range for type %s", p.toType.Name())
+}
+
+// deselectRejected removes the rejected tuples from the selection vector of
+// the batch.
+func (p *parseOp) deselectRejected(batch coldata.Batch) {
+	n := batch.Length()
+	usesSel := batch.Selection() != nil
+	batch.SetSelection(true)
+	sel := batch.Selection()
+	rejected := p.rejected
+	newLength := 0
+	for j := 0; j < n; j++ {
+		i := j
+		if usesSel {
+			i = sel[j]
+		}
+		if len(rejected) > 0 && rejected[0] == i {
+			rejected = rejected[1:]
+			continue
+		}
+		// The tuples are compacted in place since newLength <= j.
+		sel[newLength] = i
+		newLength++
+	}
+	batch.SetLength(newLength)
+}
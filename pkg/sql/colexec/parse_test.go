@@ -0,0 +1,75 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestParseOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	evalCtx := tree.NewTestingEvalContext(cluster.MakeTestingClusterSettings())
+	tcs := []struct {
+		toType   *types.T
+		tuples   tuples
+		expected tuples
+	}{
+		{
+			toType:   types.Bool,
+			tuples:   tuples{{"true"}, {"f"}, {"nope"}, {"  yes "}},
+			expected: tuples{{"true", true}, {"f", false}, {"  yes ", true}},
+		},
+		{
+			toType:   types.Int,
+			tuples:   tuples{{"1"}, {"-42"}, {"0x10"}, {"1.5"}, {"abc"}},
+			expected: tuples{{"1", 1}, {"-42", -42}, {"0x10", 16}},
+		},
+		{
+			toType:   types.Int2,
+			tuples:   tuples{{"32767"}, {"32768"}, {"-32768"}},
+			expected: tuples{{"32767", 32767}, {"-32768", -32768}},
+		},
+		{
+			toType:   types.Float,
+			tuples:   tuples{{"1.5"}, {"Infinity"}, {"1e"}, {"-2"}},
+			expected: tuples{{"1.5", 1.5}, {"Infinity", math.Inf(1)}, {"-2", -2.0}},
+		},
+		{
+			toType:   types.MakeVarChar(3),
+			tuples:   tuples{{"abc"}, {"abcd"}, {"日本語"}},
+			expected: tuples{{"abc", "abc"}, {"日本語", "日本語"}},
+		},
+		{
+			toType:   types.Date,
+			tuples:   tuples{{"1970-01-02"}, {"not a date"}},
+			expected: tuples{{"1970-01-02", 1}},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.toType.SQLString(), func(t *testing.T) {
+			runTestsWithTyps(t, []tuples{tc.tuples}, [][]coltypes.T{{coltypes.Bytes}}, tc.expected, orderedVerifier,
+				func(input []Operator) (Operator, error) {
+					return NewParseOp(
+						testAllocator, input[0], 0 /* colIdx */, 1 /* outputIdx */, tc.toType, evalCtx,
+						func(int, error) {},
+					)
+				})
+		})
+	}
+}
@@ -67,7 +67,7 @@ func newPartiallyOrderedDistinct(
 	)
 	return &partiallyOrderedDistinct{
 		input:    chunkerOperator,
-		distinct: distinct.(resettableOperator),
+		distinct: distinct.(ResettableOperator),
 	}, nil
 }
 
@@ -77,7 +77,7 @@ func newPartiallyOrderedDistinct(
 // (where "chunk" is all tuples that are equal on the ordered columns).
 type partiallyOrderedDistinct struct {
 	input    *chunkerOperator
-	distinct resettableOperator
+	distinct ResettableOperator
 }
 
 var _ Operator = &partiallyOrderedDistinct{}
@@ -108,7 +108,7 @@ func (p *partiallyOrderedDistinct) Next(ctx context.Context) coldata.Batch {
 				return coldata.ZeroBatch
 			}
 			// p.distinct will reset p.input.
-			p.distinct.reset()
+			p.distinct.Reset(ctx)
 		} else {
 			return batch
 		}
@@ -157,7 +157,7 @@ type chunkerOperator struct {
 	windowedBatch coldata.Batch
 }
 
-var _ resettableOperator = &chunkerOperator{}
+var _ ResettableOperator = &chunkerOperator{}
 
 func (c *chunkerOperator) ChildCount(bool) int {
 	return 1
@@ -224,7 +224,7 @@ func (c *chunkerOperator) done() bool {
 	return c.input.done()
 }
 
-func (c *chunkerOperator) reset() {
+func (c *chunkerOperator) Reset(context.Context) {
 	c.currentChunkFinished = false
 	if c.newChunksCol != nil {
 		if c.outputTupleStartIdx == c.numTuplesInChunks {
@@ -0,0 +1,286 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/errors"
+	"github.com/marusama/semaphore"
+)
+
+// PipelineArgs contains the arguments shared by all stages of a Pipeline.
+type PipelineArgs struct {
+	// StreamingMemAccount is the memory account used by all streaming
+	// operators of the pipeline. It is owned by the caller.
+	StreamingMemAccount *mon.BoundAccount
+	// DiskQueueCfg and FDSemaphore are used by the disk-backed operators that
+	// the buffering operators fall back to when they exceed their memory limit.
+	DiskQueueCfg colcontainer.DiskQueueCfg
+	FDSemaphore  semaphore.Semaphore
+	// ProcessorConstructor, if set, is used to wrap the stages (or their
+	// post-processing) that are not supported by the vectorized engine. If it
+	// is not set, planning such a stage results in an error.
+	ProcessorConstructor execinfra.ProcessorConstructor
+	// OperatorRegistry, if set, is used to assign IDs to the operators of the
+	// pipeline. If it is not set, the pipeline creates its own registry.
+	OperatorRegistry *OperatorRegistry
+}
+
+// Pipeline is a builder of trees of Operators that doesn't require the full
+// SQL planning. Every stage of the pipeline is planned via NewColOperator, so
+// the operators are created exactly like in a vectorized flow (with the
+// allocators, the memory monitors, and the disk spillers wired in), e.g.
+//   p := NewPipeline(ctx, flowCtx, args).Input(source, typs)
+//   p.Filter("@1 > 0").HashJoin(right, spec).Sort(ordering...)
+//   result, err := p.Build()
+//   ... (run result.Op)
+//   err = p.Close(ctx)
+// The first error encountered while planning makes all subsequent stages
+// noops and is returned by Build. Close must be called once the operators are
+// no longer used, even if Build returned an error.
+type Pipeline struct {
+	ctx     context.Context
+	flowCtx *execinfra.FlowCtx
+	args    PipelineArgs
+
+	// result accumulates the results of all stages planned so far. result.Op
+	// and result.ColumnTypes describe the last stage.
+	result NewColOperatorResult
+	// err is the first error encountered while planning.
+	err error
+	// consumed is set once the pipeline has become an input to another
+	// pipeline which has taken over the ownership of all of its resources.
+	consumed bool
+}
+
+// NewPipeline returns a new empty Pipeline. The first stage must be either a
+// Scan or an Input.
+func NewPipeline(ctx context.Context, flowCtx *execinfra.FlowCtx, args PipelineArgs) *Pipeline {
+	if args.OperatorRegistry == nil {
+		args.OperatorRegistry = NewOperatorRegistry()
+	}
+	return &Pipeline{ctx: ctx, flowCtx: flowCtx, args: args}
+}
+
+// Input starts the pipeline with an already constructed operator that
+// produces batches of the given types.
+func (p *Pipeline) Input(input Operator, typs []types.T) *Pipeline {
+	if p.err != nil {
+		return p
+	}
+	if p.result.Op != nil {
+		p.err = errors.AssertionFailedf("the pipeline already has an input")
+		return p
+	}
+	p.args.OperatorRegistry.RegisterTree(input)
+	p.result.Op = input
+	p.result.ColumnTypes = typs
+	p.result.IsStreaming = true
+	return p
+}
+
+// Scan starts the pipeline with a scan of the table described by spec. The
+// flow context must have a transaction set.
+func (p *Pipeline) Scan(spec execinfrapb.TableReaderSpec) *Pipeline {
+	return p.Processor(execinfrapb.ProcessorCoreUnion{TableReader: &spec}, execinfrapb.PostProcessSpec{})
+}
+
+// Filter adds a stage that filters out the tuples for which expr (that refers
+// to the columns as @1, @2, etc.) doesn't evaluate to true.
+func (p *Pipeline) Filter(expr string) *Pipeline {
+	return p.Processor(
+		execinfrapb.ProcessorCoreUnion{Noop: &execinfrapb.NoopCoreSpec{}},
+		execinfrapb.PostProcessSpec{Filter: execinfrapb.Expression{Expr: expr}},
+	)
+}
+
+// Project adds a stage that keeps only the specified columns (in the
+// specified order).
+func (p *Pipeline) Project(cols ...uint32) *Pipeline {
+	return p.Processor(
+		execinfrapb.ProcessorCoreUnion{Noop: &execinfrapb.NoopCoreSpec{}},
+		execinfrapb.PostProcessSpec{Projection: true, OutputColumns: cols},
+	)
+}
+
+// Limit adds a stage that outputs at most limit tuples.
+func (p *Pipeline) Limit(limit uint64) *Pipeline {
+	return p.Processor(
+		execinfrapb.ProcessorCoreUnion{Noop: &execinfrapb.NoopCoreSpec{}},
+		execinfrapb.PostProcessSpec{Limit: limit},
+	)
+}
+
+// Sort adds a stage that sorts the tuples according to ordering.
+func (p *Pipeline) Sort(ordering ...execinfrapb.Ordering_Column) *Pipeline {
+	return p.Processor(
+		execinfrapb.ProcessorCoreUnion{Sorter: &execinfrapb.SorterSpec{
+			OutputOrdering: execinfrapb.Ordering{Columns: ordering},
+		}},
+		execinfrapb.PostProcessSpec{},
+	)
+}
+
+// Aggregate adds an aggregation stage described by spec.
+func (p *Pipeline) Aggregate(spec execinfrapb.AggregatorSpec) *Pipeline {
+	return p.Processor(execinfrapb.ProcessorCoreUnion{Aggregator: &spec}, execinfrapb.PostProcessSpec{})
+}
+
+// HashJoin adds a stage that joins the output of the pipeline (the left side)
+// with the output of right according to spec. The pipeline takes over the
+// ownership of right, which must not be used afterwards.
+func (p *Pipeline) HashJoin(right *Pipeline, spec execinfrapb.HashJoinerSpec) *Pipeline {
+	return p.Processor(execinfrapb.ProcessorCoreUnion{HashJoiner: &spec}, execinfrapb.PostProcessSpec{}, right)
+}
+
+// Processor adds a stage described by the processor core and the
+// post-processing spec. The output of the pipeline is the first input to the
+// processor, and the outputs of others (if any) are the remaining inputs; the
+// pipeline takes over the ownership of others, which must not be used
+// afterwards. If others have been built with a different OperatorRegistry,
+// their operators are registered again (and get new IDs) with the registry of
+// the pipeline. It is the most general way of adding a stage: all other stages
+// (except for Input) are implemented on top of it.
+func (p *Pipeline) Processor(
+	core execinfrapb.ProcessorCoreUnion, post execinfrapb.PostProcessSpec, others ...*Pipeline,
+) *Pipeline {
+	for _, other := range others {
+		if other == p || other.consumed {
+			if p.err == nil {
+				p.err = errors.AssertionFailedf("the pipeline has already been used as an input")
+			}
+			continue
+		}
+		// The resources of others are taken over regardless of whether the
+		// planning succeeds so that they are released by p.Close.
+		p.takeOver(other)
+	}
+	if p.err != nil {
+		return p
+	}
+	inputs := append([]*Pipeline{p}, others...)
+	if core.TableReader != nil {
+		if p.result.Op != nil {
+			p.err = errors.AssertionFailedf("a scan must be the first stage of the pipeline")
+			return p
+		}
+		inputs = nil
+	} else if p.result.Op == nil {
+		p.err = errors.AssertionFailedf("the pipeline must start with a scan or an input")
+		return p
+	}
+
+	spec := &execinfrapb.ProcessorSpec{Core: core, Post: post}
+	ops := make([]Operator, len(inputs))
+	for i, input := range inputs {
+		spec.Input = append(spec.Input, execinfrapb.InputSyncSpec{ColumnTypes: input.result.ColumnTypes})
+		ops[i] = input.result.Op
+	}
+	result, err := NewColOperator(p.ctx, p.flowCtx, NewColOperatorArgs{
+		Spec:                 spec,
+		Inputs:               ops,
+		StreamingMemAccount:  p.args.StreamingMemAccount,
+		ProcessorConstructor: p.args.ProcessorConstructor,
+		DiskQueueCfg:         p.args.DiskQueueCfg,
+		FDSemaphore:          p.args.FDSemaphore,
+		OperatorRegistry:     p.args.OperatorRegistry,
+	})
+	// NewColOperator releases the memory monitoring infrastructure on an
+	// error, but the Closers still need to be closed.
+	p.result.ToClose = append(p.result.ToClose, result.ToClose...)
+	if err != nil {
+		p.err = err
+		return p
+	}
+	if inputs == nil {
+		// This is the first stage.
+		p.result.IsStreaming, p.result.CanRunInAutoMode = result.IsStreaming, result.CanRunInAutoMode
+	} else {
+		p.mergeStreaming(result)
+	}
+	p.result.Op = result.Op
+	p.result.ColumnTypes = result.ColumnTypes
+	p.result.InternalMemUsage += result.InternalMemUsage
+	p.result.MetadataSources = append(p.result.MetadataSources, result.MetadataSources...)
+	p.result.BufferingOpMemMonitors = append(p.result.BufferingOpMemMonitors, result.BufferingOpMemMonitors...)
+	p.result.BufferingOpMemAccounts = append(p.result.BufferingOpMemAccounts, result.BufferingOpMemAccounts...)
+	return p
+}
+
+// takeOver moves all the state of other (which becomes consumed) into p.
+func (p *Pipeline) takeOver(other *Pipeline) {
+	if p.err == nil && other.err != nil {
+		p.err = other.err
+	}
+	p.result.InternalMemUsage += other.result.InternalMemUsage
+	p.result.MetadataSources = append(p.result.MetadataSources, other.result.MetadataSources...)
+	p.result.BufferingOpMemMonitors = append(p.result.BufferingOpMemMonitors, other.result.BufferingOpMemMonitors...)
+	p.result.BufferingOpMemAccounts = append(p.result.BufferingOpMemAccounts, other.result.BufferingOpMemAccounts...)
+	p.result.ToClose = append(p.result.ToClose, other.result.ToClose...)
+	p.mergeStreaming(other.result)
+	if other.result.Op != nil && other.args.OperatorRegistry != p.args.OperatorRegistry {
+		p.args.OperatorRegistry.RegisterTree(other.result.Op)
+	}
+	// Only Op and ColumnTypes are left in other since they are used to plan the
+	// stage that other is an input to.
+	other.result = NewColOperatorResult{Op: other.result.Op, ColumnTypes: other.result.ColumnTypes}
+	other.consumed = true
+}
+
+// mergeStreaming updates IsStreaming and CanRunInAutoMode of the pipeline so
+// that they take into account the operators in result.
+func (p *Pipeline) mergeStreaming(result NewColOperatorResult) {
+	p.result.CanRunInAutoMode = (p.result.IsStreaming || p.result.CanRunInAutoMode) &&
+		(result.IsStreaming || result.CanRunInAutoMode)
+	p.result.IsStreaming = p.result.IsStreaming && result.IsStreaming
+}
+
+// Build returns the result of planning all the stages of the pipeline or the
+// first error encountered. The memory accounts, the memory monitors, and the
+// Closers in the result are still owned by the pipeline and are released by
+// Close.
+func (p *Pipeline) Build() (NewColOperatorResult, error) {
+	if p.err != nil {
+		return NewColOperatorResult{}, p.err
+	}
+	if p.consumed {
+		return NewColOperatorResult{}, errors.AssertionFailedf("the pipeline has already been used as an input")
+	}
+	if p.result.Op == nil {
+		return NewColOperatorResult{}, errors.AssertionFailedf("the pipeline has no stages")
+	}
+	return p.result, nil
+}
+
+// Close releases all the resources held by the operators of the pipeline. It
+// is safe to call more than once.
+func (p *Pipeline) Close(ctx context.Context) error {
+	// The Closers are closed first since they might need to release the memory
+	// that they have accounted for.
+	closers := Closers{closers: p.result.ToClose}
+	err := closers.CloseAll(ctx)
+	for _, acc := range p.result.BufferingOpMemAccounts {
+		acc.Close(ctx)
+	}
+	for _, m := range p.result.BufferingOpMemMonitors {
+		m.Stop(ctx)
+	}
+	p.result.ToClose = nil
+	p.result.BufferingOpMemAccounts = nil
+	p.result.BufferingOpMemMonitors = nil
+	return err
+}
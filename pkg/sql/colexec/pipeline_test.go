@@ -0,0 +1,128 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils/colcontainerutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipeline(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	tempEngine, diskMonitor, cleanupTempStorage := newTestingTempStorage(t, st)
+	defer cleanupTempStorage()
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings:    st,
+			TempStorage: tempEngine,
+			DiskMonitor: diskMonitor,
+		},
+	}
+
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	intTypes := []types.T{*types.Int}
+	colTypes := []coltypes.T{coltypes.Int64}
+	for _, spillForced := range []bool{false, true} {
+		t.Run(fmt.Sprintf("spillForced=%t", spillForced), func(t *testing.T) {
+			flowCtx.Cfg.TestingKnobs.ForceDiskSpill = spillForced
+			sem := NewTestingSemaphore(256)
+			tracker := newResourceTracker(t, queueCfg, sem)
+			args := PipelineArgs{
+				StreamingMemAccount: testMemAcc,
+				DiskQueueCfg:        tracker.diskQueueCfg,
+				FDSemaphore:         sem,
+			}
+			right := NewPipeline(ctx, flowCtx, args).
+				Input(newOpTestInput(1 /* batchSize */, tuples{{2}, {3}, {3}, {1}}, colTypes), intTypes)
+			p := NewPipeline(ctx, flowCtx, args).
+				Input(newOpTestInput(1 /* batchSize */, tuples{{3}, {1}, {nil}, {2}, {1}}, colTypes), intTypes).
+				Filter("@1 > 1").
+				HashJoin(right, execinfrapb.HashJoinerSpec{
+					LeftEqColumns:  []uint32{0},
+					RightEqColumns: []uint32{0},
+				}).
+				Project(1).
+				Sort(execinfrapb.Ordering_Column{ColIdx: 0})
+			result, err := p.Build()
+			require.NoError(t, err)
+			require.False(t, result.IsStreaming)
+			require.Equal(t, intTypes, result.ColumnTypes)
+
+			out := newOpTestOutput(result.Op, tuples{{2}, {3}, {3}})
+			require.NoError(t, out.Verify())
+			require.NoError(t, p.Close(ctx))
+			require.NoError(t, p.Close(ctx))
+			tracker.closeAndVerify(ctx, result.Op)
+		})
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		args := PipelineArgs{StreamingMemAccount: testMemAcc}
+		newInput := func() Operator {
+			return newOpTestInput(1 /* batchSize */, tuples{{1}}, colTypes)
+		}
+
+		_, err := NewPipeline(ctx, flowCtx, args).Build()
+		require.Error(t, err)
+
+		_, err = NewPipeline(ctx, flowCtx, args).Filter("@1 > 1").Input(newInput(), intTypes).Build()
+		require.Error(t, err)
+
+		// A pipeline cannot be used after it has become an input to another one.
+		right := NewPipeline(ctx, flowCtx, args).Input(newInput(), intTypes)
+		spec := execinfrapb.HashJoinerSpec{LeftEqColumns: []uint32{0}, RightEqColumns: []uint32{0}}
+		p := NewPipeline(ctx, flowCtx, args).Input(newInput(), intTypes).HashJoin(right, spec)
+		_, err = p.Build()
+		require.NoError(t, err)
+		_, err = right.Build()
+		require.Error(t, err)
+		p.HashJoin(right, spec)
+		_, err = p.Build()
+		require.Error(t, err)
+		require.NoError(t, p.Close(ctx))
+	})
+
+	t.Run("OperatorIDs", func(t *testing.T) {
+		// The operators get their IDs in the order of construction.
+		args := PipelineArgs{StreamingMemAccount: testMemAcc, OperatorRegistry: NewOperatorRegistry()}
+		leftInput := newOpTestInput(1 /* batchSize */, tuples{{1}}, colTypes)
+		rightInput := newOpTestInput(1 /* batchSize */, tuples{{1}}, colTypes)
+		right := NewPipeline(ctx, flowCtx, args).Input(rightInput, intTypes)
+		spec := execinfrapb.HashJoinerSpec{LeftEqColumns: []uint32{0}, RightEqColumns: []uint32{0}}
+		p := NewPipeline(ctx, flowCtx, args).Input(leftInput, intTypes).HashJoin(right, spec)
+		result, err := p.Build()
+		require.NoError(t, err)
+		for i, op := range []execinfra.OpNode{rightInput, leftInput, explainableOpNode(result.Op)} {
+			desc, ok := args.OperatorRegistry.Lookup(op)
+			require.True(t, ok)
+			require.Equal(t, OperatorID(i+1), desc.ID)
+		}
+		require.NoError(t, p.Close(ctx))
+	})
+}
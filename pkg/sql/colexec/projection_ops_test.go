@@ -25,7 +25,6 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
-	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -190,7 +189,7 @@ func TestGetProjectionConstMixedTypeOperator(t *testing.T) {
 func TestRandomComparisons(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	const numTuples = 2048
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(t)
 	evalCtx := tree.NewTestingEvalContext(cluster.MakeTestingClusterSettings())
 	ctx := evalCtx.Ctx()
 	defer evalCtx.Stop(ctx)
@@ -82,6 +82,7 @@ func (p *sort_TYPE_DIR_HANDLES_NULLSOp) heapSort(ctx context.Context, a, b int)
 
 	// Pop elements, largest first, into end of data.
 	for i := hi - 1; i >= 0; i-- {
+		p.cancelChecker.check(ctx)
 		p.Swap(first, first+i)
 		p.siftDown(lo, i, first)
 	}
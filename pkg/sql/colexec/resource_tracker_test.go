@@ -0,0 +1,148 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/errors"
+	"github.com/marusama/semaphore"
+	"github.com/stretchr/testify/require"
+)
+
+// resourceTracker is a test-only utility that keeps track of the resources
+// used by the operators under test (memory accounts and monitors, disk queues
+// and their files, file descriptors, and goroutines) and fails the test if any
+// of them survive the Close of these operators.
+//
+// The intended usage is as follows:
+//   tracker := newResourceTracker(t, queueCfg, sem)
+//   args.DiskQueueCfg = tracker.diskQueueCfg
+//   result, err := NewColOperator(ctx, flowCtx, args)
+//   tracker.trackMemory(result.BufferingOpMemAccounts, result.BufferingOpMemMonitors)
+//   ... (run result.Op)
+//   tracker.closeAndVerify(ctx, result.Op)
+type resourceTracker struct {
+	t *testing.T
+	// diskQueueCfg is the config that the operators under test must use for
+	// their disk queues in order for the disk queues to be tracked.
+	diskQueueCfg colcontainer.DiskQueueCfg
+	// numDiskQueuesCreated is the number of disk queues created with
+	// diskQueueCfg.
+	numDiskQueuesCreated int
+	// sem (if non-nil) is the semaphore used to limit the number of file
+	// descriptors that the operators under test open.
+	sem semaphore.Semaphore
+
+	memAccounts []*mon.BoundAccount
+	memMonitors []*mon.BytesMonitor
+
+	numGoroutinesBefore int
+}
+
+// newResourceTracker returns a new resourceTracker that tracks the disk queues
+// created with (the tracker's copy of) queueCfg and the file descriptors
+// acquired from sem (which can be nil).
+func newResourceTracker(
+	t *testing.T, queueCfg colcontainer.DiskQueueCfg, sem semaphore.Semaphore,
+) *resourceTracker {
+	r := &resourceTracker{
+		t:                   t,
+		diskQueueCfg:        queueCfg,
+		sem:                 sem,
+		numGoroutinesBefore: runtime.NumGoroutine(),
+	}
+	onNewDiskQueueCb := queueCfg.OnNewDiskQueueCb
+	r.diskQueueCfg.OnNewDiskQueueCb = func() {
+		r.numDiskQueuesCreated++
+		if onNewDiskQueueCb != nil {
+			onNewDiskQueueCb()
+		}
+	}
+	return r
+}
+
+// trackMemory registers the memory accounts and monitors of the operators
+// under test. The tracker takes over the ownership of these objects and will
+// close them in closeAndVerify.
+func (r *resourceTracker) trackMemory(accounts []*mon.BoundAccount, monitors []*mon.BytesMonitor) {
+	r.memAccounts = append(r.memAccounts, accounts...)
+	r.memMonitors = append(r.memMonitors, monitors...)
+}
+
+// closeAndVerify closes op and all of the tracked memory accounts and monitors
+// and verifies that:
+// - all of the disk spillers in the tree rooted at op that spilled to disk
+//   have released the memory of their in-memory operators,
+// - no disk queue files are left behind,
+// - no file descriptors are held,
+// - no memory is still allocated from the tracked monitors (which would mean
+//   that an operator has opened a memory account and has never closed it),
+// - no goroutines started by the operators are still running.
+func (r *resourceTracker) closeAndVerify(ctx context.Context, op Operator) {
+	t := r.t
+	t.Helper()
+	if c, ok := op.(Closer); ok {
+		require.NoError(t, c.Close(ctx))
+	}
+
+	r.verifyDiskSpillersReleasedMemory(op)
+
+	if r.numDiskQueuesCreated > 0 {
+		directories, err := r.diskQueueCfg.FS.ListDir(r.diskQueueCfg.Path)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(directories),
+			"%d disk queues have been created, but the files of %v have not been removed",
+			r.numDiskQueuesCreated, directories)
+	}
+
+	if r.sem != nil {
+		require.Equal(t, 0, r.sem.GetCount(), "file descriptors have not been released")
+	}
+
+	for _, acc := range r.memAccounts {
+		acc.Close(ctx)
+	}
+	r.memAccounts = nil
+	for _, m := range r.memMonitors {
+		require.Equal(t, int64(0), m.AllocBytes(),
+			"memory is still allocated from a monitor after all known accounts have been closed")
+		m.Stop(ctx)
+	}
+	r.memMonitors = nil
+
+	testutils.SucceedsSoon(t, func() error {
+		if n := runtime.NumGoroutine(); n > r.numGoroutinesBefore {
+			return errors.Errorf("%d goroutines are running, expected at most %d", n, r.numGoroutinesBefore)
+		}
+		return nil
+	})
+}
+
+// verifyDiskSpillersReleasedMemory walks the tree rooted at root and verifies
+// that the in-memory side of every disk spiller that has spilled to disk no
+// longer holds any memory.
+func (r *resourceTracker) verifyDiskSpillersReleasedMemory(root execinfra.OpNode) {
+	if d, ok := root.(*diskSpillerBase); ok && d.spilled && d.inMemoryMemAccount != nil {
+		require.Equal(r.t, int64(0), d.inMemoryMemAccount.Used(),
+			"disk spiller of %s has not released the memory of its in-memory operator", d.desc)
+	}
+	const verbose = true
+	for i := 0; i < root.ChildCount(verbose); i++ {
+		r.verifyDiskSpillersReleasedMemory(root.Child(i, verbose))
+	}
+}
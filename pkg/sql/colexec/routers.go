@@ -54,6 +54,10 @@ type routerOutputOp struct {
 
 	types []coltypes.T
 
+	// memoryLimit is the soft memory limit after which the buffered batches
+	// are spilled to disk.
+	memoryLimit int64
+
 	// unblockedEventsChan is signaled when a routerOutput changes state from
 	// blocked to unblocked.
 	unblockedEventsChan chan<- struct{}
@@ -77,7 +81,7 @@ type routerOutputOp struct {
 		// If a read comes in at this point, the batch is dequeued from o.mu.data
 		// and returned, but the memory is still accounted for. In fact, memory use
 		// increases up to when o.mu.data is full and must spill to disk.
-		// Once it spills to disk, the spillingQueue (o.mu.data), will release
+		// Once it spills to disk, the SpillingQueue (o.mu.data), will release
 		// batches it spills to disk to stop accounting for them.
 		// The tricky part comes when o.mu.data is dequeued from. In this case, the
 		// reference for a previously-returned batch is overwritten with an on-disk
@@ -97,8 +101,8 @@ type routerOutputOp struct {
 		// main use of pendingBatch is coalescing various fragmented batches into
 		// one.
 		pendingBatch coldata.Batch
-		// data is a spillingQueue, a circular buffer backed by a disk queue.
-		data      *spillingQueue
+		// data is a SpillingQueue, a circular buffer backed by a disk queue.
+		data      *SpillingQueue
 		numUnread int
 		blocked   bool
 	}
@@ -131,6 +135,7 @@ func (o *routerOutputOp) Child(nth int, verbose bool) execinfra.OpNode {
 }
 
 var _ Operator = &routerOutputOp{}
+var _ MemoryLimitReporter = &routerOutputOp{}
 
 // newRouterOutputOp creates a new router output. The caller must ensure that
 // unblockedEventsChan is a buffered channel, as the router output will write to
@@ -160,19 +165,25 @@ func newRouterOutputOpWithBlockedThresholdAndBatchSize(
 ) *routerOutputOp {
 	o := &routerOutputOp{
 		types:               types,
+		memoryLimit:         memoryLimit,
 		unblockedEventsChan: unblockedEventsChan,
 		blockedThreshold:    blockedThreshold,
 		outputBatchSize:     outputBatchSize,
 	}
 	o.mu.unlimitedAllocator = unlimitedAllocator
 	o.mu.cond = sync.NewCond(&o.mu)
-	o.mu.data = newSpillingQueue(unlimitedAllocator, types, memoryLimit, cfg, fdSemaphore, outputBatchSize)
+	o.mu.data = NewSpillingQueue(unlimitedAllocator, types, memoryLimit, cfg, fdSemaphore, outputBatchSize)
 
 	return o
 }
 
 func (o *routerOutputOp) Init() {}
 
+// MemoryLimit implements the MemoryLimitReporter interface.
+func (o *routerOutputOp) MemoryLimit() (int64, bool) {
+	return o.memoryLimit, true
+}
+
 // Next returns the next coldata.Batch from the routerOutputOp. Note that Next
 // is designed for only one concurrent caller and will block until data is
 // ready.
@@ -182,7 +193,7 @@ func (o *routerOutputOp) Next(ctx context.Context) coldata.Batch {
 	if o.mu.done {
 		return coldata.ZeroBatch
 	}
-	for o.mu.pendingBatch == nil && o.mu.data.empty() && !o.mu.done {
+	for o.mu.pendingBatch == nil && o.mu.data.Empty() && !o.mu.done {
 		// Wait until there is data to read or the output is canceled.
 		o.mu.cond.Wait()
 	}
@@ -190,8 +201,8 @@ func (o *routerOutputOp) Next(ctx context.Context) coldata.Batch {
 		return coldata.ZeroBatch
 	}
 	var b coldata.Batch
-	if o.mu.pendingBatch != nil && o.mu.data.empty() {
-		// o.mu.data is empty (i.e. nothing has been flushed to the spillingQueue),
+	if o.mu.pendingBatch != nil && o.mu.data.Empty() {
+		// o.mu.data is empty (i.e. nothing has been flushed to the SpillingQueue),
 		// but there is a o.mu.pendingBatch that has not been flushed yet. Return
 		// this batch directly.
 		b = o.mu.pendingBatch
@@ -199,7 +210,7 @@ func (o *routerOutputOp) Next(ctx context.Context) coldata.Batch {
 		o.mu.pendingBatch = nil
 	} else {
 		var err error
-		b, err = o.mu.data.dequeue()
+		b, err = o.mu.data.Dequeue()
 		if err != nil {
 			execerror.VectorizedInternalPanic(err)
 		}
@@ -219,7 +230,7 @@ func (o *routerOutputOp) Next(ctx context.Context) coldata.Batch {
 
 func (o *routerOutputOp) closeLocked(ctx context.Context) {
 	o.mu.done = true
-	if err := o.mu.data.close(); err != nil {
+	if err := o.mu.data.Close(); err != nil {
 		// This log message is Info instead of Warning because the flow will also
 		// attempt to clean up the parent directory, so this failure might not have
 		// any effect.
@@ -261,7 +272,7 @@ func (o *routerOutputOp) addBatch(ctx context.Context, batch coldata.Batch, sele
 	defer o.mu.Unlock()
 	if batch.Length() == 0 {
 		if o.mu.pendingBatch != nil {
-			if err := o.mu.data.enqueue(ctx, o.mu.pendingBatch); err != nil {
+			if err := o.mu.data.Enqueue(ctx, o.mu.pendingBatch); err != nil {
 				execerror.VectorizedInternalPanic(err)
 			}
 		}
@@ -307,7 +318,7 @@ func (o *routerOutputOp) addBatch(ctx context.Context, batch coldata.Batch, sele
 		o.mu.pendingBatch.SetLength(newLength)
 		if o.testingKnobs.alwaysFlush || newLength >= o.outputBatchSize {
 			// The capacity in o.mu.pendingBatch has been filled.
-			if err := o.mu.data.enqueue(ctx, o.mu.pendingBatch); err != nil {
+			if err := o.mu.data.Enqueue(ctx, o.mu.pendingBatch); err != nil {
 				execerror.VectorizedInternalPanic(err)
 			}
 			o.mu.pendingBatch = nil
@@ -337,10 +348,12 @@ func (o *routerOutputOp) maybeUnblockLocked() {
 }
 
 // reset resets the routerOutputOp for a benchmark run.
-func (o *routerOutputOp) reset() {
+func (o *routerOutputOp) Reset(context.Context) {
 	o.mu.Lock()
 	o.mu.done = false
-	o.mu.data.reset()
+	if err := o.mu.data.Reset(); err != nil {
+		execerror.VectorizedInternalPanic(err)
+	}
 	o.mu.numUnread = 0
 	o.mu.blocked = false
 	o.mu.Unlock()
@@ -526,9 +539,9 @@ func (r *HashRouter) processNextBatch(ctx context.Context) bool {
 }
 
 // reset resets the HashRouter for a benchmark run.
-func (r *HashRouter) reset() {
-	if i, ok := r.input.(resetter); ok {
-		i.reset()
+func (r *HashRouter) Reset(ctx context.Context) {
+	if i, ok := r.input.(Resetter); ok {
+		i.Reset(ctx)
 	}
 	r.numBlockedOutputs = 0
 	for moreToRead := true; moreToRead; {
@@ -539,7 +552,7 @@ func (r *HashRouter) reset() {
 		}
 	}
 	for _, o := range r.outputs {
-		o.(resetter).reset()
+		o.(Resetter).Reset(ctx)
 	}
 }
 
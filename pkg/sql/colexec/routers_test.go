@@ -27,7 +27,6 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/testutils/colcontainerutils"
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
-	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
@@ -131,7 +130,7 @@ func TestRouterOutputAddBatch(t *testing.T) {
 	// in this test.
 	unblockEventsChan := make(chan struct{})
 
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(t)
 	queueCfg, cleanup, memoryTestCases := getDiskQueueCfgAndMemoryTestCases(t, rng)
 	defer cleanup()
 
@@ -164,7 +163,7 @@ func TestRouterOutputAddBatch(t *testing.T) {
 				}
 
 				if !mtc.skipExpSpillCheck {
-					require.Equal(t, mtc.expSpill, o.mu.data.spilled())
+					require.Equal(t, mtc.expSpill, o.mu.data.Spilled())
 				}
 			})
 		}
@@ -221,7 +220,7 @@ func TestRouterOutputNext(t *testing.T) {
 	// never write to it in this test.
 	unblockedEventsChan := make(chan struct{})
 
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(t)
 	queueCfg, cleanup, memoryTestCases := getDiskQueueCfgAndMemoryTestCases(t, rng)
 	defer cleanup()
 
@@ -366,7 +365,7 @@ func TestRouterOutputRandom(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	ctx := context.Background()
 
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(t)
 
 	var (
 		maxValues        = coldata.BatchSize() * 4
@@ -689,7 +688,7 @@ func TestHashRouterOneOutput(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	ctx := context.Background()
 
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(t)
 
 	sel := randomSel(rng, coldata.BatchSize(), rng.Float64())
 
@@ -717,7 +716,7 @@ func TestHashRouterOneOutput(t *testing.T) {
 			o := newOpTestOutput(routerOutputs[0], expected)
 
 			ro := routerOutputs[0].(*routerOutputOp)
-			// Set alwaysFlush so that data is always flushed to the spillingQueue.
+			// Set alwaysFlush so that data is always flushed to the SpillingQueue.
 			ro.testingKnobs.alwaysFlush = true
 
 			var wg sync.WaitGroup
@@ -740,7 +739,7 @@ func TestHashRouterOneOutput(t *testing.T) {
 				// If len(sel) == 0, no items will have been enqueued so override an
 				// expected spill if this is the case.
 				mtc.expSpill = mtc.expSpill && len(sel) != 0
-				require.Equal(t, mtc.expSpill, ro.mu.data.spilled())
+				require.Equal(t, mtc.expSpill, ro.mu.data.Spilled())
 			}
 		})
 	}
@@ -750,7 +749,7 @@ func TestHashRouterRandom(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	ctx := context.Background()
 
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(t)
 
 	var (
 		maxValues        = coldata.BatchSize() * 4
@@ -929,7 +928,7 @@ func BenchmarkHashRouter(b *testing.B) {
 				b.ResetTimer()
 				for i := 0; i < b.N; i++ {
 					input.ResetBatchesToReturn(numInputBatches)
-					r.reset()
+					r.Reset(ctx)
 					wg.Add(len(outputs))
 					for j := range outputs {
 						go func(j int) {
@@ -55,11 +55,14 @@ const (
 type _GOTYPE interface{}
 
 func _ROWS_TO_COL_VEC(
-	rows sqlbase.EncDatumRows, vec coldata.Vec, columnIdx int, alloc *sqlbase.DatumAlloc,
+	rows sqlbase.EncDatumRows,
+	vec coldata.Vec,
+	columnIdx int,
+	datumToPhysicalFn func(tree.Datum) (interface{}, error),
+	alloc *sqlbase.DatumAlloc,
 ) error { // */}}
 	// {{define "rowsToColVec" -}}
 	col := vec._TemplateType()
-	datumToPhysicalFn := typeconv.GetDatumToPhysicalFn(columnType)
 	for i := range rows {
 		row := rows[i]
 		if row[columnIdx].Datum == nil {
@@ -71,7 +74,8 @@ func _ROWS_TO_COL_VEC(
 		if datum == tree.DNull {
 			vec.Nulls().SetNull(i)
 		} else {
-			v, err := datumToPhysicalFn(datum)
+			var v interface{}
+			v, err = datumToPhysicalFn(datum)
 			if err != nil {
 				return
 			}
@@ -100,26 +104,74 @@ func EncDatumRowsToColVec(
 	allocator.PerformOperation(
 		[]coldata.Vec{vec},
 		func() {
-			switch columnType.Family() {
-			// {{range .}}
-			case _FAMILY:
-				// {{ if .Widths }}
-				switch columnType.Width() {
-				// {{range .Widths}}
-				case _WIDTH:
-					_ROWS_TO_COL_VEC(rows, vec, columnIdx, columnType, alloc)
-				// {{end}}
-				default:
-					execerror.VectorizedInternalPanic(fmt.Sprintf("unsupported width %d for column type %s", columnType.Width(), columnType.String()))
+			err = encDatumRowsToColVec(
+				rows, vec, columnIdx, columnType, typeconv.GetDatumToPhysicalFn(columnType), alloc,
+			)
+		},
+	)
+	return err
+}
+
+// EncDatumRowsToColVecs converts all columns of EncDatumRows into the first
+// len(columnTypes) vectors of the batch. datumToPhysicalFns must contain the
+// result of typeconv.GetDatumToPhysicalFn for each of the column types, so
+// that callers converting many batches only need to look up the conversion
+// functions once. The memory account of the allocator is updated once for
+// all the vectors.
+func EncDatumRowsToColVecs(
+	allocator *Allocator,
+	rows sqlbase.EncDatumRows,
+	batch coldata.Batch,
+	columnTypes []types.T,
+	datumToPhysicalFns []func(tree.Datum) (interface{}, error),
+	alloc *sqlbase.DatumAlloc,
+) error {
+	var err error
+	vecs := batch.ColVecs()[:len(columnTypes)]
+	allocator.PerformOperation(
+		vecs,
+		func() {
+			for columnIdx := range columnTypes {
+				if err = encDatumRowsToColVec(
+					rows, vecs[columnIdx], columnIdx, &columnTypes[columnIdx],
+					datumToPhysicalFns[columnIdx], alloc,
+				); err != nil {
+					return
 				}
-				// {{ else }}
-				_ROWS_TO_COL_VEC(rows, vec, columnIdx, columnType, alloc)
-				// {{end}}
-			// {{end}}
-			default:
-				execerror.VectorizedInternalPanic(fmt.Sprintf("unsupported column type %s", columnType.String()))
 			}
 		},
 	)
 	return err
 }
+
+// encDatumRowsToColVec converts one column from EncDatumRows to a column
+// vector without performing any memory accounting.
+func encDatumRowsToColVec(
+	rows sqlbase.EncDatumRows,
+	vec coldata.Vec,
+	columnIdx int,
+	columnType *types.T,
+	datumToPhysicalFn func(tree.Datum) (interface{}, error),
+	alloc *sqlbase.DatumAlloc,
+) (err error) {
+	switch columnType.Family() {
+	// {{range .}}
+	case _FAMILY:
+		// {{ if .Widths }}
+		switch columnType.Width() {
+		// {{range .Widths}}
+		case _WIDTH:
+			_ROWS_TO_COL_VEC(rows, vec, columnIdx, columnType, datumToPhysicalFn, alloc)
+		// {{end}}
+		default:
+			execerror.VectorizedInternalPanic(fmt.Sprintf("unsupported width %d for column type %s", columnType.Width(), columnType.String()))
+		}
+		// {{ else }}
+		_ROWS_TO_COL_VEC(rows, vec, columnIdx, columnType, datumToPhysicalFn, alloc)
+		// {{end}}
+	// {{end}}
+	default:
+		execerror.VectorizedInternalPanic(fmt.Sprintf("unsupported column type %s", columnType.String()))
+	}
+	return err
+}
@@ -39,7 +39,7 @@ func newSorter(
 	input spooler,
 	inputTypes []coltypes.T,
 	orderingCols []execinfrapb.Ordering_Column,
-) (resettableOperator, error) {
+) (ResettableOperator, error) {
 	partitioners := make([]partitioner, len(orderingCols)-1)
 
 	var err error
@@ -108,10 +108,12 @@ type allSpooler struct {
 	// spooled indicates whether spool() has already been called.
 	spooled       bool
 	windowedBatch coldata.Batch
+	cancelChecker CancelChecker
 }
 
 var _ spooler = &allSpooler{}
-var _ resetter = &allSpooler{}
+var _ bufferReleaser = &allSpooler{}
+var _ Resetter = &allSpooler{}
 
 func newAllSpooler(allocator *Allocator, input Operator, inputTypes []coltypes.T) spooler {
 	return &allSpooler{
@@ -133,6 +135,7 @@ func (p *allSpooler) spool(ctx context.Context) {
 	}
 	p.spooled = true
 	for batch := p.input.Next(ctx); batch.Length() != 0; batch = p.input.Next(ctx) {
+		p.cancelChecker.checkWork(ctx, batch.Length())
 		p.allocator.PerformOperation(p.bufferedTuples.ColVecs(), func() {
 			numBufferedTuples := p.bufferedTuples.Length()
 			for i, colVec := range p.bufferedTuples.ColVecs() {
@@ -182,9 +185,14 @@ func (p *allSpooler) getWindowedBatch(startIdx, endIdx int) coldata.Batch {
 	return p.windowedBatch
 }
 
-func (p *allSpooler) reset() {
-	if r, ok := p.input.(resetter); ok {
-		r.reset()
+func (p *allSpooler) releaseBuffered() {
+	p.bufferedTuples = p.allocator.NewMemBatchWithSize(p.inputTypes, 0 /* size */)
+	p.windowedBatch = p.allocator.NewMemBatchWithSize(p.inputTypes, 0 /* size */)
+}
+
+func (p *allSpooler) Reset(ctx context.Context) {
+	if r, ok := p.input.(Resetter); ok {
+		r.Reset(ctx)
 	}
 	p.spooled = false
 	p.bufferedTuples.SetLength(0)
@@ -218,14 +226,44 @@ type sortOp struct {
 	emitted int
 	// state is the current state of the sort.
 	state sortState
+	// cancelChecker is used while initializing the order and partitioning the
+	// sorted columns, each of which takes time linear in the number of spooled
+	// tuples.
+	cancelChecker CancelChecker
 
 	output coldata.Batch
 
 	exported int
+
+	// materializationHook, if set, is notified once the input has been
+	// spooled.
+	materializationHook *materializationHook
+	// bufferingDoneCb, if set, is called once the input has been spooled.
+	bufferingDoneCb func()
 }
 
 var _ bufferingInMemoryOperator = &sortOp{}
-var _ resetter = &sortOp{}
+var _ bufferingDoneNotifier = &sortOp{}
+var _ bufferReleaser = &sortOp{}
+var _ Resetter = &sortOp{}
+var _ materializationReporter = &sortOp{}
+
+func (p *sortOp) setBufferingDoneCb(cb func()) {
+	p.bufferingDoneCb = cb
+}
+
+func (p *sortOp) releaseBuffered() {
+	if r, ok := p.input.(bufferReleaser); ok {
+		r.releaseBuffered()
+	}
+	p.order = nil
+}
+
+func (p *sortOp) setMaterializationHook(hook *materializationHook) {
+	if p.materializationHook == nil {
+		p.materializationHook = hook
+	}
+}
 
 // colSorter is a single-column sorter, specialized on a particular type.
 type colSorter interface {
@@ -263,6 +301,10 @@ func (p *sortOp) Next(ctx context.Context) coldata.Batch {
 	switch p.state {
 	case sortSpooling:
 		p.input.spool(ctx)
+		p.materializationHook.reportMaterialized(ctx, "sort", 0 /* inputIdx */, p.input.getNumTuples())
+		if p.bufferingDoneCb != nil {
+			p.bufferingDoneCb()
+		}
 		p.state = sortSorting
 		fallthrough
 	case sortSorting:
@@ -325,6 +367,7 @@ func (p *sortOp) sort(ctx context.Context) {
 	for i := 0; i < len(p.order); i++ {
 		p.order[i] = i
 	}
+	p.cancelChecker.checkWork(ctx, spooledTuples)
 
 	for i := range p.orderingCols {
 		inputVec := p.input.getValues(int(p.orderingCols[i].ColIdx))
@@ -397,6 +440,7 @@ func (p *sortOp) sort(ctx context.Context) {
 		// Convert the distinct vector into a selection vector - a vector of indices
 		// that were true in the distinct vector.
 		partitions = boolVecToSel64(partitionsCol, partitions[:0])
+		p.cancelChecker.checkWork(ctx, spooledTuples)
 		// For each partition (set of tuples that are identical in all of the sort
 		// columns we've seen so far), sort based on the new column.
 		sorter.sortPartitions(ctx, partitions)
@@ -411,9 +455,9 @@ func (p *sortOp) resetOutput() {
 	}
 }
 
-func (p *sortOp) reset() {
-	if r, ok := p.input.(resetter); ok {
-		r.reset()
+func (p *sortOp) Reset(ctx context.Context) {
+	if r, ok := p.input.(Resetter); ok {
+		r.Reset(ctx)
 	}
 	p.emitted = 0
 	p.exported = 0
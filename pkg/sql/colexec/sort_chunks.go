@@ -56,7 +56,7 @@ func NewSortChunks(
 type sortChunksOp struct {
 	allocator *Allocator
 	input     *chunker
-	sorter    resettableOperator
+	sorter    ResettableOperator
 
 	exportedFromBuffer int
 	exportedFromBatch  int
@@ -101,7 +101,7 @@ func (c *sortChunksOp) Next(ctx context.Context) coldata.Batch {
 			// the full reset of the chunker because we're in the middle of
 			// processing of the input to sortChunksOp.
 			c.input.emptyBuffer()
-			c.sorter.reset()
+			c.sorter.Reset(ctx)
 		} else {
 			return batch
 		}
@@ -191,7 +191,7 @@ const (
 // Note 1: the chunker assumes that its input produces batches with no
 // selection vector, so it always puts a deselector on top of its input. It
 // does the coalescing itself, so it does not use an extra coalescer.
-// Note 2: the chunker intentionally does not implement resetter interface (if
+// Note 2: the chunker intentionally does not implement Resetter interface (if
 // it did, the sorter would reset it, but we don't want that since we're likely
 // in the middle of processing the input). Instead, sortChunksOp will empty the
 // buffer when appropriate.
@@ -22,7 +22,6 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
-	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 )
 
 var sortChunksTestCases []sortTestCase
@@ -196,7 +195,7 @@ func TestSortChunks(t *testing.T) {
 
 func TestSortChunksRandomized(t *testing.T) {
 	defer leaktest.AfterTest(t)()
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(t)
 	nTups := 8
 	maxCols := 5
 	// TODO(yuzefovich): randomize types as well.
@@ -238,7 +237,7 @@ func TestSortChunksRandomized(t *testing.T) {
 }
 
 func BenchmarkSortChunks(b *testing.B) {
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(b)
 	ctx := context.Background()
 
 	sorterConstructors := []func(*Allocator, Operator, []coltypes.T, []execinfrapb.Ordering_Column, int) (Operator, error){
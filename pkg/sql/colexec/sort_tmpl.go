@@ -164,6 +164,9 @@ func (s *sort_TYPE_DIR_HANDLES_NULLSOp) sortPartitions(ctx context.Context, part
 		}
 		s.order = order[partitionStart:partitionEnd]
 		n := partitionEnd - partitionStart
+		// Most of the partitions might be too small for quickSort to check for
+		// cancellation, so we account for the work of each partition here.
+		s.cancelChecker.checkWork(ctx, n)
 		s.quickSort(ctx, 0, n, maxDepth(n))
 	}
 }
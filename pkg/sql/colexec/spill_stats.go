@@ -0,0 +1,123 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+)
+
+// diskBackedStats describes the work that the disk-backed Operators have done
+// after the in-memory Operator they replaced has spilled to disk.
+type diskBackedStats struct {
+	// numPartitions is the number of partitions that the external hash joiner
+	// has written to disk.
+	numPartitions int
+	// numRepartitions is the number of times that the external hash joiner had
+	// to recursively repartition a partition because the partition was too big
+	// to join.
+	numRepartitions int
+	// maxRepartitioningDepth is the maximum depth of the recursive
+	// repartitioning of the external hash joiner (zero if no partition has been
+	// repartitioned).
+	maxRepartitioningDepth int
+}
+
+// add adds the stats of another disk-backed Operator to s.
+func (s *diskBackedStats) add(other diskBackedStats) {
+	s.numPartitions += other.numPartitions
+	s.numRepartitions += other.numRepartitions
+	if other.maxRepartitioningDepth > s.maxRepartitioningDepth {
+		s.maxRepartitioningDepth = other.maxRepartitioningDepth
+	}
+}
+
+// diskBackedStatsReporter is implemented by the disk-backed Operators that
+// partition their input on disk.
+type diskBackedStatsReporter interface {
+	// getDiskBackedStats returns the stats accumulated since the Operator has
+	// been created (the stats are not cleared on reset).
+	getDiskBackedStats() diskBackedStats
+}
+
+// collectDiskBackedStats returns the sum of the stats of all Operators in the
+// tree rooted at root. Note that the disk-backed Operators might plan other
+// disk-backed Operators internally (for example, the external hash joiner
+// falls back to external sorts), so the whole tree is traversed.
+func collectDiskBackedStats(root execinfra.OpNode) diskBackedStats {
+	var s diskBackedStats
+	if r, ok := root.(diskBackedStatsReporter); ok {
+		s.add(r.getDiskBackedStats())
+	}
+	// We use verbose traversal in order to reach all internal Operators.
+	const verbose = true
+	for i := 0; i < root.ChildCount(verbose); i++ {
+		s.add(collectDiskBackedStats(root.Child(i, verbose)))
+	}
+	return s
+}
+
+// maxReportedDiskQueueSizes is the maximum number of disk queues whose sizes
+// are listed by spillStats.String. The external hash joiner might create
+// hundreds of partitions, so the sizes of the rest of the queues are elided.
+const maxReportedDiskQueueSizes = 16
+
+// spillStats describes the spilling to disk of a single disk spiller. It is
+// recorded as a tag of the tracing span of the flow, so that it is included in
+// the statement diagnostics bundles.
+type spillStats struct {
+	diskBackedStats
+	// numSpills is the number of times that the disk spiller has spilled.
+	numSpills int64
+	// diskQueueSizes contains the number of bytes written by each of the disk
+	// queues (one per partition, and usually one file each) that the
+	// disk-backed Operators have created.
+	diskQueueSizes []int64
+	// bytesSpilled is the number of bytes that have been written to disk.
+	bytesSpilled int64
+	// inMemoryDuration and diskBackedDuration are the wall times spent in the
+	// in-memory and disk-backed phases, respectively (including the time spent
+	// in the inputs).
+	inMemoryDuration, diskBackedDuration time.Duration
+}
+
+// String implements the fmt.Stringer interface.
+func (s spillStats) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "spills: %d, bytes written: %s, disk queues: %d",
+		s.numSpills, humanizeutil.IBytes(s.bytesSpilled), len(s.diskQueueSizes))
+	if len(s.diskQueueSizes) > 0 {
+		b.WriteString(" (sizes: ")
+		for i, size := range s.diskQueueSizes {
+			if i == maxReportedDiskQueueSizes {
+				fmt.Fprintf(&b, ", %d more", len(s.diskQueueSizes)-i)
+				break
+			}
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(humanizeutil.IBytes(size))
+		}
+		b.WriteString(")")
+	}
+	fmt.Fprintf(&b, ", partitions: %d", s.numPartitions)
+	if s.numRepartitions > 0 {
+		fmt.Fprintf(&b, ", repartitions: %d, max repartitioning depth: %d",
+			s.numRepartitions, s.maxRepartitioningDepth)
+	}
+	fmt.Fprintf(&b, ", in-memory phase: %s, disk-backed phase: %s",
+		s.inMemoryDuration, s.diskBackedDuration)
+	return b.String()
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpillStatsString(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s := spillStats{
+		diskBackedStats:    diskBackedStats{numPartitions: 2},
+		numSpills:          1,
+		diskQueueSizes:     []int64{1 << 10, 2 << 20},
+		bytesSpilled:       1<<10 + 2<<20,
+		inMemoryDuration:   time.Second,
+		diskBackedDuration: time.Minute,
+	}
+	require.Equal(t,
+		"spills: 1, bytes written: 2.0 MiB, disk queues: 2 (sizes: 1.0 KiB, 2.0 MiB), "+
+			"partitions: 2, in-memory phase: 1s, disk-backed phase: 1m0s",
+		s.String(),
+	)
+
+	// Only the sizes of the first maxReportedDiskQueueSizes queues are listed.
+	s.diskQueueSizes = make([]int64, maxReportedDiskQueueSizes+3)
+	require.True(t, strings.Contains(s.String(), ", 3 more)"))
+}
@@ -12,21 +12,32 @@ package colexec
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
-	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/errors"
 	"github.com/marusama/semaphore"
 )
 
-// spillingQueue is a Queue that uses a fixed-size in-memory circular buffer
-// and spills to disk if spillingQueue.items has no more slots available to hold
-// a reference to an enqueued batch or the allocator reports that more memory
-// than the caller-provided maxMemoryLimit is in use.
+// SpillingQueue is a FIFO queue of batches that uses a fixed-size in-memory
+// circular buffer and spills to disk if SpillingQueue.items has no more slots
+// available to hold a reference to an enqueued batch or the allocator reports
+// that more memory than the caller-provided maxMemoryLimit is in use. It is
+// the building block for any component that needs to buffer an unbounded
+// number of batches (e.g. the outputs of the hash router or the buffered
+// groups of the merge joiner) and shouldn't implement the spilling itself.
+//
+// The usage is:
+//   q := NewSpillingQueue(unlimitedAllocator, typs, memoryLimit, cfg, sem, batchSize)
+//   err := q.Enqueue(ctx, batch) // (any number of times)
+//   batch, err := q.Dequeue()    // (until q.Empty() returns true)
+//   err = q.Close()
+// The memory budget is enforced using the unlimited allocator: once its usage
+// exceeds the memory limit, all subsequently enqueued batches are written to
+// the temporary storage described by the DiskQueueCfg (which is where the
+// files of the temporary storage engine of the node are placed).
 // When spilling to disk, a DiskQueue will be created. When spilling batches to
 // disk, their memory will first be released using the allocator. When batches
 // are read from disk back into memory, that memory will be reclaimed.
@@ -37,7 +48,7 @@ import (
 // batches are unsafe for reuse, it is assumed that the previously returned
 // batch is not kept around and thus its referenced memory will be GCed as soon
 // as the batch is updated.
-type spillingQueue struct {
+type SpillingQueue struct {
 	unlimitedAllocator *Allocator
 	maxMemoryLimit     int64
 
@@ -57,21 +68,27 @@ type spillingQueue struct {
 	rewindableState struct {
 		numItemsDequeued int
 	}
+
+	// closed is set by Close and is unset by Reset.
+	closed bool
 }
 
-// newSpillingQueue creates a new spillingQueue. An unlimited allocator must be
-// passed in. The spillingQueue will use this allocator to check whether memory
-// usage exceeds the given memory limit and use disk if so.
+// NewSpillingQueue creates a new SpillingQueue that stores batches of the
+// given types. An unlimited allocator must be passed in. The SpillingQueue
+// will use this allocator to check whether memory usage exceeds the given
+// memory limit and use disk if so.
 // If fdSemaphore is nil, no Acquire or Release calls will happen. The caller
-// may want to do this if requesting FDs up front.
-func newSpillingQueue(
+// may want to do this if requesting FDs up front. batchSize is the expected
+// size of the enqueued batches and is used to estimate how many batches can
+// be kept in memory.
+func NewSpillingQueue(
 	unlimitedAllocator *Allocator,
 	typs []coltypes.T,
 	memoryLimit int64,
 	cfg colcontainer.DiskQueueCfg,
 	fdSemaphore semaphore.Semaphore,
 	batchSize int,
-) *spillingQueue {
+) *SpillingQueue {
 	// Reduce the memory limit by what the DiskQueue may need to buffer
 	// writes/reads.
 	memoryLimit -= int64(cfg.BufferSizeBytes)
@@ -86,7 +103,7 @@ func newSpillingQueue(
 		// into this slice.
 		itemsLen = 1
 	}
-	return &spillingQueue{
+	return &SpillingQueue{
 		unlimitedAllocator: unlimitedAllocator,
 		maxMemoryLimit:     memoryLimit,
 		typs:               typs,
@@ -97,24 +114,29 @@ func newSpillingQueue(
 	}
 }
 
-// newRewindableSpillingQueue creates a new spillingQueue that can be rewinded
+// NewRewindableSpillingQueue creates a new SpillingQueue that can be rewinded
 // in order to dequeue all enqueued batches all over again. An unlimited
 // allocator must be passed in. The queue will use this allocator to check
 // whether memory usage exceeds the given memory limit and use disk if so.
-func newRewindableSpillingQueue(
+// Dequeue on a rewindable queue doesn't release the dequeued batches, so all
+// batches must be enqueued before the first call to Dequeue.
+func NewRewindableSpillingQueue(
 	unlimitedAllocator *Allocator,
 	typs []coltypes.T,
 	memoryLimit int64,
 	cfg colcontainer.DiskQueueCfg,
 	fdSemaphore semaphore.Semaphore,
 	batchSize int,
-) *spillingQueue {
-	q := newSpillingQueue(unlimitedAllocator, typs, memoryLimit, cfg, fdSemaphore, batchSize)
+) *SpillingQueue {
+	q := NewSpillingQueue(unlimitedAllocator, typs, memoryLimit, cfg, fdSemaphore, batchSize)
 	q.rewindable = true
 	return q
 }
 
-func (q *spillingQueue) enqueue(ctx context.Context, batch coldata.Batch) error {
+// Enqueue adds batch to the tail of the queue. The queue takes over the
+// ownership of batch (which might be written to disk and released), so the
+// caller must not modify it afterwards. A zero-length batch is not enqueued.
+func (q *SpillingQueue) Enqueue(ctx context.Context, batch coldata.Batch) error {
 	if batch.Length() == 0 {
 		if q.diskQueue != nil {
 			if err := q.diskQueue.Enqueue(batch); err != nil {
@@ -150,8 +172,12 @@ func (q *spillingQueue) enqueue(ctx context.Context, batch coldata.Batch) error
 	return nil
 }
 
-func (q *spillingQueue) dequeue() (coldata.Batch, error) {
-	if q.empty() {
+// Dequeue removes the batch at the head of the queue and returns it. If the
+// queue is empty, a zero-length batch is returned. The returned batch is only
+// valid until the next call to Dequeue since its memory might be reused to
+// read the next batch from disk.
+func (q *SpillingQueue) Dequeue() (coldata.Batch, error) {
+	if q.Empty() {
 		return coldata.ZeroBatch, nil
 	}
 
@@ -160,7 +186,7 @@ func (q *spillingQueue) dequeue() (coldata.Batch, error) {
 		// No more in-memory items. Fill the circular buffer as much as possible.
 		// Note that there must be at least one element on disk.
 		if !q.rewindable && q.curHeadIdx != q.curTailIdx {
-			execerror.VectorizedInternalPanic(fmt.Sprintf("assertion failed in spillingQueue: curHeadIdx != curTailIdx, %d != %d", q.curHeadIdx, q.curTailIdx))
+			return nil, errors.AssertionFailedf("assertion failed in SpillingQueue: curHeadIdx != curTailIdx, %d != %d", q.curHeadIdx, q.curTailIdx)
 		}
 		// NOTE: Only one item is dequeued from disk since a deserialized batch is
 		// only valid until the next call to Dequeue. In practice we could Dequeue
@@ -175,8 +201,8 @@ func (q *spillingQueue) dequeue() (coldata.Batch, error) {
 		}
 		if !ok {
 			// There was no batch to dequeue from disk. This should not really
-			// happen, as it should have been caught by the q.empty() check above.
-			execerror.VectorizedInternalPanic("disk queue was not empty but failed to dequeue element in spillingQueue")
+			// happen, as it should have been caught by the q.Empty() check above.
+			return nil, errors.AssertionFailedf("disk queue was not empty but failed to dequeue element in SpillingQueue")
 		}
 		// Account for this batch's memory.
 		q.unlimitedAllocator.RetainBatch(q.dequeueScratch)
@@ -206,7 +232,7 @@ func (q *spillingQueue) dequeue() (coldata.Batch, error) {
 	return res, nil
 }
 
-func (q *spillingQueue) numFDsOpenAtAnyGivenTime() int {
+func (q *SpillingQueue) numFDsOpenAtAnyGivenTime() int {
 	if q.diskQueueCfg.CacheMode != colcontainer.DiskQueueCacheModeDefault {
 		// The access pattern must be write-everything then read-everything so
 		// either a read FD or a write FD are open at any one point.
@@ -216,7 +242,7 @@ func (q *spillingQueue) numFDsOpenAtAnyGivenTime() int {
 	return 2
 }
 
-func (q *spillingQueue) maybeSpillToDisk(ctx context.Context) error {
+func (q *SpillingQueue) maybeSpillToDisk(ctx context.Context) error {
 	if q.diskQueue != nil {
 		return nil
 	}
@@ -237,19 +263,28 @@ func (q *spillingQueue) maybeSpillToDisk(ctx context.Context) error {
 	return err
 }
 
-// empty returns whether there are currently no items to be dequeued.
-func (q *spillingQueue) empty() bool {
+// Empty returns whether there are currently no items to be dequeued.
+func (q *SpillingQueue) Empty() bool {
 	if q.rewindable {
 		return q.numInMemoryItems+q.numOnDiskItems == q.rewindableState.numItemsDequeued
 	}
 	return q.numInMemoryItems == 0 && q.numOnDiskItems == 0
 }
 
-func (q *spillingQueue) spilled() bool {
+// Spilled returns whether the queue has spilled to disk.
+func (q *SpillingQueue) Spilled() bool {
 	return q.diskQueue != nil
 }
 
-func (q *spillingQueue) close() error {
+// Close releases the disk resources held by the queue. The memory of the
+// batches is accounted for by the allocator passed in to the constructor, so
+// it is up to the caller to release it. It is safe to call Close more than
+// once.
+func (q *SpillingQueue) Close() error {
+	if q.closed {
+		return nil
+	}
+	q.closed = true
 	if q.diskQueue != nil {
 		if q.fdSemaphore != nil {
 			q.fdSemaphore.Release(q.numFDsOpenAtAnyGivenTime())
@@ -259,9 +294,12 @@ func (q *spillingQueue) close() error {
 	return nil
 }
 
-func (q *spillingQueue) rewind() error {
+// Rewind resets the head of a rewindable queue so that all enqueued batches
+// are dequeued all over again. It returns an error if the queue is not
+// rewindable.
+func (q *SpillingQueue) Rewind() error {
 	if !q.rewindable {
-		return errors.Newf("unexpectedly rewind() called when spilling queue is not rewindable")
+		return errors.Newf("unexpectedly Rewind() called when spilling queue is not rewindable")
 	}
 	if q.diskQueue != nil {
 		if err := q.diskQueue.(colcontainer.RewindableQueue).Rewind(); err != nil {
@@ -273,9 +311,11 @@ func (q *spillingQueue) rewind() error {
 	return nil
 }
 
-func (q *spillingQueue) reset() {
-	if err := q.close(); err != nil {
-		execerror.VectorizedInternalPanic(err)
+// Reset removes all batches from the queue and releases the disk resources so
+// that the queue can be reused.
+func (q *SpillingQueue) Reset() error {
+	if err := q.Close(); err != nil {
+		return err
 	}
 	q.diskQueue = nil
 	q.numInMemoryItems = 0
@@ -283,4 +323,6 @@ func (q *spillingQueue) reset() {
 	q.curHeadIdx = 0
 	q.curTailIdx = 0
 	q.rewindableState.numItemsDequeued = 0
+	q.closed = false
+	return nil
 }
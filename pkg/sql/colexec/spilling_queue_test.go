@@ -16,11 +16,11 @@ import (
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
 	"github.com/cockroachdb/cockroach/pkg/testutils/colcontainerutils"
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
-	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -30,7 +30,7 @@ func TestSpillingQueue(t *testing.T) {
 	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
 	defer cleanup()
 
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(t)
 	for _, rewindable := range []bool{false, true} {
 		for _, memoryLimit := range []int64{10 << 10 /* 10 KiB */, 1<<20 + int64(rng.Intn(64<<20)) /* 1 MiB up to 64 MiB */} {
 			alwaysCompress := rng.Float64() < 0.5
@@ -71,14 +71,14 @@ func TestSpillingQueue(t *testing.T) {
 				queueCfg.TestingKnobs.AlwaysCompress = alwaysCompress
 
 				// Create queue.
-				var q *spillingQueue
+				var q *SpillingQueue
 				if rewindable {
-					q = newRewindableSpillingQueue(
+					q = NewRewindableSpillingQueue(
 						testAllocator, typs, memoryLimit, queueCfg,
 						NewTestingSemaphore(2), coldata.BatchSize(),
 					)
 				} else {
-					q = newSpillingQueue(
+					q = NewSpillingQueue(
 						testAllocator, typs, memoryLimit, queueCfg,
 						NewTestingSemaphore(2), coldata.BatchSize(),
 					)
@@ -92,12 +92,12 @@ func TestSpillingQueue(t *testing.T) {
 				ctx := context.Background()
 				for {
 					b = op.Next(ctx)
-					require.NoError(t, q.enqueue(ctx, b))
+					require.NoError(t, q.Enqueue(ctx, b))
 					if b.Length() == 0 {
 						break
 					}
 					if rng.Float64() < dequeuedProbabilityBeforeAllEnqueuesAreDone {
-						if b, err = q.dequeue(); err != nil {
+						if b, err = q.Dequeue(); err != nil {
 							t.Fatal(err)
 						} else if b.Length() == 0 {
 							t.Fatal("queue incorrectly considered empty")
@@ -113,7 +113,7 @@ func TestSpillingQueue(t *testing.T) {
 				for i := 0; i < numReadIterations; i++ {
 					batchIdx := 0
 					for batches[batchIdx].Length() > 0 {
-						if b, err = q.dequeue(); err != nil {
+						if b, err = q.Dequeue(); err != nil {
 							t.Fatal(err)
 						} else if b == nil {
 							t.Fatal("unexpectedly dequeued nil batch")
@@ -124,19 +124,19 @@ func TestSpillingQueue(t *testing.T) {
 						batchIdx++
 					}
 
-					if b, err := q.dequeue(); err != nil {
+					if b, err := q.Dequeue(); err != nil {
 						t.Fatal(err)
 					} else if b.Length() != 0 {
 						t.Fatal("queue should be empty")
 					}
 
 					if rewindable {
-						require.NoError(t, q.rewind())
+						require.NoError(t, q.Rewind())
 					}
 				}
 
 				// Close queue.
-				require.NoError(t, q.close())
+				require.NoError(t, q.Close())
 
 				// Verify no directories are left over.
 				directories, err := queueCfg.FS.ListDir(queueCfg.Path)
@@ -146,3 +146,39 @@ func TestSpillingQueue(t *testing.T) {
 		}
 	}
 }
+
+func TestSpillingQueueCloseAndReset(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	ctx := context.Background()
+	typs := []coltypes.T{coltypes.Int64}
+	sem := NewTestingSemaphore(2)
+	// A zero memory limit makes all batches spill to disk.
+	q := NewSpillingQueue(testAllocator, typs, 0 /* memoryLimit */, queueCfg, sem, coldata.BatchSize())
+	for i := 0; i < 2; i++ {
+		b := testAllocator.NewMemBatchWithSize(typs, 1)
+		b.ColVec(0).Int64()[0] = int64(i)
+		b.SetLength(1)
+		require.NoError(t, q.Enqueue(ctx, b))
+		require.True(t, q.Spilled())
+		require.False(t, q.Empty())
+
+		b, err := q.Dequeue()
+		require.NoError(t, err)
+		require.Equal(t, 1, b.Length())
+		require.Equal(t, int64(i), b.ColVec(0).Int64()[0])
+		require.True(t, q.Empty())
+
+		// Close is idempotent and releases the file descriptors.
+		require.NoError(t, q.Close())
+		require.NoError(t, q.Close())
+		require.Equal(t, 0, sem.GetCount())
+
+		// The queue can be reused after a Reset.
+		require.NoError(t, q.Reset())
+		require.False(t, q.Spilled())
+	}
+}
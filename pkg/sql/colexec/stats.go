@@ -29,6 +29,10 @@ type VectorizedStatsCollector struct {
 	NonExplainable
 	execpb.VectorizedStats
 
+	// OperatorDesc is the descriptor of the wrapped Operator. It is used to
+	// annotate the collected stats in the trace.
+	OperatorDesc OperatorDescriptor
+
 	// inputWatch is a single stop watch that is shared with all the input
 	// Operators. If the Operator doesn't have any inputs (like colBatchScan),
 	// it is not shared with anyone. It is used by the wrapped Operator to
@@ -28,7 +28,6 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/testutils/distsqlutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
-	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -56,7 +55,7 @@ func TestSupportedSQLTypesIntegration(t *testing.T) {
 	}
 
 	var da sqlbase.DatumAlloc
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(t)
 
 	for _, typ := range allSupportedSQLTypes {
 		for _, numRows := range []int{
@@ -15,6 +15,9 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/diskmap"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
 )
 
 // NewUnorderedDistinct creates an unordered distinct on the given distinct
@@ -41,6 +44,7 @@ func NewUnorderedDistinct(
 		OneInputNode: NewOneInputNode(input),
 		allocator:    allocator,
 		ht:           ht,
+		colTypes:     colTypes,
 		output:       allocator.NewMemBatch(colTypes),
 	}
 }
@@ -52,20 +56,63 @@ func NewUnorderedDistinct(
 // "head" is included into the big selection vector. Once the big selection
 // vector is populated, the operator proceeds to returning the batches
 // according to a chunk of the selection vector.
+//
+// Since no tuples are emitted until the whole input has been consumed, the
+// operator can fall back to disk while it is building the hash table by
+// exporting the distinct tuples it has buffered so far.
 type unorderedDistinct struct {
 	OneInputNode
 
 	allocator     *Allocator
 	ht            *hashTable
+	colTypes      []coltypes.T
 	buildFinished bool
 
 	distinctCount int
 
 	output           coldata.Batch
 	outputBatchStart int
+
+	// exported is the number of buffered distinct tuples that have been
+	// exported so far.
+	exported      int
+	windowedBatch coldata.Batch
+	// bufferingDoneCb, if set, is called once the hash table has been built.
+	bufferingDoneCb func()
 }
 
-var _ Operator = &unorderedDistinct{}
+var _ bufferingInMemoryOperator = &unorderedDistinct{}
+var _ bufferingDoneNotifier = &unorderedDistinct{}
+
+func (op *unorderedDistinct) setBufferingDoneCb(cb func()) {
+	op.bufferingDoneCb = cb
+}
+
+// ExportBuffered implements the bufferingInMemoryOperator interface. The
+// exported tuples are distinct, but the tuples of the remaining input might
+// be duplicates of them, so the disk-backed operator has to deduplicate all of
+// its input.
+func (op *unorderedDistinct) ExportBuffered(Operator) coldata.Batch {
+	numBuffered := op.ht.vals.Length()
+	if op.exported == numBuffered {
+		return coldata.ZeroBatch
+	}
+	if op.windowedBatch == nil {
+		op.windowedBatch = op.allocator.NewMemBatchNoCols(op.colTypes, 0 /* size */)
+	}
+	newExported := op.exported + coldata.BatchSize()
+	if newExported > numBuffered {
+		newExported = numBuffered
+	}
+	for i, t := range op.colTypes {
+		window := op.ht.vals.ColVec(i).Window(t, op.exported, newExported)
+		op.windowedBatch.ReplaceCol(window, i)
+	}
+	op.windowedBatch.SetSelection(false)
+	op.windowedBatch.SetLength(newExported - op.exported)
+	op.exported = newExported
+	return op.windowedBatch
+}
 
 func (op *unorderedDistinct) Init() {
 	op.input.Init()
@@ -78,6 +125,9 @@ func (op *unorderedDistinct) Next(ctx context.Context) coldata.Batch {
 	if !op.buildFinished {
 		op.buildFinished = true
 		op.ht.build(ctx, op.input)
+		if op.bufferingDoneCb != nil {
+			op.bufferingDoneCb()
+		}
 
 		// We're using the hashTable in distinct mode, so it buffers only distinct
 		// tuples, as a result, we will be simply returning all buffered tuples.
@@ -115,15 +165,65 @@ func (op *unorderedDistinct) Next(ctx context.Context) coldata.Batch {
 	return op.output
 }
 
-// reset resets the unorderedDistinct.
-func (op *unorderedDistinct) reset() {
-	if r, ok := op.input.(resetter); ok {
-		r.reset()
+// Reset is part of the resetter interface.
+func (op *unorderedDistinct) Reset(ctx context.Context) {
+	if r, ok := op.input.(Resetter); ok {
+		r.Reset(ctx)
 	}
 	op.ht.vals.ResetInternalBatch()
 	op.ht.vals.SetLength(0)
 	op.buildFinished = false
-	op.ht.reset()
+	op.ht.Reset()
 	op.distinctCount = 0
 	op.outputBatchStart = 0
+	op.exported = 0
+}
+
+// diskBackedUnorderedDistinct is the disk-backed counterpart of the unordered
+// distinct. It sorts all tuples on the distinct columns in the temporary
+// storage engine and removes the duplicates from the sorted stream.
+type diskBackedUnorderedDistinct struct {
+	Operator
+	sorter Closer
+}
+
+var _ Closer = &diskBackedUnorderedDistinct{}
+
+// newDiskBackedUnorderedDistinct returns a disk-backed unordered distinct.
+// - allocator must have been created with a memory account derived from an
+// unlimited memory monitor (see newExternalSorter).
+// - diskMonitor is used to account for the disk space used by the sort.
+// - onWriteCb (when non-nil) is called with the number of bytes the sort has
+// spilled.
+func newDiskBackedUnorderedDistinct(
+	allocator *Allocator,
+	input Operator,
+	distinctCols []uint32,
+	colTypes []coltypes.T,
+	diskMonitor *mon.BytesMonitor,
+	engine diskmap.Factory,
+	onWriteCb func(bytesWritten int),
+) (Operator, error) {
+	var ordering execinfrapb.Ordering
+	for _, col := range distinctCols {
+		ordering.Columns = append(ordering.Columns, execinfrapb.Ordering_Column{
+			ColIdx: col, Direction: execinfrapb.Ordering_Column_ASC,
+		})
+	}
+	sorter := newExternalSorter(
+		allocator, input, colTypes, ordering, diskMonitor, engine, onWriteCb,
+	)
+	distinct, err := NewOrderedDistinct(sorter, distinctCols, colTypes)
+	if err != nil {
+		return nil, err
+	}
+	return &diskBackedUnorderedDistinct{
+		Operator: distinct,
+		sorter:   sorter.(Closer),
+	}, nil
+}
+
+// Close is part of the Closer interface.
+func (d *diskBackedUnorderedDistinct) Close(ctx context.Context) error {
+	return d.sorter.Close(ctx)
 }
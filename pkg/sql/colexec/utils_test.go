@@ -13,7 +13,6 @@ package colexec
 import (
 	"context"
 	"fmt"
-	"io"
 	"math"
 	"math/rand"
 	"reflect"
@@ -30,7 +29,6 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
-	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/pkg/errors"
 	"github.com/pmezard/go-difflib/difflib"
 	"github.com/stretchr/testify/assert"
@@ -276,11 +274,11 @@ func runTestsWithTyps(
 				"non-nulls in the input tuples, we expect for all nulls injection to "+
 				"change the output")
 		}
-		if c, ok := originalOp.(io.Closer); ok {
-			require.NoError(t, c.Close())
+		if c, ok := originalOp.(Closer); ok {
+			require.NoError(t, c.Close(ctx))
 		}
-		if c, ok := opWithNulls.(io.Closer); ok {
-			require.NoError(t, c.Close())
+		if c, ok := opWithNulls.(Closer); ok {
+			require.NoError(t, c.Close(ctx))
 		}
 	})
 }
@@ -328,6 +326,23 @@ func runTestsWithoutAllNullsInjection(
 		}
 	})
 
+	t.Run("metamorphic", func(t *testing.T) {
+		// This test feeds the operator with batches of randomly varying sizes
+		// that randomly have selection vectors and verifies that the output
+		// doesn't change.
+		runMetamorphicTests(t, tups, typs, func(t *testing.T, inputs []Operator) Operator {
+			op, err := constructor(inputs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := newOpTestOutput(op, expected)
+			if err := verifyFn(out); err != nil {
+				t.Fatal(err)
+			}
+			return op
+		})
+	})
+
 	if !skipVerifySelAndNullsResets {
 		t.Run("verifySelAndNullResets", func(t *testing.T) {
 			// This test ensures that operators that "own their own batches", such as
@@ -403,10 +418,10 @@ func runTestsWithoutAllNullsInjection(
 						assert.False(t, maybeHasNulls(b))
 					}
 				}
-				if c, ok := op.(io.Closer); ok {
+				if c, ok := op.(Closer); ok {
 					// Some operators need an explicit Close if not drained completely of
 					// input.
-					assert.NoError(t, c.Close())
+					assert.NoError(t, c.Close(ctx))
 				}
 			}
 		})
@@ -469,11 +484,11 @@ func runTestsWithFn(
 				inputSources := make([]Operator, len(tups))
 				var inputTypes []coltypes.T
 				if useSel {
+					rng, _ := newRandForTest(t)
 					for i, tup := range tups {
 						if typs != nil {
 							inputTypes = typs[i]
 						}
-						rng, _ := randutil.NewPseudoRand()
 						inputSources[i] = newOpTestSelInput(rng, batchSize, tup, inputTypes)
 					}
 				} else {
@@ -1173,7 +1188,7 @@ func TestRepeatableBatchSource(t *testing.T) {
 func TestRepeatableBatchSourceWithFixedSel(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	batch := testAllocator.NewMemBatch([]coltypes.T{coltypes.Int64})
-	rng, _ := randutil.NewPseudoRand()
+	rng, _ := newRandForTest(t)
 	batchSize := 10
 	if batchSize > coldata.BatchSize() {
 		batchSize = coldata.BatchSize()
@@ -1277,7 +1292,7 @@ func (c *chunkingBatchSource) Next(context.Context) coldata.Batch {
 	return c.batch
 }
 
-func (c *chunkingBatchSource) reset() {
+func (c *chunkingBatchSource) Reset(context.Context) {
 	c.curIdx = 0
 }
 
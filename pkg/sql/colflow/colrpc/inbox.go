@@ -115,6 +115,10 @@ type Inbox struct {
 
 var _ colexec.Operator = &Inbox{}
 
+func init() {
+	colexec.RegisterOperatorName(&Inbox{}, "inbox")
+}
+
 // NewInbox creates a new Inbox.
 func NewInbox(
 	allocator *colexec.Allocator, typs []coltypes.T, streamID execinfrapb.StreamID,
@@ -70,6 +70,10 @@ type Outbox struct {
 	runnerCtx context.Context
 }
 
+func init() {
+	colexec.RegisterOperatorName(&Outbox{}, "outbox")
+}
+
 // NewOutbox creates a new Outbox.
 func NewOutbox(
 	allocator *colexec.Allocator,
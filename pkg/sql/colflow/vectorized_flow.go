@@ -22,6 +22,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 	"github.com/cockroachdb/cockroach/pkg/rpc/nodedialer"
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
@@ -46,6 +47,29 @@ import (
 	opentracing "github.com/opentracing/opentracing-go"
 )
 
+// spillFormat is a cluster setting that determines the on-disk format of the
+// files that vectorized operators spill to.
+var spillFormat = settings.RegisterEnumSetting(
+	"sql.distsql.temp_storage.spill_format",
+	"the on-disk format of the files that vectorized operators spill to",
+	"arrow",
+	map[int64]string{
+		int64(colcontainer.DiskQueueFormatArrow):   "arrow",
+		int64(colcontainer.DiskQueueFormatParquet): "parquet",
+	},
+)
+
+// HybridVectorizationEnabled is a cluster setting that determines whether the
+// vectorized flows run the processors that are expected to process few rows
+// in the row engine rather than all-or-nothing deciding on the vectorization
+// of the whole plan.
+var HybridVectorizationEnabled = settings.RegisterBoolSetting(
+	"sql.distsql.vectorize_hybrid.enabled",
+	"if set, the processors of vectorized flows that are estimated to read fewer rows "+
+		"than vectorize_row_count_threshold are run by the row engine",
+	false,
+)
+
 // countingSemaphore is a semaphore that keeps track of the semaphore count from
 // its perspective.
 type countingSemaphore struct {
@@ -90,6 +114,15 @@ type vectorizedFlow struct {
 	// Cleanup.
 	countingSemaphore *countingSemaphore
 
+	// progress tracks the progress of this flow. It is registered with the
+	// FlowProgressRegistry of the node (if there is one) once the flow has been
+	// set up successfully.
+	progress *execinfra.FlowProgress
+
+	// releaseWorkers, if set, releases the workers that the flow has acquired
+	// from the VectorizedWorkerPool of the node.
+	releaseWorkers func()
+
 	// streamingMemAccounts are the memory accounts that are tracking the static
 	// memory usage of the whole vectorized flow as well as all dynamic memory of
 	// the streaming components.
@@ -101,6 +134,10 @@ type vectorizedFlow struct {
 	// memory usage of the buffering components.
 	bufferingMemAccounts []*mon.BoundAccount
 
+	// toClose contains all Closers of the flow. They are closed in Cleanup
+	// regardless of how the flow exits.
+	toClose colexec.Closers
+
 	tempStorage struct {
 		// path is the path to this flow's temporary storage directory.
 		path string
@@ -158,8 +195,9 @@ func (f *vectorizedFlow) Setup(
 	}
 	f.tempStorage.path = filepath.Join(f.Cfg.TempStoragePath, tempDirName)
 	diskQueueCfg := colcontainer.DiskQueueCfg{
-		FS:   f.Cfg.TempFS,
-		Path: f.tempStorage.path,
+		FS:     f.Cfg.TempFS,
+		Path:   f.tempStorage.path,
+		Format: colcontainer.DiskQueueFormat(spillFormat.Get(&f.Cfg.Settings.SV)),
 		OnNewDiskQueueCb: func() {
 			if !atomic.CompareAndSwapInt32(&f.tempStorage.created, 0, 1) {
 				// The temporary storage directory has already been created.
@@ -186,21 +224,38 @@ func (f *vectorizedFlow) Setup(
 		diskQueueCfg,
 		f.countingSemaphore,
 	)
+	f.progress = execinfra.NewFlowProgress(
+		f.GetID(), f.GetFlowCtx().QueryID, f.GetFlowCtx().EstimatedRowCount,
+	)
+	creator.flowProgress = f.progress
 	if f.testingKnobs.onSetupFlow != nil {
 		f.testingKnobs.onSetupFlow(creator)
 	}
 	_, err = creator.setupFlow(ctx, f.GetFlowCtx(), spec.Processors, opt)
+	if p := f.Cfg.VectorizedWorkerPool; err == nil && p != nil {
+		// The start-up of the flow is queued until all of its goroutines can
+		// be started. The other flows of the query might be waiting for the
+		// streams to this flow to be connected, so we give up waiting (and fail
+		// the query) well before those time out.
+		maxWait := flowinfra.SettingFlowStreamTimeout.Get(&f.Cfg.Settings.SV) / 2
+		f.releaseWorkers, err = p.Acquire(ctx, creator.numWorkers, maxWait)
+	}
 	if err == nil {
+		if r := f.Cfg.FlowProgressRegistry; r != nil {
+			r.Register(f.progress)
+		}
 		f.operatorConcurrency = creator.operatorConcurrency
 		f.streamingMemAccounts = append(f.streamingMemAccounts, creator.streamingMemAccounts...)
 		f.bufferingMemMonitors = append(f.bufferingMemMonitors, creator.bufferingMemMonitors...)
 		f.bufferingMemAccounts = append(f.bufferingMemAccounts, creator.bufferingMemAccounts...)
+		f.toClose.Add(creator.toClose...)
 		log.VEventf(ctx, 1, "vectorized flow setup succeeded")
 		return ctx, nil
 	}
 	// It is (theoretically) possible that some of the memory monitoring
-	// infrastructure was created even in case of an error, and we need to clean
-	// that up.
+	// infrastructure and some Closers were created even in case of an error, and
+	// we need to clean that up.
+	creator.closeToClose(ctx)
 	for _, memAcc := range creator.streamingMemAccounts {
 		memAcc.Close(ctx)
 	}
@@ -267,6 +322,11 @@ func (f *vectorizedFlow) tryRemoveAll(root string) error {
 
 // Cleanup is part of the flowinfra.Flow interface.
 func (f *vectorizedFlow) Cleanup(ctx context.Context) {
+	// The Closers are closed first since they might need to release the memory
+	// that they have accounted for.
+	if err := f.toClose.CloseAll(ctx); err != nil {
+		log.Warningf(ctx, "error closing the operators of flow %s: %v", f.GetID().Short(), err)
+	}
 	// This cleans up all the memory monitoring of the vectorized flow.
 	for _, memAcc := range f.streamingMemAccounts {
 		memAcc.Close(ctx)
@@ -294,6 +354,13 @@ func (f *vectorizedFlow) Cleanup(ctx context.Context) {
 	if unreleased := atomic.LoadInt64(&f.countingSemaphore.count); unreleased > 0 {
 		f.countingSemaphore.Release(int(unreleased))
 	}
+	if r := f.Cfg.FlowProgressRegistry; r != nil && f.progress != nil {
+		r.Unregister(f.progress)
+	}
+	if f.releaseWorkers != nil {
+		// All goroutines of the flow have exited by now.
+		f.releaseWorkers()
+	}
 	f.FlowBase.Cleanup(ctx)
 	f.Release()
 }
@@ -348,6 +415,9 @@ func finishVectorizedStatsCollectors(
 			// Ignore stats collectors not associated with a processor.
 			continue
 		}
+		if vsc.OperatorDesc.Name != "" {
+			spansByProcID[vsc.ID].SetTag(execinfrapb.OperatorTagKey, vsc.OperatorDesc.String())
+		}
 		tracing.SetSpanStats(spansByProcID[vsc.ID], &vsc.VectorizedStats)
 	}
 	for _, sp := range spansByProcID {
@@ -438,6 +508,10 @@ type vectorizedFlowCreator struct {
 	leaves []execinfra.OpNode
 	// operatorConcurrency is set if any operators are executed in parallel.
 	operatorConcurrency bool
+	// numWorkers is the number of goroutines that the flow will start for the
+	// asynchronous components (routers and outboxes) and the parallel
+	// operators.
+	numWorkers int
 	// streamingMemAccounts contains all memory accounts of the non-buffering
 	// components in the vectorized flow.
 	streamingMemAccounts []*mon.BoundAccount
@@ -447,9 +521,18 @@ type vectorizedFlowCreator struct {
 	// bufferingMemAccounts contains all memory accounts of the buffering
 	// components in the vectorized flow.
 	bufferingMemAccounts []*mon.BoundAccount
+	// toClose contains all Closers planned in the vectorized flow.
+	toClose []colexec.Closer
 
 	diskQueueCfg colcontainer.DiskQueueCfg
 	fdSemaphore  semaphore.Semaphore
+
+	// operatorRegistry assigns IDs to all the operators of the flow as they are
+	// created so that the diagnostics of the flow can refer to them.
+	operatorRegistry *colexec.OperatorRegistry
+	// flowProgress, if set, is updated by the operators of the flow to report
+	// their progress.
+	flowProgress *execinfra.FlowProgress
 }
 
 func newVectorizedFlowCreator(
@@ -476,6 +559,7 @@ func newVectorizedFlowCreator(
 		flowID:                         flowID,
 		diskQueueCfg:                   diskQueueCfg,
 		fdSemaphore:                    fdSemaphore,
+		operatorRegistry:               colexec.NewOperatorRegistry(),
 	}
 }
 
@@ -496,6 +580,18 @@ func (s *vectorizedFlowCreator) createBufferingUnlimitedMemMonitor(
 	return bufferingOpUnlimitedMemMonitor
 }
 
+// closeToClose closes all Closers planned so far. It should only be used when
+// the flow is not going to be run (otherwise, the Closers are closed by the
+// flow in Cleanup).
+func (s *vectorizedFlowCreator) closeToClose(ctx context.Context) {
+	var closers colexec.Closers
+	closers.Add(s.toClose...)
+	if err := closers.CloseAll(ctx); err != nil {
+		log.VEventf(ctx, 1, "error closing the operators: %v", err)
+	}
+	s.toClose = nil
+}
+
 // newStreamingMemAccount creates a new memory account bound to the monitor in
 // flowCtx and accumulates it into streamingMemAccounts slice.
 func (s *vectorizedFlowCreator) newStreamingMemAccount(
@@ -524,6 +620,7 @@ func (s *vectorizedFlowCreator) setupRemoteOutputStream(
 	if err != nil {
 		return nil, err
 	}
+	s.operatorRegistry.RegisterTree(outbox)
 	atomic.AddInt32(&s.numOutboxes, 1)
 	run := func(ctx context.Context, cancelFn context.CancelFunc) {
 		outbox.Run(ctx, s.nodeDialer, stream.TargetNodeID, s.flowID, stream.StreamID, cancelFn)
@@ -540,6 +637,7 @@ func (s *vectorizedFlowCreator) setupRemoteOutputStream(
 			cancelFn()
 		}
 	}
+	s.numWorkers++
 	s.accumulateAsyncComponent(run)
 	return outbox, nil
 }
@@ -583,10 +681,14 @@ func (s *vectorizedFlowCreator) setupRouter(
 		limit = 1
 	}
 	router, outputs := colexec.NewHashRouter(allocators, input, outputTyps, output.HashColumns, limit, s.diskQueueCfg, s.fdSemaphore)
+	for _, op := range outputs {
+		s.operatorRegistry.RegisterTree(op)
+	}
 	runRouter := func(ctx context.Context, _ context.CancelFunc) {
 		logtags.AddTag(ctx, "hashRouterID", mmName)
 		router.Run(ctx)
 	}
+	s.numWorkers++
 	s.accumulateAsyncComponent(runRouter)
 
 	// Append the router to the metadata sources.
@@ -670,6 +772,7 @@ func (s *vectorizedFlowCreator) setupInput(
 			if err != nil {
 				return nil, nil, err
 			}
+			s.operatorRegistry.RegisterTree(inbox)
 			s.addStreamEndpoint(inputStream.StreamID, inbox, s.waitGroup)
 			metaSources = append(metaSources, inbox)
 			op = inbox
@@ -711,12 +814,14 @@ func (s *vectorizedFlowCreator) setupInput(
 			} else {
 				op = colexec.NewParallelUnorderedSynchronizer(inputStreamOps, typs, s.waitGroup)
 				s.operatorConcurrency = true
+				s.numWorkers += len(inputStreamOps)
 			}
 			// Don't use the unordered synchronizer's inputs for stats collection
 			// given that they run concurrently. The stall time will be collected
 			// instead.
 			statsInputs = nil
 		}
+		s.operatorRegistry.RegisterTree(op)
 		if s.recordingStats {
 			// TODO(asubiotto): Once we have IDs for synchronizers, plumb them into
 			// this stats collector to display stats.
@@ -900,6 +1005,9 @@ func (s *vectorizedFlowCreator) setupFlow(
 			ProcessorConstructor: rowexec.NewProcessor,
 			DiskQueueCfg:         s.diskQueueCfg,
 			FDSemaphore:          s.fdSemaphore,
+			OperatorRegistry:     s.operatorRegistry,
+			FlowProgress:         s.flowProgress,
+			RunInRowEngine:       shouldRunInRowEngine(flowCtx, pspec),
 		}
 		result, err := colexec.NewColOperator(ctx, flowCtx, args)
 		// Even when err is non-nil, it is possible that the buffering memory
@@ -908,11 +1016,21 @@ func (s *vectorizedFlowCreator) setupFlow(
 		s.bufferingMemMonitors = append(s.bufferingMemMonitors, result.BufferingOpMemMonitors...)
 		s.bufferingMemAccounts = append(s.bufferingMemAccounts, result.BufferingOpMemAccounts...)
 		if err != nil {
+			s.toClose = append(s.toClose, result.ToClose...)
 			return nil, errors.Wrapf(err, "unable to vectorize execution plan")
 		}
 		if flowCtx.Cfg != nil && flowCtx.Cfg.TestingKnobs.EnableVectorizedInvariantsChecker {
-			result.Op = colexec.NewInvariantsChecker(result.Op, len(result.ColumnTypes))
+			checker := colexec.NewInvariantsChecker(result.Op, len(result.ColumnTypes))
+			// The invariants checker verifies that the operator it wraps is closed
+			// only once, so it replaces that operator in the Closers registry.
+			for i, c := range result.ToClose {
+				if interface{}(c) == interface{}(result.Op) {
+					result.ToClose[i] = checker.(colexec.Closer)
+				}
+			}
+			result.Op = checker
 		}
+		s.toClose = append(s.toClose, result.ToClose...)
 		if flowCtx.EvalCtx.SessionData.VectorizeMode == sessiondata.Vectorize192Auto &&
 			!result.IsStreaming {
 			return nil, errors.Errorf("non-streaming operator encountered when vectorize=192auto")
@@ -930,11 +1048,21 @@ func (s *vectorizedFlowCreator) setupFlow(
 		metadataSourcesQueue = append(metadataSourcesQueue, result.MetadataSources...)
 
 		op := result.Op
+		if _, ok := op.(*colexec.Columnarizer); !ok {
+			// The errors are annotated on the boundaries of the processors so
+			// that they are attributed to the processor in which they occurred.
+			// The Columnarizers are not wrapped so that their consumers can
+			// still remove them (a Columnarizer doesn't produce errors of its
+			// own anyway).
+			op = colexec.NewOperatorErrorAnnotator(op, result.Op)
+		}
 		if s.recordingStats {
 			vsc, err := wrapWithVectorizedStatsCollector(op, inputs, pspec)
 			if err != nil {
 				return nil, err
 			}
+			// The stats collector gets the descriptor of the operator it wraps.
+			s.operatorRegistry.RegisterTree(vsc)
 			s.vectorizedStatsCollectorsQueue = append(s.vectorizedStatsCollectorsQueue, vsc)
 			s.procIDs = append(s.procIDs, pspec.ProcessorID)
 			op = vsc
@@ -1123,11 +1251,44 @@ func (r *noopFlowCreatorHelper) getCancelFlowFn() context.CancelFunc {
 	return nil
 }
 
+// shouldRunInRowEngine returns whether the processor should be run in the row
+// engine even if it is supported by the vectorized engine. This is the case
+// when the hybrid vectorization is enabled and the processor is estimated to
+// read fewer rows than the vectorize row count threshold, in which case the
+// overhead of the vectorized engine is not expected to pay off. A processor
+// has an estimate only if all of its inputs have one, and its estimate is then
+// at least as high as theirs (see physicalplan.PhysicalPlan.EstimatedRowCounts),
+// so such processors form contiguous row-based fragments that are only
+// columnarized at the boundaries with the vectorized fragments. The processors
+// without an estimate are never run in the row engine.
+//
+// Note that the estimates are only available on the gateway, so the processors
+// on the remote nodes are always vectorized when possible.
+func shouldRunInRowEngine(flowCtx *execinfra.FlowCtx, pspec *execinfrapb.ProcessorSpec) bool {
+	if flowCtx.ProcessorEstimatedRowCounts == nil || flowCtx.Cfg == nil || flowCtx.Cfg.Settings == nil ||
+		!HybridVectorizationEnabled.Get(&flowCtx.Cfg.Settings.SV) {
+		return false
+	}
+	switch flowCtx.EvalCtx.SessionData.VectorizeMode {
+	case sessiondata.Vectorize192Auto, sessiondata.VectorizeAuto:
+	default:
+		// The user has explicitly asked for the vectorized engine.
+		return false
+	}
+	if pspec.Core.Noop != nil {
+		// Noop processors are trivial in both engines, and wrapping them would
+		// only introduce unnecessary conversions.
+		return false
+	}
+	estimate, ok := flowCtx.ProcessorEstimatedRowCounts[pspec.ProcessorID]
+	return ok && estimate < flowCtx.EvalCtx.SessionData.VectorizeRowCountThreshold
+}
+
 // SupportsVectorized checks whether flow is supported by the vectorized engine
 // and returns an error if it isn't. Note that it does so by setting up the
 // full flow without running the components asynchronously.
-// It returns a list of the leaf operators of all flows for the purposes of
-// EXPLAIN output.
+// It returns a list of the leaf operators of all flows as well as the registry
+// that has assigned IDs to the operators for the purposes of EXPLAIN output.
 // Note that passed-in output can be nil, but if it is non-nil, only Types()
 // method on it might be called (nothing will actually get Push()'ed into it).
 func SupportsVectorized(
@@ -1136,7 +1297,7 @@ func SupportsVectorized(
 	processorSpecs []execinfrapb.ProcessorSpec,
 	fuseOpt flowinfra.FuseOpt,
 	output execinfra.RowReceiver,
-) (leaves []execinfra.OpNode, err error) {
+) (leaves []execinfra.OpNode, registry *colexec.OperatorRegistry, err error) {
 	if output == nil {
 		output = &execinfra.RowChannel{}
 	}
@@ -1157,6 +1318,7 @@ func SupportsVectorized(
 	memoryMonitor.Start(ctx, nil, mon.MakeStandaloneBudget(math.MaxInt64))
 	defer memoryMonitor.Stop(ctx)
 	defer func() {
+		creator.closeToClose(ctx)
 		for _, memAcc := range creator.streamingMemAccounts {
 			memAcc.Close(ctx)
 		}
@@ -1170,9 +1332,9 @@ func SupportsVectorized(
 	if vecErr := execerror.CatchVectorizedRuntimeError(func() {
 		leaves, err = creator.setupFlow(ctx, flowCtx, processorSpecs, fuseOpt)
 	}); vecErr != nil {
-		return leaves, vecErr
+		return leaves, creator.operatorRegistry, vecErr
 	}
-	return leaves, err
+	return leaves, creator.operatorRegistry, err
 }
 
 // VectorizeAlwaysException is an object that returns whether or not execution
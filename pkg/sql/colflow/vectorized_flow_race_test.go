@@ -0,0 +1,394 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colflow_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/colflow/colrpc"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/colcontainerutils"
+	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/marusama/semaphore"
+	"github.com/stretchr/testify/require"
+)
+
+// delayingOperator is an Operator that, before calling Next on its input,
+// randomly either sleeps for a short while or yields the processor. It is
+// used to shuffle the scheduling of the goroutines of a flow.
+type delayingOperator struct {
+	colexec.OneInputNode
+	rng *rand.Rand
+}
+
+var _ colexec.Operator = &delayingOperator{}
+
+// newDelayingOperator returns a new delayingOperator. It gets its own random
+// number generator (seeded from rng) since the delayingOperators of a flow are
+// used concurrently.
+func newDelayingOperator(rng *rand.Rand, input colexec.Operator) colexec.Operator {
+	return &delayingOperator{
+		OneInputNode: colexec.NewOneInputNode(input),
+		rng:          rand.New(rand.NewSource(rng.Int63())),
+	}
+}
+
+func (d *delayingOperator) Init() {
+	d.Input().Init()
+}
+
+func (d *delayingOperator) Next(ctx context.Context) coldata.Batch {
+	switch p := d.rng.Float64(); {
+	case p < 0.1:
+		time.Sleep(time.Duration(d.rng.Intn(500)) * time.Microsecond)
+	case p < 0.5:
+		runtime.Gosched()
+	}
+	return d.Input().Next(ctx)
+}
+
+type raceScenario struct {
+	string
+}
+
+var (
+	// raceFullyConsumed is the scenario in which the output of the flow is
+	// fully consumed and verified.
+	raceFullyConsumed = raceScenario{"FullyConsumed"}
+	// raceConsumerClosed is the scenario in which the consumer closes the flow
+	// after having read a random number of rows.
+	raceConsumerClosed = raceScenario{"ConsumerClosed"}
+	// raceRemoteCanceled is the scenario in which the context of the remote
+	// node is canceled after the consumer has read a random number of rows.
+	raceRemoteCanceled = raceScenario{"RemoteCanceled"}
+	raceScenarios      = []raceScenario{raceFullyConsumed, raceConsumerClosed, raceRemoteCanceled}
+)
+
+// TestVectorizedFlowRaceStress is a stress test that is meant to be run with
+// the race detector. It runs multi-stream flows of the following shape:
+//
+//                     Remote Node            |     Local Node
+//                                            |
+//             -> output -> sort -> Outbox -> | -> Inbox -> |
+//            |                               |             |
+// Hash Router -> output -> sort -> Outbox -> | -> Inbox -> | -> Synchronizer -> materializer
+//            |                               |             |
+//             -> output -> sort -> Outbox -> | -> Inbox -> |
+//
+// The outputs of the hash router spill to disk because of their small memory
+// limit, and the sorts are forced to spill to disk after a random number of
+// tuples, so the transitions to disk happen while the other streams are being
+// concurrently consumed. The synchronizer is randomly either ordered or
+// parallel unordered, and every stage is wrapped in a delayingOperator to
+// randomize the scheduling. The flow is then either fully consumed (in which
+// case the output is verified), closed by the consumer, or canceled on the
+// remote node mid-way.
+func TestVectorizedFlowRaceStress(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.Background())
+	_, mockServer, addr, err := execinfrapb.StartMockDistSQLServer(
+		hlc.NewClock(hlc.UnixNano, time.Nanosecond), stopper, execinfra.StaticNodeID,
+	)
+	require.NoError(t, err)
+	dialer := &execinfrapb.MockDialer{Addr: addr}
+	defer dialer.Close()
+
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	numRuns := 5
+	if util.RaceEnabled {
+		// The race detector slows the test down considerably, but this is also
+		// the configuration in which the test is the most useful.
+		numRuns = 3
+	}
+	if testing.Short() {
+		numRuns = 1
+	}
+	for run := 0; run < numRuns; run++ {
+		for _, scenario := range raceScenarios {
+			t.Run(fmt.Sprintf("run=%d/scenario=%s", run, scenario.string), func(t *testing.T) {
+				runVectorizedFlowRaceStress(t, mockServer, dialer, queueCfg, scenario)
+			})
+		}
+	}
+}
+
+func runVectorizedFlowRaceStress(
+	t *testing.T,
+	mockServer *execinfrapb.MockDistSQLServer,
+	dialer *execinfrapb.MockDialer,
+	queueCfg colcontainer.DiskQueueCfg,
+	scenario raceScenario,
+) {
+	ctxLocal, cancelLocal := context.WithCancel(context.Background())
+	defer cancelLocal()
+	ctxRemote, cancelRemote := context.WithCancel(context.Background())
+	defer cancelRemote()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctxLocal)
+	rng, _ := randutil.NewPseudoRand()
+	tempEngine, _, err := storage.NewPebbleTempEngine(ctxLocal, base.DefaultTestTempStorageConfig(st), base.DefaultTestStoreSpec)
+	require.NoError(t, err)
+	defer tempEngine.Close()
+	diskMonitor := execinfra.NewTestDiskMonitor(ctxLocal, st)
+	defer diskMonitor.Stop(ctxLocal)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings:    st,
+			TempStorage: tempEngine,
+			DiskMonitor: diskMonitor,
+			TestingKnobs: execinfra.TestingKnobs{
+				ForceDiskSpillAfterNumTuples: 1 + rng.Intn(2*coldata.BatchSize()),
+			},
+		},
+	}
+
+	var (
+		wg               sync.WaitGroup
+		typs             = []coltypes.T{coltypes.Int64}
+		semtyps          = []types.T{*types.Int}
+		numStreams       = 1 + rng.Intn(4)
+		useOrderedSync   = rng.Float64() < 0.5
+		handleStreamErrs = make([]chan error, numStreams)
+		// fdSemaphore is shared by all disk-backed components of the flow (as
+		// it is on a real node), so it must be safe for concurrent use.
+		fdSemaphore = semaphore.New(256)
+		// toClose and memAccounts are closed and monitors are stopped once all
+		// goroutines of the flow have exited.
+		toClose     colexec.Closers
+		memAccounts []*mon.BoundAccount
+		monitors    []*mon.BytesMonitor
+		// expected is accessed only by the goroutine running the hash router
+		// until the flow is shut down.
+		expected []int64
+	)
+	defer func() {
+		require.NoError(t, toClose.CloseAll(ctxLocal))
+		for _, acc := range memAccounts {
+			acc.Close(ctxLocal)
+		}
+		for _, m := range monitors {
+			m.Stop(ctxLocal)
+		}
+	}()
+	newMemAccount := func() *mon.BoundAccount {
+		acc := testMemMonitor.MakeBoundAccount()
+		memAccounts = append(memAccounts, &acc)
+		return &acc
+	}
+
+	source := colexec.NewRandomDataOp(
+		colexec.NewAllocator(ctxRemote, newMemAccount()),
+		rand.New(rand.NewSource(rng.Int63())),
+		colexec.RandomDataOpArgs{
+			DeterministicTyps: typs,
+			NumBatches:        1 + rng.Intn(8),
+			Selection:         true,
+			BatchAccumulator: func(b coldata.Batch) {
+				col := b.ColVec(0).Int64()
+				sel := b.Selection()
+				for i := 0; i < b.Length(); i++ {
+					if sel != nil {
+						expected = append(expected, col[sel[i]])
+					} else {
+						expected = append(expected, col[i])
+					}
+				}
+			},
+		},
+	)
+	allocators := make([]*colexec.Allocator, numStreams)
+	for i := range allocators {
+		allocators[i] = colexec.NewAllocator(ctxRemote, newMemAccount())
+	}
+	// A tiny memory limit makes the router outputs spill to disk almost
+	// immediately.
+	routerMemLimit := 1 + rng.Int63n(64<<10)
+	hashRouter, hashRouterOutputs := colexec.NewHashRouter(
+		allocators, newDelayingOperator(rng, source), typs, []uint32{0}, routerMemLimit,
+		queueCfg, fdSemaphore,
+	)
+	wg.Add(1)
+	go func() {
+		hashRouter.Run(ctxRemote)
+		wg.Done()
+	}()
+
+	flowID := execinfrapb.FlowID{UUID: uuid.MakeV4()}
+	inboxes := make([]*colrpc.Inbox, numStreams)
+	syncInputs := make([]colexec.Operator, numStreams)
+	metadataSources := make([]execinfrapb.MetadataSource, numStreams)
+	for i := 0; i < numStreams; i++ {
+		sortResult, err := colexec.NewColOperator(ctxRemote, flowCtx, colexec.NewColOperatorArgs{
+			Spec: &execinfrapb.ProcessorSpec{
+				Input: []execinfrapb.InputSyncSpec{{ColumnTypes: semtyps}},
+				Core: execinfrapb.ProcessorCoreUnion{
+					Sorter: &execinfrapb.SorterSpec{
+						OutputOrdering: execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}},
+					},
+				},
+			},
+			Inputs:              []colexec.Operator{newDelayingOperator(rng, hashRouterOutputs[i])},
+			StreamingMemAccount: newMemAccount(),
+			DiskQueueCfg:        queueCfg,
+			FDSemaphore:         fdSemaphore,
+		})
+		require.NoError(t, err)
+		memAccounts = append(memAccounts, sortResult.BufferingOpMemAccounts...)
+		monitors = append(monitors, sortResult.BufferingOpMemMonitors...)
+		toClose.Add(sortResult.ToClose...)
+		outboxMetadataSources := sortResult.MetadataSources
+		if i == 0 {
+			// Only one outbox should drain the hash router.
+			outboxMetadataSources = append(outboxMetadataSources, hashRouter)
+		}
+		outbox, err := colrpc.NewOutbox(
+			colexec.NewAllocator(ctxRemote, newMemAccount()),
+			newDelayingOperator(rng, sortResult.Op), typs, outboxMetadataSources,
+		)
+		require.NoError(t, err)
+		wg.Add(1)
+		go func(id int) {
+			outbox.Run(ctxRemote, dialer, execinfra.StaticNodeID, flowID, execinfrapb.StreamID(id), cancelRemote)
+			wg.Done()
+		}(i)
+
+		inboxes[i], err = colrpc.NewInbox(
+			colexec.NewAllocator(ctxLocal, newMemAccount()), typs, execinfrapb.StreamID(i),
+		)
+		require.NoError(t, err)
+		serverStreamNotification := <-mockServer.InboundStreams
+		serverStream := serverStreamNotification.Stream
+		handleStreamErrs[i] = make(chan error, 1)
+		wg.Add(1)
+		go func(id int, stream execinfrapb.DistSQL_FlowStreamServer, donec chan<- error) {
+			handleStreamErrs[id] <- inboxes[id].RunWithStream(stream.Context(), stream)
+			close(donec)
+			wg.Done()
+		}(i, serverStream, serverStreamNotification.Donec)
+		syncInputs[i] = newDelayingOperator(rng, inboxes[i])
+		metadataSources[i] = inboxes[i]
+	}
+
+	var synchronizer colexec.Operator
+	if useOrderedSync {
+		synchronizer = colexec.NewOrderedSynchronizer(
+			colexec.NewAllocator(ctxLocal, newMemAccount()), syncInputs, typs,
+			sqlbase.ColumnOrdering{{ColIdx: 0, Direction: encoding.Ascending}},
+		)
+	} else {
+		synchronizer = colexec.NewParallelUnorderedSynchronizer(syncInputs, typs, &wg)
+	}
+	materializer, err := colexec.NewMaterializer(
+		flowCtx,
+		1, /* processorID */
+		synchronizer,
+		semtyps,
+		&execinfrapb.PostProcessSpec{},
+		nil, /* output */
+		metadataSources,
+		nil, /* outputStatsToTrace */
+		func() context.CancelFunc { return cancelLocal },
+	)
+	require.NoError(t, err)
+	materializer.Start(ctxLocal)
+
+	// next returns the next row of the flow (nil once the flow is done),
+	// failing the test on any unexpected errors. Errors caused by the
+	// cancellation of the remote node are allowed only if canceled is true.
+	canceled := false
+	next := func() sqlbase.EncDatumRow {
+		for {
+			row, meta := materializer.Next()
+			if meta == nil {
+				return row
+			}
+			if meta.Err != nil {
+				require.True(t, canceled, "unexpected error: %v", meta.Err)
+				require.True(t, testutils.IsError(meta.Err, "context canceled"), meta.Err)
+			}
+		}
+	}
+	var actual []int64
+	readRows := func(n int) {
+		for i := 0; i < n; i++ {
+			row := next()
+			if row == nil {
+				return
+			}
+			actual = append(actual, int64(*row[0].Datum.(*tree.DInt)))
+		}
+	}
+
+	switch scenario {
+	case raceFullyConsumed:
+		readRows(math.MaxInt64)
+	case raceConsumerClosed:
+		readRows(rng.Intn(2 * coldata.BatchSize()))
+		materializer.ConsumerClosed()
+	case raceRemoteCanceled:
+		readRows(rng.Intn(2 * coldata.BatchSize()))
+		canceled = true
+		cancelRemote()
+		readRows(math.MaxInt64)
+	}
+
+	// Simulate the shutdown of the flow on the remote node (see
+	// TestVectorizedFlowShutdown).
+	cancelRemote()
+	for i := range handleStreamErrs {
+		if err := <-handleStreamErrs[i]; err != nil {
+			require.True(t, testutils.IsError(err, "context canceled"), err)
+		}
+	}
+	wg.Wait()
+
+	if scenario == raceFullyConsumed {
+		if useOrderedSync {
+			require.True(t, sort.SliceIsSorted(actual, func(i, j int) bool { return actual[i] < actual[j] }))
+		}
+		sort.Slice(actual, func(i, j int) bool { return actual[i] < actual[j] })
+		sort.Slice(expected, func(i, j int) bool { return expected[i] < expected[j] })
+		require.Equal(t, expected, actual)
+	}
+}
@@ -44,6 +44,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
 	"gopkg.in/yaml.v2"
 )
@@ -85,6 +86,7 @@ var crdbInternal = virtualSchema{
 		sqlbase.CrdbInternalLocalQueriesTableID:         crdbInternalLocalQueriesTable,
 		sqlbase.CrdbInternalLocalSessionsTableID:        crdbInternalLocalSessionsTable,
 		sqlbase.CrdbInternalLocalMetricsTableID:         crdbInternalLocalMetricsTable,
+		sqlbase.CrdbInternalLocalVectorizedFlowsTableID: crdbInternalLocalVectorizedFlowsTable,
 		sqlbase.CrdbInternalPartitionsTableID:           crdbInternalPartitionsTable,
 		sqlbase.CrdbInternalPredefinedCommentsTableID:   crdbInternalPredefinedCommentsTable,
 		sqlbase.CrdbInternalRangesNoLeasesTableID:       crdbInternalRangesNoLeasesTable,
@@ -639,7 +641,9 @@ CREATE TABLE crdb_internal.node_statement_statistics (
   overhead_lat_var    FLOAT NOT NULL,
   bytes_read          INT NOT NULL,
   rows_read           INT NOT NULL,
-  implicit_txn        BOOL NOT NULL
+  implicit_txn        BOOL NOT NULL,
+  spill_count         INT NOT NULL,
+  max_bytes_spilled   INT NOT NULL
 )`,
 	populate: func(ctx context.Context, p *planner, _ *DatabaseDescriptor, addRow func(...tree.Datum) error) error {
 		if err := p.RequireAdminRole(ctx, "access application statistics"); err != nil {
@@ -718,6 +722,8 @@ CREATE TABLE crdb_internal.node_statement_statistics (
 					tree.NewDInt(tree.DInt(s.data.BytesRead)),
 					tree.NewDInt(tree.DInt(s.data.RowsRead)),
 					tree.MakeDBool(tree.DBool(stmtKey.implicitTxn)),
+					tree.NewDInt(tree.DInt(s.data.SpillCount)),
+					tree.NewDInt(tree.DInt(s.data.MaxBytesSpilled)),
 				)
 				s.Unlock()
 				if err != nil {
@@ -1145,6 +1151,61 @@ func populateSessionsTable(
 	return nil
 }
 
+// crdbInternalLocalVectorizedFlowsTable exposes the progress of the
+// vectorized flows currently running on this node.
+var crdbInternalLocalVectorizedFlowsTable = virtualSchemaTable{
+	comment: "progress of vectorized flows running on this node (RAM; local node only)",
+	schema: `
+CREATE TABLE crdb_internal.node_vectorized_flows (
+  node_id        INT NOT NULL,       -- the node on which the flow is running
+  query_id       STRING,             -- the ID of the query, NULL for the remote flows
+  flow_id        UUID,               -- the ID of the flow (shared by the flows of a distributed query), NULL for local flows
+  start          TIMESTAMP NOT NULL, -- the time at which the flow was set up
+  phase          STRING NOT NULL,    -- what the flow is currently busy with
+  rows_read      INT NOT NULL,       -- number of rows read by the scans of the flow
+  estimated_rows INT,                -- number of rows the scans of the flow are estimated to read, if known
+  paused         BOOL NOT NULL       -- whether the flow has been paused with crdb_internal.pause_vectorized_flows
+)`,
+	populate: func(ctx context.Context, p *planner, _ *DatabaseDescriptor, addRow func(...tree.Datum) error) error {
+		if err := p.RequireAdminRole(ctx, "read crdb_internal.node_vectorized_flows"); err != nil {
+			return err
+		}
+
+		registry := p.ExecCfg().DistSQLSrv.ServerConfig.FlowProgressRegistry
+		if registry == nil {
+			return nil
+		}
+		nodeID := tree.NewDInt(tree.DInt(int64(p.ExecCfg().NodeID.Get())))
+		for _, progress := range registry.Flows() {
+			queryID := tree.DNull
+			if progress.QueryID != "" {
+				queryID = tree.NewDString(progress.QueryID)
+			}
+			flowID := tree.DNull
+			if !progress.FlowID.Equal(uuid.Nil) {
+				flowID = tree.NewDUuid(tree.DUuid{UUID: progress.FlowID.UUID})
+			}
+			estimatedRows := tree.DNull
+			if progress.EstimatedRowCount != 0 {
+				estimatedRows = tree.NewDInt(tree.DInt(progress.EstimatedRowCount))
+			}
+			if err := addRow(
+				nodeID,
+				queryID,
+				flowID,
+				tree.MakeDTimestamp(progress.StartTime, time.Microsecond),
+				tree.NewDString(progress.Phase().String()),
+				tree.NewDInt(tree.DInt(progress.RowsRead())),
+				estimatedRows,
+				tree.MakeDBool(tree.DBool(progress.Paused())),
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
 // crdbInternalLocalMetricsTable exposes a snapshot of the metrics on the
 // current node.
 var crdbInternalLocalMetricsTable = virtualSchemaTable{
@@ -0,0 +1,400 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package distsql
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+// randomConfig is a randomly generated processor configuration together with
+// its inputs that is run through both the row-by-row and the vectorized
+// engines.
+type randomConfig struct {
+	// description is a human-readable summary of the configuration that is
+	// printed out when the engines disagree.
+	description string
+	args        verifyColOperatorArgs
+}
+
+// randomConfigGenerator generates a random processor core of a particular
+// kind. It returns the configuration with the post-processing spec unset.
+type randomConfigGenerator func(rng *rand.Rand) randomConfig
+
+// randomConfigGenerators are all processor cores that the randomized
+// equivalence test knows how to generate.
+var randomConfigGenerators = map[string]randomConfigGenerator{
+	"sorter":     generateRandomSorter,
+	"distinct":   generateRandomDistinct,
+	"aggregator": generateRandomAggregator,
+	"hashjoiner": generateRandomHashJoiner,
+}
+
+// TestRandomConfigsAgainstProcessor generates random processor configurations
+// (a processor core with random inputs followed by random post-processing)
+// and verifies that the vectorized engine produces the same results as the
+// row-by-row engine, including the handling of NULLs and the ordering
+// guarantees of the output.
+func TestRandomConfigsAgainstProcessor(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	rng, seed := randutil.NewPseudoRand()
+
+	nRuns := 20
+	for run := 0; run < nRuns; run++ {
+		for name, generator := range randomConfigGenerators {
+			config := generator(rng)
+			addRandomPostProcessing(rng, &config)
+			if err := verifyColOperator(config.args); err != nil {
+				// Columnar operators check whether an overflow occurs whereas
+				// processors don't, so we simply swallow the integer out of range
+				// error if such occurs and move on.
+				if strings.Contains(err.Error(), tree.ErrIntOutOfRange.Error()) {
+					continue
+				}
+				fmt.Printf("--- seed = %d run = %d core = %s ---\n%s\n", seed, run, name, config.description)
+				for i := range config.args.inputs {
+					tableName := fmt.Sprintf("t%d", i)
+					prettyPrintTypes(config.args.inputTypes[i], tableName)
+					prettyPrintInput(config.args.inputs[i], config.args.inputTypes[i], tableName)
+				}
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+// generateRandomRows generates nRows rows of random supported types. Each
+// column has a chance of consisting solely of NULLs since such columns are
+// often handled by separate code paths in the vectorized engine.
+func generateRandomRows(rng *rand.Rand, nRows int, typs []types.T) sqlbase.EncDatumRows {
+	rows := sqlbase.RandEncDatumRowsOfTypes(rng, nRows, typs)
+	for colIdx := range typs {
+		allNulls := rng.Float64() < nullProbability
+		for _, row := range rows {
+			if allNulls || rng.Float64() < nullProbability {
+				row[colIdx] = sqlbase.EncDatum{Datum: tree.DNull}
+			}
+		}
+	}
+	return rows
+}
+
+func generateRandomSorter(rng *rand.Rand) randomConfig {
+	nCols := 1 + rng.Intn(4)
+	nRows := rng.Intn(300)
+	inputTypes := generateRandomSupportedTypes(rng, nCols)
+	rows := generateRandomRows(rng, nRows, inputTypes)
+
+	// If the ordering doesn't include all of the columns, the output is only
+	// partially ordered, so we compare the results as sets and rely on the
+	// ordering check instead.
+	nOrderingCols := 1 + rng.Intn(nCols)
+	ordering := execinfrapb.Ordering{Columns: generateColumnOrdering(rng, nCols, nOrderingCols)}
+	return randomConfig{
+		description: fmt.Sprintf("sorter ordering = %v", ordering),
+		args: verifyColOperatorArgs{
+			anyOrder:       nOrderingCols < nCols,
+			outputOrdering: ordering,
+			inputTypes:     [][]types.T{inputTypes},
+			inputs:         []sqlbase.EncDatumRows{rows},
+			outputTypes:    inputTypes,
+			pspec: &execinfrapb.ProcessorSpec{
+				Input: []execinfrapb.InputSyncSpec{{ColumnTypes: inputTypes}},
+				Core: execinfrapb.ProcessorCoreUnion{
+					Sorter: &execinfrapb.SorterSpec{OutputOrdering: ordering},
+				},
+			},
+		},
+	}
+}
+
+func generateRandomDistinct(rng *rand.Rand) randomConfig {
+	nCols := 1 + rng.Intn(3)
+	nRows := rng.Intn(300)
+	// Distinct is more interesting when there are duplicates, so we use a
+	// small set of values most of the time.
+	var (
+		inputTypes []types.T
+		rows       sqlbase.EncDatumRows
+	)
+	if rng.Float64() < randTypesProbability {
+		inputTypes = generateRandomSupportedTypes(rng, nCols)
+		rows = generateRandomRows(rng, nRows, inputTypes)
+	} else {
+		inputTypes = make([]types.T, nCols)
+		for i := range inputTypes {
+			inputTypes[i] = *types.Int
+		}
+		rows = sqlbase.MakeRandIntRowsInRange(rng, nRows, nCols, 3 /* maxNum */, nullProbability)
+	}
+	// We use all columns as the distinct columns since otherwise the values in
+	// the remaining columns are not deterministic.
+	distinctCols := make([]uint32, nCols)
+	for i, col := range rng.Perm(nCols) {
+		distinctCols[i] = uint32(col)
+	}
+	return randomConfig{
+		description: fmt.Sprintf("distinct cols = %v", distinctCols),
+		args: verifyColOperatorArgs{
+			anyOrder:    true,
+			inputTypes:  [][]types.T{inputTypes},
+			inputs:      []sqlbase.EncDatumRows{rows},
+			outputTypes: inputTypes,
+			pspec: &execinfrapb.ProcessorSpec{
+				Input: []execinfrapb.InputSyncSpec{{ColumnTypes: inputTypes}},
+				Core: execinfrapb.ProcessorCoreUnion{
+					Distinct: &execinfrapb.DistinctSpec{DistinctColumns: distinctCols},
+				},
+			},
+		},
+	}
+}
+
+func generateRandomAggregator(rng *rand.Rand) randomConfig {
+	nGroupingCols := rng.Intn(3)
+	nAggCols := 1 + rng.Intn(3)
+	nRows := rng.Intn(300)
+
+	// The grouping columns are INTs with few distinct values so that the
+	// groups are non-trivial.
+	inputTypes := make([]types.T, 0, nGroupingCols+nAggCols)
+	for i := 0; i < nGroupingCols; i++ {
+		inputTypes = append(inputTypes, *types.Int)
+	}
+	inputTypes = append(inputTypes, generateRandomSupportedTypes(rng, nAggCols)...)
+	rows := generateRandomRows(rng, nRows, inputTypes)
+	for _, row := range rows {
+		for i := 0; i < nGroupingCols; i++ {
+			if !row[i].IsNull() {
+				row[i] = sqlbase.EncDatum{Datum: tree.NewDInt(tree.DInt(rng.Intn(4)))}
+			}
+		}
+	}
+
+	groupCols := make([]uint32, nGroupingCols)
+	var (
+		aggregations []execinfrapb.AggregatorSpec_Aggregation
+		outputTypes  []types.T
+	)
+	// The values of the grouping columns are included into the output so that
+	// the groups with NULL keys can be told apart.
+	for i := range groupCols {
+		groupCols[i] = uint32(i)
+		aggregations = append(aggregations, execinfrapb.AggregatorSpec_Aggregation{
+			Func:   execinfrapb.AggregatorSpec_ANY_NOT_NULL,
+			ColIdx: []uint32{uint32(i)},
+		})
+		outputTypes = append(outputTypes, inputTypes[i])
+	}
+	aggFns := []execinfrapb.AggregatorSpec_Func{
+		execinfrapb.AggregatorSpec_COUNT_ROWS,
+		execinfrapb.AggregatorSpec_COUNT,
+		execinfrapb.AggregatorSpec_MIN,
+		execinfrapb.AggregatorSpec_MAX,
+	}
+	for colIdx := nGroupingCols; colIdx < len(inputTypes); colIdx++ {
+		aggFn := aggFns[rng.Intn(len(aggFns))]
+		aggInputTypes := []types.T{inputTypes[colIdx]}
+		aggregation := execinfrapb.AggregatorSpec_Aggregation{
+			Func:   aggFn,
+			ColIdx: []uint32{uint32(colIdx)},
+		}
+		if aggFn == execinfrapb.AggregatorSpec_COUNT_ROWS {
+			// Count rows takes no arguments.
+			aggInputTypes = nil
+			aggregation.ColIdx = []uint32{}
+		}
+		_, outputType, err := execinfrapb.GetAggregateInfo(aggFn, aggInputTypes...)
+		if err != nil {
+			// The aggregate function is not defined on this type, so we fall back
+			// to counting the rows.
+			aggregation.Func = execinfrapb.AggregatorSpec_COUNT_ROWS
+			aggregation.ColIdx = []uint32{}
+			outputType = types.Int
+		}
+		aggregations = append(aggregations, aggregation)
+		outputTypes = append(outputTypes, *outputType)
+	}
+
+	aggType := execinfrapb.AggregatorSpec_NON_SCALAR
+	if nGroupingCols == 0 {
+		aggType = execinfrapb.AggregatorSpec_SCALAR
+	}
+	return randomConfig{
+		description: fmt.Sprintf("aggregator group cols = %v aggregations = %v", groupCols, aggregations),
+		args: verifyColOperatorArgs{
+			anyOrder:    true,
+			inputTypes:  [][]types.T{inputTypes},
+			inputs:      []sqlbase.EncDatumRows{rows},
+			outputTypes: outputTypes,
+			pspec: &execinfrapb.ProcessorSpec{
+				Input: []execinfrapb.InputSyncSpec{{ColumnTypes: inputTypes}},
+				Core: execinfrapb.ProcessorCoreUnion{
+					Aggregator: &execinfrapb.AggregatorSpec{
+						Type:         aggType,
+						GroupCols:    groupCols,
+						Aggregations: aggregations,
+					},
+				},
+			},
+		},
+	}
+}
+
+func generateRandomHashJoiner(rng *rand.Rand) randomConfig {
+	joinTypes := []sqlbase.JoinType{
+		sqlbase.JoinType_INNER,
+		sqlbase.JoinType_LEFT_OUTER,
+		sqlbase.JoinType_RIGHT_OUTER,
+		sqlbase.JoinType_FULL_OUTER,
+		sqlbase.JoinType_LEFT_SEMI,
+		sqlbase.JoinType_LEFT_ANTI,
+	}
+	joinType := joinTypes[rng.Intn(len(joinTypes))]
+	nCols := 1 + rng.Intn(3)
+	nEqCols := 1 + rng.Intn(nCols)
+
+	// The equality columns are INTs in a small range so that the inputs
+	// actually match each other.
+	lInputTypes := generateRandomSupportedTypes(rng, nCols)
+	rInputTypes := generateRandomSupportedTypes(rng, nCols)
+	lEqCols := generateEqualityColumns(rng, nCols, nEqCols)
+	rEqCols := generateEqualityColumns(rng, nCols, nEqCols)
+	for i := range lEqCols {
+		lInputTypes[lEqCols[i]] = *types.Int
+		rInputTypes[rEqCols[i]] = *types.Int
+	}
+	generateInput := func(typs []types.T, eqCols []uint32) sqlbase.EncDatumRows {
+		rows := generateRandomRows(rng, rng.Intn(100), typs)
+		for _, row := range rows {
+			for _, col := range eqCols {
+				if !row[col].IsNull() {
+					row[col] = sqlbase.EncDatum{Datum: tree.NewDInt(tree.DInt(rng.Intn(5)))}
+				}
+			}
+		}
+		return rows
+	}
+	lRows := generateInput(lInputTypes, lEqCols)
+	rRows := generateInput(rInputTypes, rEqCols)
+
+	outputTypes := append([]types.T(nil), lInputTypes...)
+	if joinType != sqlbase.JoinType_LEFT_SEMI && joinType != sqlbase.JoinType_LEFT_ANTI {
+		outputTypes = append(outputTypes, rInputTypes...)
+	}
+	return randomConfig{
+		description: fmt.Sprintf("hash joiner type = %s left eq cols = %v right eq cols = %v",
+			joinType, lEqCols, rEqCols),
+		args: verifyColOperatorArgs{
+			anyOrder:    true,
+			inputTypes:  [][]types.T{lInputTypes, rInputTypes},
+			inputs:      []sqlbase.EncDatumRows{lRows, rRows},
+			outputTypes: outputTypes,
+			pspec: &execinfrapb.ProcessorSpec{
+				Input: []execinfrapb.InputSyncSpec{
+					{ColumnTypes: lInputTypes},
+					{ColumnTypes: rInputTypes},
+				},
+				Core: execinfrapb.ProcessorCoreUnion{
+					HashJoiner: &execinfrapb.HashJoinerSpec{
+						LeftEqColumns:  lEqCols,
+						RightEqColumns: rEqCols,
+						Type:           joinType,
+					},
+				},
+			},
+		},
+	}
+}
+
+// addRandomPostProcessing randomly adds a filter, a projection, and a limit
+// with an offset to the post-processing spec of config, adjusting the
+// expectations about the output accordingly.
+func addRandomPostProcessing(rng *rand.Rand, config *randomConfig) {
+	args := &config.args
+	post := &args.pspec.Post
+	nCols := len(args.outputTypes)
+
+	if rng.Float64() < 0.3 {
+		colIdx := rng.Intn(nCols)
+		if rng.Float64() < 0.5 {
+			// NULL handling is one of the most error-prone areas, so we exercise
+			// IS NULL and IS NOT NULL filters explicitly.
+			op := "IS NULL"
+			if rng.Float64() < 0.5 {
+				op = "IS NOT NULL"
+			}
+			post.Filter = execinfrapb.Expression{Expr: fmt.Sprintf("@%d %s", colIdx+1, op)}
+		} else {
+			post.Filter = generateFilterExpr(
+				rng, nCols, nCols, args.outputTypes,
+				true /* forceConstComparison */, true, /* forceLeftSide */
+			)
+		}
+	}
+
+	if rng.Float64() < 0.3 {
+		nOutputCols := 1 + rng.Intn(nCols)
+		outputCols := make([]uint32, nOutputCols)
+		for i, col := range rng.Perm(nCols)[:nOutputCols] {
+			outputCols[i] = uint32(col)
+		}
+		post.Projection = true
+		post.OutputColumns = outputCols
+		outputTypes := make([]types.T, nOutputCols)
+		for i, col := range outputCols {
+			outputTypes[i] = args.outputTypes[col]
+		}
+		args.outputTypes = outputTypes
+		args.outputOrdering = projectOrdering(args.outputOrdering, outputCols)
+	}
+
+	// A limit is only deterministic when the output is fully ordered.
+	if !args.anyOrder && rng.Float64() < 0.3 {
+		post.Offset = uint64(rng.Intn(10))
+		post.Limit = uint64(1 + rng.Intn(100))
+	}
+
+	config.description = fmt.Sprintf("%s post = %s", config.description, post)
+}
+
+// projectOrdering returns the ordering that is still guaranteed after the
+// projection onto outputCols. This is the longest prefix of ordering that
+// consists only of the projected columns.
+func projectOrdering(ordering execinfrapb.Ordering, outputCols []uint32) execinfrapb.Ordering {
+	var projected execinfrapb.Ordering
+	for _, c := range ordering.Columns {
+		found := false
+		for i, col := range outputCols {
+			if col == c.ColIdx {
+				projected.Columns = append(projected.Columns, execinfrapb.Ordering_Column{
+					ColIdx:    uint32(i),
+					Direction: c.Direction,
+				})
+				found = true
+				break
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	return projected
+}
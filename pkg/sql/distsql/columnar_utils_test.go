@@ -37,6 +37,11 @@ type verifyColOperatorArgs struct {
 	// colsForEqCheck (when non-nil) specifies the column indices that should be
 	// used for equality check. If it is nil, then the whole rows are compared.
 	colsForEqCheck []uint32
+	// outputOrdering (when non-empty) specifies the ordering that the output of
+	// both the processor and the columnar operator must satisfy. It is checked
+	// regardless of anyOrder, so it can be used to verify the ordering
+	// guarantees of operators whose output is only partially ordered.
+	outputOrdering execinfrapb.Ordering
 	inputTypes     [][]types.T
 	inputs         []sqlbase.EncDatumRows
 	outputTypes    []types.T
@@ -170,6 +175,7 @@ func verifyColOperator(args verifyColOperatorArgs) error {
 		return res
 	}
 	var procRows, colOpRows [][]string
+	var procEncRows, colOpEncRows sqlbase.EncDatumRows
 	var procMetas, colOpMetas []execinfrapb.ProducerMetadata
 	for {
 		rowProc, metaProc := outProc.Next()
@@ -180,6 +186,7 @@ func verifyColOperator(args verifyColOperatorArgs) error {
 					"different length\n%s", row)
 			}
 			procRows = append(procRows, row)
+			procEncRows = append(procEncRows, rowProc.Copy())
 		}
 		if metaProc != nil {
 			if metaProc.Err == nil {
@@ -196,6 +203,7 @@ func verifyColOperator(args verifyColOperatorArgs) error {
 					"different length\n%s", row)
 			}
 			colOpRows = append(colOpRows, printRowForChecking(rowColOp))
+			colOpEncRows = append(colOpEncRows, rowColOp.Copy())
 		}
 		if metaColOp != nil {
 			if metaColOp.Err == nil {
@@ -333,6 +341,30 @@ func verifyColOperator(args verifyColOperatorArgs) error {
 		}
 	}
 
+	if len(args.outputOrdering.Columns) > 0 {
+		var da sqlbase.DatumAlloc
+		ordering := execinfrapb.ConvertToColumnOrdering(args.outputOrdering)
+		for _, output := range []struct {
+			name string
+			rows sqlbase.EncDatumRows
+		}{
+			{name: "processor", rows: procEncRows},
+			{name: "columnar operator", rows: colOpEncRows},
+		} {
+			for i := 1; i < len(output.rows); i++ {
+				cmp, err := output.rows[i-1].Compare(args.outputTypes, &da, ordering, &evalCtx, output.rows[i])
+				if err != nil {
+					return err
+				}
+				if cmp > 0 {
+					return errors.Errorf("%s output is not ordered according to %v: row %d %s "+
+						"is followed by %s", output.name, args.outputOrdering, i-1,
+						output.rows[i-1].String(args.outputTypes), output.rows[i].String(args.outputTypes))
+				}
+			}
+		}
+	}
+
 	if args.forceDiskSpill {
 		// Check that the spilling did occur.
 		if !spilled {
@@ -0,0 +1,105 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package distsql
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/cgroups"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+var memoryPressureSpillingEnabled = settings.RegisterBoolSetting(
+	"sql.distsql.memory_pressure_spilling.enabled",
+	"if set, the buffering operators are asked to spill to disk preemptively "+
+		"when the cgroup of the node reports memory pressure",
+	false,
+)
+
+var memoryPressureThreshold = settings.RegisterFloatSetting(
+	"sql.distsql.memory_pressure_spilling.stall_threshold",
+	"the percentage of the last 10 seconds during which the node was stalled "+
+		"waiting for memory above which the buffering operators are asked to spill to disk",
+	10,
+)
+
+const (
+	// memoryPressurePollInterval is how often the memory pressure of the
+	// cgroup of the node is checked.
+	memoryPressurePollInterval = time.Second
+	// memoryPressureSpillFraction is the fraction of the bytes buffered by the
+	// operators that haven't spilled yet that is requested to be spilled every
+	// time the memory pressure is detected.
+	memoryPressureSpillFraction = 0.5
+)
+
+// startMemoryPressureWatcher starts a worker that periodically checks the
+// memory pressure reported by the cgroup of the node and asks the largest
+// buffering operators to spill to disk while the node is under pressure. This
+// way the memory used by large in-memory sorts and joins is released before
+// the OOM killer takes down the node.
+//
+// The node is considered to be under memory pressure if its memory usage has
+// exceeded the memory.high boundary of the cgroup since the last check or if
+// the pressure stall information indicates that the node has been stalled
+// waiting for memory for long enough. The memory pressure is only reported by
+// cgroup v2, so the worker exits right away on other systems.
+func (ds *ServerImpl) startMemoryPressureWatcher() {
+	if ds.SpillRegistry == nil {
+		return
+	}
+	ctx := ds.AnnotateCtx(context.Background())
+	pressure, warnings, err := cgroups.GetMemoryPressure()
+	if err != nil {
+		log.Infof(ctx, "not watching memory pressure: %v", err)
+		return
+	}
+	if warnings != "" {
+		log.Infof(ctx, "watching memory pressure: %s", warnings)
+	}
+	ds.Stopper.RunWorker(ctx, func(ctx context.Context) {
+		lastHighEvents := pressure.HighEvents
+		every := log.Every(time.Minute)
+		ticker := time.NewTicker(memoryPressurePollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-ds.Stopper.ShouldStop():
+				return
+			}
+			pressure, _, err := cgroups.GetMemoryPressure()
+			if err != nil {
+				if every.ShouldLog() {
+					log.Warningf(ctx, "unable to check memory pressure: %v", err)
+				}
+				continue
+			}
+			highEventsOccurred := pressure.HighEvents > lastHighEvents
+			lastHighEvents = pressure.HighEvents
+			if !memoryPressureSpillingEnabled.Get(&ds.Settings.SV) {
+				continue
+			}
+			if !highEventsOccurred && pressure.SomeAvg10 < memoryPressureThreshold.Get(&ds.Settings.SV) {
+				continue
+			}
+			if n, bytes := ds.SpillRegistry.RequestSpills(memoryPressureSpillFraction); n > 0 {
+				log.Infof(
+					ctx, "under memory pressure (stalled %.2f%% of the time), asked %d operators "+
+						"buffering %s to spill to disk", pressure.SomeAvg10, n, humanizeutil.IBytes(bytes),
+				)
+			}
+		}
+	})
+}
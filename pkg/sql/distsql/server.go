@@ -101,6 +101,7 @@ func (ds *ServerImpl) Start() {
 	}
 
 	ds.flowScheduler.Start()
+	ds.startMemoryPressureWatcher()
 }
 
 // Drain changes the node's draining state through gossip and drains the
@@ -331,6 +332,12 @@ func (ds *ServerImpl) setupFlow(
 		NodeID:         nodeID,
 		TraceKV:        req.TraceKV,
 		Local:          localState.IsLocal,
+
+		QueryID:                          localState.QueryID,
+		EstimatedRowCount:                localState.EstimatedRowCount,
+		ProcessorEstimatedRowCounts:      localState.ProcessorEstimatedRowCounts,
+		ProcessorInputEstimatedRowCounts: localState.ProcessorInputEstimatedRowCounts,
+		MaterializationObserver:          localState.MaterializationObserver,
 	}
 	// req always contains the desired vectorize mode, regardless of whether we
 	// have non-nil localState.EvalContext. We don't want to update EvalContext
@@ -442,6 +449,30 @@ type LocalState struct {
 	// If there is concurrency, a LeafTxn will be created.
 	Txn *kv.Txn
 
+	// QueryID is filled in on the gateway only. It is the ID of the query that
+	// the flow is running.
+	QueryID string
+
+	// EstimatedRowCount is filled in on the gateway only. It is the number of
+	// rows that the optimizer estimated the scans of the gateway flow would
+	// read.
+	EstimatedRowCount uint64
+
+	// ProcessorEstimatedRowCounts is filled in on the gateway only. It maps the
+	// IDs of the processors to the number of rows that the optimizer estimated
+	// the table readers feeding into them would read (see
+	// physicalplan.PhysicalPlan.EstimatedRowCounts).
+	ProcessorEstimatedRowCounts map[int32]uint64
+
+	// ProcessorInputEstimatedRowCounts is filled in on the gateway only. It
+	// maps the IDs of the processors to the optimizer's estimates of their
+	// inputs (see physicalplan.PhysicalPlan.InputEstimatedRowCounts).
+	ProcessorInputEstimatedRowCounts map[int32][]uint64
+
+	// MaterializationObserver, if set, is notified about the cardinalities
+	// observed at the materialization points of the flow on the gateway.
+	MaterializationObserver execinfra.MaterializationObserver
+
 	/////////////////////////////////////////////
 	// Fields below are empty if IsLocal == false
 	/////////////////////////////////////////////
@@ -0,0 +1,152 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/physicalplan"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqltelemetry"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// joinMisestimateFactor is the factor by which the number of rows produced by
+// a join has to differ from the optimizer's estimate (in either direction) for
+// the estimate to be considered wrong.
+const joinMisestimateFactor = 10
+
+// observeCardinalities aggregates the cardinalities observed by the vectorized
+// operators of the flows of plan (which all report to the gateway, see
+// DistSQLReceiver.observedRowCounts) per stage and feeds them back. The number
+// of rows read by a full scan of the primary index of a table is reported to
+// the CardinalityObserver of the server (i.e. the automatic statistics
+// refresher), and the number of rows produced by a hash or merge join is
+// compared against the optimizer's estimate in order to count the
+// misestimates. A stage is only considered if all of its processors have
+// reported their cardinality, i.e. if all of them have produced all of their
+// output.
+func (dsp *DistSQLPlanner) observeCardinalities(
+	ctx context.Context, plan *PhysicalPlan, observedRowCounts map[int32]int64,
+) {
+	type stage struct {
+		processors []physicalplan.ProcessorIdx
+		rowCount   int64
+		// complete is true if all processors of the stage have reported their
+		// cardinality.
+		complete bool
+	}
+	stages := make(map[int32]*stage)
+	for i := range plan.Processors {
+		spec := &plan.Processors[i].Spec
+		if spec.StageID == 0 || (spec.Core.TableReader == nil &&
+			spec.Core.HashJoiner == nil && spec.Core.MergeJoiner == nil) {
+			continue
+		}
+		s, ok := stages[spec.StageID]
+		if !ok {
+			s = &stage{complete: true}
+			stages[spec.StageID] = s
+		}
+		s.processors = append(s.processors, physicalplan.ProcessorIdx(i))
+		rowCount, ok := observedRowCounts[spec.ProcessorID]
+		s.complete = s.complete && ok
+		s.rowCount += rowCount
+	}
+
+	observer := dsp.distSQLSrv.ServerConfig.CardinalityObserver
+	for stageID, s := range stages {
+		if !s.complete {
+			continue
+		}
+		first := &plan.Processors[s.processors[0]]
+		if tr := first.Spec.Core.TableReader; tr != nil {
+			if observer != nil && isFullPrimaryIndexScan(plan, s.processors) {
+				observer.ObserveTableRowCount(ctx, tr.Table.ID, s.rowCount)
+			}
+			continue
+		}
+		estimate := first.EstimatedJoinRowCount
+		if estimate == 0 {
+			continue
+		}
+		if isCardinalityMisestimate(estimate, s.rowCount) {
+			log.VEventf(ctx, 1, "cardinality misestimate at the join of stage %d "+
+				"(estimated %d rows, observed %d rows)", stageID, estimate, s.rowCount)
+			telemetry.Inc(sqltelemetry.JoinCardinalityMisestimateCounter)
+		}
+	}
+}
+
+// isFullPrimaryIndexScan returns whether the given table readers (which must
+// be all the processors of a single stage) read the full primary index of
+// their table between them. Only the row counts of such scans can be compared
+// against the table statistics.
+func isFullPrimaryIndexScan(plan *PhysicalPlan, readers []physicalplan.ProcessorIdx) bool {
+	var spans roachpb.Spans
+	for _, pIdx := range readers {
+		tr := plan.Processors[pIdx].Spec.Core.TableReader
+		if tr.IndexIdx != 0 {
+			return false
+		}
+		for i := range tr.Spans {
+			spans = append(spans, tr.Spans[i].Span)
+		}
+	}
+	if len(spans) == 0 {
+		return false
+	}
+	// The spans of different readers are disjoint, so they cover the full
+	// index span if they are contiguous once sorted and start and end at its
+	// boundaries.
+	sort.Sort(spans)
+	fullSpan := plan.Processors[readers[0]].Spec.Core.TableReader.Table.PrimaryIndexSpan()
+	if !spans[0].Key.Equal(fullSpan.Key) {
+		return false
+	}
+	for i := 1; i < len(spans); i++ {
+		if !spans[i-1].EndKey.Equal(spans[i].Key) {
+			return false
+		}
+	}
+	return spans[len(spans)-1].EndKey.Equal(fullSpan.EndKey)
+}
+
+// isCardinalityMisestimate returns whether the observed number of rows
+// differs from the estimate by more than joinMisestimateFactor. Both numbers
+// are rounded up to one row so that tiny estimates don't count as wrong.
+func isCardinalityMisestimate(estimate uint64, observed int64) bool {
+	e, o := float64(estimate), float64(observed)
+	if e < 1 {
+		e = 1
+	}
+	if o < 1 {
+		o = 1
+	}
+	return o > e*joinMisestimateFactor || e > o*joinMisestimateFactor
+}
+
+// materializationTracer is an execinfra.MaterializationObserver that records
+// the cardinalities observed at the materialization points of the flow on the
+// gateway in the trace of the statement, next to the optimizer's estimates.
+type materializationTracer struct{}
+
+var _ execinfra.MaterializationObserver = materializationTracer{}
+
+// OnMaterialized implements the execinfra.MaterializationObserver interface.
+func (materializationTracer) OnMaterialized(
+	ctx context.Context, point execinfra.MaterializationPoint,
+) {
+	log.VEventf(ctx, 2, "materialized %s", point)
+}
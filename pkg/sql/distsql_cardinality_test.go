@@ -0,0 +1,108 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsql"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/physicalplan"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+type testCardinalityObserver struct {
+	rowCounts map[sqlbase.ID]int64
+}
+
+var _ execinfra.CardinalityObserver = &testCardinalityObserver{}
+
+func (o *testCardinalityObserver) ObserveTableRowCount(
+	_ context.Context, tableID sqlbase.ID, rowCount int64,
+) {
+	o.rowCounts[tableID] = rowCount
+}
+
+// TestObserveCardinalities verifies that the row counts of the distributed
+// full scans are aggregated per table on the gateway.
+func TestObserveCardinalities(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	desc := &sqlbase.TableDescriptor{ID: 53, PrimaryIndex: sqlbase.IndexDescriptor{ID: 1}}
+	fullSpan := desc.PrimaryIndexSpan()
+	split := append(fullSpan.Key[:len(fullSpan.Key):len(fullSpan.Key)], 0x89)
+	reader := func(stageID, processorID int32, spans ...roachpb.Span) physicalplan.Processor {
+		tr := &execinfrapb.TableReaderSpec{Table: *desc}
+		for _, sp := range spans {
+			tr.Spans = append(tr.Spans, execinfrapb.TableReaderSpan{Span: sp})
+		}
+		return physicalplan.Processor{Spec: execinfrapb.ProcessorSpec{
+			Core:        execinfrapb.ProcessorCoreUnion{TableReader: tr},
+			StageID:     stageID,
+			ProcessorID: processorID,
+		}}
+	}
+	left := roachpb.Span{Key: fullSpan.Key, EndKey: split}
+	right := roachpb.Span{Key: split, EndKey: fullSpan.EndKey}
+
+	for _, tc := range []struct {
+		name       string
+		processors []physicalplan.Processor
+		observed   map[int32]int64
+		expected   map[sqlbase.ID]int64
+	}{
+		{
+			name:       "distributed full scan",
+			processors: []physicalplan.Processor{reader(1, 0, right), reader(1, 1, left)},
+			observed:   map[int32]int64{0: 10, 1: 5},
+			expected:   map[sqlbase.ID]int64{53: 15},
+		},
+		{
+			name:       "incomplete full scan",
+			processors: []physicalplan.Processor{reader(1, 0, right), reader(1, 1, left)},
+			observed:   map[int32]int64{0: 10},
+			expected:   map[sqlbase.ID]int64{},
+		},
+		{
+			name:       "partial scan",
+			processors: []physicalplan.Processor{reader(1, 0, right)},
+			observed:   map[int32]int64{0: 10},
+			expected:   map[sqlbase.ID]int64{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			observer := &testCardinalityObserver{rowCounts: make(map[sqlbase.ID]int64)}
+			dsp := &DistSQLPlanner{distSQLSrv: &distsql.ServerImpl{
+				ServerConfig: execinfra.ServerConfig{CardinalityObserver: observer},
+			}}
+			var plan PhysicalPlan
+			plan.Processors = tc.processors
+			dsp.observeCardinalities(context.Background(), &plan, tc.observed)
+			require.Equal(t, tc.expected, observer.rowCounts)
+		})
+	}
+}
+
+func TestIsCardinalityMisestimate(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	require.False(t, isCardinalityMisestimate(100, 100))
+	require.False(t, isCardinalityMisestimate(100, 500))
+	require.False(t, isCardinalityMisestimate(1, 0))
+	require.True(t, isCardinalityMisestimate(100, 1001))
+	require.True(t, isCardinalityMisestimate(100, 9))
+	require.True(t, isCardinalityMisestimate(100, 0))
+}
@@ -1128,7 +1128,8 @@ func (dsp *DistSQLPlanner) createTableReaders(
 		}
 
 		proc := physicalplan.Processor{
-			Node: sp.Node,
+			Node:              sp.Node,
+			EstimatedRowCount: n.estimatedRowCount,
 			Spec: execinfrapb.ProcessorSpec{
 				Core:    execinfrapb.ProcessorCoreUnion{TableReader: tr},
 				Output:  []execinfrapb.OutputRouterSpec{{Type: execinfrapb.OutputRouterSpec_PASS_THROUGH}},
@@ -2266,6 +2267,10 @@ func (dsp *DistSQLPlanner) createPlanForJoin(
 		nodes, core, post, leftEqCols, rightEqCols, leftTypes, rightTypes,
 		leftMergeOrd, rightMergeOrd, leftRouters, rightRouters,
 	)
+	// The result routers are the join processors that have just been added.
+	for _, pIdx := range p.ResultRouters {
+		p.Processors[pIdx].EstimatedJoinRowCount = n.estimatedRowCount
+	}
 
 	p.PlanToStreamColMap = joinToStreamColMap
 	p.ResultTypes, err = getTypesForPlanResult(n, joinToStreamColMap)
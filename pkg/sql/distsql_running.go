@@ -164,7 +164,7 @@ func (dsp *DistSQLPlanner) setupFlows(
 			// TODO(yuzefovich): this is a safe but quite inefficient way of setting
 			// up vectorized flows since the flows will effectively be planned twice.
 			for _, spec := range flows {
-				if _, err := colflow.SupportsVectorized(
+				if _, _, err := colflow.SupportsVectorized(
 					ctx, &execinfra.FlowCtx{
 						EvalCtx: &evalCtx.EvalContext,
 						Cfg: &execinfra.ServerConfig{
@@ -298,6 +298,28 @@ func (dsp *DistSQLPlanner) Run(
 	// the line.
 	localState.EvalContext = &evalCtx.EvalContext
 	localState.Txn = txn
+	if planCtx.planner != nil && planCtx.planner.stmt != nil &&
+		planCtx.planner.stmt.queryID != (ClusterWideID{}) {
+		localState.QueryID = planCtx.planner.stmt.queryID.String()
+	}
+	localState.EstimatedRowCount = plan.EstimatedScannedRowsOnNode(dsp.nodeDesc.NodeID)
+	if sp := opentracing.SpanFromContext(ctx); sp != nil && tracing.IsRecording(sp) {
+		// The cardinalities observed at the materialization points are only
+		// of interest when the statement is being traced.
+		localState.MaterializationObserver = materializationTracer{}
+		estimates := plan.InputEstimatedRowCounts()
+		localState.ProcessorInputEstimatedRowCounts = make(map[int32][]uint64, len(estimates))
+		for idx, counts := range estimates {
+			localState.ProcessorInputEstimatedRowCounts[plan.Processors[idx].Spec.ProcessorID] = counts
+		}
+	}
+	if colflow.HybridVectorizationEnabled.Get(&dsp.st.SV) {
+		estimates := plan.EstimatedRowCounts()
+		localState.ProcessorEstimatedRowCounts = make(map[int32]uint64, len(estimates))
+		for idx, count := range estimates {
+			localState.ProcessorEstimatedRowCounts[plan.Processors[idx].Spec.ProcessorID] = count
+		}
+	}
 	if planCtx.isLocal {
 		localState.IsLocal = true
 		localState.LocalProcs = plan.LocalProcessors
@@ -379,6 +401,9 @@ func (dsp *DistSQLPlanner) Run(
 		log.Fatalf(ctx, "unexpected error from syncFlow.Start(): %s "+
 			"The error should have gone to the consumer.", err)
 	}
+	if len(recv.observedRowCounts) > 0 {
+		dsp.observeCardinalities(ctx, plan, recv.observedRowCounts)
+	}
 
 	// TODO(yuzefovich): it feels like this closing should happen after
 	// PlanAndRun. We should refactor this and get rid off ignoreClose field.
@@ -464,15 +489,34 @@ type DistSQLReceiver struct {
 	// this node's clock.
 	updateClock func(observedTs hlc.Timestamp)
 
-	// bytesRead and rowsRead track the corresponding metrics while executing the
+	// stats tracks the metrics reported by the flows while executing the
 	// statement.
-	bytesRead int64
-	rowsRead  int64
+	stats topLevelQueryStats
+
+	// observedRowCounts maps the IDs of the processors whose cardinality has
+	// been observed by the vectorized operators (see
+	// execinfrapb.RemoteProducerMetadata_Metrics.CardinalityObserved) to the
+	// number of rows they have produced.
+	observedRowCounts map[int32]int64
 
 	expectedRowsRead int64
 	progressAtomic   *uint64
 }
 
+// topLevelQueryStats are the statistics about the execution of a single
+// statement that are collected by the DistSQLReceiver from the metadata sent
+// by the flows.
+type topLevelQueryStats struct {
+	// bytesRead and rowsRead are the number of bytes and rows read from disk.
+	bytesRead int64
+	rowsRead  int64
+	// spillCount is the number of times that operators spilled to disk.
+	spillCount int64
+	// bytesSpilled is the number of bytes written to temporary storage by the
+	// operators that spilled to disk.
+	bytesSpilled int64
+}
+
 // rowResultWriter is a subset of CommandResult to be used with the
 // DistSQLReceiver. It's implemented by RowResultWriter.
 type rowResultWriter interface {
@@ -651,10 +695,18 @@ func (r *DistSQLReceiver) Push(
 			}
 		}
 		if meta.Metrics != nil {
-			r.bytesRead += meta.Metrics.BytesRead
-			r.rowsRead += meta.Metrics.RowsRead
+			r.stats.bytesRead += meta.Metrics.BytesRead
+			r.stats.rowsRead += meta.Metrics.RowsRead
+			r.stats.spillCount += meta.Metrics.SpillCount
+			r.stats.bytesSpilled += meta.Metrics.BytesSpilled
+			if meta.Metrics.CardinalityObserved {
+				if r.observedRowCounts == nil {
+					r.observedRowCounts = make(map[int32]int64)
+				}
+				r.observedRowCounts[meta.Metrics.ProcessorID] += meta.Metrics.OutputRowCount
+			}
 			if r.progressAtomic != nil && r.expectedRowsRead != 0 {
-				progress := float64(r.rowsRead) / float64(r.expectedRowsRead)
+				progress := float64(r.stats.rowsRead) / float64(r.expectedRowsRead)
 				atomic.StoreUint64(r.progressAtomic, math.Float64bits(progress))
 			}
 			meta.Metrics.Release()
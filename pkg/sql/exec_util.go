@@ -1875,6 +1875,10 @@ func (m *sessionDataMutator) SetVectorizeRowCountThreshold(val uint64) {
 	m.data.VectorizeRowCountThreshold = val
 }
 
+func (m *sessionDataMutator) SetVectorizeForceSpillAfterNumTuples(val int) {
+	m.data.VectorizeForceSpillAfterNumTuples = val
+}
+
 func (m *sessionDataMutator) SetOptimizerFKs(val bool) {
 	m.data.OptimizerFKs = val
 }
@@ -1968,11 +1972,11 @@ func (s *sqlStatsCollector) recordStatement(
 	numRows int,
 	err error,
 	parseLat, planLat, runLat, svcLat, ovhLat float64,
-	bytesRead, rowsRead int64,
+	stats topLevelQueryStats,
 ) {
 	s.appStats.recordStatement(
 		stmt, samplePlanDescription, distSQLUsed, implicitTxn, automaticRetryCount, numRows, err,
-		parseLat, planLat, runLat, svcLat, ovhLat, bytesRead, rowsRead)
+		parseLat, planLat, runLat, svcLat, ovhLat, stats)
 }
 
 // recordTransaction records stats for one transaction.
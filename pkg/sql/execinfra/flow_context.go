@@ -59,6 +59,32 @@ type FlowCtx struct {
 
 	// Local is true if this flow is being run as part of a local-only query.
 	Local bool
+
+	// QueryID is the ID of the query that the flow is running. It is only set
+	// on the gateway and is used for progress reporting.
+	QueryID string
+
+	// EstimatedRowCount is the number of rows that the optimizer estimated the
+	// scans of this flow would read. It is only set on the gateway and is used
+	// for progress reporting.
+	EstimatedRowCount uint64
+
+	// ProcessorEstimatedRowCounts maps the IDs of the processors of the flow to
+	// the number of rows that the optimizer estimated the table readers feeding
+	// into them would read. It is only set on the gateway and is used by the
+	// vectorized flows to decide which processors to run in the row engine.
+	ProcessorEstimatedRowCounts map[int32]uint64
+
+	// ProcessorInputEstimatedRowCounts maps the IDs of the processors of the
+	// flow to the optimizer's estimates of the number of rows of each of their
+	// inputs (zero if unknown). It is only set on the gateway and is used to
+	// report the cardinalities to the MaterializationObserver.
+	ProcessorInputEstimatedRowCounts map[int32][]uint64
+
+	// MaterializationObserver, if set, is notified about the cardinalities
+	// observed at the materialization points of the flow. It is only set on
+	// the gateway when the statement is being traced.
+	MaterializationObserver MaterializationObserver
 }
 
 // NewEvalCtx returns a modifiable copy of the FlowCtx's EvalContext.
@@ -0,0 +1,235 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package execinfra
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// FlowPhase describes what a running flow is currently busy with.
+type FlowPhase int32
+
+const (
+	// FlowPhaseRunning indicates that the flow is processing its inputs.
+	FlowPhaseRunning FlowPhase = iota
+	// FlowPhaseSpilling indicates that at least one component of the flow has
+	// reached its memory limit and is processing its input on disk.
+	FlowPhaseSpilling
+	// FlowPhaseMergingRuns indicates that an external sort of the flow is
+	// reading the sorted rows back from the temporary storage engine.
+	FlowPhaseMergingRuns
+	numFlowPhases
+)
+
+// String implements the fmt.Stringer interface.
+func (p FlowPhase) String() string {
+	switch p {
+	case FlowPhaseRunning:
+		return "running"
+	case FlowPhaseSpilling:
+		return "spilling"
+	case FlowPhaseMergingRuns:
+		return "merging runs"
+	default:
+		return "unknown"
+	}
+}
+
+// FlowProgress tracks the progress of a single flow. It is updated by the
+// components of the flow and can be read concurrently by the
+// FlowProgressRegistry.
+//
+// FlowProgress also allows for a flow to be paused (see Pause), so that the
+// work of a running query can be deprioritized without canceling it (see
+// crdb_internal.pause_vectorized_flows).
+type FlowProgress struct {
+	// FlowID is the ID of the flow. It is unset for local flows. The flows of a
+	// distributed query share the same ID on all nodes.
+	FlowID execinfrapb.FlowID
+	// QueryID is the ID of the query the flow is running (as shown in
+	// crdb_internal.node_queries). It is only known on the gateway and is empty
+	// otherwise; the remote flows can be matched to the gateway flow by FlowID.
+	QueryID string
+	// StartTime is the time at which the flow was set up.
+	StartTime time.Time
+	// EstimatedRowCount is the number of rows that the optimizer estimated the
+	// scans of this flow would read. It is only known on the gateway and is
+	// zero otherwise.
+	EstimatedRowCount uint64
+
+	// rowsRead, numInPhase, and paused are accessed atomically.
+	rowsRead int64
+	// numInPhase contains, for every phase other than FlowPhaseRunning, the
+	// number of components of the flow that are currently in that phase.
+	numInPhase [numFlowPhases]int32
+	paused     int32
+
+	pauseMu struct {
+		syncutil.Mutex
+		// resumeCh is non-nil while the flow is paused and is closed once the
+		// flow is resumed.
+		resumeCh chan struct{}
+	}
+}
+
+// NewFlowProgress returns a new FlowProgress for the flow with the given ID.
+func NewFlowProgress(
+	flowID execinfrapb.FlowID, queryID string, estimatedRowCount uint64,
+) *FlowProgress {
+	return &FlowProgress{
+		FlowID:            flowID,
+		QueryID:           queryID,
+		StartTime:         timeutil.Now(),
+		EstimatedRowCount: estimatedRowCount,
+	}
+}
+
+// AddRowsRead records that n more rows have been read by the leaf scans of
+// the flow.
+func (p *FlowProgress) AddRowsRead(n int64) {
+	atomic.AddInt64(&p.rowsRead, n)
+}
+
+// RowsRead returns the number of rows read by the leaf scans of the flow so
+// far.
+func (p *FlowProgress) RowsRead() int64 {
+	return atomic.LoadInt64(&p.rowsRead)
+}
+
+// StartPhase records that a component of the flow has entered the given
+// phase. Every call must be matched by a call to FinishPhase once the
+// component is done with the phase.
+func (p *FlowProgress) StartPhase(phase FlowPhase) {
+	atomic.AddInt32(&p.numInPhase[phase], 1)
+}
+
+// FinishPhase records that a component of the flow is done with the given
+// phase.
+func (p *FlowProgress) FinishPhase(phase FlowPhase) {
+	atomic.AddInt32(&p.numInPhase[phase], -1)
+}
+
+// Phase returns the current phase of the flow. If the components of the flow
+// are in different phases, the latest phase is returned, and the flow is back
+// to FlowPhaseRunning once all components are done with their phases.
+func (p *FlowProgress) Phase() FlowPhase {
+	for phase := numFlowPhases - 1; phase > FlowPhaseRunning; phase-- {
+		if atomic.LoadInt32(&p.numInPhase[phase]) > 0 {
+			return phase
+		}
+	}
+	return FlowPhaseRunning
+}
+
+// Pause requests the cooperative components of the flow (the ones that call
+// WaitIfPaused before producing a batch, e.g. the leaf scans and the
+// disk-backed operators) to stop producing batches until Resume is called.
+// The flow keeps all of its resources while paused. Pausing a paused flow is
+// a noop.
+func (p *FlowProgress) Pause() {
+	p.pauseMu.Lock()
+	defer p.pauseMu.Unlock()
+	if p.pauseMu.resumeCh == nil {
+		p.pauseMu.resumeCh = make(chan struct{})
+		atomic.StoreInt32(&p.paused, 1)
+	}
+}
+
+// Resume unblocks the components of the flow that are waiting in
+// WaitIfPaused. Resuming a flow that is not paused is a noop.
+func (p *FlowProgress) Resume() {
+	p.pauseMu.Lock()
+	defer p.pauseMu.Unlock()
+	if p.pauseMu.resumeCh != nil {
+		atomic.StoreInt32(&p.paused, 0)
+		close(p.pauseMu.resumeCh)
+		p.pauseMu.resumeCh = nil
+	}
+}
+
+// Paused returns whether the flow is currently paused.
+func (p *FlowProgress) Paused() bool {
+	return atomic.LoadInt32(&p.paused) == 1
+}
+
+// WaitIfPaused blocks while the flow is paused. It returns ctx.Err() if ctx is
+// canceled before the flow is resumed. The check is cheap when the flow is not
+// paused, so it can be called before producing every batch.
+func (p *FlowProgress) WaitIfPaused(ctx context.Context) error {
+	if !p.Paused() {
+		return nil
+	}
+	p.pauseMu.Lock()
+	resumeCh := p.pauseMu.resumeCh
+	p.pauseMu.Unlock()
+	if resumeCh == nil {
+		// The flow has been resumed concurrently.
+		return nil
+	}
+	select {
+	case <-resumeCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FlowProgressRegistry keeps track of the progress of all flows that are
+// running on a node.
+type FlowProgressRegistry struct {
+	mu struct {
+		syncutil.Mutex
+		flows map[*FlowProgress]struct{}
+	}
+}
+
+// NewFlowProgressRegistry returns a new empty FlowProgressRegistry.
+func NewFlowProgressRegistry() *FlowProgressRegistry {
+	r := &FlowProgressRegistry{}
+	r.mu.flows = make(map[*FlowProgress]struct{})
+	return r
+}
+
+// Register starts tracking the given FlowProgress.
+func (r *FlowProgressRegistry) Register(p *FlowProgress) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mu.flows[p] = struct{}{}
+}
+
+// Unregister stops tracking the given FlowProgress.
+func (r *FlowProgressRegistry) Unregister(p *FlowProgress) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.mu.flows, p)
+}
+
+// Flows returns the progress of all currently registered flows ordered by
+// their start time.
+func (r *FlowProgressRegistry) Flows() []*FlowProgress {
+	r.mu.Lock()
+	flows := make([]*FlowProgress, 0, len(r.mu.flows))
+	for p := range r.mu.flows {
+		flows = append(flows, p)
+	}
+	r.mu.Unlock()
+	sort.Slice(flows, func(i, j int) bool {
+		return flows[i].StartTime.Before(flows[j].StartTime)
+	})
+	return flows
+}
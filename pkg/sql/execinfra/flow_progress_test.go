@@ -0,0 +1,112 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package execinfra
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+)
+
+func TestFlowProgressRegistry(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	r := NewFlowProgressRegistry()
+	first := NewFlowProgress(execinfrapb.FlowID{UUID: uuid.MakeV4()}, "" /* queryID */, 100)
+	second := NewFlowProgress(execinfrapb.FlowID{}, "15f1a2d3c4b5a6970000000000000001", 0)
+	// Make sure that the start times are ordered regardless of the clock
+	// resolution.
+	second.StartTime = first.StartTime.Add(1)
+	r.Register(second)
+	r.Register(first)
+
+	first.AddRowsRead(10)
+	first.AddRowsRead(5)
+	second.StartPhase(FlowPhaseSpilling)
+	second.StartPhase(FlowPhaseSpilling)
+	second.StartPhase(FlowPhaseMergingRuns)
+
+	flows := r.Flows()
+	if len(flows) != 2 || flows[0] != first || flows[1] != second {
+		t.Fatalf("unexpected flows %v", flows)
+	}
+	if rowsRead := first.RowsRead(); rowsRead != 15 {
+		t.Fatalf("expected 15 rows read, found %d", rowsRead)
+	}
+	if phase := first.Phase(); phase != FlowPhaseRunning {
+		t.Fatalf("expected phase %s, found %s", FlowPhaseRunning, phase)
+	}
+	if phase := second.Phase(); phase != FlowPhaseMergingRuns {
+		t.Fatalf("expected phase %s, found %s", FlowPhaseMergingRuns, phase)
+	}
+	// The flow goes back to running once all of its components are done with
+	// their phases.
+	second.FinishPhase(FlowPhaseMergingRuns)
+	second.FinishPhase(FlowPhaseSpilling)
+	if phase := second.Phase(); phase != FlowPhaseSpilling {
+		t.Fatalf("expected phase %s, found %s", FlowPhaseSpilling, phase)
+	}
+	second.FinishPhase(FlowPhaseSpilling)
+	if phase := second.Phase(); phase != FlowPhaseRunning {
+		t.Fatalf("expected phase %s, found %s", FlowPhaseRunning, phase)
+	}
+
+	r.Unregister(first)
+	if flows := r.Flows(); len(flows) != 1 || flows[0] != second {
+		t.Fatalf("unexpected flows %v", flows)
+	}
+}
+
+func TestFlowProgressPause(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+
+	p := NewFlowProgress(execinfrapb.FlowID{}, 0)
+	if err := p.WaitIfPaused(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	p.Pause()
+	p.Pause()
+	if !p.Paused() {
+		t.Fatal("expected the flow to be paused")
+	}
+	errCh := make(chan error)
+	go func() {
+		errCh <- p.WaitIfPaused(ctx)
+	}()
+	select {
+	case err := <-errCh:
+		t.Fatalf("WaitIfPaused returned %v while the flow is paused", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	p.Resume()
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+	p.Resume()
+	if p.Paused() {
+		t.Fatal("expected the flow to be resumed")
+	}
+
+	// Canceling the context unblocks the waiters.
+	p.Pause()
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := p.WaitIfPaused(cancelCtx); err != context.Canceled {
+		t.Fatalf("expected %v, found %v", context.Canceled, err)
+	}
+	p.Resume()
+}
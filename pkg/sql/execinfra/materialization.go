@@ -0,0 +1,56 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package execinfra
+
+import (
+	"context"
+	"fmt"
+)
+
+// MaterializationPoint describes a point of a flow at which an operator has
+// fully materialized one of the inputs of its processor (for example, the hash
+// table of a hash joiner has been built or the input of a sorter has been
+// spooled), so the actual cardinality of that input is known.
+type MaterializationPoint struct {
+	// ProcessorID is the ID of the processor that the operator was planned
+	// for.
+	ProcessorID int32
+	// Operator is the name of the operator that has materialized the input.
+	Operator string
+	// Input is the index of the input of the processor that has been
+	// materialized.
+	Input int
+	// EstimatedRowCount is the number of rows that the optimizer estimated
+	// the input would produce. The estimate is only known if the input is
+	// produced directly by table readers; zero means that there is no
+	// estimate.
+	EstimatedRowCount uint64
+	// ObservedRowCount is the number of rows that have been materialized.
+	ObservedRowCount uint64
+}
+
+// String implements the fmt.Stringer interface.
+func (p MaterializationPoint) String() string {
+	return fmt.Sprintf(
+		"%s of input %d of processor %d (estimated %d rows, observed %d rows)",
+		p.Operator, p.Input, p.ProcessorID, p.EstimatedRowCount, p.ObservedRowCount,
+	)
+}
+
+// MaterializationObserver is an interface through which the execution engines
+// report the actual cardinalities observed at the materialization points of a
+// flow, so that they can be compared against the optimizer's estimates while
+// the query is still running.
+type MaterializationObserver interface {
+	// OnMaterialized is called synchronously by the operator that has reached
+	// the materialization point, so it must not block.
+	OnMaterialized(ctx context.Context, point MaterializationPoint)
+}
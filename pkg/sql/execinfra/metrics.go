@@ -28,6 +28,11 @@ type DistSQLMetrics struct {
 	MaxBytesHist  *metric.Histogram
 	CurBytesCount *metric.Gauge
 	VecOpenFDs    *metric.Gauge
+	// VecWorkersActive, VecFlowsQueued and VecFlowsQueueWaitHist describe the
+	// VectorizedWorkerPool of the node.
+	VecWorkersActive      *metric.Gauge
+	VecFlowsQueued        *metric.Gauge
+	VecFlowsQueueWaitHist *metric.Histogram
 }
 
 // MetricStruct implements the metrics.Struct interface.
@@ -90,6 +95,24 @@ var (
 		Measurement: "Files",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaVecWorkersActive = metric.Metadata{
+		Name:        "sql.distsql.vec.workers.active",
+		Help:        "Number of goroutines of vectorized flows currently admitted by the vectorized worker pool",
+		Measurement: "Goroutines",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaVecFlowsQueued = metric.Metadata{
+		Name:        "sql.distsql.vec.flows.queued",
+		Help:        "Number of vectorized flows currently waiting for the vectorized worker pool to start",
+		Measurement: "Flows",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaVecFlowsQueueWaitHist = metric.Metadata{
+		Name:        "sql.distsql.vec.flows.queue_wait",
+		Help:        "Duration of time vectorized flows spend waiting for the vectorized worker pool",
+		Measurement: "Nanoseconds",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
 )
 
 // See pkg/sql/mem_metrics.go
@@ -108,6 +131,10 @@ func MakeDistSQLMetrics(histogramWindow time.Duration) DistSQLMetrics {
 		MaxBytesHist:  metric.NewHistogram(metaMemMaxBytes, histogramWindow, log10int64times1000, 3),
 		CurBytesCount: metric.NewGauge(metaMemCurBytes),
 		VecOpenFDs:    metric.NewGauge(metaVecOpenFDs),
+
+		VecWorkersActive:      metric.NewGauge(metaVecWorkersActive),
+		VecFlowsQueued:        metric.NewGauge(metaVecFlowsQueued),
+		VecFlowsQueueWaitHist: metric.NewLatency(metaVecFlowsQueueWaitHist, histogramWindow),
 	}
 }
 
@@ -13,6 +13,8 @@
 package execinfra
 
 import (
+	"context"
+
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/gossip"
 	"github.com/cockroachdb/cockroach/pkg/jobs"
@@ -26,6 +28,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
 	"github.com/cockroachdb/cockroach/pkg/storage/cloud"
 	"github.com/cockroachdb/cockroach/pkg/storage/fs"
@@ -179,6 +182,34 @@ type ServerConfig struct {
 	// subsystem. It is queried during the GC process and in the handling of
 	// AdminVerifyProtectedTimestampRequest.
 	ProtectedTimestampProvider protectedts.Provider
+
+	// FlowProgressRegistry, if set, keeps track of the progress of the flows
+	// running on this node.
+	FlowProgressRegistry *FlowProgressRegistry
+
+	// SpillRegistry, if set, keeps track of the in-memory buffering operators
+	// running on this node so that they can be asked to spill to disk when the
+	// node is under memory pressure.
+	SpillRegistry *SpillRegistry
+
+	// VectorizedWorkerPool, if set, bounds the number of goroutines that the
+	// vectorized flows run concurrently on this node.
+	VectorizedWorkerPool *VectorizedWorkerPool
+
+	// CardinalityObserver, if set, is notified (on the gateway) about the row
+	// counts observed during execution so that the statistics subsystem can
+	// learn from them.
+	CardinalityObserver CardinalityObserver
+}
+
+// CardinalityObserver is an interface through which the gateway can report the
+// actual number of rows observed during execution. It is implemented by the
+// automatic statistics refresher.
+type CardinalityObserver interface {
+	// ObserveTableRowCount is called once all table readers of a scan over the
+	// full primary index of the given table (which might be distributed across
+	// several nodes) have completed and have read rowCount rows between them.
+	ObserveTableRowCount(ctx context.Context, tableID sqlbase.ID, rowCount int64)
 }
 
 // RuntimeStats is an interface through which the rowexec layer can get
@@ -217,6 +248,14 @@ type TestingKnobs struct {
 	// settings.
 	MemoryLimitBytes int64
 
+	// ForceDiskSpillAfterNumTuples, if positive, forces all vectorized
+	// operators that can fall back to disk to do so once they have consumed
+	// that many tuples, regardless of their memory usage. Unlike
+	// ForceDiskSpill, this allows for the in-memory operators to process some
+	// of the input before the transition to disk, so the hand-off between the
+	// two is exercised.
+	ForceDiskSpillAfterNumTuples int
+
 	// DrainFast, if enabled, causes the server to not wait for any currently
 	// running flows to complete or give a grace period of minFlowDrainWait
 	// to incoming flows to register.
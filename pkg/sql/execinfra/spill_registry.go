@@ -0,0 +1,104 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package execinfra
+
+import (
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// Spillable is implemented by the components that buffer tuples in memory and
+// are able to fall back to disk before reaching their memory limit.
+type Spillable interface {
+	// BufferedBytes returns the number of bytes that the component currently
+	// has buffered in memory. It can be called concurrently with the component
+	// running.
+	BufferedBytes() int64
+	// RequestSpill asks the component to spill to disk the next time it needs
+	// more memory. It can be called concurrently with the component running.
+	RequestSpill()
+}
+
+// SpillRegistry keeps track of the Spillables that are currently running on a
+// node so that they can be asked to spill to disk preemptively when the node
+// is running low on memory.
+type SpillRegistry struct {
+	mu struct {
+		syncutil.Mutex
+		// spillables maps the registered Spillables to whether they have
+		// already been asked to spill.
+		spillables map[Spillable]bool
+	}
+}
+
+// NewSpillRegistry returns a new empty SpillRegistry.
+func NewSpillRegistry() *SpillRegistry {
+	r := &SpillRegistry{}
+	r.mu.spillables = make(map[Spillable]bool)
+	return r
+}
+
+// Register starts tracking the given Spillable. Registering a Spillable that
+// has already been asked to spill makes it eligible to be asked again.
+func (r *SpillRegistry) Register(s Spillable) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mu.spillables[s] = false
+}
+
+// Unregister stops tracking the given Spillable.
+func (r *SpillRegistry) Unregister(s Spillable) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.mu.spillables, s)
+}
+
+// RequestSpills asks the registered Spillables with the largest buffers to
+// spill to disk until the Spillables that have been asked account for at
+// least the given fraction of the bytes buffered by all of them. Every
+// Spillable is asked at most once until it registers again. It returns the
+// number of Spillables that have been asked to spill and the number of bytes
+// they had buffered.
+func (r *SpillRegistry) RequestSpills(fraction float64) (numRequested int, bytes int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	type candidate struct {
+		s     Spillable
+		bytes int64
+	}
+	candidates := make([]candidate, 0, len(r.mu.spillables))
+	var total int64
+	for s, requested := range r.mu.spillables {
+		if requested {
+			continue
+		}
+		c := candidate{s: s, bytes: s.BufferedBytes()}
+		if c.bytes > 0 {
+			candidates = append(candidates, c)
+			total += c.bytes
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].bytes > candidates[j].bytes
+	})
+	target := int64(float64(total) * fraction)
+	for _, c := range candidates {
+		if numRequested > 0 && bytes >= target {
+			break
+		}
+		c.s.RequestSpill()
+		r.mu.spillables[c.s] = true
+		numRequested++
+		bytes += c.bytes
+	}
+	return numRequested, bytes
+}
@@ -0,0 +1,65 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package execinfra
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+type testSpillable struct {
+	bytes     int64
+	requested bool
+}
+
+func (s *testSpillable) BufferedBytes() int64 {
+	return s.bytes
+}
+
+func (s *testSpillable) RequestSpill() {
+	s.requested = true
+}
+
+func TestSpillRegistry(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	r := NewSpillRegistry()
+	small := &testSpillable{bytes: 10}
+	medium := &testSpillable{bytes: 30}
+	large := &testSpillable{bytes: 60}
+	empty := &testSpillable{}
+	for _, s := range []*testSpillable{small, medium, large, empty} {
+		r.Register(s)
+	}
+
+	// The largest Spillable alone accounts for more than half of the bytes.
+	if n, bytes := r.RequestSpills(0.5); n != 1 || bytes != 60 || !large.requested {
+		t.Fatalf("unexpected spills: %d spillables with %d bytes", n, bytes)
+	}
+	// The largest Spillable has already been asked, so the next largest one is
+	// asked even though a small fraction is requested.
+	if n, bytes := r.RequestSpills(0.01); n != 1 || bytes != 30 || !medium.requested {
+		t.Fatalf("unexpected spills: %d spillables with %d bytes", n, bytes)
+	}
+
+	r.Unregister(small)
+	if n, _ := r.RequestSpills(1); n != 0 || small.requested || empty.requested {
+		t.Fatalf("unexpected spills of %d spillables", n)
+	}
+
+	// Registering a Spillable again makes it eligible to be asked again.
+	large.requested = false
+	r.Register(large)
+	if n, bytes := r.RequestSpills(1); n != 1 || bytes != 60 || !large.requested {
+		t.Fatalf("unexpected spills: %d spillables with %d bytes", n, bytes)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package execinfra
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/quotapool"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+var settingMaxRunningVectorizedWorkers = settings.RegisterNonNegativeIntSetting(
+	"sql.distsql.max_running_vectorized_workers",
+	"maximum number of goroutines (for routers, outboxes, and parallel operators) "+
+		"that the vectorized flows can run concurrently on a node; the start-up of "+
+		"the flows that would exceed it is queued (0 disables the limit)",
+	4096,
+)
+
+// VectorizedWorkerPool bounds the number of goroutines that the vectorized
+// flows run concurrently on a node. Every flow acquires a worker for each of
+// the goroutines it is about to start before it starts, so the start-up of the
+// flows is queued (in FIFO order) once the limit has been reached. A flow that
+// needs more workers than the limit is started once it can acquire all of
+// them, that is once it is the only flow running.
+//
+// The wait of a flow is bounded (see Acquire): the other flows of its query
+// might have already been started on other nodes and be waiting for it to
+// connect their streams, and the flows holding the workers on this node might
+// in turn be waiting for the queued flows of their queries on other nodes. A
+// flow that gives up waiting fails its query, so the limit is never exceeded.
+type VectorizedWorkerPool struct {
+	pool    *quotapool.IntPool
+	metrics *DistSQLMetrics
+}
+
+// NewVectorizedWorkerPool creates a new VectorizedWorkerPool whose limit is
+// determined by the sql.distsql.max_running_vectorized_workers cluster
+// setting.
+func NewVectorizedWorkerPool(st *cluster.Settings, metrics *DistSQLMetrics) *VectorizedWorkerPool {
+	p := &VectorizedWorkerPool{
+		pool:    quotapool.NewIntPool("vectorized workers", maxRunningVectorizedWorkers(st)),
+		metrics: metrics,
+	}
+	settingMaxRunningVectorizedWorkers.SetOnChange(&st.SV, func() {
+		p.pool.UpdateCapacity(maxRunningVectorizedWorkers(st))
+	})
+	return p
+}
+
+// maxRunningVectorizedWorkers returns the capacity of the pool of workers. The
+// disabled limit is emulated by the maximum capacity so that the workers
+// acquired while the limit is disabled are still accounted for once it is
+// enabled.
+func maxRunningVectorizedWorkers(st *cluster.Settings) uint64 {
+	if limit := settingMaxRunningVectorizedWorkers.Get(&st.SV); limit > 0 {
+		return uint64(limit)
+	}
+	return math.MaxInt64
+}
+
+// Acquire acquires numWorkers workers, blocking until they are available or
+// ctx is canceled. If maxWait is positive and the workers couldn't be acquired
+// within maxWait, an error is returned. On success, the returned function must
+// be called to release the workers once all goroutines of the flow have
+// exited.
+func (p *VectorizedWorkerPool) Acquire(
+	ctx context.Context, numWorkers int, maxWait time.Duration,
+) (release func(), _ error) {
+	if numWorkers == 0 {
+		return func() {}, nil
+	}
+	alloc, err := p.pool.TryAcquire(ctx, uint64(numWorkers))
+	if err == quotapool.ErrNotEnoughQuota {
+		log.VEventf(ctx, 1, "vectorized worker pool is queuing a flow that needs %d workers", numWorkers)
+		p.metrics.VecFlowsQueued.Inc(1)
+		start := timeutil.Now()
+		waitCtx := ctx
+		if maxWait > 0 {
+			var cancel context.CancelFunc
+			waitCtx, cancel = context.WithTimeout(ctx, maxWait)
+			defer cancel()
+		}
+		alloc, err = p.pool.Acquire(waitCtx, uint64(numWorkers))
+		wait := timeutil.Since(start)
+		p.metrics.VecFlowsQueued.Dec(1)
+		p.metrics.VecFlowsQueueWaitHist.RecordValue(int64(wait))
+		if err != nil && ctx.Err() == nil && waitCtx.Err() != nil {
+			return nil, pgerror.Newf(pgcode.InsufficientResources,
+				"gave up waiting for %d vectorized workers after %s", numWorkers, wait)
+		}
+		log.VEventf(ctx, 1, "vectorized worker pool dequeued a flow, spent %s in queue", wait)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The request is truncated to the capacity of the pool if it exceeds it.
+	acquired := int64(alloc.Acquired())
+	p.metrics.VecWorkersActive.Inc(acquired)
+	return func() {
+		p.metrics.VecWorkersActive.Dec(acquired)
+		alloc.Release()
+	}, nil
+}
+
+// Close closes the pool, so that all current and future acquisitions fail.
+func (p *VectorizedWorkerPool) Close() {
+	p.pool.Close("vectorized worker pool closed")
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package execinfra
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/errors"
+)
+
+func TestVectorizedWorkerPool(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	settingMaxRunningVectorizedWorkers.Override(&st.SV, 4)
+	metrics := MakeDistSQLMetrics(time.Hour /* histogramWindow */)
+	p := NewVectorizedWorkerPool(st, &metrics)
+	defer p.Close()
+
+	releaseFirst, err := p.Acquire(ctx, 3, 0 /* maxWait */)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active := metrics.VecWorkersActive.Value(); active != 3 {
+		t.Fatalf("expected 3 active workers, found %d", active)
+	}
+
+	// The second flow doesn't fit, so its start-up is queued until the first
+	// flow releases its workers. It needs more workers than the limit, so it
+	// gets all of them.
+	acquired := make(chan func())
+	go func() {
+		release, err := p.Acquire(ctx, 5, 0 /* maxWait */)
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- release
+	}()
+	testutils.SucceedsSoon(t, func() error {
+		if queued := metrics.VecFlowsQueued.Value(); queued != 1 {
+			return errors.Errorf("expected 1 queued flow, found %d", queued)
+		}
+		return nil
+	})
+	releaseFirst()
+	releaseSecond := <-acquired
+	if queued := metrics.VecFlowsQueued.Value(); queued != 0 {
+		t.Fatalf("expected no queued flows, found %d", queued)
+	}
+	if active := metrics.VecWorkersActive.Value(); active != 4 {
+		t.Fatalf("expected 4 active workers, found %d", active)
+	}
+	if count := metrics.VecFlowsQueueWaitHist.TotalCount(); count != 1 {
+		t.Fatalf("expected 1 recorded queue wait, found %d", count)
+	}
+
+	// A flow whose wait is bounded fails once it has waited for too long.
+	if _, err := p.Acquire(ctx, 1, time.Millisecond /* maxWait */); err == nil {
+		t.Fatal("expected an error")
+	} else if code := pgerror.GetPGCode(err); code != pgcode.InsufficientResources {
+		t.Fatalf("unexpected error code %s: %v", code, err)
+	}
+	if active := metrics.VecWorkersActive.Value(); active != 4 {
+		t.Fatalf("expected 4 active workers, found %d", active)
+	}
+	if count := metrics.VecFlowsQueueWaitHist.TotalCount(); count != 2 {
+		t.Fatalf("expected 2 recorded queue waits, found %d", count)
+	}
+
+	// Flows without goroutines are never queued.
+	releaseEmpty, err := p.Acquire(ctx, 0, 0 /* maxWait */)
+	if err != nil {
+		t.Fatal(err)
+	}
+	releaseEmpty()
+
+	// Once the limit is disabled, the flows are no longer queued.
+	settingMaxRunningVectorizedWorkers.Override(&st.SV, 0)
+	releaseThird, err := p.Acquire(ctx, 100, 0 /* maxWait */)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active := metrics.VecWorkersActive.Value(); active != 104 {
+		t.Fatalf("expected 104 active workers, found %d", active)
+	}
+	releaseThird()
+	releaseSecond()
+	if active := metrics.VecWorkersActive.Value(); active != 0 {
+		t.Fatalf("expected no active workers, found %d", active)
+	}
+}
@@ -18,6 +18,16 @@ const StreamIDTagKey = tracing.TagPrefix + "streamid"
 // ProcessorIDTagKey is the key used for processor id tags in tracing spans.
 const ProcessorIDTagKey = tracing.TagPrefix + "processorid"
 
+// OperatorTagKey is the key used for vectorized operator tags in tracing
+// spans.
+const OperatorTagKey = tracing.TagPrefix + "operator"
+
+// SpillStatsTagPrefix is the prefix of the tags of the tracing spans with
+// which the vectorized operators that have spilled to disk describe their
+// spilling. The prefix is followed by the name of the memory monitor of the
+// operator, and the value of the tag is a human-readable summary.
+const SpillStatsTagPrefix = tracing.TagPrefix + "spill."
+
 // DistSQLSpanStats is a tracing.SpanStats that returns a list of stats to
 // output on a query plan.
 type DistSQLSpanStats interface {
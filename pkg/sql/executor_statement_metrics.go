@@ -101,14 +101,15 @@ func (EngineMetrics) MetricStruct() {}
 //   so far.
 // - result is the result set computed by the query/statement.
 // - err is the error encountered, if any.
+// - stats are the statistics reported by the flows that executed the
+//   statement.
 func (ex *connExecutor) recordStatementSummary(
 	ctx context.Context,
 	planner *planner,
 	automaticRetryCount int,
 	rowsAffected int,
 	err error,
-	bytesRead int64,
-	rowsRead int64,
+	stats topLevelQueryStats,
 ) {
 	phaseTimes := &ex.statsCollector.phaseTimes
 
@@ -153,7 +154,7 @@ func (ex *connExecutor) recordStatementSummary(
 		stmt, planner.curPlan.instrumentation.savedPlanForStats,
 		flags.IsSet(planFlagDistributed), flags.IsSet(planFlagImplicitTxn),
 		automaticRetryCount, rowsAffected, err,
-		parseLat, planLat, runLat, svcLat, execOverhead, bytesRead, rowsRead,
+		parseLat, planLat, runLat, svcLat, execOverhead, stats,
 	)
 
 	if log.V(2) {
@@ -15,8 +15,10 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/memo"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
@@ -99,16 +101,19 @@ func setExplainBundleResult(
 //
 // Returns the bundle ID, which is the key for the row added in
 // statement_diagnostics.
-func buildStatementBundle(plan *planTop, trace string) (*bytes.Buffer, error) {
+func buildStatementBundle(
+	plan *planTop, recording tracing.Recording, trace string,
+) (*bytes.Buffer, error) {
 	if plan == nil {
 		return nil, errors.AssertionFailedf("execution terminated early")
 	}
-	b := makeStmtBundleBuilder(plan, trace)
+	b := makeStmtBundleBuilder(plan, recording, trace)
 
 	b.addStatement()
 	b.addOptPlans()
 	b.addExecPlan()
 	b.addTrace()
+	b.addSpills()
 
 	return b.finalize()
 }
@@ -117,14 +122,18 @@ func buildStatementBundle(plan *planTop, trace string) (*bytes.Buffer, error) {
 type stmtBundleBuilder struct {
 	plan *planTop
 
+	// recording is the recorded trace.
+	recording tracing.Recording
 	// trace is the recorded trace (formatted as JSON).
 	trace string
 
 	z memZipper
 }
 
-func makeStmtBundleBuilder(plan *planTop, trace string) stmtBundleBuilder {
-	b := stmtBundleBuilder{plan: plan, trace: trace}
+func makeStmtBundleBuilder(
+	plan *planTop, recording tracing.Recording, trace string,
+) stmtBundleBuilder {
+	b := stmtBundleBuilder{plan: plan, recording: recording, trace: trace}
 	b.z.Init()
 	return b
 }
@@ -170,6 +179,26 @@ func (b *stmtBundleBuilder) addTrace() {
 	}
 }
 
+// addSpills adds the description of the spilling to disk done by the
+// vectorized operators (as recorded in the tags of the trace) as file
+// spills.txt. The file is only added if some operator has spilled.
+func (b *stmtBundleBuilder) addSpills() {
+	var spills []string
+	for i := range b.recording {
+		sp := &b.recording[i]
+		for k, v := range sp.Tags {
+			if op := strings.TrimPrefix(k, execinfrapb.SpillStatsTagPrefix); op != k {
+				spills = append(spills, fmt.Sprintf("%s (span %q)\n  %s\n", op, sp.Operation, v))
+			}
+		}
+	}
+	if len(spills) == 0 {
+		return
+	}
+	sort.Strings(spills)
+	b.z.AddFile("spills.txt", strings.Join(spills, "\n"))
+}
+
 // finalize generates the zipped bundle and returns it as a buffer.
 func (b *stmtBundleBuilder) finalize() (*bytes.Buffer, error) {
 	return b.z.Finalize()
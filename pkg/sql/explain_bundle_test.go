@@ -49,6 +49,14 @@ func TestExplainBundle(t *testing.T) {
 	}
 	// The bundle url is inside the error detail.
 	checkBundle(t, fmt.Sprintf("%+v", err.(*pq.Error).Detail), "statement.txt trace.json")
+
+	// The spilling to disk of the vectorized operators is described in a
+	// separate file.
+	r.Exec(t, "INSERT INTO abc SELECT i, i % 7, i FROM generate_series(1, 1000) AS g(i)")
+	r.Exec(t, "SET CLUSTER SETTING sql.distsql.temp_storage.workmem = '2KiB'")
+	r.Exec(t, "SET vectorize = experimental_always")
+	rows = r.QueryStr(t, "EXPLAIN BUNDLE SELECT * FROM abc ORDER BY b")
+	checkBundle(t, fmt.Sprint(rows), "statement.txt opt.txt opt-v.txt opt-vv.txt plan.txt trace.json spills.txt")
 }
 
 // checkBundle searches text strings for a bundle URL and then verifies that the
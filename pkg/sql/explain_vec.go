@@ -12,6 +12,7 @@ package sql
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"sort"
 
@@ -24,6 +25,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/flowinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
 	"github.com/cockroachdb/cockroach/pkg/util/treeprinter"
 	"github.com/cockroachdb/errors"
@@ -97,7 +99,7 @@ func (n *explainVecNode) startExec(params runParams) error {
 		if flow.nodeID == thisNodeID && !willDistributePlan {
 			fuseOpt = flowinfra.FuseAggressively
 		}
-		opChains, err := colflow.SupportsVectorized(params.ctx, flowCtx, flow.flow.Processors, fuseOpt, nil /* output */)
+		opChains, registry, err := colflow.SupportsVectorized(params.ctx, flowCtx, flow.flow.Processors, fuseOpt, nil /* output */)
 		if err != nil {
 			return err
 		}
@@ -105,8 +107,11 @@ func (n *explainVecNode) startExec(params runParams) error {
 		// a panic (an input that doesn't implement OpNode interface), so we're
 		// catching such errors.
 		if err := execerror.CatchVectorizedRuntimeError(func() {
+			// The IDs of the operators have been assigned in the same way as
+			// when the flow is actually run, so they match the ones used in the
+			// diagnostics of the flow.
 			for _, op := range opChains {
-				formatOpChain(op, node, verbose)
+				formatOpChain(op, node, verbose, registry)
 			}
 		}); err != nil {
 			return err
@@ -156,24 +161,60 @@ func shouldOutput(operator execinfra.OpNode, verbose bool) bool {
 	return !nonExplainable || verbose
 }
 
-func formatOpChain(operator execinfra.OpNode, node treeprinter.Node, verbose bool) {
+// formatOpName returns the name of the operator to be shown in the output of
+// EXPLAIN (VEC) (which includes its ID if it has one). In verbose mode,
+// buffering operators are annotated with their memory limits and whether they
+// can fall back to disk.
+func formatOpName(
+	operator execinfra.OpNode, verbose bool, registry *colexec.OperatorRegistry,
+) string {
+	name := colexec.OperatorName(operator)
+	if desc, ok := registry.Lookup(operator); ok {
+		name = desc.String()
+	}
+	if !verbose {
+		return name
+	}
+	r, ok := operator.(colexec.MemoryLimitReporter)
+	if !ok {
+		return name
+	}
+	limit, diskFallback := r.MemoryLimit()
+	limitStr := "unlimited"
+	if limit > 0 {
+		limitStr = humanizeutil.IBytes(limit)
+	}
+	diskFallbackStr := "no disk fallback"
+	if diskFallback {
+		diskFallbackStr = "disk fallback"
+	}
+	return fmt.Sprintf("%s [memory limit: %s, %s]", name, limitStr, diskFallbackStr)
+}
+
+func formatOpChain(
+	operator execinfra.OpNode,
+	node treeprinter.Node,
+	verbose bool,
+	registry *colexec.OperatorRegistry,
+) {
 	seenOps := make(map[reflect.Value]struct{})
 	if shouldOutput(operator, verbose) {
-		doFormatOpChain(operator, node.Child(reflect.TypeOf(operator).String()), verbose, seenOps)
+		doFormatOpChain(operator, node.Child(formatOpName(operator, verbose, registry)), verbose, registry, seenOps)
 	} else {
-		doFormatOpChain(operator, node, verbose, seenOps)
+		doFormatOpChain(operator, node, verbose, registry, seenOps)
 	}
 }
 func doFormatOpChain(
 	operator execinfra.OpNode,
 	node treeprinter.Node,
 	verbose bool,
+	registry *colexec.OperatorRegistry,
 	seenOps map[reflect.Value]struct{},
 ) {
 	for i := 0; i < operator.ChildCount(verbose); i++ {
 		child := operator.Child(i, verbose)
 		childOpValue := reflect.ValueOf(child)
-		childOpName := reflect.TypeOf(child).String()
+		childOpName := formatOpName(child, verbose, registry)
 		if _, seenOp := seenOps[childOpValue]; seenOp {
 			// We have already seen this operator, so in order to not repeat the full
 			// chain again, we will simply print out this operator's name and will
@@ -184,9 +225,9 @@ func doFormatOpChain(
 		}
 		seenOps[childOpValue] = struct{}{}
 		if shouldOutput(child, verbose) {
-			doFormatOpChain(child, node.Child(childOpName), verbose, seenOps)
+			doFormatOpChain(child, node.Child(childOpName), verbose, registry, seenOps)
 		} else {
-			doFormatOpChain(child, node, verbose, seenOps)
+			doFormatOpChain(child, node, verbose, registry, seenOps)
 		}
 	}
 }
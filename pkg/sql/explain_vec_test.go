@@ -0,0 +1,91 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/datadriven"
+)
+
+// TestExplainVec verifies the vectorized operator trees that are planned for
+// a corpus of queries, including which operators get disk spillers and which
+// processors are wrapped into the vectorized flow, so that the planning
+// changes that silently disable the vectorization of a query are caught.
+//
+// The following commands are supported:
+//  - exec: executes the SQL statements in the input.
+//  - explain-vec [verbose]: outputs the result of EXPLAIN (VEC) (or
+//    EXPLAIN (VEC, VERBOSE) if verbose is specified) of the query in the
+//    input.
+//
+// Run with -rewrite to regenerate the expected output.
+func TestExplainVec(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	s, sqlDB, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	r := sqlutils.MakeSQLRunner(sqlDB)
+	r.Exec(t, `
+		SET CLUSTER SETTING sql.stats.automatic_collection.enabled = false;
+		CREATE DATABASE t;
+		USE t;
+	`)
+
+	datadriven.RunTest(t, "testdata/explain_vec", func(t *testing.T, d *datadriven.TestData) string {
+		switch d.Cmd {
+		case "exec":
+			r.Exec(t, d.Input)
+			return ""
+
+		case "explain-vec":
+			options := "VEC"
+			for _, arg := range d.CmdArgs {
+				switch arg.Key {
+				case "verbose":
+					options += ", VERBOSE"
+				default:
+					t.Fatalf("%s: unknown argument %s", d.Pos, arg.Key)
+				}
+			}
+			rows, err := sqlDB.Query(fmt.Sprintf("EXPLAIN (%s) %s", options, d.Input))
+			if err != nil {
+				return fmt.Sprintf("error: %v\n", err)
+			}
+			defer rows.Close()
+			var lines []string
+			for rows.Next() {
+				var line string
+				if err := rows.Scan(&line); err != nil {
+					t.Fatal(err)
+				}
+				lines = append(lines, strings.TrimRight(line, " "))
+			}
+			if err := rows.Err(); err != nil {
+				return fmt.Sprintf("error: %v\n", err)
+			}
+			return strings.Join(lines, "\n") + "\n"
+
+		default:
+			t.Fatalf("%s: unsupported command %s", d.Pos, d.Cmd)
+			return ""
+		}
+	})
+}
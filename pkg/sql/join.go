@@ -39,6 +39,11 @@ type joinNode struct {
 
 	// columns contains the metadata for the results of this node.
 	columns sqlbase.ResultColumns
+
+	// estimatedRowCount is the estimated number of rows that this joinNode will
+	// output. When there are no statistics to make the estimation, it will be
+	// set to zero.
+	estimatedRowCount uint64
 }
 
 func (p *planner) makeJoinNode(
@@ -392,6 +392,10 @@ type testClusterConfig struct {
 	// fall back to disk do so immediately, using only their disk-based
 	// implementation.
 	sqlExecUseDisk bool
+	// if positive, vectorized operators that can fall back to disk do so once
+	// they have consumed that many tuples, so that both the in-memory and the
+	// disk-backed implementations process some of the input.
+	sqlExecSpillAfterNumTuples int
 	// if set, enables DistSQL metadata propagation tests.
 	distSQLMetadataTestEnabled bool
 	// if set and the -test.short flag is passed, skip this config.
@@ -482,6 +486,16 @@ var logicTestConfigs = []testClusterConfig{
 		sqlExecUseDisk:      true,
 		skipShort:           true,
 	},
+	{
+		name:                       "fakedist-vec-spill",
+		numNodes:                   3,
+		useFakeSpanResolver:        true,
+		overrideDistSQLMode:        "on",
+		overrideAutoStats:          "false",
+		overrideVectorize:          "experimental_on",
+		sqlExecSpillAfterNumTuples: 2,
+		skipShort:                  true,
+	},
 	{
 		name:                       "fakedist-metadata",
 		numNodes:                   3,
@@ -1088,6 +1102,9 @@ func (t *logicTest) setup(cfg testClusterConfig) {
 	if cfg.sqlExecUseDisk {
 		distSQLKnobs.ForceDiskSpill = true
 	}
+	if cfg.sqlExecSpillAfterNumTuples > 0 {
+		distSQLKnobs.ForceDiskSpillAfterNumTuples = cfg.sqlExecSpillAfterNumTuples
+	}
 	if cfg.distSQLMetadataTestEnabled {
 		distSQLKnobs.MetadataTestLevel = execinfra.On
 	}
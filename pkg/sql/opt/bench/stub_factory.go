@@ -76,6 +76,7 @@ func (f *stubFactory) ConstructHashJoin(
 	leftEqCols, rightEqCols []exec.ColumnOrdinal,
 	leftEqColsAreKey, rightEqColsAreKey bool,
 	extraOnCond tree.TypedExpr,
+	rowCount float64,
 ) (exec.Node, error) {
 	return struct{}{}, nil
 }
@@ -100,6 +101,7 @@ func (f *stubFactory) ConstructMergeJoin(
 	leftOrdering, rightOrdering sqlbase.ColumnOrdering,
 	reqOrdering exec.OutputOrdering,
 	leftEqColsAreKey, rightEqColsAreKey bool,
+	rowCount float64,
 ) (exec.Node, error) {
 	return struct{}{}, nil
 }
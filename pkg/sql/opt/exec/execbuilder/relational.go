@@ -794,6 +794,7 @@ func (b *Builder) buildHashJoin(join memo.RelExpr) (execPlan, error) {
 		leftEqOrdinals, rightEqOrdinals,
 		leftEqColsAreKey, rightEqColsAreKey,
 		onExpr,
+		estimatedRowCount(join),
 	)
 	if err != nil {
 		return execPlan{}, err
@@ -822,6 +823,7 @@ func (b *Builder) buildMergeJoin(join *memo.MergeJoinExpr) (execPlan, error) {
 		onExpr,
 		leftOrd, rightOrd, reqOrd,
 		leftEqColsAreKey, rightEqColsAreKey,
+		estimatedRowCount(join),
 	)
 	if err != nil {
 		return execPlan{}, err
@@ -829,6 +831,15 @@ func (b *Builder) buildMergeJoin(join *memo.MergeJoinExpr) (execPlan, error) {
 	return ep, nil
 }
 
+// estimatedRowCount returns the estimated number of rows produced by e, or zero
+// if the statistics are not available.
+func estimatedRowCount(e memo.RelExpr) float64 {
+	if !e.Relational().Stats.Available {
+		return 0
+	}
+	return e.Relational().Stats.RowCount
+}
+
 // initJoinBuild builds the inputs to the join as well as the ON expression.
 func (b *Builder) initJoinBuild(
 	leftChild memo.RelExpr,
@@ -142,19 +142,25 @@ type Factory interface {
 	//
 	// The extraOnCond expression can refer to columns from both inputs using
 	// IndexedVars (first the left columns, then the right columns).
+	//
+	// rowCount is the optimizer's estimate of the number of rows produced by
+	// the join (zero if the statistics are not available).
 	ConstructHashJoin(
 		joinType sqlbase.JoinType,
 		left, right Node,
 		leftEqCols, rightEqCols []ColumnOrdinal,
 		leftEqColsAreKey, rightEqColsAreKey bool,
 		extraOnCond tree.TypedExpr,
+		rowCount float64,
 	) (Node, error)
 
 	// ConstructMergeJoin returns a node that (under distsql) runs a merge join.
 	// The ON expression can refer to columns from both inputs using IndexedVars
 	// (first the left columns, then the right columns). In addition, the i-th
 	// column in leftOrdering is constrained to equal the i-th column in
-	// rightOrdering. The directions must match between the two orderings.
+	// rightOrdering. The directions must match between the two orderings. The
+	// rowCount is the optimizer's estimate of the number of rows produced by
+	// the join (zero if the statistics are not available).
 	ConstructMergeJoin(
 		joinType sqlbase.JoinType,
 		left, right Node,
@@ -162,6 +168,7 @@ type Factory interface {
 		leftOrdering, rightOrdering sqlbase.ColumnOrdering,
 		reqOrdering OutputOrdering,
 		leftEqColsAreKey, rightEqColsAreKey bool,
+		rowCount float64,
 	) (Node, error)
 
 	// ConstructGroupBy returns a node that runs an aggregation. A set of
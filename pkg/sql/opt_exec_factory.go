@@ -269,6 +269,7 @@ func (ef *execFactory) ConstructHashJoin(
 	leftEqCols, rightEqCols []exec.ColumnOrdinal,
 	leftEqColsAreKey, rightEqColsAreKey bool,
 	extraOnCond tree.TypedExpr,
+	rowCount float64,
 ) (exec.Node, error) {
 	p := ef.planner
 	leftSrc := asDataSource(left)
@@ -300,7 +301,9 @@ func (ef *execFactory) ConstructHashJoin(
 		extraOnCond, false /* alsoReset */, false, /* normalizeToNonNil */
 	)
 
-	return p.makeJoinNode(leftSrc, rightSrc, pred), nil
+	node := p.makeJoinNode(leftSrc, rightSrc, pred)
+	node.estimatedRowCount = uint64(rowCount)
+	return node, nil
 }
 
 // ConstructApplyJoin is part of the exec.Factory interface.
@@ -336,6 +339,7 @@ func (ef *execFactory) ConstructMergeJoin(
 	leftOrdering, rightOrdering sqlbase.ColumnOrdering,
 	reqOrdering exec.OutputOrdering,
 	leftEqColsAreKey, rightEqColsAreKey bool,
+	rowCount float64,
 ) (exec.Node, error) {
 	p := ef.planner
 	leftSrc := asDataSource(left)
@@ -367,6 +371,7 @@ func (ef *execFactory) ConstructMergeJoin(
 	}
 
 	node := p.makeJoinNode(leftSrc, rightSrc, pred)
+	node.estimatedRowCount = uint64(rowCount)
 	node.mergeJoinOrdering = make(sqlbase.ColumnOrdering, n)
 	for i := 0; i < n; i++ {
 		// The mergeJoinOrdering "columns" are equality column indices.  Because of
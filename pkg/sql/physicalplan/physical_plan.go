@@ -38,6 +38,18 @@ type Processor struct {
 	// synchronizers and output routers are not set until the end of the planning
 	// process.
 	Spec execinfrapb.ProcessorSpec
+
+	// EstimatedRowCount is the number of rows that the optimizer estimated the
+	// processor would read. It is only set for table readers (zero means that
+	// there is no estimate); see PhysicalPlan.EstimatedRowCounts for the
+	// estimates of all processors.
+	EstimatedRowCount uint64
+
+	// EstimatedJoinRowCount is the number of rows that the optimizer estimated
+	// the join planned as the stage of the processor would produce (before the
+	// post-processing). It is only set for hash and merge joiners (zero means
+	// that there is no estimate).
+	EstimatedJoinRowCount uint64
 }
 
 // ProcessorIdx identifies a processor by its index in PhysicalPlan.Processors.
@@ -858,6 +870,105 @@ func (p *PhysicalPlan) GenerateFlowSpecs(
 	return flows
 }
 
+// EstimatedRowCounts returns the estimated row count of every processor of the
+// plan whose estimate is known. The estimate of a table reader is known if the
+// optimizer estimated it (i.e. it is non-zero), and the estimate of any other
+// processor is known if the estimates of all of its inputs are known, in which
+// case it is the maximum of them. In other words, a processor whose (direct or
+// transitive) inputs include a source without an estimate doesn't have an
+// estimate either.
+func (p *PhysicalPlan) EstimatedRowCounts() map[ProcessorIdx]uint64 {
+	inputs := make(map[ProcessorIdx][]ProcessorIdx)
+	for _, s := range p.Streams {
+		inputs[s.DestProcessor] = append(inputs[s.DestProcessor], s.SourceProcessor)
+	}
+	counts := make(map[ProcessorIdx]uint64)
+	visited := make([]bool, len(p.Processors))
+	var visit func(idx ProcessorIdx) (_ uint64, known bool)
+	visit = func(idx ProcessorIdx) (_ uint64, known bool) {
+		if visited[idx] {
+			count, known := counts[idx]
+			return count, known
+		}
+		visited[idx] = true
+		count := p.Processors[idx].EstimatedRowCount
+		known = count > 0 || len(inputs[idx]) > 0
+		for _, input := range inputs[idx] {
+			c, ok := visit(input)
+			if !ok {
+				known = false
+			}
+			if c > count {
+				count = c
+			}
+		}
+		if known {
+			counts[idx] = count
+		}
+		return count, known
+	}
+	for i := range p.Processors {
+		visit(ProcessorIdx(i))
+	}
+	return counts
+}
+
+// EstimatedScannedRowsOnNode returns the number of rows that the optimizer
+// estimated the table readers planned on the given node would read. The
+// table readers of a single scan (i.e. of the same stage) all have the
+// estimate of the whole scan, so it is split evenly between them.
+func (p *PhysicalPlan) EstimatedScannedRowsOnNode(nodeID roachpb.NodeID) uint64 {
+	numReadersInStage := make(map[int32]uint64)
+	for _, proc := range p.Processors {
+		if proc.EstimatedRowCount > 0 {
+			numReadersInStage[proc.Spec.StageID]++
+		}
+	}
+	var count uint64
+	for _, proc := range p.Processors {
+		if proc.EstimatedRowCount > 0 && proc.Node == nodeID {
+			n := numReadersInStage[proc.Spec.StageID]
+			// Round up so that a non-zero estimate stays non-zero.
+			count += (proc.EstimatedRowCount + n - 1) / n
+		}
+	}
+	return count
+}
+
+// InputEstimatedRowCounts returns, for every processor of the plan that has
+// inputs, the optimizer's estimates of the number of rows of each of its
+// inputs. The estimate of an input is only known if all of the streams feeding
+// into it come from table readers with an estimate (the table readers of a
+// single scan all have the estimate of the whole scan); otherwise it is zero.
+func (p *PhysicalPlan) InputEstimatedRowCounts() map[ProcessorIdx][]uint64 {
+	counts := make(map[ProcessorIdx][]uint64)
+	type input struct {
+		proc ProcessorIdx
+		idx  int
+	}
+	unknown := make(map[input]bool)
+	for _, s := range p.Streams {
+		c, ok := counts[s.DestProcessor]
+		if !ok {
+			c = make([]uint64, len(p.Processors[s.DestProcessor].Spec.Input))
+			counts[s.DestProcessor] = c
+		}
+		if s.DestInput >= len(c) {
+			// The input synchronizers might not have been set up yet.
+			c = append(c, make([]uint64, s.DestInput+1-len(c))...)
+			counts[s.DestProcessor] = c
+		}
+		in := input{proc: s.DestProcessor, idx: s.DestInput}
+		if estimate := p.Processors[s.SourceProcessor].EstimatedRowCount; estimate == 0 || unknown[in] {
+			unknown[in] = true
+			c[s.DestInput] = 0
+		} else if estimate > c[s.DestInput] {
+			c[s.DestInput] = estimate
+		}
+	}
+	return counts
+}
+
 // MergePlans merges the processors and streams of two plan into a new plan.
 // The result routers for each side are also returned (they point at processors
 // in the merged plan).
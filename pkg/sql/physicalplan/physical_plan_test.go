@@ -20,6 +20,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
@@ -402,3 +403,87 @@ func TestMergeResultTypes(t *testing.T) {
 		})
 	}
 }
+
+func TestEstimatedRowCounts(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// Two table readers feed a join whose output goes through a noop, and a
+	// third table reader without an estimate feeds a separate noop. Another
+	// join of the first table reader and the one without an estimate doesn't
+	// have an estimate either, and neither does its consumer.
+	p := PhysicalPlan{
+		Processors: []Processor{
+			{EstimatedRowCount: 10},
+			{EstimatedRowCount: 1000},
+			{},
+			{},
+			{},
+			{},
+			{},
+			{},
+		},
+		Streams: []Stream{
+			{SourceProcessor: 0, DestProcessor: 3},
+			{SourceProcessor: 1, DestProcessor: 3, DestInput: 1},
+			{SourceProcessor: 3, DestProcessor: 4},
+			{SourceProcessor: 2, DestProcessor: 5},
+			{SourceProcessor: 0, DestProcessor: 6},
+			{SourceProcessor: 2, DestProcessor: 6, DestInput: 1},
+			{SourceProcessor: 6, DestProcessor: 7},
+		},
+	}
+	expected := map[ProcessorIdx]uint64{0: 10, 1: 1000, 3: 1000, 4: 1000}
+	if result := p.EstimatedRowCounts(); !reflect.DeepEqual(expected, result) {
+		t.Fatalf("expected %v, got %v", expected, result)
+	}
+}
+
+func TestEstimatedScannedRowsOnNode(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// A scan is split between three nodes, and another scan is planned on the
+	// second node only. The noop doesn't have an estimate.
+	p := PhysicalPlan{
+		Processors: []Processor{
+			{Node: 1, EstimatedRowCount: 100, Spec: execinfrapb.ProcessorSpec{StageID: 1}},
+			{Node: 2, EstimatedRowCount: 100, Spec: execinfrapb.ProcessorSpec{StageID: 1}},
+			{Node: 3, EstimatedRowCount: 100, Spec: execinfrapb.ProcessorSpec{StageID: 1}},
+			{Node: 2, EstimatedRowCount: 10, Spec: execinfrapb.ProcessorSpec{StageID: 2}},
+			{Node: 1, Spec: execinfrapb.ProcessorSpec{StageID: 3}},
+		},
+	}
+	for nodeID, expected := range map[roachpb.NodeID]uint64{1: 34, 2: 44, 3: 34, 4: 0} {
+		if result := p.EstimatedScannedRowsOnNode(nodeID); result != expected {
+			t.Errorf("node %d: expected %d, got %d", nodeID, expected, result)
+		}
+	}
+}
+
+func TestInputEstimatedRowCounts(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// Two table readers of the same scan feed the left input of a join, and a
+	// table reader and a processor without an estimate feed its right input.
+	// The join feeds a noop.
+	p := PhysicalPlan{
+		Processors: []Processor{
+			{EstimatedRowCount: 100},
+			{EstimatedRowCount: 100},
+			{EstimatedRowCount: 10},
+			{},
+			{},
+			{},
+		},
+		Streams: []Stream{
+			{SourceProcessor: 0, DestProcessor: 4},
+			{SourceProcessor: 1, DestProcessor: 4},
+			{SourceProcessor: 2, DestProcessor: 4, DestInput: 1},
+			{SourceProcessor: 3, DestProcessor: 4, DestInput: 1},
+			{SourceProcessor: 4, DestProcessor: 5},
+		},
+	}
+	expected := map[ProcessorIdx][]uint64{4: {100, 0}, 5: {0}}
+	if result := p.InputEstimatedRowCounts(); !reflect.DeepEqual(expected, result) {
+		t.Fatalf("expected %v, got %v", expected, result)
+	}
+}
@@ -425,6 +425,35 @@ func (p *planner) ParseType(sql string) (*types.T, error) {
 	return parser.ParseType(sql)
 }
 
+// SetVectorizedFlowsPaused implements the tree.EvalPlanner interface.
+// Only the flows on the gateway of the query are known by the query ID, but
+// pausing them also stops the remote flows of the query once the streams to
+// the gateway are full.
+func (p *planner) SetVectorizedFlowsPaused(
+	ctx context.Context, queryID string, paused bool,
+) (int, error) {
+	if err := p.RequireAdminRole(ctx, "pause or resume vectorized flows"); err != nil {
+		return 0, err
+	}
+	registry := p.ExecCfg().DistSQLSrv.ServerConfig.FlowProgressRegistry
+	if registry == nil {
+		return 0, nil
+	}
+	n := 0
+	for _, progress := range registry.Flows() {
+		if progress.QueryID != queryID {
+			continue
+		}
+		if paused {
+			progress.Pause()
+		} else {
+			progress.Resume()
+		}
+		n++
+	}
+	return n, nil
+}
+
 // ParseQualifiedTableName implements the tree.EvalDatabase interface.
 // This exists to get around a circular dependency between sql/sem/tree and
 // sql/parser. sql/parser depends on tree to make objects, so tree cannot import
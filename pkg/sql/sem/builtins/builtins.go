@@ -3600,6 +3600,53 @@ may increase either contention or retry errors, or both.`,
 		},
 	),
 
+	"crdb_internal.pause_vectorized_flows": makeBuiltin(
+		tree.FunctionProperties{
+			Category: categorySystemInfo,
+			Impure:   true,
+		},
+		tree.Overload{
+			Types:      tree.ArgTypes{{"query_id", types.String}},
+			ReturnType: tree.FixedReturnType(types.Int),
+			Fn: func(ctx *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				n, err := ctx.Planner.SetVectorizedFlowsPaused(
+					ctx.Ctx(), string(tree.MustBeDString(args[0])), true, /* paused */
+				)
+				if err != nil {
+					return nil, err
+				}
+				return tree.NewDInt(tree.DInt(n)), nil
+			},
+			Info: "Pauses the vectorized flows of the query with the given ID (as shown in " +
+				"`crdb_internal.node_queries`) on the gateway node processing this request " +
+				"and returns the number of paused flows. The flows keep their resources " +
+				"while paused; resume them with `crdb_internal.resume_vectorized_flows`.",
+		},
+	),
+
+	"crdb_internal.resume_vectorized_flows": makeBuiltin(
+		tree.FunctionProperties{
+			Category: categorySystemInfo,
+			Impure:   true,
+		},
+		tree.Overload{
+			Types:      tree.ArgTypes{{"query_id", types.String}},
+			ReturnType: tree.FixedReturnType(types.Int),
+			Fn: func(ctx *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				n, err := ctx.Planner.SetVectorizedFlowsPaused(
+					ctx.Ctx(), string(tree.MustBeDString(args[0])), false, /* paused */
+				)
+				if err != nil {
+					return nil, err
+				}
+				return tree.NewDInt(tree.DInt(n)), nil
+			},
+			Info: "Resumes the vectorized flows of the query with the given ID that have been " +
+				"paused with `crdb_internal.pause_vectorized_flows` on the gateway node processing " +
+				"this request and returns the number of resumed flows.",
+		},
+	),
+
 	// Returns the number of distinct inverted index entries that would be
 	// generated for a value.
 	"crdb_internal.num_inverted_index_entries": makeBuiltin(
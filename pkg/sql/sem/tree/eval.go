@@ -2613,6 +2613,11 @@ type EvalPlanner interface {
 
 	// EvalSubquery returns the Datum for the given subquery node.
 	EvalSubquery(expr *Subquery) (Datum, error)
+
+	// SetVectorizedFlowsPaused pauses (or resumes, if paused is false) the
+	// vectorized flows of the query with the given ID that are running on this
+	// node and returns the number of flows that have been paused (or resumed).
+	SetVectorizedFlowsPaused(ctx context.Context, queryID string, paused bool) (int, error)
 }
 
 // EvalSessionAccessor is a limited interface to access session variables.
@@ -70,6 +70,10 @@ type SessionData struct {
 	// VectorizeRowCountThreshold indicates the row count above which the
 	// vectorized execution engine will be used if possible.
 	VectorizeRowCountThreshold uint64
+	// VectorizeForceSpillAfterNumTuples, if positive, forces all vectorized
+	// operators that can fall back to disk to do so once they have consumed
+	// that many tuples. It is a testing-only setting.
+	VectorizeForceSpillAfterNumTuples int
 	// ForceSavepointRestart overrides the default SAVEPOINT behavior
 	// for compatibility with certain ORMs. When this flag is set,
 	// the savepoint name will no longer be compared against the magic
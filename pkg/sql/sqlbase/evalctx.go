@@ -110,6 +110,13 @@ func (ep *DummyEvalPlanner) EvalSubquery(expr *tree.Subquery) (tree.Datum, error
 	return nil, errors.WithStack(errEvalPlanner)
 }
 
+// SetVectorizedFlowsPaused is part of the tree.EvalPlanner interface.
+func (ep *DummyEvalPlanner) SetVectorizedFlowsPaused(
+	ctx context.Context, queryID string, paused bool,
+) (int, error) {
+	return 0, errors.WithStack(errEvalPlanner)
+}
+
 // DummyPrivilegedAccessor implements the tree.PrivilegedAccessor interface by returning errors.
 type DummyPrivilegedAccessor struct{}
 
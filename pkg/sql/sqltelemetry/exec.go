@@ -19,3 +19,8 @@ var DistSQLExecCounter = telemetry.GetCounterOnce("sql.exec.query.is-distributed
 // VecExecCounter is to be incremented whenever a query runs with the vectorized
 // execution engine.
 var VecExecCounter = telemetry.GetCounterOnce("sql.exec.query.is-vectorized")
+
+// JoinCardinalityMisestimateCounter is to be incremented whenever the number
+// of rows produced by a hash or merge join executed by the vectorized engine
+// differs from the optimizer's estimate by more than a constant factor.
+var JoinCardinalityMisestimateCounter = telemetry.GetCounterOnce("sql.exec.join-cardinality-misestimate")
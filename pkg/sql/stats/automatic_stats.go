@@ -175,6 +175,13 @@ const (
 // signaling is best-effort; if the channel is full, the metadata will not be
 // sent.
 //
+// In addition to mutations, the Refresher learns from the row counts observed
+// during query execution. When a full scan of a table completes, the observed
+// number of rows is sent to the Refresher thread via ObserveTableRowCount. The
+// difference between the observed row count and the row count of the most
+// recent statistic is treated as a number of stale rows, so a table that was
+// badly misestimated is likely to be refreshed on the next cycle.
+//
 type Refresher struct {
 	st      *cluster.Settings
 	ex      sqlutil.InternalExecutor
@@ -198,6 +205,14 @@ type Refresher struct {
 	// mutationCounts contains aggregated mutation counts for each table that
 	// have yet to be processed by the refresher.
 	mutationCounts map[sqlbase.ID]int64
+
+	// observations is the buffered channel used to pass the row counts
+	// observed during query execution to the background Refresher thread.
+	observations chan observation
+
+	// observedRowCounts contains the most recent observed row count for each
+	// table that has yet to be processed by the refresher.
+	observedRowCounts map[sqlbase.ID]int64
 }
 
 // mutation contains metadata about a SQL mutation and is the message passed to
@@ -207,6 +222,14 @@ type mutation struct {
 	rowsAffected int
 }
 
+// observation contains the number of rows of a table observed during query
+// execution and is the message passed to the background refresher thread to
+// (possibly) trigger a statistics refresh.
+type observation struct {
+	tableID  sqlbase.ID
+	rowCount int64
+}
+
 // MakeRefresher creates a new Refresher.
 func MakeRefresher(
 	st *cluster.Settings,
@@ -225,6 +248,9 @@ func MakeRefresher(
 		asOfTime:       asOfTime,
 		extraTime:      time.Duration(rand.Int63n(int64(time.Hour))),
 		mutationCounts: make(map[sqlbase.ID]int64, 16),
+
+		observations:      make(chan observation, refreshChanBufferLen),
+		observedRowCounts: make(map[sqlbase.ID]int64, 16),
 	}
 }
 
@@ -257,6 +283,7 @@ func (r *Refresher) Start(
 
 			case <-timer.C:
 				mutationCounts := r.mutationCounts
+				observedRowCounts := r.observedRowCounts
 				if err := stopper.RunAsyncTask(
 					ctx, "stats.Refresher: maybeRefreshStats", func(ctx context.Context) {
 						// Wait so that the latest changes will be reflected according to the
@@ -270,6 +297,10 @@ func (r *Refresher) Start(
 							return
 						}
 
+						for tableID, rowCount := range observedRowCounts {
+							mutationCounts[tableID] += r.misestimatedRows(ctx, tableID, rowCount)
+						}
+
 						for tableID, rowsAffected := range mutationCounts {
 							// Check the cluster setting before each refresh in case it was
 							// disabled recently.
@@ -292,10 +323,14 @@ func (r *Refresher) Start(
 					log.Errorf(ctx, "failed to refresh stats: %v", err)
 				}
 				r.mutationCounts = make(map[sqlbase.ID]int64, len(r.mutationCounts))
+				r.observedRowCounts = make(map[sqlbase.ID]int64, len(r.observedRowCounts))
 
 			case mut := <-r.mutations:
 				r.mutationCounts[mut.tableID] += int64(mut.rowsAffected)
 
+			case obs := <-r.observations:
+				r.observedRowCounts[obs.tableID] = obs.rowCount
+
 			case <-stopper.ShouldStop():
 				return
 			}
@@ -378,6 +413,64 @@ func (r *Refresher) NotifyMutation(tableID sqlbase.ID, rowsAffected int) {
 	}
 }
 
+// ObserveTableRowCount is called by the gateway of a query to signal to the
+// Refresher that a full scan of a table has been performed and that rowCount
+// rows have been observed. It implements the execinfra.CardinalityObserver
+// interface.
+func (r *Refresher) ObserveTableRowCount(ctx context.Context, tableID sqlbase.ID, rowCount int64) {
+	if !AutomaticStatisticsClusterMode.Get(&r.st.SV) {
+		// Automatic stats are disabled.
+		return
+	}
+
+	if sqlbase.IsReservedID(tableID) || sqlbase.IsVirtualTable(tableID) {
+		// Don't try to create statistics for system or virtual tables.
+		return
+	}
+
+	// Send the observation to the refresher thread to avoid adding latency to
+	// the query.
+	select {
+	case r.observations <- observation{tableID: tableID, rowCount: rowCount}:
+	default:
+		// Don't block if there is no room in the buffered channel.
+		if bufferedChanFullLogLimiter.ShouldLog() {
+			log.Warningf(ctx,
+				"buffered channel is full. Unable to record %d observed rows for table %d",
+				rowCount, tableID)
+		}
+	}
+}
+
+// misestimatedRows returns the absolute difference between the observed row
+// count of the given table and the row count of its most recent statistic.
+// This difference is treated as a number of stale rows by maybeRefreshStats.
+// If there are no statistics on the table, zero is returned (the refresh will
+// be forced anyway).
+func (r *Refresher) misestimatedRows(
+	ctx context.Context, tableID sqlbase.ID, observedRowCount int64,
+) int64 {
+	tableStats, err := r.cache.GetTableStats(ctx, tableID)
+	if err != nil {
+		log.Errorf(ctx, "failed to get table statistics: %v", err)
+		return 0
+	}
+	if len(tableStats) == 0 {
+		return 0
+	}
+	// Stats are sorted with the most recent first.
+	estimatedRowCount := int64(tableStats[0].RowCount)
+	diff := observedRowCount - estimatedRowCount
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > 0 {
+		log.VEventf(ctx, 1, "table %d: observed %d rows, but statistics estimate %d rows",
+			tableID, observedRowCount, estimatedRowCount)
+	}
+	return diff
+}
+
 // maybeRefreshStats implements the core logic described in the comment for
 // Refresher. It is called by the background Refresher thread.
 func (r *Refresher) maybeRefreshStats(
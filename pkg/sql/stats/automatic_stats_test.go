@@ -19,6 +19,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/gossip"
+	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -409,6 +410,85 @@ func TestMutationsChannel(t *testing.T) {
 	}
 }
 
+func TestObservationsChannel(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.NewTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+
+	AutomaticStatisticsClusterMode.Override(&st.SV, true)
+	r := Refresher{
+		st:           st,
+		observations: make(chan observation, refreshChanBufferLen),
+	}
+
+	// Test that the observations channel doesn't block even when we add 10
+	// more items than can fit in the buffer.
+	for i := 0; i < refreshChanBufferLen+10; i++ {
+		r.ObserveTableRowCount(ctx, sqlbase.ID(53), 5 /* rowCount */)
+	}
+
+	if expected, actual := refreshChanBufferLen, len(r.observations); expected != actual {
+		t.Fatalf("expected channel size %d but found %d", expected, actual)
+	}
+
+	// Observations for system tables must be ignored.
+	r.observations = make(chan observation, refreshChanBufferLen)
+	r.ObserveTableRowCount(ctx, keys.DescriptorTableID, 5 /* rowCount */)
+	if actual := len(r.observations); actual != 0 {
+		t.Fatalf("expected no observations but found %d", actual)
+	}
+}
+
+func TestMisestimatedRows(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.NewTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+
+	AutomaticStatisticsClusterMode.Override(&st.SV, false)
+
+	sqlRun := sqlutils.MakeSQLRunner(sqlDB)
+	sqlRun.Exec(t,
+		`CREATE DATABASE t;
+		CREATE TABLE t.a (k INT PRIMARY KEY);
+		INSERT INTO t.a SELECT generate_series(1, 10);`)
+
+	executor := s.InternalExecutor().(sqlutil.InternalExecutor)
+	tableID := sqlbase.GetTableDescriptor(s.DB(), "t", "a").ID
+	cache := NewTableStatisticsCache(10 /* cacheSize */, s.GossipI().(*gossip.Gossip), kvDB, executor)
+	refresher := MakeRefresher(st, executor, cache, time.Microsecond /* asOfTime */)
+
+	// There are no stats yet, so there is nothing to compare against.
+	if actual := refresher.misestimatedRows(ctx, tableID, 100 /* observedRowCount */); actual != 0 {
+		t.Fatalf("expected 0 misestimated rows but found %d", actual)
+	}
+
+	sqlRun.Exec(t, `CREATE STATISTICS s FROM t.a`)
+	if err := checkStatsCount(ctx, cache, tableID, 1 /* expected */); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		observed, expected int64
+	}{
+		{observed: 10, expected: 0},
+		{observed: 100, expected: 90},
+		{observed: 4, expected: 6},
+	} {
+		if actual := refresher.misestimatedRows(ctx, tableID, tc.observed); actual != tc.expected {
+			t.Fatalf("observed %d rows: expected %d misestimated rows but found %d",
+				tc.observed, tc.expected, actual)
+		}
+	}
+}
+
 func TestDefaultColumns(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	ctx := context.Background()
@@ -429,6 +429,29 @@ var varGen = map[string]sessionVar{
 		},
 	},
 
+	// CockroachDB extension. Testing-only: note that the value is not
+	// propagated to the remote nodes, so only the flows on the gateway are
+	// affected.
+	`vectorize_force_spill_after_num_tuples`: {
+		Hidden: true,
+		Set: func(_ context.Context, m *sessionDataMutator, s string) error {
+			b, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return err
+			}
+			if b < 0 {
+				return pgerror.Newf(pgcode.InvalidParameterValue,
+					"cannot set vectorize_force_spill_after_num_tuples to a negative value: %d", b)
+			}
+			m.SetVectorizeForceSpillAfterNumTuples(int(b))
+			return nil
+		},
+		Get: func(evalCtx *extendedEvalContext) string {
+			return strconv.Itoa(evalCtx.SessionData.VectorizeForceSpillAfterNumTuples)
+		},
+		GlobalDefault: func(sv *settings.Values) string { return "0" },
+	},
+
 	// CockroachDB extension.
 	// This is deprecated; the only allowable setting is "on".
 	`optimizer`: {
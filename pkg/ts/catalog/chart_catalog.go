@@ -1372,6 +1372,23 @@ var charts = []sectionDescription{
 			},
 		},
 	},
+	{
+		Organization: [][]string{{SQLLayer, "DistSQL", "Vectorized Workers"}},
+		Charts: []chartDescription{
+			{
+				Title:   "Active",
+				Metrics: []string{"sql.distsql.vec.workers.active"},
+			},
+			{
+				Title:   "Queue Wait",
+				Metrics: []string{"sql.distsql.vec.flows.queue_wait"},
+			},
+			{
+				Title:   "Queued",
+				Metrics: []string{"sql.distsql.vec.flows.queued"},
+			},
+		},
+	},
 	{
 		Organization: [][]string{{SQLLayer, "Bulk"}},
 		Charts: []chartDescription{
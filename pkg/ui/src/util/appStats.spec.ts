@@ -152,6 +152,7 @@ function randomStats(sensitiveInfo?: ISensitiveInfo): StatementStatistics {
   // tslint:disable:variable-name
   const first_attempt_count = randomInt(count);
   const max_retries = randomInt(count - first_attempt_count);
+  const spill_count = randomInt(count);
   // tslint:enable:variable-name
 
   return {
@@ -165,6 +166,8 @@ function randomStats(sensitiveInfo?: ISensitiveInfo): StatementStatistics {
     service_lat: randomStat(),
     overhead_lat: randomStat(),
     sensitive_info: sensitiveInfo || makeSensitiveInfo(null, null),
+    spill_count: Long.fromNumber(spill_count),
+    max_bytes_spilled: Long.fromNumber(spill_count > 0 ? randomInt(1 << 30) : 0),
   };
 }
 
@@ -221,6 +224,12 @@ describe("combineStatementStats", () => {
     assert.equal(ab_c.max_retries.toString(), ac_b.max_retries.toString());
     assert.equal(ab_c.max_retries.toString(), bc_a.max_retries.toString());
 
+    assert.equal(ab_c.spill_count.toString(), ac_b.spill_count.toString());
+    assert.equal(ab_c.spill_count.toString(), bc_a.spill_count.toString());
+
+    assert.equal(ab_c.max_bytes_spilled.toString(), ac_b.max_bytes_spilled.toString());
+    assert.equal(ab_c.max_bytes_spilled.toString(), bc_a.max_bytes_spilled.toString());
+
     assert.approximately(ab_c.num_rows.mean, ac_b.num_rows.mean, 0.0000001);
     assert.approximately(ab_c.num_rows.mean, bc_a.num_rows.mean, 0.0000001);
     assert.approximately(ab_c.num_rows.squared_diffs, ac_b.num_rows.squared_diffs, 0.0000001);
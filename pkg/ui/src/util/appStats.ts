@@ -61,6 +61,10 @@ export function addStatementStats(a: StatementStatistics, b: StatementStatistics
     service_lat: addNumericStats(a.service_lat, b.service_lat, countA, countB),
     overhead_lat: addNumericStats(a.overhead_lat, b.overhead_lat, countA, countB),
     sensitive_info: coalesceSensitiveInfo(a.sensitive_info, b.sensitive_info),
+    spill_count: FixLong(a.spill_count).add(FixLong(b.spill_count)),
+    max_bytes_spilled: FixLong(a.max_bytes_spilled).greaterThan(FixLong(b.max_bytes_spilled))
+      ? a.max_bytes_spilled
+      : b.max_bytes_spilled,
   };
 }
 
@@ -29,7 +29,7 @@ import {
 } from "src/util/appStats";
 import { appAttr, implicitTxnAttr, statementAttr } from "src/util/constants";
 import { FixLong } from "src/util/fixLong";
-import { Duration } from "src/util/format";
+import { Bytes, Duration } from "src/util/format";
 import { intersperse } from "src/util/intersperse";
 import { Pick } from "src/util/pick";
 import Loading from "src/views/shared/components/loading";
@@ -243,6 +243,8 @@ export class StatementDetails extends React.Component<StatementDetailsProps, Sta
     const firstAttemptsBarChart = longToInt(this.props.statement.stats.first_attempt_count);
     const retriesBarChart = totalCountBarChart - firstAttemptsBarChart;
     const maxRetriesBarChart = longToInt(this.props.statement.stats.max_retries);
+    const spillCount = longToInt(this.props.statement.stats.spill_count);
+    const maxBytesSpilled = longToInt(this.props.statement.stats.max_bytes_spilled);
 
     const statsByNode = this.props.statement.byNode;
     const logicalPlan = stats.sensitive_info && stats.sensitive_info.most_recent_plan_description;
@@ -320,6 +322,16 @@ export class StatementDetails extends React.Component<StatementDetailsProps, Sta
                   <h4 className="summary--card__item--label">Standard Deviation</h4>
                   <p className="summary--card__item--value">{ rowsBarChart() }</p>
                 </div>
+                <p className="summary--card__divider"></p>
+                <h2 className="base-heading summary--card__title">Disk Spilling</h2>
+                <div className="summary--card__item">
+                  <h4 className="summary--card__item--label">Executions that Spilled</h4>
+                  <p className={classNames("summary--card__item--value", { "summary--card__item--value-red": spillCount > 0})}>{ spillCount }</p>
+                </div>
+                <div className="summary--card__item">
+                  <h4 className="summary--card__item--label">Max Bytes Spilled</h4>
+                  <p className="summary--card__item--value">{ Bytes(maxBytesSpilled) }</p>
+                </div>
               </SummaryCard>
             </Col>
           </Row>
@@ -391,6 +391,8 @@ function makeStats() {
     overhead_lat: makeStat(),
     service_lat: makeStat(),
     sensitive_info: makeEmptySensitiveInfo(),
+    spill_count: Long.fromNumber(0),
+    max_bytes_spilled: Long.fromNumber(0),
   };
 }
 
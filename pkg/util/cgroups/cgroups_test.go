@@ -115,6 +115,75 @@ func TestCgroupsGetMemory(t *testing.T) {
 	}
 }
 
+func TestCgroupsGetMemoryPressure(t *testing.T) {
+	const v2Path = "/sys/fs/cgroup/machine.slice/libpod-f1c6b44c0d61f273952b8daecf154cee1be2d503b7e9184ebf7fcaf48e139810.scope"
+	for _, tc := range []struct {
+		name     string
+		paths    map[string]string
+		errMsg   string
+		pressure MemoryPressure
+		warn     string
+	}{
+		{
+			name: "fails for cgroup v1",
+			paths: map[string]string{
+				"/proc/self/cgroup":    v1CgroupWithMemoryController,
+				"/proc/self/mountinfo": v1MountsWithMemController,
+			},
+			errMsg: "memory pressure is not reported by cgroup v1",
+		},
+		{
+			name: "fails when the events file is missing for cgroup v2",
+			paths: map[string]string{
+				"/proc/self/cgroup":    v2CgroupWithMemoryController,
+				"/proc/self/mountinfo": v2Mounts,
+			},
+			errMsg: "can't read memory events from cgroup v2",
+		},
+		{
+			name: "fetches the high events without pressure stall information for cgroup v2",
+			paths: map[string]string{
+				"/proc/self/cgroup":       v2CgroupWithMemoryController,
+				"/proc/self/mountinfo":    v2Mounts,
+				v2Path + "/memory.events": v2MemoryEvents,
+			},
+			pressure: MemoryPressure{HighEvents: 42},
+			warn:     "no memory pressure stall information found",
+		},
+		{
+			name: "fails when unable to parse the pressure for cgroup v2",
+			paths: map[string]string{
+				"/proc/self/cgroup":         v2CgroupWithMemoryController,
+				"/proc/self/mountinfo":      v2Mounts,
+				v2Path + "/memory.events":   v2MemoryEvents,
+				v2Path + "/memory.pressure": "some avg10=unparsable\n",
+			},
+			errMsg: "can't parse memory pressure from cgroup v2 in",
+		},
+		{
+			name: "fetches the pressure for cgroup v2",
+			paths: map[string]string{
+				"/proc/self/cgroup":         v2CgroupWithMemoryController,
+				"/proc/self/mountinfo":      v2Mounts,
+				v2Path + "/memory.events":   v2MemoryEvents,
+				v2Path + "/memory.pressure": v2MemoryPressure,
+			},
+			pressure: MemoryPressure{SomeAvg10: 12.5, HighEvents: 42},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := createFiles(t, tc.paths)
+			defer func() { _ = os.RemoveAll(dir) }()
+
+			pressure, warn, err := getCgroupMemPressure(dir)
+			require.True(t, testutils.IsError(err, tc.errMsg),
+				"%v %v", err, tc.errMsg)
+			require.Regexp(t, tc.warn, warn)
+			require.Equal(t, tc.pressure, pressure)
+		})
+	}
+}
+
 func createFiles(t *testing.T, paths map[string]string) (dir string) {
 	dir, err := ioutil.TempDir("", "")
 	require.NoError(t, err)
@@ -154,6 +223,17 @@ const (
 	v2CgroupWithMemoryController = `0::/machine.slice/libpod-f1c6b44c0d61f273952b8daecf154cee1be2d503b7e9184ebf7fcaf48e139810.scope
 `
 
+	v2MemoryEvents = `low 0
+high 42
+max 3
+oom 0
+oom_kill 0
+`
+
+	v2MemoryPressure = `some avg10=12.50 avg60=3.21 avg300=0.80 total=123456
+full avg10=4.00 avg60=1.00 avg300=0.20 total=23456
+`
+
 	v1MountsWithMemController = `625 367 0:71 / / rw,relatime master:85 - overlay overlay rw,lowerdir=/var/lib/docker/overlay2/l/DOLSFLPSKANL4GJ7XKF3OG6PKN:/var/lib/docker/overlay2/l/P7UJPLDFEUSRQ7CZILB7L4T5OP:/var/lib/docker/overlay2/l/FSKO5FFFNQ6XOSVF7T6R2DWZVZ:/var/lib/docker/overlay2/l/YNE4EZZE2GW2DIXRBUP47LB3GU:/var/lib/docker/overlay2/l/F2JNS7YWT5CU7FUXHNV5JUJWQY,upperdir=/var/lib/docker/overlay2/b12d4d510f3eaf4552a749f9d4f6da182d55bfcdc75755f1972fd8ca33f51278/diff,workdir=/var/lib/docker/overlay2/b12d4d510f3eaf4552a749f9d4f6da182d55bfcdc75755f1972fd8ca33f51278/work
 626 625 0:79 / /proc rw,nosuid,nodev,noexec,relatime - proc proc rw
 687 625 0:75 / /dev rw,nosuid - tmpfs tmpfs rw,size=65536k,mode=755
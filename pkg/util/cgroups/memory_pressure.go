@@ -0,0 +1,137 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package cgroups
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	cgroupV2MemPressureFilename = "memory.pressure"
+	cgroupV2MemEventsFilename   = "memory.events"
+)
+
+// MemoryPressure describes the memory pressure that the cgroup of the current
+// process is under.
+type MemoryPressure struct {
+	// SomeAvg10 is the percentage of the last 10 seconds during which at least
+	// one task of the cgroup was stalled waiting for memory. It is only
+	// available if the kernel supports pressure stall information (PSI).
+	SomeAvg10 float64
+	// HighEvents is the number of times that the memory usage of the cgroup
+	// has exceeded its memory.high boundary and the cgroup has been throttled.
+	HighEvents int64
+}
+
+// GetMemoryPressure attempts to retrieve the memory pressure of the cgroup of
+// the current process. The memory pressure is only reported by cgroup v2.
+func GetMemoryPressure() (pressure MemoryPressure, warnings string, err error) {
+	return getCgroupMemPressure("/")
+}
+
+// `root` is set to "/" in production code and exists only for testing.
+func getCgroupMemPressure(root string) (pressure MemoryPressure, warnings string, err error) {
+	path, err := detectMemCntrlPath(filepath.Join(root, "/proc/self/cgroup"))
+	if err != nil {
+		return MemoryPressure{}, "", err
+	}
+
+	// no memory controller detected
+	if path == "" {
+		return MemoryPressure{}, "", errors.New("no cgroup memory controller detected")
+	}
+
+	mount, ver, err := getCgroupDetails(filepath.Join(root, "/proc/self/mountinfo"), path)
+	if err != nil {
+		return MemoryPressure{}, "", err
+	}
+	if ver != 2 {
+		return MemoryPressure{}, "", fmt.Errorf("memory pressure is not reported by cgroup v%d", ver)
+	}
+
+	cRoot := filepath.Join(root, mount, path)
+	if pressure.HighEvents, err = detectHighEventsInV2(cRoot); err != nil {
+		return MemoryPressure{}, "", err
+	}
+	pressure.SomeAvg10, warnings, err = detectSomeAvg10InV2(cRoot)
+	return pressure, warnings, err
+}
+
+// Finds the number of memory.high events via looking into [controller mount path]/[leaf path]/memory.events
+func detectHighEventsInV2(cRoot string) (int64, error) {
+	eventsFilePath := filepath.Join(cRoot, cgroupV2MemEventsFilename)
+	events, err := os.Open(eventsFilePath)
+	if err != nil {
+		return 0, errors.Wrapf(err, "can't read memory events from cgroup v2 at %s", eventsFilePath)
+	}
+	defer func() {
+		_ = events.Close()
+	}()
+
+	scanner := bufio.NewScanner(events)
+	for scanner.Scan() {
+		fields := bytes.Fields(scanner.Bytes())
+		if len(fields) != 2 || string(fields[0]) != "high" {
+			continue
+		}
+
+		highEvents, err := strconv.ParseInt(string(fields[1]), 10, 64)
+		if err != nil {
+			return 0, errors.Wrapf(err, "can't parse memory events from cgroup v2 in %s", eventsFilePath)
+		}
+		return highEvents, nil
+	}
+
+	return 0, fmt.Errorf("failed to find expected high memory events for cgroup v2 in %s", eventsFilePath)
+}
+
+// Finds the `some avg10` memory pressure via looking into [controller mount path]/[leaf path]/memory.pressure
+// The file has the following format:
+//
+//   some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//   full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func detectSomeAvg10InV2(cRoot string) (someAvg10 float64, warnings string, err error) {
+	pressureFilePath := filepath.Join(cRoot, cgroupV2MemPressureFilename)
+	pressure, err := os.Open(pressureFilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// The kernel doesn't support pressure stall information.
+			return 0, fmt.Sprintf("no memory pressure stall information found at %s", pressureFilePath), nil
+		}
+		return 0, "", errors.Wrapf(err, "can't read memory pressure from cgroup v2 at %s", pressureFilePath)
+	}
+	defer func() {
+		_ = pressure.Close()
+	}()
+
+	scanner := bufio.NewScanner(pressure)
+	for scanner.Scan() {
+		fields := bytes.Fields(scanner.Bytes())
+		if len(fields) < 2 || string(fields[0]) != "some" || !bytes.HasPrefix(fields[1], []byte("avg10=")) {
+			continue
+		}
+
+		someAvg10, err = strconv.ParseFloat(string(bytes.TrimPrefix(fields[1], []byte("avg10="))), 64)
+		if err != nil {
+			return 0, "", errors.Wrapf(err, "can't parse memory pressure from cgroup v2 in %s", pressureFilePath)
+		}
+		return someAvg10, "", nil
+	}
+
+	return 0, "", fmt.Errorf("failed to find expected memory pressure for cgroup v2 in %s", pressureFilePath)
+}
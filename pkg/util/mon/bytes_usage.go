@@ -204,7 +204,8 @@ type BytesMonitor struct {
 	// hit constraints on the owner monitor. This is useful to limit allocations
 	// when an owner monitor has a larger capacity than wanted but should still
 	// keep track of allocations made through this monitor. Note that child
-	// monitors are affected by this limit.
+	// monitors are affected by this limit. It can only be changed (see
+	// SetLimit) while holding mu.
 	limit int64
 
 	// poolAllocationSize specifies the allocation unit for requests to the
@@ -433,6 +434,17 @@ func (mm *BytesMonitor) AllocBytes() int64 {
 	return mm.mu.curAllocated
 }
 
+// SetLimit changes the hard limit of the monitor and returns the previous
+// one. Lowering the limit below the number of bytes currently allocated does
+// not release any of them, but all further allocations are denied until the
+// usage drops below the new limit.
+func (mm *BytesMonitor) SetLimit(limit int64) (prev int64) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	prev, mm.limit = mm.limit, limit
+	return prev
+}
+
 // SetMetrics sets the metric objects for the monitor.
 func (mm *BytesMonitor) SetMetrics(curCount *metric.Gauge, maxHist *metric.Histogram) {
 	mm.curBytesCount = curCount
@@ -586,9 +598,7 @@ func (mm *BytesMonitor) reserveBytes(ctx context.Context, x int64) error {
 	// so that it handles overflow correctly. Consider what happens if
 	// x==math.MaxInt64. mm.limit-x will be a large negative number.
 	if mm.mu.curAllocated > mm.limit-x {
-		return errors.Wrap(
-			mm.resource.NewBudgetExceededError(x, mm.mu.curAllocated, mm.limit), mm.name,
-		)
+		return mm.newBudgetExceededError(x, mm.mu.curAllocated, mm.limit)
 	}
 	// Check whether we need to request an increase of our budget.
 	if mm.mu.curAllocated > mm.mu.curBudget.used+mm.reserved.used-x {
@@ -657,9 +667,7 @@ func (mm *BytesMonitor) releaseBytes(ctx context.Context, sz int64) {
 func (mm *BytesMonitor) increaseBudget(ctx context.Context, minExtra int64) error {
 	// NB: mm.mu Already locked by reserveBytes().
 	if mm.mu.curBudget.mon == nil {
-		return errors.Wrap(mm.resource.NewBudgetExceededError(
-			minExtra, mm.mu.curAllocated, mm.reserved.used), mm.name,
-		)
+		return mm.newBudgetExceededError(minExtra, mm.mu.curAllocated, mm.reserved.used)
 	}
 	if log.V(2) {
 		log.Infof(ctx, "%s: requesting %d bytes from the pool", mm.name, minExtra)
@@ -668,6 +676,20 @@ func (mm *BytesMonitor) increaseBudget(ctx context.Context, minExtra int64) erro
 	return mm.mu.curBudget.Grow(ctx, minExtra)
 }
 
+// newBudgetExceededError returns the error of the resource of the monitor,
+// prefixed with the name of the monitor and annotated with it (see
+// BudgetExceededMonitorName).
+func (mm *BytesMonitor) newBudgetExceededError(
+	requestedBytes int64, reservedBytes int64, budgetBytes int64,
+) error {
+	return &withMonitorName{
+		cause: errors.Wrap(
+			mm.resource.NewBudgetExceededError(requestedBytes, reservedBytes, budgetBytes), mm.name,
+		),
+		name: mm.name,
+	}
+}
+
 // roundSize rounds its argument to the smallest greater or equal
 // multiple of `poolAllocationSize`.
 func (mm *BytesMonitor) roundSize(sz int64) int64 {
@@ -323,6 +323,17 @@ func TestBytesMonitor(t *testing.T) {
 	}
 	limitedMonitor.releaseBytes(ctx, 10)
 
+	if prev := limitedMonitor.SetLimit(5); prev != 10 {
+		t.Fatalf("incorrect previous limit: got %d, expected %d", prev, 10)
+	}
+	if err := limitedMonitor.reserveBytes(ctx, 6); err == nil {
+		t.Fatal("limited monitor allowed allocation over lowered limit")
+	}
+	if err := limitedMonitor.reserveBytes(ctx, 5); err != nil {
+		t.Fatalf("limited monitor refused allocation under lowered limit: %v", err)
+	}
+	limitedMonitor.releaseBytes(ctx, 5)
+
 	limitedMonitor.Stop(ctx)
 	m.Stop(ctx)
 }
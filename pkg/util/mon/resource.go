@@ -11,9 +11,13 @@
 package mon
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/errors"
+	"github.com/gogo/protobuf/proto"
 )
 
 // Resource is an interface used to abstract the specifics of tracking bytes
@@ -61,3 +65,59 @@ func (d diskResource) NewBudgetExceededError(
 			errors.Safe(budgetBytes),
 		), pgcode.DiskFull)
 }
+
+// withMonitorName is an error wrapper that records the name of the monitor
+// that denied an allocation. It doesn't change the message nor the pg code of
+// the wrapped error (the monitors prefix the messages with their names
+// separately).
+type withMonitorName struct {
+	cause error
+	name  string
+}
+
+var _ error = (*withMonitorName)(nil)
+var _ errors.SafeDetailer = (*withMonitorName)(nil)
+var _ fmt.Formatter = (*withMonitorName)(nil)
+var _ errors.Formatter = (*withMonitorName)(nil)
+
+func (w *withMonitorName) Error() string { return w.cause.Error() }
+func (w *withMonitorName) Cause() error  { return w.cause }
+func (w *withMonitorName) Unwrap() error { return w.cause }
+func (w *withMonitorName) SafeDetails() []string {
+	return []string{w.name}
+}
+
+func (w *withMonitorName) Format(s fmt.State, verb rune) { errors.FormatError(w, s, verb) }
+
+func (w *withMonitorName) FormatError(p errors.Printer) (next error) {
+	if p.Detail() {
+		p.Printf("denied by monitor %s", w.name)
+	}
+	return w.cause
+}
+
+// decodeWithMonitorName is a custom decoder that will be used when decoding
+// withMonitorName error objects.
+func decodeWithMonitorName(
+	_ context.Context, cause error, _ string, details []string, _ proto.Message,
+) error {
+	w := &withMonitorName{cause: cause}
+	if len(details) > 0 {
+		w.name = details[0]
+	}
+	return w
+}
+
+func init() {
+	errors.RegisterWrapperDecoder(errors.GetTypeKey((*withMonitorName)(nil)), decodeWithMonitorName)
+}
+
+// BudgetExceededMonitorName returns the name of the monitor that returned err
+// if err is (or wraps) a budget exceeded error returned by a BytesMonitor.
+func BudgetExceededMonitorName(err error) (name string, ok bool) {
+	var w *withMonitorName
+	if errors.As(err, &w) {
+		return w.name, true
+	}
+	return "", false
+}